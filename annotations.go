@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+	"gopkg.in/yaml.v3"
+)
+
+// Standard OpenContainers annotation keys.
+const (
+	annotationSource      = "org.opencontainers.image.source"
+	annotationRevision    = "org.opencontainers.image.revision"
+	annotationCreated     = "org.opencontainers.image.created"
+	annotationDescription = "org.opencontainers.image.description"
+)
+
+// ociAnnotations returns the annotations to attach to an OCI chart: defaults
+// derived from Chart.yaml and the release context, overridden by the configured
+// annotations. An override with an empty value removes the default. created is
+// left out when zero.
+func ociAnnotations(chart *Chart, releaseCtx *plugin.ReleaseContext, overrides map[string]string, created time.Time) map[string]string {
+	annotations := map[string]string{}
+	if !created.IsZero() {
+		annotations[annotationCreated] = created.UTC().Format(time.RFC3339)
+	}
+	if len(chart.Sources) > 0 && chart.Sources[0] != "" {
+		annotations[annotationSource] = chart.Sources[0]
+	}
+	if releaseCtx.CommitSHA != "" {
+		annotations[annotationRevision] = releaseCtx.CommitSHA
+	}
+	if chart.Description != "" {
+		annotations[annotationDescription] = chart.Description
+	}
+
+	for k, v := range overrides {
+		if v == "" {
+			delete(annotations, k)
+			continue
+		}
+		annotations[k] = v
+	}
+	return annotations
+}
+
+// annotationCreatedTime returns the time for the created annotation, so the
+// same release always gets the same annotations and package digest: the
+// SOURCE_DATE_EPOCH of the release environment, or the release commit's
+// committer date from the git repository holding the chart. It returns the zero
+// time when neither is available.
+func annotationCreatedTime(ctx context.Context, releaseCtx *plugin.ReleaseContext, chartPath string) time.Time {
+	epoch := releaseCtx.Environment["SOURCE_DATE_EPOCH"]
+	if epoch == "" {
+		epoch = os.Getenv("SOURCE_DATE_EPOCH")
+	}
+	if epoch == "" && releaseCtx.CommitSHA != "" {
+		cmd := exec.CommandContext(ctx, "git", "show", "-s", "--format=%ct", releaseCtx.CommitSHA)
+		cmd.Dir = chartPath
+		if out, err := cmd.Output(); err == nil {
+			epoch = strings.TrimSpace(string(out))
+		}
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
+
+// annotationOverrides merges the annotations configured on every OCI repository.
+// The chart is packaged once for all repositories, so later entries win.
+func annotationOverrides(repos []*Repository) (map[string]string, bool) {
+	overrides := map[string]string{}
+	hasOCI := false
+	for _, repo := range repos {
+		if repo.config.Type != "oci" {
+			continue
+		}
+		hasOCI = true
+		for k, v := range repo.config.Annotations {
+			overrides[k] = v
+		}
+	}
+	return overrides, hasOCI
+}
+
+// validateRepositoryAnnotations adds an error to vb if the repository sets
+// annotations that are never written because oci_annotations is disabled.
+func validateRepositoryAnnotations(vb *helpers.ValidationBuilder, field string, repo RepositoryConfig, enabled bool) {
+	if len(repo.Annotations) > 0 && !enabled {
+		vb.AddError(field+".annotations", "annotations are only written into OCI packages with oci_annotations enabled")
+	}
+}
+
+// renderChartAnnotations renders the chart_annotations templates. Annotations
+// rendering to an empty string, e.g. {{.Changes}} for a release without
+// commits, are left out so an existing value is kept.
+func renderChartAnnotations(formats map[string]string, data appVersionData) (map[string]string, error) {
+	annotations := make(map[string]string, len(formats))
+	for _, key := range slices.Sorted(maps.Keys(formats)) {
+		value, err := renderTemplate("chart_annotations."+key, formats[key], data)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(value) != "" {
+			annotations[key] = value
+		}
+	}
+	return annotations, nil
+}
+
+// artifactHubChange is an entry of the artifacthub.io/changes annotation.
+type artifactHubChange struct {
+	Kind        string `yaml:"kind"`
+	Description string `yaml:"description"`
+}
+
+// artifactHubChanges renders a release's commits as an artifacthub.io/changes
+// YAML list, or "" when there are none. Breaking changes and performance
+// improvements are reported as changed; other commit types are left out.
+func artifactHubChanges(changes *plugin.CategorizedChanges) string {
+	if changes == nil {
+		return ""
+	}
+	var entries []artifactHubChange
+	seen := map[string]bool{}
+	for _, group := range []struct {
+		kind    string
+		commits []plugin.ConventionalCommit
+	}{
+		{"changed", changes.Breaking},
+		{"added", changes.Features},
+		{"fixed", changes.Fixes},
+		{"changed", changes.Performance},
+	} {
+		for _, commit := range group.commits {
+			// A breaking feature or fix may also be listed under its type
+			if commit.Hash != "" {
+				if seen[commit.Hash] {
+					continue
+				}
+				seen[commit.Hash] = true
+			}
+			description := commit.Description
+			if commit.Breaking && commit.BreakingDescription != "" {
+				description = commit.BreakingDescription
+			}
+			if description != "" {
+				entries = append(entries, artifactHubChange{Kind: group.kind, Description: description})
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	data, err := yaml.Marshal(entries)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
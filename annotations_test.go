@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestOCIAnnotations(t *testing.T) {
+	chart := &Chart{
+		Name:        "my-app",
+		Description: "My application",
+		Sources:     []string{"https://github.com/myorg/my-app", "https://example.com"},
+	}
+	releaseCtx := &plugin.ReleaseContext{CommitSHA: "abc123"}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	got := ociAnnotations(chart, releaseCtx, map[string]string{
+		annotationDescription: "",
+		"com.example.team":    "platform",
+	}, now)
+
+	want := map[string]string{
+		annotationSource:   "https://github.com/myorg/my-app",
+		annotationRevision: "abc123",
+		annotationCreated:  "2024-05-01T12:00:00Z",
+		"com.example.team": "platform",
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("expected %s=%q, got %q", k, v, got[k])
+		}
+	}
+
+	if got := ociAnnotations(chart, releaseCtx, nil, time.Time{}); got[annotationCreated] != "" {
+		t.Errorf("expected no created annotation without a time, got %q", got[annotationCreated])
+	}
+}
+
+func TestAnnotationCreatedTime(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE=2024-05-01T12:00:00Z")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s failed: %v: %s", args[0], err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--quiet")
+	git("commit", "--quiet", "--allow-empty", "-m", "Release")
+	sha := git("rev-parse", "HEAD")
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+
+	tests := []struct {
+		name       string
+		releaseCtx *plugin.ReleaseContext
+		want       time.Time
+	}{
+		{name: "commit date", releaseCtx: &plugin.ReleaseContext{CommitSHA: sha}, want: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{name: "SOURCE_DATE_EPOCH", releaseCtx: &plugin.ReleaseContext{CommitSHA: sha, Environment: map[string]string{"SOURCE_DATE_EPOCH": "1700000000"}}, want: time.Unix(1700000000, 0).UTC()},
+		{name: "unknown commit", releaseCtx: &plugin.ReleaseContext{CommitSHA: "0000000000000000000000000000000000000000"}},
+		{name: "no commit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releaseCtx := tt.releaseCtx
+			if releaseCtx == nil {
+				releaseCtx = &plugin.ReleaseContext{}
+			}
+			if got := annotationCreatedTime(context.Background(), releaseCtx, dir); !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateRepositoryAnnotations(t *testing.T) {
+	repo := RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg/charts", Annotations: map[string]string{"com.example.team": "platform"}}
+	for _, enabled := range []bool{false, true} {
+		vb := helpers.NewValidationBuilder()
+		validateRepositoryAnnotations(vb, "repository", repo, enabled)
+		if resp := vb.Build(); resp.Valid != enabled {
+			t.Errorf("oci_annotations=%v: expected valid=%v, got %+v", enabled, enabled, resp.Errors)
+		}
+	}
+}
+
+func TestAnnotationOverridesOnlyOCI(t *testing.T) {
+	repos := []*Repository{
+		NewRepository(RepositoryConfig{Type: "chartmuseum", Annotations: map[string]string{"a": "museum"}}),
+		NewRepository(RepositoryConfig{Type: "oci", Annotations: map[string]string{"b": "oci"}}),
+	}
+
+	overrides, hasOCI := annotationOverrides(repos)
+	if !hasOCI {
+		t.Error("expected hasOCI to be true")
+	}
+	if len(overrides) != 1 || overrides["b"] != "oci" {
+		t.Errorf("unexpected overrides: %v", overrides)
+	}
+}
+
+func TestArtifactHubChanges(t *testing.T) {
+	breaking := plugin.ConventionalCommit{Hash: "c3", Type: "feat", Description: "drop v1 API", Breaking: true, BreakingDescription: "the v1 API was removed"}
+	changes := &plugin.CategorizedChanges{
+		Features: []plugin.ConventionalCommit{{Hash: "c1", Description: "add ingress: className"}, breaking},
+		Fixes:    []plugin.ConventionalCommit{{Hash: "c2", Description: "fix probe port"}},
+		Breaking: []plugin.ConventionalCommit{breaking},
+		Docs:     []plugin.ConventionalCommit{{Hash: "c4", Description: "update README"}},
+	}
+
+	want := `- kind: changed
+  description: the v1 API was removed
+- kind: added
+  description: 'add ingress: className'
+- kind: fixed
+  description: fix probe port
+`
+	if got := artifactHubChanges(changes); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+	if got := artifactHubChanges(&plugin.CategorizedChanges{Docs: changes.Docs}); got != "" {
+		t.Errorf("expected no changes, got:\n%s", got)
+	}
+}
+
+func TestExecutePrePublishChartAnnotations(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "kind: ConfigMap"`)
+	chartDir := t.TempDir()
+	content := "apiVersion: v2\nname: my-app\nversion: 1.0.0\nannotations:\n  artifacthub.io/license: Apache-2.0 # keep me\n  artifacthub.io/changes: |\n    - previous release\n"
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_path": chartDir,
+		"lint":       false,
+		"chart_annotations": map[string]any{
+			"artifacthub.io/changes":    "{{.Changes}}",
+			"artifacthub.io/prerelease": "false",
+			"example.com/notes":         "{{.ReleaseNotes}}",
+		},
+		"version":      map[string]any{"update_chart": false},
+		"dependencies": map[string]any{"update": false, "build": false},
+	})
+
+	releaseCtx := &plugin.ReleaseContext{
+		Version: "1.0.0",
+		Changes: &plugin.CategorizedChanges{Features: []plugin.ConventionalCommit{{Description: "add HPA"}}},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePrePublish(context.Background(), releaseCtx, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(chartDir, "Chart.yaml"))
+	want := `apiVersion: v2
+name: my-app
+version: 1.0.0
+annotations:
+  artifacthub.io/license: Apache-2.0 # keep me
+  artifacthub.io/changes: |
+    - kind: added
+      description: add HPA
+  artifacthub.io/prerelease: "false"
+`
+	if string(got) != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
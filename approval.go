@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// approvalRequest is the chart metadata sent to the approval webhook.
+type approvalRequest struct {
+	Chart        string            `json:"chart"`
+	Version      string            `json:"version"`
+	Packages     []approvalPackage `json:"packages"`
+	Repositories []string          `json:"repositories"`
+	Tag          string            `json:"tag,omitempty"`
+	Commit       string            `json:"commit,omitempty"`
+	Repository   string            `json:"repository,omitempty"`
+}
+
+// approvalPackage describes a package awaiting approval.
+type approvalPackage struct {
+	Environment string `json:"environment,omitempty"`
+	Version     string `json:"version"`
+	Digest      string `json:"digest"`
+}
+
+// approvalResponse is the webhook's decision.
+type approvalResponse struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason"`
+}
+
+// requestApproval posts the chart metadata to the approval webhook and returns
+// an error unless the webhook responds 200 with {"approved": true}.
+func requestApproval(ctx context.Context, webhookURL string, req approvalRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode approval request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("approval request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("approval webhook returned %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+
+	var decision approvalResponse
+	if err := json.Unmarshal(respBody, &decision); err != nil {
+		return fmt.Errorf("failed to parse approval response: %w", err)
+	}
+	if !decision.Approved {
+		if decision.Reason == "" {
+			return fmt.Errorf("publish was not approved")
+		}
+		return fmt.Errorf("publish was not approved: %s", decision.Reason)
+	}
+	return nil
+}
+
+// newApprovalRequest builds the approval request for the given packages.
+func newApprovalRequest(chartName string, packages []chartPackage, repos []*Repository, releaseCtx *plugin.ReleaseContext) (approvalRequest, error) {
+	req := approvalRequest{
+		Chart:      chartName,
+		Version:    releaseCtx.Version,
+		Tag:        releaseCtx.TagName,
+		Commit:     releaseCtx.CommitSHA,
+		Repository: releaseCtx.RepositoryURL,
+	}
+	for _, pkg := range packages {
+		digest, err := fileDigest(pkg.Path)
+		if err != nil {
+			return req, err
+		}
+		req.Packages = append(req.Packages, approvalPackage{
+			Environment: pkg.Environment,
+			Version:     pkg.Version,
+			Digest:      digest,
+		})
+	}
+	for _, repo := range repos {
+		req.Repositories = append(req.Repositories, repo.config.URL)
+	}
+	return req, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestApproval(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{name: "approved", status: http.StatusOK, body: `{"approved": true}`},
+		{name: "denied with reason", status: http.StatusOK, body: `{"approved": false, "reason": "change freeze"}`, wantErr: "not approved: change freeze"},
+		{name: "denied without reason", status: http.StatusOK, body: `{}`, wantErr: "not approved"},
+		{name: "server error", status: http.StatusInternalServerError, body: "boom", wantErr: "returned 500: boom"},
+		{name: "invalid response", status: http.StatusOK, body: "yes", wantErr: "failed to parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got approvalRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					t.Errorf("expected POST, got %s", r.Method)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			err := requestApproval(context.Background(), server.URL, approvalRequest{
+				Chart:        "my-app",
+				Version:      "1.0.0",
+				Packages:     []approvalPackage{{Version: "1.0.0", Digest: "sha256:abc"}},
+				Repositories: []string{"oci://ghcr.io/myorg"},
+			})
+
+			if got.Chart != "my-app" || len(got.Packages) != 1 || got.Packages[0].Digest != "sha256:abc" {
+				t.Errorf("unexpected request payload: %+v", got)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileDigest returns the SHA256 digest of a file in the form "sha256:<hex>".
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readPackagedChart reads the Chart.yaml metadata from a packaged chart archive.
+// Helm places it at "<chart-name>/Chart.yaml"; Chart.yaml files of bundled
+// subcharts live deeper in the tree and are ignored.
+func readPackagedChart(path string) (*Chart, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer func() { _ = gz.Close() }()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("no Chart.yaml found in %s", path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		parts := strings.Split(strings.TrimPrefix(hdr.Name, "./"), "/")
+		if len(parts) != 2 || parts[1] != "Chart.yaml" {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Chart.yaml from %s: %w", path, err)
+		}
+		var chart Chart
+		if err := yaml.Unmarshal(data, &chart); err != nil {
+			return nil, fmt.Errorf("failed to parse Chart.yaml from %s: %w", path, err)
+		}
+		return &chart, nil
+	}
+}
+
+// gzipMagic are the first bytes of every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// checkChartArchive checks that path is a gzip-compressed chart archive with a
+// Chart.yaml in its chart directory, so a corrupt or unrelated file is never
+// uploaded.
+func checkChartArchive(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open package: %w", err)
+	}
+	magic := make([]byte, len(gzipMagic))
+	_, err = io.ReadFull(file, magic)
+	_ = file.Close()
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("failed to read package: %w", err)
+	}
+	if !bytes.Equal(magic, gzipMagic) {
+		return fmt.Errorf("%s is not a chart package: not a gzip archive", path)
+	}
+	if _, err := readPackagedChart(path); err != nil {
+		return fmt.Errorf("%s is not a chart package: %w", path, err)
+	}
+	return nil
+}
+
+// verifyPackagedChart checks that the chart metadata inside a package matches
+// the name and version we intend to publish.
+func verifyPackagedChart(path, name, version string) error {
+	chart, err := readPackagedChart(path)
+	if err != nil {
+		return err
+	}
+	if chart.Name != name {
+		return fmt.Errorf("package %s contains chart %q, expected %q", path, chart.Name, name)
+	}
+	if chart.Version != version {
+		return fmt.Errorf("package %s contains version %s, expected %s", path, chart.Version, version)
+	}
+	return nil
+}
+
+// readPrebuiltPackage reads the chart in a package built outside the plugin. The
+// package must be named after the chart it contains, as helm package names it,
+// and the chart must carry the release version (after any configured
+// stripping).
+func readPrebuiltPackage(path string, cfg VersionConfig, releaseVersion string) (*Chart, error) {
+	chart, err := readPackagedChart(path)
+	if err != nil {
+		return nil, err
+	}
+	if want := fmt.Sprintf("%s-%s.tgz", chart.Name, chart.Version); filepath.Base(path) != want {
+		return nil, fmt.Errorf("package %s contains chart %s %s, expected it to be named %s", path, chart.Name, chart.Version, want)
+	}
+
+	// Without a release version the package is published under its own
+	if strings.TrimSpace(releaseVersion) == "" {
+		return chart, nil
+	}
+	version, err := cfg.chartVersion(releaseVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid release version %q: %w", releaseVersion, err)
+	}
+	if strings.TrimPrefix(chart.Version, "v") != strings.TrimPrefix(version, "v") {
+		return nil, fmt.Errorf("package %s contains version %s, expected release version %s", path, chart.Version, version)
+	}
+	return chart, nil
+}
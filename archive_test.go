@@ -0,0 +1,200 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileDigest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chart.tgz")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	digest, err := fileDigest(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if digest != want {
+		t.Errorf("expected '%s', got '%s'", want, digest)
+	}
+
+	if _, err := fileDigest(filepath.Join(t.TempDir(), "missing.tgz")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+// writeChartArchive writes a gzipped tarball containing files to path.
+func writeChartArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+}
+
+// writeTestPackage writes a minimal chart package to path and returns its
+// content.
+func writeTestPackage(t *testing.T, path string) string {
+	t.Helper()
+	writeChartArchive(t, path, map[string]string{"my-app/Chart.yaml": "apiVersion: v2\nname: my-app\nversion: 1.0.0\n"})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read package: %v", err)
+	}
+	return string(data)
+}
+
+func TestVerifyPackagedChart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	writeChartArchive(t, path, map[string]string{
+		"my-app/Chart.yaml":              "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+		"my-app/charts/redis/Chart.yaml": "apiVersion: v2\nname: redis\nversion: 17.0.0\n",
+		"my-app/values.yaml":             "replicas: 1\n",
+	})
+
+	tests := []struct {
+		name    string
+		chart   string
+		version string
+		wantErr bool
+	}{
+		{name: "matches", chart: "my-app", version: "1.0.0"},
+		{name: "wrong name", chart: "other-app", version: "1.0.0", wantErr: true},
+		{name: "wrong version", chart: "my-app", version: "1.0.1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyPackagedChart(path, tt.chart, tt.version)
+			if tt.wantErr && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadPackagedChartWithoutChartYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.tgz")
+	writeChartArchive(t, path, map[string]string{"my-app/values.yaml": "replicas: 1\n"})
+
+	if _, err := readPackagedChart(path); err == nil {
+		t.Error("expected error for archive without Chart.yaml")
+	}
+}
+
+func TestCheckChartArchive(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "my-app-1.0.0.tgz")
+	writeTestPackage(t, valid)
+	noChart := filepath.Join(dir, "no-chart.tgz")
+	writeChartArchive(t, noChart, map[string]string{"my-app/values.yaml": "replicas: 1\n", "Chart.yaml": "name: misplaced\n"})
+	notGzip := filepath.Join(dir, "index.tgz")
+	if err := os.WriteFile(notGzip, []byte("apiVersion: v1\nentries: {}\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	empty := filepath.Join(dir, "empty.tgz")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{name: "chart package", path: valid},
+		{name: "no chart directory", path: noChart, wantErr: "no Chart.yaml found"},
+		{name: "not gzip", path: notGzip, wantErr: "not a gzip archive"},
+		{name: "empty", path: empty, wantErr: "not a gzip archive"},
+		{name: "missing", path: filepath.Join(dir, "missing.tgz"), wantErr: "failed to open package"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkChartArchive(tt.path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestReadPrebuiltPackage(t *testing.T) {
+	dir := t.TempDir()
+	chartYAML := map[string]string{"my-app/Chart.yaml": "apiVersion: v2\nname: my-app\nversion: 1.2.3\n"}
+	writeChartArchive(t, filepath.Join(dir, "my-app-1.2.3.tgz"), chartYAML)
+	writeChartArchive(t, filepath.Join(dir, "renamed.tgz"), chartYAML)
+	if err := os.WriteFile(filepath.Join(dir, "plain-1.2.3.tgz"), []byte("not gzip"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		version string
+		strip   bool
+		wantErr string
+	}{
+		{name: "matches", file: "my-app-1.2.3.tgz", version: "1.2.3"},
+		{name: "v prefix", file: "my-app-1.2.3.tgz", version: "v1.2.3"},
+		{name: "stripped prerelease", file: "my-app-1.2.3.tgz", version: "1.2.3-rc.1", strip: true},
+		{name: "no release version", file: "my-app-1.2.3.tgz", version: ""},
+		{name: "wrong version", file: "my-app-1.2.3.tgz", version: "1.2.4", wantErr: "expected release version 1.2.4"},
+		{name: "not named after the chart", file: "renamed.tgz", version: "1.2.3", wantErr: "expected it to be named my-app-1.2.3.tgz"},
+		{name: "not gzip", file: "plain-1.2.3.tgz", version: "1.2.3", wantErr: "failed to read"},
+		{name: "missing", file: "missing-1.2.3.tgz", version: "1.2.3", wantErr: "failed to open"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chart, err := readPrebuiltPackage(filepath.Join(dir, tt.file), VersionConfig{StripPrerelease: tt.strip}, tt.version)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if chart.Name != "my-app" || chart.Version != "1.2.3" {
+					t.Errorf("unexpected chart %+v", chart)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// provenanceSuffix is appended to a package path by helm package --sign.
+const provenanceSuffix = ".prov"
+
+// packageArtifacts lists the files written to the output directory for
+// packages: each chart package and, when it was signed, its provenance file.
+// Files are listed in package order, each package followed by its provenance.
+func packageArtifacts(packages []chartPackage) ([]plugin.Artifact, error) {
+	var artifacts []plugin.Artifact
+	for _, pkg := range packages {
+		for _, path := range []string{pkg.Path, pkg.Path + provenanceSuffix} {
+			artifact, err := fileArtifact(path)
+			if os.IsNotExist(err) && path != pkg.Path {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	return artifacts, nil
+}
+
+// fileArtifact describes the file at path as an artifact. A missing file
+// returns an error satisfying os.IsNotExist.
+func fileArtifact(path string) (plugin.Artifact, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return plugin.Artifact{}, err
+	}
+	if err != nil {
+		return plugin.Artifact{}, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	digest, err := fileDigest(path)
+	if err != nil {
+		return plugin.Artifact{}, err
+	}
+	return plugin.Artifact{
+		Name:     filepath.Base(path),
+		Path:     path,
+		Type:     "file",
+		Size:     info.Size(),
+		Checksum: digest,
+	}, nil
+}
+
+// artifactPaths returns the paths of artifacts, for the "artifacts" output.
+func artifactPaths(artifacts []plugin.Artifact) []string {
+	paths := make([]string, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		paths = append(paths, artifact.Path)
+	}
+	return paths
+}
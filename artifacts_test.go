@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestExecutePostPublishArtifacts(t *testing.T) {
+	// Fake helm packaging the staged chart's Chart.yaml, with a provenance
+	// file when signing
+	writeFakeCommand(t, "helm", `case "$1" in
+package)
+	version=$(sed -n 's/^version: //p' "$2/Chart.yaml")
+	staging=$(mktemp -d)
+	mkdir "$staging/my-app"
+	cp "$2/Chart.yaml" "$staging/my-app/"
+	tar -czf "$4/my-app-$version.tgz" -C "$staging" my-app
+	case "$*" in *--sign*) echo "signature" > "$4/my-app-$version.tgz.prov" ;; esac
+	echo "Successfully packaged chart and saved it to: $4/my-app-$version.tgz"
+	;;
+push) echo "Digest: sha256:deadbeef" ;;
+esac
+`)
+
+	root := t.TempDir()
+	chartDir := filepath.Join(root, "my-app")
+	if err := os.MkdirAll(chartDir, 0755); err != nil {
+		t.Fatalf("failed to create chart: %v", err)
+	}
+	files := map[string]string{
+		filepath.Join(chartDir, "Chart.yaml"):   "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+		filepath.Join(chartDir, "values.yaml"):  "replicas: 1\n",
+		filepath.Join(root, "values-prod.yaml"): "replicas: 3\n",
+		filepath.Join(root, "values-dev.yaml"):  "replicas: 1\n",
+		filepath.Join(root, "pubring.gpg"):      "keyring",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	outputDir := filepath.Join(root, "packages")
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_path": chartDir,
+		"output_dir": outputDir,
+		"repository": map[string]any{"url": "oci://ghcr.io/myorg/charts"},
+		"sign":       true,
+		"sign_key":   "release@example.com",
+		"keyring":    filepath.Join(root, "pubring.gpg"),
+		"environments": map[string]any{
+			"prod": filepath.Join(root, "values-prod.yaml"),
+			"dev":  filepath.Join(root, "values-dev.yaml"),
+		},
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePostPublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		t.Fatalf("failed to read output dir: %v", err)
+	}
+	var created []string
+	for _, entry := range entries {
+		created = append(created, filepath.Join(outputDir, entry.Name()))
+	}
+
+	paths, _ := resp.Outputs["artifacts"].([]string)
+	listed := append([]string{}, paths...)
+	sort.Strings(listed)
+	if strings.Join(listed, "\n") != strings.Join(created, "\n") {
+		t.Errorf("expected artifacts to match created files\ncreated:\n%s\nlisted:\n%s", strings.Join(created, "\n"), strings.Join(listed, "\n"))
+	}
+	if len(created) != 4 {
+		t.Errorf("expected a package and provenance file per environment, got %v", created)
+	}
+
+	if len(resp.Artifacts) != len(paths) {
+		t.Fatalf("expected %d response artifacts, got %d", len(paths), len(resp.Artifacts))
+	}
+	for i, artifact := range resp.Artifacts {
+		if artifact.Path != paths[i] || artifact.Name != filepath.Base(paths[i]) || artifact.Size == 0 || !strings.HasPrefix(artifact.Checksum, "sha256:") {
+			t.Errorf("unexpected artifact %+v", artifact)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+const (
+	// attestationSuffix is appended to a package's file name for its in-toto
+	// provenance statement.
+	attestationSuffix = ".intoto.json"
+
+	inTotoStatementType = "https://in-toto.io/Statement/v1"
+	slsaProvenanceType  = "https://slsa.dev/provenance/v1"
+
+	// attestationMediaType is the artifact type attestations are attached to
+	// OCI charts with.
+	attestationMediaType = "application/vnd.in-toto+json"
+
+	defaultBuilderID = "https://github.com/relicta-tech/plugin-helm"
+	defaultBuildType = "https://github.com/relicta-tech/plugin-helm/chart-package@v1"
+)
+
+// ProvenanceConfig defines the in-toto provenance attestation written for each
+// package. It is separate from helm's GPG .prov file.
+type ProvenanceConfig struct {
+	Attestation bool   `json:"attestation"`
+	BuilderID   string `json:"builder_id"`
+	BuildType   string `json:"build_type"`
+	// ExternalParameters are recorded in the predicate's buildDefinition next to
+	// the chart name and version.
+	ExternalParameters map[string]string `json:"external_parameters"`
+	// Push attaches the statement to the pushed chart as an OCI referrer with
+	// oras, on registries that support it (oci only).
+	Push bool `json:"push"`
+}
+
+// parseProvenanceConfig parses the provenance block.
+func parseProvenanceConfig(raw any) ProvenanceConfig {
+	cfg := ProvenanceConfig{BuilderID: defaultBuilderID, BuildType: defaultBuildType}
+	provRaw, ok := raw.(map[string]any)
+	if !ok {
+		return cfg
+	}
+	if attestation, ok := provRaw["attestation"].(bool); ok {
+		cfg.Attestation = attestation
+	}
+	if builderID, ok := provRaw["builder_id"].(string); ok && builderID != "" {
+		cfg.BuilderID = builderID
+	}
+	if buildType, ok := provRaw["build_type"].(string); ok && buildType != "" {
+		cfg.BuildType = buildType
+	}
+	cfg.ExternalParameters = parseStringMap(provRaw["external_parameters"])
+	if push, ok := provRaw["push"].(bool); ok {
+		cfg.Push = push
+	}
+	return cfg
+}
+
+// inTotoStatement is an in-toto v1 statement carrying a SLSA v1 provenance
+// predicate.
+type inTotoStatement struct {
+	Type          string          `json:"_type"`
+	Subject       []inTotoSubject `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     slsaProvenance  `json:"predicate"`
+}
+
+type inTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type slsaProvenance struct {
+	BuildDefinition slsaBuildDefinition `json:"buildDefinition"`
+	RunDetails      slsaRunDetails      `json:"runDetails"`
+}
+
+type slsaBuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   map[string]string    `json:"externalParameters"`
+	ResolvedDependencies []slsaResourceDigest `json:"resolvedDependencies,omitempty"`
+}
+
+type slsaResourceDigest struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+type slsaRunDetails struct {
+	Builder  slsaBuilder  `json:"builder"`
+	Metadata slsaMetadata `json:"metadata"`
+}
+
+type slsaBuilder struct {
+	ID string `json:"id"`
+}
+
+type slsaMetadata struct {
+	FinishedOn string `json:"finishedOn"`
+}
+
+// newProvenanceStatement builds the statement for the package at path,
+// recording the release commit it was built from and when.
+func newProvenanceStatement(cfg ProvenanceConfig, chart *Chart, pkg chartPackage, releaseCtx *plugin.ReleaseContext, now time.Time) (inTotoStatement, error) {
+	digest, err := fileDigest(pkg.Path)
+	if err != nil {
+		return inTotoStatement{}, err
+	}
+
+	params := map[string]string{"chart": chart.Name, "version": pkg.Version}
+	if pkg.Environment != "" {
+		params["environment"] = pkg.Environment
+	}
+	maps.Copy(params, cfg.ExternalParameters)
+
+	var deps []slsaResourceDigest
+	if releaseCtx.CommitSHA != "" {
+		uri := releaseCtx.RepositoryURL
+		if uri != "" && !strings.HasPrefix(uri, "git+") {
+			uri = "git+" + uri
+		}
+		deps = append(deps, slsaResourceDigest{URI: uri, Digest: map[string]string{"gitCommit": releaseCtx.CommitSHA}})
+	}
+
+	return inTotoStatement{
+		Type: inTotoStatementType,
+		Subject: []inTotoSubject{{
+			Name:   filepath.Base(pkg.Path),
+			Digest: map[string]string{"sha256": strings.TrimPrefix(digest, "sha256:")},
+		}},
+		PredicateType: slsaProvenanceType,
+		Predicate: slsaProvenance{
+			BuildDefinition: slsaBuildDefinition{
+				BuildType:            cfg.BuildType,
+				ExternalParameters:   params,
+				ResolvedDependencies: deps,
+			},
+			RunDetails: slsaRunDetails{
+				Builder:  slsaBuilder{ID: cfg.BuilderID},
+				Metadata: slsaMetadata{FinishedOn: now.UTC().Format(time.RFC3339)},
+			},
+		},
+	}, nil
+}
+
+// attestationPath returns where the attestation for the package at
+// packagePath is written in outputDir.
+func attestationPath(outputDir, packagePath string) string {
+	return filepath.Join(outputDir, filepath.Base(packagePath)+attestationSuffix)
+}
+
+// writeAttestations writes a provenance statement for each package to
+// outputDir and returns their paths, in package order.
+func writeAttestations(cfg ProvenanceConfig, chart *Chart, packages []chartPackage, releaseCtx *plugin.ReleaseContext, outputDir string, now time.Time) ([]string, error) {
+	var paths []string
+	for _, pkg := range packages {
+		statement, err := newProvenanceStatement(cfg, chart, pkg, releaseCtx, now)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.MarshalIndent(statement, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode attestation: %w", err)
+		}
+		path := attestationPath(outputDir, pkg.Path)
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write attestation: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// AttachAttestation attaches the attestation at path to the chart manifest
+// with digest as an OCI referrer, using oras. oras falls back to the referrers
+// tag schema on registries without the referrers API.
+func (r *Repository) AttachAttestation(ctx context.Context, chartName, digest, path string) error {
+	if err := r.loginRegistryTool(ctx, "oras"); err != nil {
+		return err
+	}
+	subject := strings.TrimPrefix(r.ociChart(chartName), "oci://") + "@" + digest
+	_, err := r.runRegistryTool(ctx, "oras", nil, "attach", "--artifact-type", attestationMediaType, subject, path+":"+attestationMediaType)
+	return err
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestNewProvenanceStatement(t *testing.T) {
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.2.0-prod.tgz")
+	if err := os.WriteFile(packagePath, []byte("chart content"), 0644); err != nil {
+		t.Fatalf("failed to write package: %v", err)
+	}
+
+	cfg := parseProvenanceConfig(map[string]any{
+		"attestation":         true,
+		"builder_id":          "https://ci.example.com/runners/helm",
+		"external_parameters": map[string]any{"workflow": "release.yaml", "version": "overridden"},
+	})
+	releaseCtx := &plugin.ReleaseContext{CommitSHA: "abc1234", RepositoryURL: "https://github.com/myorg/charts"}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	statement, err := newProvenanceStatement(cfg, &Chart{Name: "my-app"}, chartPackage{Environment: "prod", Version: "1.2.0-prod", Path: packagePath}, releaseCtx, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	digest, _ := fileDigest(packagePath)
+	if len(statement.Subject) != 1 || statement.Subject[0].Name != "my-app-1.2.0-prod.tgz" || "sha256:"+statement.Subject[0].Digest["sha256"] != digest {
+		t.Errorf("unexpected subject %+v", statement.Subject)
+	}
+	if statement.Type != inTotoStatementType || statement.PredicateType != slsaProvenanceType {
+		t.Errorf("unexpected statement types %s, %s", statement.Type, statement.PredicateType)
+	}
+
+	def := statement.Predicate.BuildDefinition
+	if def.BuildType != defaultBuildType {
+		t.Errorf("expected default build type, got %s", def.BuildType)
+	}
+	wantParams := map[string]string{"chart": "my-app", "version": "overridden", "environment": "prod", "workflow": "release.yaml"}
+	if len(def.ExternalParameters) != len(wantParams) {
+		t.Errorf("expected parameters %v, got %v", wantParams, def.ExternalParameters)
+	}
+	for k, v := range wantParams {
+		if def.ExternalParameters[k] != v {
+			t.Errorf("expected parameter %s=%s, got %v", k, v, def.ExternalParameters)
+		}
+	}
+	if len(def.ResolvedDependencies) != 1 || def.ResolvedDependencies[0].URI != "git+https://github.com/myorg/charts" || def.ResolvedDependencies[0].Digest["gitCommit"] != "abc1234" {
+		t.Errorf("unexpected resolved dependencies %+v", def.ResolvedDependencies)
+	}
+
+	run := statement.Predicate.RunDetails
+	if run.Builder.ID != "https://ci.example.com/runners/helm" || run.Metadata.FinishedOn != "2026-03-01T11:00:00Z" {
+		t.Errorf("unexpected run details %+v", run)
+	}
+}
+
+func TestExecutePostPublishAttestation(t *testing.T) {
+	writeFakeCommand(t, "helm", `case "$1" in
+package)
+	staging=$(mktemp -d)
+	mkdir "$staging/my-app"
+	cp "$2/Chart.yaml" "$staging/my-app/"
+	tar -czf "$4/my-app-1.0.0.tgz" -C "$staging" my-app
+	echo "Successfully packaged chart and saved it to: $4/my-app-1.0.0.tgz"
+	;;
+push) printf 'Pushed: ghcr.io/myorg/charts/my-app:1.0.0\nDigest: sha256:deadbeef\n' ;;
+esac
+`)
+	orasDir := writeFakeCommand(t, "oras", `echo "$@" >> "$(dirname "$0")/calls"`)
+
+	root := t.TempDir()
+	chartDir := filepath.Join(root, "my-app")
+	if err := os.MkdirAll(chartDir, 0755); err != nil {
+		t.Fatalf("failed to create chart: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	outputDir := filepath.Join(root, "packages")
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_path": chartDir,
+		"output_dir": outputDir,
+		"repository": map[string]any{"url": "oci://ghcr.io/myorg/charts"},
+		"provenance": map[string]any{"attestation": true, "push": true},
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePostPublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0", CommitSHA: "abc1234"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	attestation := filepath.Join(outputDir, "my-app-1.0.0.tgz.intoto.json")
+	data, err := os.ReadFile(attestation)
+	if err != nil {
+		t.Fatalf("expected attestation to be written: %v", err)
+	}
+	var statement inTotoStatement
+	if err := json.Unmarshal(data, &statement); err != nil {
+		t.Fatalf("invalid attestation: %v", err)
+	}
+	if statement.Subject[0].Name != "my-app-1.0.0.tgz" || statement.Predicate.RunDetails.Builder.ID != defaultBuilderID {
+		t.Errorf("unexpected statement %+v", statement)
+	}
+
+	paths, _ := resp.Outputs["artifacts"].([]string)
+	if len(paths) != 2 || paths[1] != attestation {
+		t.Errorf("expected the attestation listed as an artifact, got %v", paths)
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(orasDir, "calls"))
+	want := "attach --artifact-type application/vnd.in-toto+json ghcr.io/myorg/charts/my-app@sha256:deadbeef " + attestation + ":application/vnd.in-toto+json"
+	if strings.TrimSpace(string(calls)) != want {
+		t.Errorf("expected oras call %q, got %q", want, calls)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Defaults for polling a pushed chart until the repository serves it.
+const (
+	DefaultWaitTimeout  = 2 * time.Minute
+	DefaultWaitInterval = 5 * time.Second
+)
+
+// waitSettings returns the configured wait_timeout and wait_interval, falling
+// back to the defaults when unset or invalid.
+func (c RepositoryConfig) waitSettings() (timeout, interval time.Duration) {
+	timeout, interval = DefaultWaitTimeout, DefaultWaitInterval
+	if d, err := time.ParseDuration(c.WaitTimeout); err == nil && d > 0 {
+		timeout = d
+	}
+	if d, err := time.ParseDuration(c.WaitInterval); err == nil && d > 0 {
+		interval = d
+	}
+	return timeout, interval
+}
+
+// Exists reports whether the repository serves the chart version: with helm
+// show chart for OCI registries, or from the ChartMuseum API.
+func (r *Repository) Exists(ctx context.Context, chartName, version string) (bool, error) {
+	switch r.config.Type {
+	case "oci":
+		return r.ociExists(ctx, chartName, version)
+	case "chartmuseum":
+		return r.chartMuseumExists(ctx, chartName, version)
+	default:
+		return false, fmt.Errorf("checking chart versions is not supported for repository type: %s", r.config.Type)
+	}
+}
+
+// ociExists reports whether the chart version can be fetched from the registry.
+func (r *Repository) ociExists(ctx context.Context, chartName, version string) (bool, error) {
+	var output bytes.Buffer
+	var showErr error
+	args := append([]string{"show", "chart", r.ociChart(chartName), "--version", version}, r.ociTLSArgs(false)...)
+	err := r.withRegistrySession(ctx, func() error {
+		showErr = runHelm(ctx, r.helm, r.timeout, args, func(cmd *exec.Cmd) error {
+			cmd.Stdout = io.Discard
+			cmd.Stderr = &output
+			return cmd.Run()
+		})
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if err = showErr; err == nil {
+		return true, nil
+	}
+	for _, s := range manifestNotFound {
+		if strings.Contains(output.String(), s) {
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("%w: %s", helmFailure("show chart", err), strings.TrimSpace(output.String()))
+}
+
+// chartMuseumExists reports whether ChartMuseum lists the chart version.
+func (r *Repository) chartMuseumExists(ctx context.Context, chartName, version string) (bool, error) {
+	endpoint := r.chartMuseumAPI("/" + url.PathEscape(chartName) + "/" + url.PathEscape(version))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := r.setAuth(ctx, req); err != nil {
+		return false, err
+	}
+
+	client, err := r.httpClient(30 * time.Second)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to check chart version: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("checking chart version failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+}
+
+// waitAvailable polls the repository until it serves the chart version, or
+// fails once wait_timeout has passed. Errors while polling are retried, since
+// a registry still propagating the push may answer with one.
+func waitAvailable(ctx context.Context, repo *Repository, chartName, version string, logger *slog.Logger) error {
+	timeout, interval := repo.config.waitSettings()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		exists, err := repo.Exists(ctx, chartName, version)
+		if exists {
+			return nil
+		}
+		if err != nil {
+			logger.Debug("Checking chart availability failed", "url", repo.config.URL, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("%s %s not available after %s: %w", chartName, version, timeout, err)
+			}
+			return fmt.Errorf("%s %s not available after %s", chartName, version, timeout)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+)
+
+func TestPushToRepositoryWaitAvailable(t *testing.T) {
+	tests := []struct {
+		name        string
+		availableAt int32 // GET attempt the version is first served on; 0 for never
+		wantErr     string
+	}{
+		{name: "available after propagation", availableAt: 3},
+		{name: "never available", wantErr: "my-app 1.0.0 not available after 100ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checks atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPost && r.URL.Path == "/api/charts":
+					w.WriteHeader(http.StatusCreated)
+				case r.Method == http.MethodGet && r.URL.Path == "/api/charts/my-app/1.0.0":
+					if n := checks.Add(1); tt.availableAt == 0 || n < tt.availableAt {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					_, _ = w.Write([]byte(`{"name": "my-app", "version": "1.0.0"}`))
+				default:
+					http.Error(w, "unexpected request", http.StatusBadRequest)
+				}
+			}))
+			defer server.Close()
+
+			packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+			writeTestPackage(t, packagePath)
+
+			repo := NewRepository(RepositoryConfig{
+				Type:          "chartmuseum",
+				URL:           server.URL,
+				WaitAvailable: true,
+				WaitTimeout:   "100ms",
+				WaitInterval:  "10ms",
+			})
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			result := pushToRepository(context.Background(), repo, packagePath, false, true, logger)
+
+			if tt.wantErr == "" {
+				if result.Err != nil {
+					t.Fatalf("unexpected error: %v", result.Err)
+				}
+				if got := checks.Load(); got != tt.availableAt {
+					t.Errorf("expected %d availability checks, got %d", tt.availableAt, got)
+				}
+				return
+			}
+			if result.Err == nil || !strings.Contains(result.Err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, result.Err)
+			}
+		})
+	}
+}
+
+func TestRepositoryExistsOCI(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		want    bool
+		wantErr bool
+	}{
+		{name: "available", script: `echo "name: my-app"`, want: true},
+		{name: "not yet available", script: `echo 'Error: ghcr.io/myorg/charts/my-app:1.0.0: not found' >&2; exit 1`},
+		{name: "registry error", script: `echo 'Error: unexpected status code 500' >&2; exit 1`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFakeCommand(t, "helm", `echo "$@" > "$(dirname "$0")/args"
+`+tt.script)
+
+			repo := NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg/charts"})
+			got, err := repo.Exists(context.Background(), "my-app", "1.0.0")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected exists=%v, got %v", tt.want, got)
+			}
+
+			args, _ := os.ReadFile(filepath.Join(dir, "args"))
+			if strings.TrimSpace(string(args)) != "show chart oci://ghcr.io/myorg/charts/my-app --version 1.0.0" {
+				t.Errorf("unexpected helm args: %s", args)
+			}
+		})
+	}
+}
+
+func TestValidateWaitAvailable(t *testing.T) {
+	tests := []struct {
+		name      string
+		repo      RepositoryConfig
+		wantValid bool
+	}{
+		{name: "oci", repo: RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg/charts", WaitAvailable: true, WaitTimeout: "5m"}, wantValid: true},
+		{name: "http", repo: RepositoryConfig{Type: "http", URL: "https://charts.example.com", WaitAvailable: true}},
+		{name: "invalid interval", repo: RepositoryConfig{Type: "chartmuseum", URL: "https://charts.example.com", WaitAvailable: true, WaitInterval: "often"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vb := helpers.NewValidationBuilder()
+			validateRepositoryConfig(vb, "repository", tt.repo, helmBinary{}, "")
+			if resp := vb.Build(); resp.Valid != tt.wantValid {
+				t.Errorf("expected valid=%v, got %+v", tt.wantValid, resp.Errors)
+			}
+		})
+	}
+}
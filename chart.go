@@ -1,11 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
 
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
 	"gopkg.in/yaml.v3"
 )
 
@@ -25,6 +31,7 @@ type Chart struct {
 	Icon         string            `yaml:"icon,omitempty"`
 	Deprecated   bool              `yaml:"deprecated,omitempty"`
 	KubeVersion  string            `yaml:"kubeVersion,omitempty"`
+	Annotations  map[string]string `yaml:"annotations,omitempty"`
 }
 
 // ChartDependency represents a chart dependency.
@@ -35,6 +42,9 @@ type ChartDependency struct {
 	Condition  string   `yaml:"condition,omitempty"`
 	Tags       []string `yaml:"tags,omitempty"`
 	Alias      string   `yaml:"alias,omitempty"`
+	// ImportValues holds child values imported into the parent: export names
+	// as strings, or maps with "child" and "parent" paths.
+	ImportValues []any `yaml:"import-values,omitempty"`
 }
 
 // Maintainer represents a chart maintainer.
@@ -44,9 +54,20 @@ type Maintainer struct {
 	URL   string `yaml:"url,omitempty"`
 }
 
+// normalizeChartPath trims whitespace and cleans a chart path, so that variants
+// like "./charts/my-app/" and "charts/my-app" are treated identically. An empty
+// path means the current directory.
+func normalizeChartPath(chartPath string) string {
+	chartPath = strings.TrimSpace(chartPath)
+	if chartPath == "" {
+		return "."
+	}
+	return filepath.Clean(chartPath)
+}
+
 // ParseChart parses a Chart.yaml file.
 func ParseChart(chartPath string) (*Chart, error) {
-	chartFile := filepath.Join(chartPath, "Chart.yaml")
+	chartFile := filepath.Join(normalizeChartPath(chartPath), "Chart.yaml")
 	data, err := os.ReadFile(chartFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read Chart.yaml: %w", err)
@@ -61,63 +82,266 @@ func ParseChart(chartPath string) (*Chart, error) {
 }
 
 // UpdateChartVersion updates the version in Chart.yaml.
+// The file is edited as a YAML node tree so comments, key order and
+// unrelated formatting are preserved.
 func UpdateChartVersion(chartPath, version, appVersion string) error {
 	chartFile := filepath.Join(chartPath, "Chart.yaml")
-	data, err := os.ReadFile(chartFile)
+	doc, err := readYAMLDocument(chartFile)
 	if err != nil {
-		return fmt.Errorf("failed to read Chart.yaml: %w", err)
+		return err
 	}
 
-	// Update version using regex to preserve formatting
-	versionPattern := regexp.MustCompile(`(?m)^version:\s*.+$`)
-	if !versionPattern.Match(data) {
+	root := doc.Content[0]
+	if mappingValue(root, "version") == nil {
 		return fmt.Errorf("version field not found in Chart.yaml")
 	}
-	data = versionPattern.ReplaceAll(data, []byte(fmt.Sprintf("version: %s", version)))
+	setMappingScalar(root, "version", version, 0, "")
 
-	// Update appVersion if provided
+	// Update appVersion if provided, adding it after version when missing
 	if appVersion != "" {
-		appVersionPattern := regexp.MustCompile(`(?m)^appVersion:\s*.+$`)
-		if appVersionPattern.Match(data) {
-			// Update existing appVersion
-			data = appVersionPattern.ReplaceAll(data, []byte(fmt.Sprintf("appVersion: %q", appVersion)))
-		} else {
-			// Add appVersion after version
-			versionLine := regexp.MustCompile(`(?m)^version:\s*.+$`)
-			data = versionLine.ReplaceAll(data,
-				[]byte(fmt.Sprintf("version: %s\nappVersion: %q", version, appVersion)))
+		setMappingScalar(root, "appVersion", appVersion, yaml.DoubleQuotedStyle, "version")
+	}
+
+	return writeYAMLDocument(chartFile, doc)
+}
+
+// UpdateChartAnnotations sets annotations in Chart.yaml, adding the annotations
+// block when missing. Existing annotations not in the map are kept. Multi-line
+// values, such as artifacthub.io/changes lists, are written as literal blocks.
+func UpdateChartAnnotations(chartPath string, annotations map[string]string) error {
+	chartFile := filepath.Join(chartPath, "Chart.yaml")
+	doc, err := readYAMLDocument(chartFile)
+	if err != nil {
+		return err
+	}
+
+	root := doc.Content[0]
+	block := mappingValue(root, "annotations")
+	if block == nil {
+		block = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "annotations"}, block)
+	} else if block.Kind != yaml.MappingNode {
+		// e.g. an empty "annotations:" key
+		*block = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(annotations)) {
+		style := yaml.DoubleQuotedStyle
+		if strings.Contains(annotations[k], "\n") {
+			style = yaml.LiteralStyle
 		}
+		setMappingScalar(block, k, annotations[k], style, "")
+	}
+
+	return writeYAMLDocument(chartFile, doc)
+}
+
+// readYAMLDocument reads a YAML file whose top-level node is a mapping.
+func readYAMLDocument(path string) (*yaml.Node, error) {
+	name := filepath.Base(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
 	}
 
-	if err := os.WriteFile(chartFile, data, 0644); err != nil {
-		return fmt.Errorf("failed to write Chart.yaml: %w", err)
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
 	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s is not a YAML mapping", name)
+	}
+	return &doc, nil
+}
 
+// writeYAMLDocument writes a YAML node tree back to a file with two-space indentation.
+func writeYAMLDocument(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
 	return nil
 }
 
-// ValidateChart validates Chart.yaml contents.
-func ValidateChart(chart *Chart) error {
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingScalar sets key to a string scalar in a mapping node. An existing
+// value is replaced in place, keeping its comments. A missing key is inserted
+// after afterKey, or appended when afterKey is empty or not present.
+func setMappingScalar(mapping *yaml.Node, key, value string, style yaml.Style, afterKey string) {
+	if node := mappingValue(mapping, key); node != nil {
+		node.Kind = yaml.ScalarNode
+		node.Tag = "!!str"
+		node.Value = value
+		node.Style = style
+		node.Content = nil
+		return
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	valueNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: style}
+
+	insertAt := len(mapping.Content)
+	if afterKey != "" {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value == afterKey {
+				insertAt = i + 2
+				break
+			}
+		}
+	}
+
+	content := make([]*yaml.Node, 0, len(mapping.Content)+2)
+	content = append(content, mapping.Content[:insertAt]...)
+	content = append(content, keyNode, valueNode)
+	content = append(content, mapping.Content[insertAt:]...)
+	mapping.Content = content
+}
+
+// ChartPolicy defines Chart.yaml authoring rules enforced on top of the
+// fields helm requires.
+type ChartPolicy struct {
+	RequireDescription bool `json:"require_description"`
+	RequireMaintainer  bool `json:"require_maintainer"`   // at least one maintainer
+	RequireHTTPSIcon   bool `json:"require_https_icon"`   // icon must be set to an https URL
+	RequireType        bool `json:"require_type"`         // type must be application or library
+	RequireKubeVersion bool `json:"require_kube_version"` // a kubeVersion constraint must be set
+}
+
+// parseChartPolicy parses the chart_policy block.
+func parseChartPolicy(raw any) ChartPolicy {
+	policyRaw, ok := raw.(map[string]any)
+	if !ok {
+		return ChartPolicy{}
+	}
+	parser := helpers.NewConfigParser(policyRaw)
+	return ChartPolicy{
+		RequireDescription: parser.GetBool("require_description", false),
+		RequireMaintainer:  parser.GetBool("require_maintainer", false),
+		RequireHTTPSIcon:   parser.GetBool("require_https_icon", false),
+		RequireType:        parser.GetBool("require_type", false),
+		RequireKubeVersion: parser.GetBool("require_kube_version", false),
+	}
+}
+
+// enabled reports whether any rule is enabled.
+func (p ChartPolicy) enabled() bool {
+	return p != ChartPolicy{}
+}
+
+// ValidateChart validates Chart.yaml contents against the fields helm requires
+// and the rules enabled in policy. Every violation is reported, joined with
+// errors.Join.
+func ValidateChart(chart *Chart, policy ChartPolicy) error {
+	var errs []error
 	if chart.Name == "" {
-		return fmt.Errorf("chart name is required")
+		errs = append(errs, fmt.Errorf("chart name is required"))
 	}
 
 	if chart.Version == "" {
-		return fmt.Errorf("chart version is required")
+		errs = append(errs, fmt.Errorf("chart version is required"))
 	}
 
 	// Validate API version
 	if chart.APIVersion == "" {
-		return fmt.Errorf("apiVersion is required")
+		errs = append(errs, fmt.Errorf("apiVersion is required"))
+	} else if chart.APIVersion != "v2" && chart.APIVersion != "v1" {
+		errs = append(errs, fmt.Errorf("apiVersion must be v1 or v2, got: %s", chart.APIVersion))
 	}
 
-	if chart.APIVersion != "v2" && chart.APIVersion != "v1" {
-		return fmt.Errorf("apiVersion must be v1 or v2, got: %s", chart.APIVersion)
+	if policy.RequireDescription && strings.TrimSpace(chart.Description) == "" {
+		errs = append(errs, fmt.Errorf("description is required"))
+	}
+	if policy.RequireMaintainer && len(chart.Maintainers) == 0 {
+		errs = append(errs, fmt.Errorf("at least one maintainer is required"))
+	}
+	if policy.RequireHTTPSIcon {
+		if chart.Icon == "" {
+			errs = append(errs, fmt.Errorf("icon is required"))
+		} else if !strings.HasPrefix(chart.Icon, "https://") {
+			errs = append(errs, fmt.Errorf("icon must be an https URL, got: %s", chart.Icon))
+		}
+	}
+	if policy.RequireType && chart.Type != "application" && chart.Type != "library" {
+		errs = append(errs, fmt.Errorf("type must be application or library, got: %q", chart.Type))
 	}
+	if policy.RequireKubeVersion && strings.TrimSpace(chart.KubeVersion) == "" {
+		errs = append(errs, fmt.Errorf("kubeVersion constraint is required"))
+	}
+
+	return errors.Join(errs...)
+}
 
+// dns1123Label matches a DNS-1123 label, which helm requires for subchart names.
+var dns1123Label = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// ValidateDependencyNames checks that every dependency name and alias is a
+// DNS-1123 label, reporting all violations together with a suggested label.
+func ValidateDependencyNames(deps []ChartDependency) error {
+	var problems []string
+	for _, dep := range deps {
+		if !isDNS1123Label(dep.Name) {
+			problems = append(problems, fmt.Sprintf("name %q: %s", dep.Name, dnsLabelHint(dep.Name)))
+		}
+		if dep.Alias != "" && !isDNS1123Label(dep.Alias) {
+			problems = append(problems, fmt.Sprintf("alias %q of %s: %s", dep.Alias, dep.Name, dnsLabelHint(dep.Alias)))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d invalid dependency name(s):\n  - %s", len(problems), strings.Join(problems, "\n  - "))
+	}
 	return nil
 }
 
+func isDNS1123Label(s string) bool {
+	return len(s) <= 63 && dns1123Label.MatchString(s)
+}
+
+// dnsLabelHint explains the label rules and suggests a normalized label.
+func dnsLabelHint(s string) string {
+	hint := "must be a DNS-1123 label (lowercase letters, digits and '-', at most 63 characters)"
+	if label := toDNS1123Label(s); label != "" {
+		hint += fmt.Sprintf(", e.g. %q", label)
+	}
+	return hint
+}
+
+// toDNS1123Label normalizes s into a DNS-1123 label by lowercasing it and
+// replacing other characters with '-'. It returns "" if nothing is left.
+func toDNS1123Label(s string) string {
+	label := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '-'
+		}
+	}, s)
+	if len(label) > 63 {
+		label = label[:63]
+	}
+	return strings.Trim(label, "-")
+}
+
 // GetChartName returns the chart name.
 func (c *Chart) GetChartName() string {
 	return c.Name
@@ -3,7 +3,11 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestParseChart(t *testing.T) {
@@ -95,6 +99,84 @@ dependencies:
 	}
 }
 
+func TestParseChartFullSpec(t *testing.T) {
+	content := `apiVersion: v2
+name: my-app
+version: 1.2.0
+kubeVersion: ">=1.25.0-0"
+description: A chart using every Chart.yaml field
+type: application
+keywords:
+  - web
+home: https://example.com/my-app
+sources:
+  - https://github.com/myorg/my-app
+dependencies:
+  - name: redis
+    version: "17.0.0"
+    repository: https://charts.bitnami.com/bitnami
+    condition: redis.enabled
+    tags:
+      - cache
+    alias: cache
+    import-values:
+      - data
+      - child: persistence
+        parent: cachePersistence
+maintainers:
+  - name: Jane Doe
+    email: jane@example.com
+    url: https://example.com/jane
+icon: https://example.com/icon.png
+appVersion: "2.0.1"
+deprecated: false
+annotations:
+  artifacthub.io/license: Apache-2.0
+  category: Web
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	chart, err := ParseChart(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if chart.KubeVersion != ">=1.25.0-0" || chart.Type != "application" || chart.AppVersion != "2.0.1" || chart.Icon == "" {
+		t.Errorf("unexpected chart metadata %+v", chart)
+	}
+	if chart.Annotations["artifacthub.io/license"] != "Apache-2.0" || chart.Annotations["category"] != "Web" {
+		t.Errorf("unexpected annotations %v", chart.Annotations)
+	}
+	if len(chart.Maintainers) != 1 || chart.Maintainers[0].URL != "https://example.com/jane" {
+		t.Errorf("unexpected maintainers %+v", chart.Maintainers)
+	}
+
+	dep := chart.Dependencies[0]
+	if dep.Alias != "cache" || dep.Condition != "redis.enabled" || len(dep.Tags) != 1 {
+		t.Errorf("unexpected dependency %+v", dep)
+	}
+	want := []any{"data", map[string]any{"child": "persistence", "parent": "cachePersistence"}}
+	if !reflect.DeepEqual(dep.ImportValues, want) {
+		t.Errorf("expected import-values %v, got %v", want, dep.ImportValues)
+	}
+
+	// Writing the chart back out and reading it again loses nothing
+	data, err := yaml.Marshal(chart)
+	if err != nil {
+		t.Fatalf("failed to marshal chart: %v", err)
+	}
+	var roundTripped Chart
+	if err := yaml.Unmarshal(data, &roundTripped); err != nil {
+		t.Fatalf("failed to unmarshal chart: %v", err)
+	}
+	if !reflect.DeepEqual(&roundTripped, chart) {
+		t.Errorf("round trip changed the chart:\n%+v\n%+v", roundTripped, *chart)
+	}
+}
+
 func TestUpdateChartVersion(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -179,6 +261,81 @@ description: Test chart
 				}
 			},
 		},
+		{
+			name: "commented out version is untouched",
+			content: `apiVersion: v2
+name: my-chart
+# version: 9.9.9
+version: 1.0.0
+description: Test chart
+`,
+			version:    "2.0.0",
+			appVersion: "",
+			wantErr:    false,
+			validate: func(t *testing.T, content string) {
+				if !contains(content, "# version: 9.9.9") {
+					t.Error("commented-out version was modified")
+				}
+				if !contains(content, "\nversion: 2.0.0\n") {
+					t.Errorf("version not updated:\n%s", content)
+				}
+			},
+		},
+		{
+			name: "quoted version containing a hash",
+			content: `apiVersion: v2
+name: my-chart
+version: "1.0.0 # stable"
+description: Test chart
+`,
+			version:    "2.0.0",
+			appVersion: "",
+			wantErr:    false,
+			validate: func(t *testing.T, content string) {
+				if contains(content, "stable") {
+					t.Errorf("old version value left behind:\n%s", content)
+				}
+				if !contains(content, "version: 2.0.0\n") {
+					t.Errorf("version not updated:\n%s", content)
+				}
+			},
+		},
+		{
+			name: "folded multi-line version",
+			content: `apiVersion: v2
+name: my-chart
+version: >-
+  1.0.0
+description: Test chart
+`,
+			version:    "2.0.0",
+			appVersion: "",
+			wantErr:    false,
+			validate: func(t *testing.T, content string) {
+				if !contains(content, "version: 2.0.0\ndescription: Test chart") {
+					t.Errorf("folded version not replaced:\n%s", content)
+				}
+			},
+		},
+		{
+			name: "nested version keys are untouched",
+			content: `apiVersion: v2
+name: my-chart
+version: 1.0.0
+dependencies:
+  - name: redis
+    version: 17.0.0
+    repository: https://charts.bitnami.com/bitnami
+`,
+			version:    "2.0.0",
+			appVersion: "",
+			wantErr:    false,
+			validate: func(t *testing.T, content string) {
+				if !contains(content, "    version: 17.0.0") {
+					t.Errorf("dependency version was modified:\n%s", content)
+				}
+			},
+		},
 		{
 			name: "no version field",
 			content: `apiVersion: v2
@@ -282,7 +439,7 @@ func TestValidateChart(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := ValidateChart(tt.chart)
+			err := ValidateChart(tt.chart, ChartPolicy{})
 
 			if tt.wantErr {
 				if err == nil {
@@ -339,3 +496,152 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestUpdateChartAnnotations(t *testing.T) {
+	tempDir := t.TempDir()
+	chartFile := filepath.Join(tempDir, "Chart.yaml")
+	content := `apiVersion: v2
+name: my-chart
+version: 1.0.0
+annotations:
+  category: Infrastructure # keep me
+`
+	if err := os.WriteFile(chartFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	err := UpdateChartAnnotations(tempDir, map[string]string{
+		"org.opencontainers.image.revision": "abc123",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	chart, err := ParseChart(tempDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if chart.Annotations["category"] != "Infrastructure" {
+		t.Errorf("existing annotation lost: %v", chart.Annotations)
+	}
+	if chart.Annotations["org.opencontainers.image.revision"] != "abc123" {
+		t.Errorf("annotation not added: %v", chart.Annotations)
+	}
+
+	result, _ := os.ReadFile(chartFile)
+	if !contains(string(result), "# keep me") {
+		t.Errorf("comment not preserved:\n%s", result)
+	}
+}
+
+func TestParseChartPathVariants(t *testing.T) {
+	root := t.TempDir()
+	chartDir := filepath.Join(root, "charts", "my-app")
+	if err := os.MkdirAll(chartDir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	t.Chdir(root)
+
+	for _, chartPath := range []string{"charts/my-app", "./charts/my-app/", " charts/my-app\n"} {
+		chart, err := ParseChart(chartPath)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", chartPath, err)
+			continue
+		}
+		if chart.Name != "my-app" {
+			t.Errorf("%q: expected name my-app, got %s", chartPath, chart.Name)
+		}
+	}
+}
+
+func TestValidateChartPolicy(t *testing.T) {
+	policy := ChartPolicy{
+		RequireDescription: true,
+		RequireMaintainer:  true,
+		RequireHTTPSIcon:   true,
+		RequireType:        true,
+		RequireKubeVersion: true,
+	}
+
+	compliant := &Chart{
+		APIVersion:  "v2",
+		Name:        "my-chart",
+		Version:     "1.0.0",
+		Description: "My chart",
+		Maintainers: []Maintainer{{Name: "Platform Team"}},
+		Icon:        "https://example.com/icon.svg",
+		Type:        "application",
+		KubeVersion: ">=1.27.0-0",
+	}
+	if err := ValidateChart(compliant, policy); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	bare := &Chart{APIVersion: "v2", Name: "my-chart", Version: "1.0.0", Icon: "http://example.com/icon.svg"}
+	if err := ValidateChart(bare, ChartPolicy{}); err != nil {
+		t.Errorf("expected no violations without a policy, got %v", err)
+	}
+
+	err := ValidateChart(bare, policy)
+	want := []string{
+		"description is required",
+		"at least one maintainer is required",
+		"icon must be an https URL, got: http://example.com/icon.svg",
+		`type must be application or library, got: ""`,
+		"kubeVersion constraint is required",
+	}
+	if err == nil || err.Error() != strings.Join(want, "\n") {
+		t.Errorf("expected every violation, got %v", err)
+	}
+
+	// Policy violations are reported together with missing required fields
+	err = ValidateChart(&Chart{APIVersion: "v2", Name: "my-chart", Type: "library", Icon: "https://example.com/icon.svg"}, ChartPolicy{RequireType: true, RequireHTTPSIcon: true, RequireDescription: true})
+	if err == nil || err.Error() != "chart version is required\ndescription is required" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateDependencyNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		deps    []ChartDependency
+		wantErr string
+	}{
+		{
+			name: "valid names and aliases",
+			deps: []ChartDependency{
+				{Name: "postgresql"},
+				{Name: "redis", Alias: "cache-01"},
+			},
+		},
+		{
+			name:    "invalid alias",
+			deps:    []ChartDependency{{Name: "redis", Alias: "Cache_DB"}},
+			wantErr: "1 invalid dependency name(s):\n  - alias \"Cache_DB\" of redis: must be a DNS-1123 label (lowercase letters, digits and '-', at most 63 characters), e.g. \"cache-db\"",
+		},
+		{
+			name: "invalid name and alias",
+			deps: []ChartDependency{
+				{Name: "my.chart"},
+				{Name: "redis", Alias: "-cache"},
+				{Name: "common", Alias: strings.Repeat("a", 64)},
+			},
+			wantErr: "3 invalid dependency name(s):\n  - name \"my.chart\": must be a DNS-1123 label (lowercase letters, digits and '-', at most 63 characters), e.g. \"my-chart\"\n  - alias \"-cache\" of redis: must be a DNS-1123 label (lowercase letters, digits and '-', at most 63 characters), e.g. \"cache\"\n  - alias \"" + strings.Repeat("a", 64) + "\" of common",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDependencyNames(tt.deps)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected error starting with %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
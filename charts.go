@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// chartHook runs a hook for a single chart, with the state shared by all
+// charts of the Execute call.
+type chartHook func(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, run *chartRun, chartPath string, logger *slog.Logger) (*plugin.ExecuteResponse, error)
+
+// chartRun is the state shared by the charts of one Execute call, which
+// forEachChart hands to every chart's hook so that charts processed
+// concurrently coordinate on what they have in common.
+type chartRun struct {
+	// logins bounds registry logins across all charts by login_concurrency.
+	logins loginLimiter
+	// depRepos are the dependency repositories added with auto_add_repos.
+	depRepos *dependencyRepos
+}
+
+// newChartRun creates the shared state for one Execute call.
+func newChartRun(cfg *Config) *chartRun {
+	return &chartRun{
+		logins:   newLoginLimiter(cfg.LoginConcurrency),
+		depRepos: newDependencyRepos(),
+	}
+}
+
+// chartPath returns the single configured chart path.
+func (c *Config) chartPath() string {
+	if c.ChartPath == "" {
+		return "."
+	}
+	return c.ChartPath
+}
+
+// resolveChartPaths expands chart path glob patterns into the sorted list of
+// directories containing a Chart.yaml. A pattern matching no chart is an error.
+func resolveChartPaths(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chart path pattern %q: %w", pattern, err)
+		}
+
+		found := false
+		for _, match := range matches {
+			if _, err := os.Stat(filepath.Join(match, "Chart.yaml")); err != nil {
+				continue
+			}
+			found = true
+			if !seen[match] {
+				seen[match] = true
+				paths = append(paths, match)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no charts found matching %q", pattern)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// chartDependencies returns, for each chart, the other charts in chartPaths it
+// depends on through one of the target repositories. Charts that can't be
+// parsed are left for the hook to report.
+func chartDependencies(chartPaths []string, repos []RepositoryConfig) map[string][]string {
+	targets := make(map[string]bool)
+	for _, repo := range repos {
+		targets[strings.TrimSuffix(repo.URL, "/")] = true
+		if repo.Type == "http" && repo.URL != "" {
+			targets[NewRepository(repo).packageDirURL()] = true
+		}
+	}
+
+	byName := make(map[string]string)
+	charts := make(map[string]*Chart)
+	for _, chartPath := range chartPaths {
+		if chart, err := ParseChart(chartPath); err == nil {
+			charts[chartPath] = chart
+			byName[chart.Name] = chartPath
+		}
+	}
+
+	deps := make(map[string][]string)
+	for _, chartPath := range chartPaths {
+		chart := charts[chartPath]
+		if chart == nil {
+			continue
+		}
+		for _, dep := range chart.Dependencies {
+			if depPath, ok := byName[dep.Name]; ok && targets[strings.TrimSuffix(dep.Repository, "/")] {
+				deps[chartPath] = append(deps[chartPath], depPath)
+			}
+		}
+	}
+	return deps
+}
+
+// orderChartPaths orders charts so that the charts each one depends on, as
+// given by chartDependencies, come before it, keeping the given order
+// otherwise. A dependency cycle is an error.
+func orderChartPaths(chartPaths []string, deps map[string][]string) ([]string, error) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	ordered := make([]string, 0, len(chartPaths))
+	var stack []string
+	var visit func(chartPath string) error
+	visit = func(chartPath string) error {
+		switch state[chartPath] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for stack[start] != chartPath {
+				start++
+			}
+			cycle := append(append([]string{}, stack[start:]...), chartPath)
+			return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		state[chartPath] = visiting
+		stack = append(stack, chartPath)
+		for _, depPath := range deps[chartPath] {
+			if err := visit(depPath); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[chartPath] = done
+		ordered = append(ordered, chartPath)
+		return nil
+	}
+
+	for _, chartPath := range chartPaths {
+		if err := visit(chartPath); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// forEachChart runs hook for every configured chart. A single chart_path is
+// handled directly; with chart_paths each chart is processed independently,
+// dependencies first, and the results are summarized in one response. Up to
+// concurrency charts are processed at once; a chart starts only after the
+// charts it depends on have finished, and results are reported in chart order
+// regardless of which finishes first. Every hook is given the same run.
+func (p *HelmPlugin) forEachChart(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, run *chartRun, logger *slog.Logger, hook chartHook) (*plugin.ExecuteResponse, error) {
+	if len(cfg.ChartPaths) == 0 {
+		return hook(ctx, releaseCtx, cfg, run, cfg.chartPath(), logger)
+	}
+
+	chartPaths, err := resolveChartPaths(cfg.ChartPaths)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to resolve chart paths: %v", err),
+		}, nil
+	}
+
+	deps := chartDependencies(chartPaths, cfg.targetRepositories())
+	chartPaths, err = orderChartPaths(chartPaths, deps)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to order charts: %v", err),
+		}, nil
+	}
+
+	done := make(map[string]chan struct{}, len(chartPaths))
+	for _, chartPath := range chartPaths {
+		done[chartPath] = make(chan struct{})
+	}
+
+	// Charts not yet started are skipped once a hook errors, or once a chart
+	// fails with fail_fast set.
+	responses := make([]*plugin.ExecuteResponse, len(chartPaths))
+	errs := make([]error, len(chartPaths))
+	var aborted, failed atomic.Bool
+	group := newWorkerGroup(cfg.Concurrency)
+	for i, chartPath := range chartPaths {
+		wait := func() {
+			for _, dep := range deps[chartPath] {
+				<-done[dep]
+			}
+		}
+		group.Go(wait, func() {
+			defer close(done[chartPath])
+			if aborted.Load() || (cfg.FailFast && failed.Load()) {
+				return
+			}
+			resp, err := hook(ctx, releaseCtx, cfg, run, chartPath, logger.With("chart_path", chartPath))
+			if err != nil {
+				aborted.Store(true)
+				errs[i] = err
+				return
+			}
+			if !resp.Success {
+				failed.Store(true)
+			}
+			responses[i] = resp
+		})
+	}
+	group.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return combineChartResponses(chartPaths, responses), nil
+}
+
+// combineChartResponses summarizes per-chart responses. The combined response
+// succeeds only if every chart succeeded; per-chart outputs are listed under
+// "charts". Charts without a response, either nil or past the end of responses,
+// are reported as skipped.
+func combineChartResponses(chartPaths []string, responses []*plugin.ExecuteResponse) *plugin.ExecuteResponse {
+	success := true
+	failed := 0
+	var lines []string
+	var charts []map[string]any
+	var artifacts []plugin.Artifact
+	for i, chartPath := range chartPaths {
+		var resp *plugin.ExecuteResponse
+		if i < len(responses) {
+			resp = responses[i]
+		}
+		if resp == nil {
+			lines = append(lines, fmt.Sprintf("  - %s: skipped", chartPath))
+			continue
+		}
+
+		status := "ok"
+		if !resp.Success {
+			success = false
+			failed++
+			status = "failed"
+		}
+		lines = append(lines, fmt.Sprintf("  - %s: %s: %s", chartPath, status, resp.Message))
+
+		entry := map[string]any{
+			"chart_path": chartPath,
+			"success":    resp.Success,
+			"message":    resp.Message,
+		}
+		for k, v := range resp.Outputs {
+			entry[k] = v
+		}
+		charts = append(charts, entry)
+		artifacts = append(artifacts, resp.Artifacts...)
+	}
+
+	msg := fmt.Sprintf("Processed %d chart(s)", len(chartPaths))
+	if failed > 0 {
+		msg = fmt.Sprintf("%d of %d chart(s) failed", failed, len(chartPaths))
+	}
+	return &plugin.ExecuteResponse{
+		Success:   success,
+		Message:   msg + ":\n" + strings.Join(lines, "\n"),
+		Outputs:   map[string]any{"charts": charts},
+		Artifacts: artifacts,
+	}
+}
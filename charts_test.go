@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// writeChart writes a minimal chart into dir.
+func writeChart(t *testing.T, dir, name, version string) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create chart dir: %v", err)
+	}
+	content := "apiVersion: v2\nname: " + name + "\nversion: " + version + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+}
+
+func TestResolveChartPaths(t *testing.T) {
+	root := t.TempDir()
+	writeChart(t, filepath.Join(root, "charts", "web"), "web", "1.0.0")
+	writeChart(t, filepath.Join(root, "charts", "api"), "api", "1.0.0")
+	writeChart(t, filepath.Join(root, "umbrella"), "umbrella", "1.0.0")
+	if err := os.MkdirAll(filepath.Join(root, "charts", "docs"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	paths, err := resolveChartPaths([]string{
+		filepath.Join(root, "charts", "*"),
+		filepath.Join(root, "umbrella"),
+		filepath.Join(root, "charts", "web"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(root, "charts", "api"),
+		filepath.Join(root, "charts", "web"),
+		filepath.Join(root, "umbrella"),
+	}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, paths)
+	}
+
+	if _, err := resolveChartPaths([]string{filepath.Join(root, "missing", "*")}); err == nil {
+		t.Error("expected error for pattern matching no charts")
+	}
+}
+
+func TestOrderChartPaths(t *testing.T) {
+	const repo = "oci://ghcr.io/myorg/charts"
+	writeChartWithDeps := func(t *testing.T, dir, name string, deps ...string) {
+		t.Helper()
+		writeChart(t, dir, name, "1.0.0")
+		if len(deps) == 0 {
+			return
+		}
+		content := "apiVersion: v2\nname: " + name + "\nversion: 1.0.0\ndependencies:\n"
+		for _, dep := range deps {
+			name, repository, _ := strings.Cut(dep, "@")
+			content += "  - name: " + name + "\n    version: 1.0.0\n    repository: " + repository + "\n"
+		}
+		if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write Chart.yaml: %v", err)
+		}
+	}
+
+	t.Run("dependencies first", func(t *testing.T) {
+		root := t.TempDir()
+		// alpha -> web -> api -> common, web -> common; zeta's redis is external
+		writeChartWithDeps(t, filepath.Join(root, "alpha"), "alpha", "web@"+repo)
+		writeChartWithDeps(t, filepath.Join(root, "api"), "api", "common@"+repo+"/")
+		writeChartWithDeps(t, filepath.Join(root, "common"), "common")
+		writeChartWithDeps(t, filepath.Join(root, "web"), "web", "api@"+repo, "common@"+repo)
+		writeChartWithDeps(t, filepath.Join(root, "zeta"), "zeta", "redis@https://charts.bitnami.com/bitnami", "common@https://other.example.com/charts")
+
+		paths, err := resolveChartPaths([]string{filepath.Join(root, "*")})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ordered, err := orderChartPaths(paths, chartDependencies(paths, []RepositoryConfig{{Type: "oci", URL: repo}}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var names []string
+		for _, path := range ordered {
+			names = append(names, filepath.Base(path))
+		}
+		if got, want := strings.Join(names, ","), "common,api,web,alpha,zeta"; got != want {
+			t.Errorf("expected order %s, got %s", want, got)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		root := t.TempDir()
+		writeChartWithDeps(t, filepath.Join(root, "a"), "a", "b@"+repo)
+		writeChartWithDeps(t, filepath.Join(root, "b"), "b", "c@"+repo)
+		writeChartWithDeps(t, filepath.Join(root, "c"), "c", "a@"+repo)
+
+		paths := []string{filepath.Join(root, "a"), filepath.Join(root, "b"), filepath.Join(root, "c")}
+		_, err := orderChartPaths(paths, chartDependencies(paths, []RepositoryConfig{{Type: "oci", URL: repo}}))
+		want := "dependency cycle: " + strings.Join(append(paths, paths[0]), " -> ")
+		if err == nil || err.Error() != want {
+			t.Errorf("expected %q, got %v", want, err)
+		}
+	})
+}
+
+func TestForEachChartConcurrency(t *testing.T) {
+	const repo = "oci://ghcr.io/myorg/charts"
+	root := t.TempDir()
+	for _, name := range []string{"api", "common", "web", "worker"} {
+		writeChart(t, filepath.Join(root, name), name, "1.0.0")
+	}
+	// web depends on common, published to the same repository
+	content := "apiVersion: v2\nname: web\nversion: 1.0.0\ndependencies:\n  - name: common\n    version: 1.0.0\n    repository: " + repo + "\n"
+	if err := os.WriteFile(filepath.Join(root, "web", "Chart.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	finished := map[string]bool{}
+	hook := func(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, run *chartRun, chartPath string, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+		name := filepath.Base(chartPath)
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		commonDone := finished["common"]
+		mu.Unlock()
+		if name == "web" && !commonDone {
+			t.Error("expected web to start after common finished")
+		}
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		finished[name] = true
+		mu.Unlock()
+		return &plugin.ExecuteResponse{Success: name != "worker", Message: "done " + name}, nil
+	}
+
+	p := &HelmPlugin{}
+	cfg := &Config{
+		ChartPaths:  []string{filepath.Join(root, "*")},
+		Repository:  RepositoryConfig{Type: "oci", URL: repo},
+		Concurrency: 2,
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.forEachChart(context.Background(), &plugin.ReleaseContext{}, cfg, newChartRun(cfg), logger, hook)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if maxRunning != 2 {
+		t.Errorf("expected 2 charts to run at once, got %d", maxRunning)
+	}
+	var names []string
+	for _, chart := range resp.Outputs["charts"].([]map[string]any) {
+		names = append(names, filepath.Base(chart["chart_path"].(string)))
+	}
+	if got, want := strings.Join(names, ","), "api,common,web,worker"; got != want {
+		t.Errorf("expected results in chart order %s, got %s", want, got)
+	}
+	if resp.Success || !strings.HasPrefix(resp.Message, "1 of 4 chart(s) failed") {
+		t.Errorf("unexpected response: %s", resp.Message)
+	}
+}
+
+func TestForEachChartSharesLogins(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"api", "web", "worker"} {
+		writeChart(t, filepath.Join(root, name), name, "1.0.0")
+	}
+
+	// Each chart holds a login slot for a while; with the default
+	// login_concurrency of one the charts must take turns even though they
+	// are processed concurrently.
+	var mu sync.Mutex
+	holding, maxHolding := 0, 0
+	hook := func(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, run *chartRun, chartPath string, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+		if err := run.logins.acquire(ctx); err != nil {
+			return nil, err
+		}
+		defer run.logins.release()
+		mu.Lock()
+		holding++
+		maxHolding = max(maxHolding, holding)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		holding--
+		mu.Unlock()
+		return &plugin.ExecuteResponse{Success: true}, nil
+	}
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_paths": []any{filepath.Join(root, "*")},
+		"concurrency": 3,
+	})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if _, err := p.forEachChart(context.Background(), &plugin.ReleaseContext{}, cfg, newChartRun(cfg), logger, hook); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if maxHolding != 1 {
+		t.Errorf("expected logins to be serialized across charts, got %d at once", maxHolding)
+	}
+}
+
+func TestExecutePrePublishMultipleCharts(t *testing.T) {
+	root := t.TempDir()
+	writeChart(t, filepath.Join(root, "charts", "api"), "api", "0.1.0")
+	writeChart(t, filepath.Join(root, "charts", "web"), "web", "0.2.0")
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_paths":       []any{filepath.Join(root, "charts", "*")},
+		"lint":              false,
+		"template_validate": false,
+		"dependencies": map[string]any{
+			"update": false,
+			"build":  false,
+		},
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.2.3"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+	if !strings.Contains(resp.Message, "Processed 2 chart(s)") {
+		t.Errorf("unexpected message: %s", resp.Message)
+	}
+
+	for _, name := range []string{"api", "web"} {
+		chart, err := ParseChart(filepath.Join(root, "charts", name))
+		if err != nil {
+			t.Fatalf("failed to parse chart: %v", err)
+		}
+		if chart.Version != "1.2.3" {
+			t.Errorf("expected %s version 1.2.3, got %s", name, chart.Version)
+		}
+	}
+}
+
+func TestCombineChartResponses(t *testing.T) {
+	resp := combineChartResponses(
+		[]string{"charts/api", "charts/web", "charts/worker"},
+		[]*plugin.ExecuteResponse{
+			{Success: true, Message: "Published api-1.0.0", Outputs: map[string]any{"chart_version": "1.0.0"}},
+			{Success: false, Message: "Failed to package chart: boom"},
+		},
+	)
+
+	if resp.Success {
+		t.Error("expected combined response to fail")
+	}
+	if !strings.HasPrefix(resp.Message, "1 of 3 chart(s) failed") {
+		t.Errorf("unexpected message: %s", resp.Message)
+	}
+	if !strings.Contains(resp.Message, "charts/worker: skipped") {
+		t.Errorf("expected skipped chart in message: %s", resp.Message)
+	}
+
+	charts := resp.Outputs["charts"].([]map[string]any)
+	if len(charts) != 2 || charts[0]["chart_version"] != "1.0.0" {
+		t.Errorf("unexpected chart outputs: %v", charts)
+	}
+}
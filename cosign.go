@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// CosignOptions contains cosign signing options for OCI pushes.
+type CosignOptions struct {
+	Key     string // path to a cosign private key
+	Keyless bool   // sign keylessly via Sigstore
+}
+
+// args returns the cosign sign arguments for ref.
+func (o *CosignOptions) args(ref string) []string {
+	args := []string{"sign", "--yes"}
+	if o.Key != "" {
+		args = append(args, "--key", o.Key)
+	}
+	return append(args, ref)
+}
+
+// cosignSign signs the pushed artifact ref with cosign, bounded by timeout
+// like helm commands are.
+func cosignSign(ctx context.Context, opts *CosignOptions, ref string, timeout time.Duration) error {
+	newCmd := func(ctx context.Context) *exec.Cmd { return exec.CommandContext(ctx, "cosign", opts.args(ref)...) }
+	err := runWithTimeout(ctx, "cosign sign", timeout, newCmd, func(cmd *exec.Cmd) error {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Env = os.Environ()
+		if opts.Keyless {
+			cmd.Env = append(cmd.Env, "COSIGN_EXPERIMENTAL=1")
+		}
+		return cmd.Run()
+	})
+	if errors.Is(err, ErrHelmTimeout) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("cosign sign failed: %w", err)
+	}
+	return nil
+}
+
+// extractPushedReference extracts the pushed reference from helm push output.
+// Output: "Pushed: ghcr.io/myorg/my-chart:1.0.0\nDigest: sha256:..."
+func extractPushedReference(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if ref, ok := strings.CutPrefix(line, "Pushed:"); ok {
+			return strings.TrimSpace(ref)
+		}
+	}
+	return ""
+}
+
+// digestReference replaces the tag of a pushed reference with digest, e.g.
+// "ghcr.io/myorg/my-chart:1.0.0" becomes "ghcr.io/myorg/my-chart@sha256:...".
+func digestReference(pushed, digest string) string {
+	name := pushed
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return name + "@" + digest
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigestReference(t *testing.T) {
+	tests := []struct {
+		pushed string
+		want   string
+	}{
+		{pushed: "ghcr.io/myorg/my-chart:1.0.0", want: "ghcr.io/myorg/my-chart@sha256:abc"},
+		{pushed: "localhost:5000/charts/my-chart:1.0.0", want: "localhost:5000/charts/my-chart@sha256:abc"},
+		{pushed: "localhost:5000/charts/my-chart", want: "localhost:5000/charts/my-chart@sha256:abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pushed, func(t *testing.T) {
+			if got := digestReference(tt.pushed, "sha256:abc"); got != tt.want {
+				t.Errorf("expected '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRepositoryPushOCISignsWithCosign(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "Pushed: ghcr.io/myorg/my-app:1.0.0"
+echo "Digest: sha256:deadbeef"
+`)
+	cosignDir := writeFakeCommand(t, "cosign", `echo "$@" > "$(dirname "$0")/args"
+echo "$COSIGN_EXPERIMENTAL" > "$(dirname "$0")/env"
+`)
+
+	repo := NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg"})
+	repo.SetCosign(&CosignOptions{Keyless: true})
+
+	if _, err := repo.Push(context.Background(), "/tmp/my-app-1.0.0.tgz"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(cosignDir, "args"))
+	if got := strings.TrimSpace(string(args)); got != "sign --yes ghcr.io/myorg/my-app@sha256:deadbeef" {
+		t.Errorf("unexpected cosign args: %s", got)
+	}
+	env, _ := os.ReadFile(filepath.Join(cosignDir, "env"))
+	if strings.TrimSpace(string(env)) != "1" {
+		t.Errorf("expected COSIGN_EXPERIMENTAL=1, got %q", env)
+	}
+}
+
+func TestRepositoryPushOCICosignRequiresDigest(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "Pushed: ghcr.io/myorg/my-app:1.0.0"`)
+
+	repo := NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg"})
+	repo.SetCosign(&CosignOptions{Key: "cosign.key"})
+
+	if _, err := repo.Push(context.Background(), "/tmp/my-app-1.0.0.tgz"); err == nil {
+		t.Error("expected error when push output has no digest")
+	}
+}
+
+func TestRepositoryPushOCICosignTimeout(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "Pushed: ghcr.io/myorg/my-app:1.0.0"
+echo "Digest: sha256:deadbeef"
+`)
+	writeFakeCommand(t, "cosign", `exec sleep 5`)
+
+	repo := NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg"})
+	repo.SetCosign(&CosignOptions{Key: "cosign.key"})
+	repo.SetTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	_, err := repo.Push(context.Background(), "/tmp/my-app-1.0.0.tgz")
+	if !errors.Is(err, ErrHelmTimeout) || err.Error() != "cosign sign timed out after 50ms" {
+		t.Fatalf("expected cosign to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("cosign was not cancelled promptly (%s)", elapsed)
+	}
+}
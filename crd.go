@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// crdDefinition is the API a CustomResourceDefinition serves.
+type crdDefinition struct {
+	Group    string
+	Kind     string
+	Versions []string
+}
+
+// parseCRD extracts the group, kind and served versions of a CRD manifest,
+// supporting both apiextensions.k8s.io/v1 (spec.versions) and the older
+// v1beta1 (spec.version) layout.
+func parseCRD(m Manifest) (crdDefinition, bool) {
+	if m.Kind != "CustomResourceDefinition" {
+		return crdDefinition{}, false
+	}
+	spec, _ := m.Object["spec"].(map[string]any)
+	names, _ := spec["names"].(map[string]any)
+
+	var crd crdDefinition
+	crd.Group, _ = spec["group"].(string)
+	crd.Kind, _ = names["kind"].(string)
+	if version, ok := spec["version"].(string); ok {
+		crd.Versions = append(crd.Versions, version)
+	}
+	versions, _ := spec["versions"].([]any)
+	for _, v := range versions {
+		entry, _ := v.(map[string]any)
+		if name, ok := entry["name"].(string); ok {
+			crd.Versions = append(crd.Versions, name)
+		}
+	}
+	return crd, crd.Group != "" && crd.Kind != ""
+}
+
+// chartCRDFiles lists the YAML and JSON files in the chart's crds/ directory.
+func chartCRDFiles(chartPath string) ([]string, error) {
+	dir := filepath.Join(chartPath, "crds")
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRDs: %w", err)
+	}
+	return files, nil
+}
+
+// loadChartCRDs parses the CRDs shipped in the chart's crds/ directory.
+func loadChartCRDs(chartPath string) ([]Manifest, error) {
+	files, err := chartCRDFiles(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	var crds []Manifest
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CRDs: %w", err)
+		}
+		manifests, err := ParseManifests(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CRDs: %s: %w", path, err)
+		}
+		crds = append(crds, manifests...)
+	}
+	return crds, nil
+}
+
+// validateCRDFiles checks that every file in the chart's crds/ directory is
+// valid YAML holding only CustomResourceDefinitions. Helm installs these files
+// as-is, without templating, so a broken CRD otherwise only shows up in the
+// cluster. Every malformed file is reported with its path.
+func validateCRDFiles(chartPath string) error {
+	files, err := chartCRDFiles(chartPath)
+	if err != nil {
+		return err
+	}
+	var failures []string
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read CRDs: %w", err)
+		}
+		manifests, err := ParseManifests(data)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: invalid YAML: %v", path, errors.Unwrap(err)))
+			continue
+		}
+		if len(manifests) == 0 {
+			failures = append(failures, fmt.Sprintf("%s: no CustomResourceDefinition found", path))
+		}
+		for _, m := range manifests {
+			if m.Kind != "CustomResourceDefinition" {
+				failures = append(failures, fmt.Sprintf("%s: %s has kind %q, expected CustomResourceDefinition", path, m.Name, m.Kind))
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d malformed CRD file(s):\n  - %s", len(failures), strings.Join(failures, "\n  - "))
+	}
+	return nil
+}
+
+// findCRDrift reports custom resources whose apiVersion/kind isn't served by the
+// chart's CRDs. Only resources in an API group the chart defines a CRD for are
+// checked, so built-in and third-party resources are ignored.
+func findCRDrift(manifests, crds []Manifest) []string {
+	served := make(map[string]map[string][]string) // group -> kind -> versions
+	for _, m := range slices.Concat(crds, manifests) {
+		crd, ok := parseCRD(m)
+		if !ok {
+			continue
+		}
+		if served[crd.Group] == nil {
+			served[crd.Group] = make(map[string][]string)
+		}
+		served[crd.Group][crd.Kind] = append(served[crd.Group][crd.Kind], crd.Versions...)
+	}
+
+	var offenders []string
+	for _, m := range manifests {
+		group, version, ok := strings.Cut(m.APIVersion, "/")
+		if !ok || served[group] == nil {
+			continue
+		}
+		versions, ok := served[group][m.Kind]
+		switch {
+		case !ok:
+			offenders = append(offenders, fmt.Sprintf("%s uses kind %s, which no CRD in group %s defines", m, m.Kind, group))
+		case !slices.Contains(versions, version):
+			sort.Strings(versions)
+			offenders = append(offenders, fmt.Sprintf("%s uses %s, but the %s CRD serves %s", m, m.APIVersion, m.Kind, strings.Join(versions, ", ")))
+		}
+	}
+	return offenders
+}
+
+// checkCRConsistency verifies every custom resource rendered from the chart's
+// templates matches a CRD shipped in crds/ (or rendered alongside it).
+func checkCRConsistency(chartPath string, manifests []Manifest) error {
+	crds, err := loadChartCRDs(chartPath)
+	if err != nil {
+		return err
+	}
+	if offenders := findCRDrift(manifests, crds); len(offenders) > 0 {
+		return fmt.Errorf("%d custom resource(s) don't match the chart's CRDs:\n  - %s", len(offenders), strings.Join(offenders, "\n  - "))
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const widgetCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+    - name: v1
+      served: true
+      storage: true
+    - name: v1beta1
+      served: true
+      storage: false
+`
+
+func TestCheckCRConsistency(t *testing.T) {
+	tests := []struct {
+		name     string
+		rendered string
+		wantErr  string
+	}{
+		{
+			name: "matching",
+			rendered: `---
+# Source: my-app/templates/widget.yaml
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: default
+---
+# Source: my-app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+---
+# Source: my-app/templates/monitor.yaml
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: my-app
+`,
+		},
+		{
+			name: "version drift",
+			rendered: `---
+# Source: my-app/templates/widget.yaml
+apiVersion: example.com/v1alpha1
+kind: Widget
+metadata:
+  name: default
+`,
+			wantErr: "Widget/default (my-app/templates/widget.yaml) uses example.com/v1alpha1, but the Widget CRD serves v1, v1beta1",
+		},
+		{
+			name: "unknown kind",
+			rendered: `---
+# Source: my-app/templates/gadget.yaml
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: default
+`,
+			wantErr: "Gadget/default (my-app/templates/gadget.yaml) uses kind Gadget, which no CRD in group example.com defines",
+		},
+	}
+
+	chartDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(chartDir, "crds"), 0755); err != nil {
+		t.Fatalf("failed to create crds directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "crds", "widgets.yaml"), []byte(widgetCRD), 0644); err != nil {
+		t.Fatalf("failed to write CRD: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCRConsistency(chartDir, mustParseManifests(t, tt.rendered))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckCRConsistencyWithoutCRDs(t *testing.T) {
+	rendered := "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: default\n"
+	if err := checkCRConsistency(t.TempDir(), mustParseManifests(t, rendered)); err != nil {
+		t.Errorf("expected charts without CRDs to pass, got %v", err)
+	}
+
+	// CRDs rendered from templates count as well
+	rendered = "---\n" + widgetCRD + "---\napiVersion: example.com/v2\nkind: Widget\nmetadata:\n  name: default\n"
+	if err := checkCRConsistency(t.TempDir(), mustParseManifests(t, rendered)); err == nil {
+		t.Error("expected drift against a templated CRD to be reported")
+	}
+}
+
+func TestValidateCRDFiles(t *testing.T) {
+	chartDir := t.TempDir()
+	crdDir := filepath.Join(chartDir, "crds")
+	if err := os.MkdirAll(filepath.Join(crdDir, "nested"), 0755); err != nil {
+		t.Fatalf("failed to create crds dir: %v", err)
+	}
+	if err := validateCRDFiles(chartDir); err != nil {
+		t.Fatalf("expected an empty crds directory to pass, got %v", err)
+	}
+
+	files := map[string]string{
+		"widgets.yaml":        widgetCRD,
+		"nested/gadgets.yaml": strings.ReplaceAll(widgetCRD, "widget", "gadget"),
+		"README.md":           "not a manifest",
+		"broken.yaml":         "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nspec:\n  group: [example.com\n",
+		"configmap.yml":       "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n",
+		"empty.yaml":          "# nothing here\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(crdDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	err := validateCRDFiles(chartDir)
+	if err == nil {
+		t.Fatal("expected malformed CRD files to be reported")
+	}
+	for _, want := range []string{
+		"3 malformed CRD file(s)",
+		filepath.Join(crdDir, "broken.yaml") + ": invalid YAML",
+		filepath.Join(crdDir, "configmap.yml") + `: settings has kind "ConfigMap", expected CustomResourceDefinition`,
+		filepath.Join(crdDir, "empty.yaml") + ": no CustomResourceDefinition found",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got: %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "widgets.yaml") || strings.Contains(err.Error(), "gadgets.yaml") || strings.Contains(err.Error(), "README") {
+		t.Errorf("expected only malformed files reported, got: %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// credentialSet holds resolved repository credentials.
+type credentialSet struct {
+	username string
+	password string
+}
+
+// credentialCommandOutput is the JSON document a credential command must print.
+type credentialCommandOutput struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Token    string `json:"token"`
+}
+
+// credentials returns the username and password used to authenticate with the
+// repository, according to the configured auth mode. Credentials obtained from
+// external commands are cached for the lifetime of the repository handler.
+func (r *Repository) credentials(ctx context.Context) (string, string, error) {
+	r.credMu.Lock()
+	defer r.credMu.Unlock()
+
+	if r.creds != nil {
+		return r.creds.username, r.creds.password, nil
+	}
+
+	var creds credentialSet
+	switch {
+	case r.config.CredentialCommand != "":
+		out, err := runCredentialCommand(ctx, r.config.CredentialCommand)
+		if err != nil {
+			return "", "", err
+		}
+		creds.username = out.Username
+		if creds.username == "" {
+			username, err := resolveCredential(r.config.Username, r.config.UsernameEnv, r.config.UsernameFile)
+			if err != nil {
+				return "", "", fmt.Errorf("failed to resolve username: %w", err)
+			}
+			creds.username = username
+		}
+		creds.password = out.Password
+		if creds.password == "" {
+			creds.password = out.Token
+		}
+	case r.config.AuthMode == "" || r.config.AuthMode == "static":
+		var err error
+		if creds.username, err = resolveCredential(r.config.Username, r.config.UsernameEnv, r.config.UsernameFile); err != nil {
+			return "", "", fmt.Errorf("failed to resolve username: %w", err)
+		}
+		if creds.password, err = resolveCredential(r.config.Password, r.config.PasswordEnv, r.config.PasswordFile); err != nil {
+			return "", "", fmt.Errorf("failed to resolve password: %w", err)
+		}
+	case r.config.AuthMode == "ecr":
+		token, err := ecrLoginPassword(ctx, r.config.URL)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to obtain ECR token: %w", err)
+		}
+		creds = credentialSet{username: "AWS", password: token}
+	default:
+		return "", "", fmt.Errorf("unsupported auth mode: %s", r.config.AuthMode)
+	}
+
+	r.creds = &creds
+	return creds.username, creds.password, nil
+}
+
+// resolveCredential returns a credential from, in order of precedence, the
+// contents of file, the environment variable envVar, or the literal value.
+// Trailing newlines are trimmed from file contents.
+func resolveCredential(literal, envVar, file string) (string, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read credential file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if envVar != "" {
+		if value := os.Getenv(envVar); value != "" {
+			return value, nil
+		}
+	}
+	return literal, nil
+}
+
+// credentialSourceWarnings reports credentials that have a literal value in the
+// config alongside an env or file source, where the literal is silently ignored.
+func credentialSourceWarnings(field string, repo RepositoryConfig) []string {
+	var warnings []string
+	if repo.Username != "" && (repo.UsernameEnv != "" || repo.UsernameFile != "") {
+		warnings = append(warnings, fmt.Sprintf("%s.username is overridden by username_env/username_file", field))
+	}
+	if repo.Password != "" && (repo.PasswordEnv != "" || repo.PasswordFile != "") {
+		warnings = append(warnings, fmt.Sprintf("%s.password is set alongside password_env/password_file; remove the literal password from the config", field))
+	}
+	return warnings
+}
+
+// runCredentialCommand runs a credential helper command through the shell and
+// parses its JSON output. The output is never logged or included in errors.
+func runCredentialCommand(ctx context.Context, command string) (*credentialCommandOutput, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var creds credentialCommandOutput
+	if err := json.Unmarshal(output, &creds); err != nil {
+		return nil, fmt.Errorf("credential command returned invalid JSON")
+	}
+	if creds.Password == "" && creds.Token == "" {
+		return nil, fmt.Errorf("credential command returned neither a password nor a token")
+	}
+	return &creds, nil
+}
+
+// setAuth adds authentication to an HTTP request when credentials are
+// available: the API key or bearer token of artifactory and cloudsmith
+// repositories when configured, basic authentication otherwise.
+func (r *Repository) setAuth(ctx context.Context, req *http.Request) error {
+	if header := apiKeyHeaders[r.config.Type]; header != "" {
+		apiKey, err := resolveCredential(r.config.APIKey, r.config.APIKeyEnv, "")
+		if err != nil {
+			return err
+		}
+		if apiKey != "" {
+			req.Header.Set(header, apiKey)
+			return nil
+		}
+		token, err := resolveCredential(r.config.Token, r.config.TokenEnv, "")
+		if err != nil {
+			return err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		}
+	}
+
+	username, password, err := r.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	if username != "" && password != "" {
+		req.SetBasicAuth(username, password)
+	}
+	return nil
+}
+
+// CheckAuth confirms the repository accepts the configured credentials without
+// publishing anything: OCI registries are logged in to, ChartMuseum and HTTP
+// repositories must not reject an authenticated GET, and Cloudsmith must accept
+// the credentials for its current user endpoint. Bucket repositories
+// authenticate through the cloud provider environment and are not checked.
+func (r *Repository) CheckAuth(ctx context.Context) error {
+	switch r.config.Type {
+	case "oci":
+		return r.loginOCI(ctx)
+	case "chartmuseum":
+		return r.checkHTTPAuth(ctx, r.chartMuseumAPI(""))
+	case "http", "artifactory":
+		return r.checkHTTPAuth(ctx, r.config.URL)
+	case "cloudsmith":
+		return r.checkHTTPAuth(ctx, cloudsmithAPIURL+"/v1/user/self/")
+	default:
+		return nil
+	}
+}
+
+// checkHTTPAuth sends an authenticated GET to endpoint and fails if the
+// credentials are rejected.
+func (r *Repository) checkHTTPAuth(ctx context.Context, endpoint string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := r.setAuth(ctx, req); err != nil {
+		return err
+	}
+
+	client, err := r.httpClient(10 * time.Second)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("auth check request failed: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("authentication failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ecrLoginPassword fetches a short-lived ECR token using the AWS CLI. The CLI
+// honors AWS_PROFILE and AWS_REGION from the environment; when no region is set
+// it is derived from the registry host.
+func ecrLoginPassword(ctx context.Context, registryURL string) (string, error) {
+	args := []string{"ecr", "get-login-password"}
+	if os.Getenv("AWS_REGION") == "" && os.Getenv("AWS_DEFAULT_REGION") == "" {
+		if region := ecrRegion(registryURL); region != "" {
+			args = append(args, "--region", region)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "aws", args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("aws ecr get-login-password failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	token := strings.TrimSpace(string(output))
+	if token == "" {
+		return "", fmt.Errorf("aws ecr get-login-password returned an empty token")
+	}
+	return token, nil
+}
+
+// ecrRegion extracts the region from an ECR registry URL such as
+// oci://123456789012.dkr.ecr.eu-west-1.amazonaws.com/charts.
+func ecrRegion(registryURL string) string {
+	host := strings.SplitN(strings.TrimPrefix(registryURL, "oci://"), "/", 2)[0]
+	parts := strings.Split(host, ".")
+	for i := 0; i+2 < len(parts); i++ {
+		if parts[i] == "dkr" && parts[i+1] == "ecr" {
+			return parts[i+2]
+		}
+	}
+	return ""
+}
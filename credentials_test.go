@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+)
+
+func TestECRRegion(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"oci://123456789012.dkr.ecr.eu-west-1.amazonaws.com/charts", "eu-west-1"},
+		{"123456789012.dkr.ecr.us-east-2.amazonaws.com", "us-east-2"},
+		{"oci://ghcr.io/myorg", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ecrRegion(tt.url); got != tt.want {
+			t.Errorf("ecrRegion(%q): expected '%s', got '%s'", tt.url, tt.want, got)
+		}
+	}
+}
+
+func TestRepositoryCredentialsStatic(t *testing.T) {
+	repo := NewRepository(RepositoryConfig{
+		Type:     "oci",
+		AuthMode: "static",
+		Username: "user",
+		Password: "pass",
+	})
+
+	username, password, err := repo.credentials(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if username != "user" || password != "pass" {
+		t.Errorf("expected user/pass, got %s/%s", username, password)
+	}
+}
+
+func TestRepositoryCredentialsECR(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	dir := writeFakeCommand(t, "aws", `echo "$@" > "$(dirname "$0")/args"
+echo "ecr-token"
+`)
+
+	repo := NewRepository(RepositoryConfig{
+		Type:     "oci",
+		URL:      "oci://123456789012.dkr.ecr.eu-west-1.amazonaws.com/charts",
+		AuthMode: "ecr",
+		Username: "ignored",
+	})
+
+	username, password, err := repo.credentials(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if username != "AWS" {
+		t.Errorf("expected username 'AWS', got '%s'", username)
+	}
+	if password != "ecr-token" {
+		t.Errorf("expected password 'ecr-token', got '%s'", password)
+	}
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatalf("failed to read recorded args: %v", err)
+	}
+	if strings.TrimSpace(string(args)) != "ecr get-login-password --region eu-west-1" {
+		t.Errorf("unexpected aws args: %s", args)
+	}
+}
+
+func TestRepositoryCredentialsECRFailure(t *testing.T) {
+	writeFakeCommand(t, "aws", "echo 'Unable to locate credentials' >&2\nexit 255\n")
+
+	repo := NewRepository(RepositoryConfig{
+		Type:     "oci",
+		URL:      "oci://123456789012.dkr.ecr.eu-west-1.amazonaws.com",
+		AuthMode: "ecr",
+	})
+
+	_, _, err := repo.credentials(context.Background())
+	if err == nil {
+		t.Fatal("expected error when aws CLI fails")
+	}
+	if !strings.Contains(err.Error(), "Unable to locate credentials") {
+		t.Errorf("expected aws stderr in error, got: %v", err)
+	}
+}
+
+func TestRepositoryCredentialsUnsupportedMode(t *testing.T) {
+	repo := NewRepository(RepositoryConfig{AuthMode: "kerberos"})
+	if _, _, err := repo.credentials(context.Background()); err == nil {
+		t.Error("expected error for unsupported auth mode")
+	}
+}
+
+func TestRepositoryCredentialCommand(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "count")
+	script := filepath.Join(dir, "creds.sh")
+	content := "#!/bin/sh\necho x >> " + counter + "\necho '{\"username\": \"robot\", \"token\": \"s3cret\"}'\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	repo := NewRepository(RepositoryConfig{
+		Type:              "oci",
+		Username:          "ignored",
+		Password:          "ignored",
+		CredentialCommand: script,
+	})
+
+	for i := 0; i < 2; i++ {
+		username, password, err := repo.credentials(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if username != "robot" || password != "s3cret" {
+			t.Errorf("expected robot/s3cret, got %s/%s", username, password)
+		}
+	}
+
+	calls, _ := os.ReadFile(counter)
+	if n := strings.Count(string(calls), "x"); n != 1 {
+		t.Errorf("expected credential command to run once, ran %d times", n)
+	}
+}
+
+func TestRepositoryCredentialCommandFallsBackToConfiguredUsername(t *testing.T) {
+	repo := NewRepository(RepositoryConfig{
+		Username:          "deploy",
+		CredentialCommand: `echo '{"password": "pw"}'`,
+	})
+
+	username, password, err := repo.credentials(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if username != "deploy" || password != "pw" {
+		t.Errorf("expected deploy/pw, got %s/%s", username, password)
+	}
+}
+
+func TestRepositoryCredentialCommandErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+	}{
+		{name: "command fails", command: "exit 3"},
+		{name: "invalid json", command: "echo not-json-s3cret"},
+		{name: "no secret", command: `echo '{"username": "robot"}'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewRepository(RepositoryConfig{CredentialCommand: tt.command})
+			_, _, err := repo.credentials(context.Background())
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if strings.Contains(err.Error(), "s3cret") {
+				t.Errorf("credential command output leaked into error: %v", err)
+			}
+		})
+	}
+}
+
+func TestResolveCredential(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("failed to write credential file: %v", err)
+	}
+	t.Setenv("TEST_REPO_PASSWORD", "from-env")
+	t.Setenv("TEST_REPO_EMPTY", "")
+
+	tests := []struct {
+		name    string
+		literal string
+		envVar  string
+		file    string
+		want    string
+		wantErr bool
+	}{
+		{name: "literal", literal: "literal", want: "literal"},
+		{name: "env over literal", literal: "literal", envVar: "TEST_REPO_PASSWORD", want: "from-env"},
+		{name: "file over env", literal: "literal", envVar: "TEST_REPO_PASSWORD", file: file, want: "from-file"},
+		{name: "empty env falls back to literal", literal: "literal", envVar: "TEST_REPO_EMPTY", want: "literal"},
+		{name: "missing file", literal: "literal", file: filepath.Join(t.TempDir(), "missing"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveCredential(tt.literal, tt.envVar, tt.file)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRepositoryCredentialsFromEnvAndFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(file, []byte("s3cret\r\n\n"), 0600); err != nil {
+		t.Fatalf("failed to write credential file: %v", err)
+	}
+	t.Setenv("TEST_REPO_USERNAME", "ci-bot")
+
+	repo := NewRepository(RepositoryConfig{
+		Type:         "chartmuseum",
+		AuthMode:     "static",
+		UsernameEnv:  "TEST_REPO_USERNAME",
+		PasswordFile: file,
+	})
+
+	username, password, err := repo.credentials(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if username != "ci-bot" || password != "s3cret" {
+		t.Errorf("expected ci-bot/s3cret, got %s/%s", username, password)
+	}
+}
+
+func TestCredentialSourceWarnings(t *testing.T) {
+	warnings := credentialSourceWarnings("repository", RepositoryConfig{
+		Password:    "literal",
+		PasswordEnv: "HELM_REPO_PASSWORD",
+		Username:    "user",
+	})
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "repository.password") {
+		t.Errorf("expected one password warning, got %v", warnings)
+	}
+
+	if warnings := credentialSourceWarnings("repository", RepositoryConfig{PasswordFile: "/run/secrets/pw"}); len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+}
+
+func TestValidateCredentialFilesOrder(t *testing.T) {
+	repo := RepositoryConfig{
+		Type:         "oci",
+		URL:          "oci://ghcr.io/myorg/charts",
+		UsernameFile: "/nonexistent/username",
+		PasswordFile: "/nonexistent/password",
+	}
+	for range 5 {
+		vb := helpers.NewValidationBuilder()
+		validateRepositoryConfig(vb, "repository", repo, helmBinary{}, "")
+		var fields []string
+		for _, e := range vb.Build().Errors {
+			fields = append(fields, e.Field)
+		}
+		if want := "repository.password_file repository.username_file"; strings.Join(fields, " ") != want {
+			t.Fatalf("expected errors for %s, got %v", want, fields)
+		}
+	}
+}
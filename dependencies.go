@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRepoCheckTimeout bounds each dependency repository reachability check.
+const DefaultRepoCheckTimeout = 10 * time.Second
+
+// checkDependencyRepos confirms the repository of every chart dependency is
+// reachable before helm tries to fetch from it:
+//   - http(s) repositories must serve <repository>/index.yaml (or require auth)
+//   - oci charts must be readable with helm show chart
+//   - file:// repositories must exist relative to the chart
+//   - "@name" and "alias:name" must name a repository in helm repo list, whose
+//     URL is then checked like an http(s) repository
+//   - git+ repositories (helm-git) need the helm-git plugin
+//
+// All unreachable repositories are reported together, each explaining which
+// kind of repository failed and why.
+func checkDependencyRepos(ctx context.Context, chartPath string, deps []ChartDependency, helm helmBinary, timeout time.Duration) error {
+	c := &dependencyRepoChecker{
+		client:    &http.Client{Timeout: timeout},
+		helm:      helm,
+		timeout:   timeout,
+		chartPath: chartPath,
+	}
+	checked := make(map[string]error)
+
+	var failures []string
+	for _, dep := range deps {
+		repo := strings.TrimSpace(dep.Repository)
+		if repo == "" {
+			continue
+		}
+
+		// OCI repositories are checked per chart, everything else per repository
+		key := repo
+		if strings.HasPrefix(repo, "oci://") {
+			key = repo + "/" + dep.Name + "@" + dep.Version
+		}
+		err, ok := checked[key]
+		if !ok {
+			err = c.check(ctx, dep, repo)
+			checked[key] = err
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s (%v)", dep.Name, repo, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("%d dependency repository(s) unreachable:\n  - %s", len(failures), strings.Join(failures, "\n  - "))
+	}
+	return nil
+}
+
+// dependencyRepoChecker checks single dependency repositories.
+type dependencyRepoChecker struct {
+	client    *http.Client
+	helm      helmBinary
+	timeout   time.Duration
+	chartPath string
+
+	helmRepos    map[string]string // name -> URL, loaded on first use
+	helmReposErr error
+}
+
+// check checks the repository of dep. Errors are prefixed with the kind of
+// repository, e.g. "http: index.yaml returned 404".
+func (c *dependencyRepoChecker) check(ctx context.Context, dep ChartDependency, repo string) error {
+	if name, ok := strings.CutPrefix(repo, "@"); ok {
+		return c.checkAlias(ctx, name)
+	}
+	if name, ok := strings.CutPrefix(repo, "alias:"); ok {
+		return c.checkAlias(ctx, name)
+	}
+
+	u, err := url.Parse(repo)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		path := strings.TrimPrefix(repo, "file://")
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.chartPath, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("file: path not found relative to the chart")
+		}
+		return nil
+	case "oci":
+		if err := c.checkOCI(ctx, dep, repo); err != nil {
+			return fmt.Errorf("oci: %w", err)
+		}
+		return nil
+	case "http", "https":
+		if err := c.checkIndex(ctx, repo); err != nil {
+			return fmt.Errorf("http: %w", err)
+		}
+		return nil
+	case "git+https", "git+ssh", "git+http", "git+file":
+		plugins, err := listHelmPlugins(c.helm)
+		if err != nil {
+			return fmt.Errorf("helm-git: %w", err)
+		}
+		if !slices.Contains(plugins, "helm-git") {
+			return fmt.Errorf("helm-git: the helm-git plugin is not installed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+}
+
+// checkIndex checks that an http(s) repository serves index.yaml.
+func (c *dependencyRepoChecker) checkIndex(ctx context.Context, repo string) error {
+	index := strings.TrimSuffix(repo, "/") + "/index.yaml"
+	resp, err := probe(ctx, c.client, http.MethodHead, index)
+	if err == nil && resp.StatusCode == http.StatusMethodNotAllowed {
+		_ = resp.Body.Close()
+		resp, err = probe(ctx, c.client, http.MethodGet, index)
+	}
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	// helm may hold credentials for the repository, so auth failures
+	// still count as reachable
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("index.yaml returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// checkOCI reads the dependency's Chart.yaml from the registry, which uses
+// helm's registry credentials.
+func (c *dependencyRepoChecker) checkOCI(ctx context.Context, dep ChartDependency, repo string) error {
+	args := []string{"show", "chart", strings.TrimSuffix(repo, "/") + "/" + dep.Name}
+	if dep.Version != "" {
+		args = append(args, "--version", dep.Version)
+	}
+	var stderr bytes.Buffer
+	err := runHelm(ctx, c.helm, c.timeout, args, func(cmd *exec.Cmd) error {
+		cmd.Stderr = &stderr
+		return cmd.Run()
+	})
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("helm show chart failed: %s", msg)
+		}
+		return fmt.Errorf("helm show chart failed: %w", err)
+	}
+	return nil
+}
+
+// checkAlias resolves a repository alias against helm repo list and checks
+// the repository it names.
+func (c *dependencyRepoChecker) checkAlias(ctx context.Context, name string) error {
+	if c.helmRepos == nil && c.helmReposErr == nil {
+		c.helmRepos, c.helmReposErr = listHelmRepos(ctx, c.helm, c.timeout)
+	}
+	if c.helmReposErr != nil {
+		return fmt.Errorf("alias: %w", c.helmReposErr)
+	}
+	repoURL, ok := c.helmRepos[name]
+	if !ok {
+		return fmt.Errorf("alias: no helm repository named %q (add it with helm repo add)", name)
+	}
+	if err := c.checkIndex(ctx, repoURL); err != nil {
+		return fmt.Errorf("alias: %s: %w", repoURL, err)
+	}
+	return nil
+}
+
+// listHelmRepos returns the locally configured helm repositories by name.
+func listHelmRepos(ctx context.Context, helm helmBinary, timeout time.Duration) (map[string]string, error) {
+	var stdout, stderr bytes.Buffer
+	err := runHelm(ctx, helm, timeout, []string{"repo", "list", "-o", "json"}, func(cmd *exec.Cmd) error {
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		return cmd.Run()
+	})
+	repos := make(map[string]string)
+	if err != nil {
+		// helm fails when no repositories are configured at all
+		if strings.Contains(stderr.String(), "no repositories") {
+			return repos, nil
+		}
+		return nil, fmt.Errorf("helm repo list failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var entries []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse helm repo list output: %w", err)
+	}
+	for _, entry := range entries {
+		repos[entry.Name] = entry.URL
+	}
+	return repos, nil
+}
+
+// probe issues a request without a body and returns the response.
+func probe(ctx context.Context, client *http.Client, method, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return client.Do(req)
+}
+
+// nonRepoNameChars are runs of characters not allowed in derived repository names.
+var nonRepoNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// dependencyRepoName derives a stable helm repository name from a repository
+// URL's host and path, e.g. charts-bitnami-com-bitnami for
+// https://charts.bitnami.com/bitnami.
+func dependencyRepoName(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	name := repoURL
+	if err == nil {
+		name = u.Host + u.Path
+	}
+	return strings.Trim(nonRepoNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// addDependencyRepos adds the http(s) repositories of deps that aren't yet
+// configured with helm repo add, under names derived from their URLs, and
+// refreshes the repository indexes. Repositories already configured under any
+// name are left alone. It returns the names of the repositories it added.
+func addDependencyRepos(ctx context.Context, helm helmBinary, timeout time.Duration, deps []ChartDependency) ([]string, error) {
+	var urls []string
+	for _, dep := range deps {
+		repo := strings.TrimSuffix(strings.TrimSpace(dep.Repository), "/")
+		if (strings.HasPrefix(repo, "http://") || strings.HasPrefix(repo, "https://")) && !slices.Contains(urls, repo) {
+			urls = append(urls, repo)
+		}
+	}
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	sort.Strings(urls)
+
+	existing, err := listHelmRepos(ctx, helm, timeout)
+	if err != nil {
+		return nil, err
+	}
+	configured := make(map[string]bool, len(existing))
+	for _, repoURL := range existing {
+		configured[strings.TrimSuffix(repoURL, "/")] = true
+	}
+
+	var added []string
+	for _, repoURL := range urls {
+		if configured[repoURL] {
+			continue
+		}
+		name := dependencyRepoName(repoURL)
+		if other, ok := existing[name]; ok {
+			return added, fmt.Errorf("helm repository %s already exists for %s, can't add %s under that name", name, other, repoURL)
+		}
+		if err := runHelmRepo(ctx, helm, timeout, "add", name, repoURL); err != nil {
+			return added, err
+		}
+		added = append(added, name)
+	}
+	if len(added) > 0 {
+		if err := runHelmRepo(ctx, helm, timeout, "update"); err != nil {
+			return added, err
+		}
+	}
+	return added, nil
+}
+
+// removeDependencyRepos removes repositories added by addDependencyRepos.
+func removeDependencyRepos(ctx context.Context, helm helmBinary, timeout time.Duration, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	return runHelmRepo(ctx, helm, timeout, append([]string{"remove"}, names...)...)
+}
+
+// dependencyRepos tracks the dependency repositories added for the charts of
+// one Execute call. Charts processed concurrently may share repositories, so
+// each repository is reference counted and, with cleanup_repos, removed only
+// once the last chart using it is done.
+type dependencyRepos struct {
+	mu   sync.Mutex
+	refs map[string]int // repository name -> charts using it
+}
+
+// newDependencyRepos creates an empty set of dependency repositories.
+func newDependencyRepos() *dependencyRepos {
+	return &dependencyRepos{refs: make(map[string]int)}
+}
+
+// add adds the repositories of deps with addDependencyRepos and returns the
+// names it added along with every name the chart holds, which includes
+// repositories another chart of the run added earlier. The held names are
+// passed to remove once the chart is done with them.
+func (d *dependencyRepos) add(ctx context.Context, helm helmBinary, timeout time.Duration, deps []ChartDependency) (added, held []string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	added, err = addDependencyRepos(ctx, helm, timeout, deps)
+	held = slices.Clone(added)
+	for _, dep := range deps {
+		name := dependencyRepoName(strings.TrimSuffix(strings.TrimSpace(dep.Repository), "/"))
+		if d.refs[name] > 0 && !slices.Contains(held, name) {
+			held = append(held, name)
+		}
+	}
+	for _, name := range held {
+		d.refs[name]++
+	}
+	return added, held, err
+}
+
+// remove releases the names returned by add and removes the repositories no
+// other chart holds anymore.
+func (d *dependencyRepos) remove(ctx context.Context, helm helmBinary, timeout time.Duration, held []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var unused []string
+	for _, name := range held {
+		if d.refs[name]--; d.refs[name] == 0 {
+			delete(d.refs, name)
+			unused = append(unused, name)
+		}
+	}
+	return removeDependencyRepos(ctx, helm, timeout, unused)
+}
+
+// runHelmRepo runs a helm repo subcommand, including its output in errors.
+func runHelmRepo(ctx context.Context, helm helmBinary, timeout time.Duration, args ...string) error {
+	var output bytes.Buffer
+	err := runHelm(ctx, helm, timeout, append([]string{"repo"}, args...), func(cmd *exec.Cmd) error {
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		return cmd.Run()
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", helmFailure("repo "+args[0], err), strings.TrimSpace(output.String()))
+	}
+	return nil
+}
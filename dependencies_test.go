@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestCheckDependencyRepos(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/stable/index.yaml":
+			w.WriteHeader(http.StatusOK)
+		case "/get-only/index.yaml":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/private/index.yaml":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Fake helm with one configured repository, one readable OCI chart and no plugins
+	writeFakeCommand(t, "helm", `case "$1 $2" in
+	"repo list") echo '[{"name":"bitnami","url":"`+server.URL+`/stable"},{"name":"gone","url":"`+server.URL+`/gone"}]' ;;
+	"show chart") [ "$3" = "oci://registry.example.com/charts/app" ] || { echo "Error: oci://registry.example.com/charts/api:1.0.0: not found" >&2; exit 1; } ;;
+	"plugin list") echo "NAME	VERSION	DESCRIPTION" ;;
+esac
+`)
+
+	chartDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(chartDir, "..", "common"), 0755); err != nil {
+		t.Fatalf("failed to create local dependency: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		deps    []ChartDependency
+		wantErr []string
+	}{
+		{
+			name: "reachable",
+			deps: []ChartDependency{
+				{Name: "redis", Repository: server.URL + "/stable"},
+				{Name: "nginx", Repository: server.URL + "/get-only/"},
+				{Name: "internal", Repository: server.URL + "/private"},
+				{Name: "common", Repository: "file://../common"},
+				{Name: "postgres", Repository: "@bitnami"},
+				{Name: "mysql", Repository: "alias:bitnami"},
+				{Name: "app", Version: "1.0.0", Repository: "oci://registry.example.com/charts"},
+				{Name: "vendored"},
+			},
+		},
+		{
+			name: "unreachable",
+			deps: []ChartDependency{
+				{Name: "redis", Repository: server.URL + "/stable"},
+				{Name: "mongodb", Repository: server.URL + "/missing"},
+				{Name: "shared", Repository: "file://../shared"},
+				{Name: "legacy", Repository: "ftp://charts.example.com"},
+				{Name: "api", Version: "1.0.0", Repository: "oci://registry.example.com/charts"},
+				{Name: "kafka", Repository: "@confluent"},
+				{Name: "old", Repository: "alias:gone"},
+				{Name: "infra", Repository: "git+https://github.com/myorg/charts@charts/infra?ref=main"},
+			},
+			wantErr: []string{
+				"7 dependency repository(s) unreachable",
+				"mongodb: " + server.URL + "/missing (http: index.yaml returned 404)",
+				"shared: file://../shared (file: path not found relative to the chart)",
+				`legacy: ftp://charts.example.com (unsupported scheme "ftp")`,
+				"api: oci://registry.example.com/charts (oci: helm show chart failed: Error: oci://registry.example.com/charts/api:1.0.0: not found)",
+				`kafka: @confluent (alias: no helm repository named "confluent" (add it with helm repo add))`,
+				"old: alias:gone (alias: " + server.URL + "/gone: index.yaml returned 404)",
+				"infra: git+https://github.com/myorg/charts@charts/infra?ref=main (helm-git: the helm-git plugin is not installed)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDependencyRepos(context.Background(), chartDir, tt.deps, helmBinary{}, time.Second)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error containing %q, got %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDependencyReposTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	deps := []ChartDependency{{Name: "redis", Repository: server.URL}}
+	err := checkDependencyRepos(context.Background(), t.TempDir(), deps, helmBinary{}, 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "redis: "+server.URL) {
+		t.Errorf("expected timeout error for redis, got %v", err)
+	}
+}
+
+func TestDependencyRepoName(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://charts.bitnami.com/bitnami", want: "charts-bitnami-com-bitnami"},
+		{url: "https://charts.example.com:8443/Stable/", want: "charts-example-com-8443-stable"},
+		{url: "http://localhost/", want: "localhost"},
+	}
+
+	for _, tt := range tests {
+		if got := dependencyRepoName(tt.url); got != tt.want {
+			t.Errorf("dependencyRepoName(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestExecutePrePublishAutoAddRepos(t *testing.T) {
+	// bitnami is configured already, under another name and with a trailing slash
+	helmDir := writeFakeCommand(t, "helm", `echo "$@" >> "$(dirname "$0")/calls"
+case "$1 $2" in
+"repo list") echo '[{"name":"bitnami","url":"https://charts.bitnami.com/bitnami/"}]' ;;
+esac
+`)
+
+	chartDir := t.TempDir()
+	chartYAML := `apiVersion: v2
+name: my-app
+version: 1.0.0
+dependencies:
+  - name: redis
+    version: 17.0.0
+    repository: https://charts.bitnami.com/bitnami
+  - name: ingress-nginx
+    version: 4.0.0
+    repository: https://kubernetes.github.io/ingress-nginx
+  - name: common
+    version: 1.0.0
+    repository: oci://registry.example.com/charts
+  - name: postgresql
+    version: 12.0.0
+    repository: https://kubernetes.github.io/ingress-nginx/
+`
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chartYAML), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_path":        chartDir,
+		"lint":              false,
+		"template_validate": false,
+		"version":           map[string]any{"update_chart": false},
+		"dependencies":      map[string]any{"update": true, "build": false, "auto_add_repos": true, "cleanup_repos": true},
+	})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(helmDir, "calls"))
+	want := strings.Join([]string{
+		"repo list -o json",
+		"repo add kubernetes-github-io-ingress-nginx https://kubernetes.github.io/ingress-nginx",
+		"repo update",
+		"dependency update " + chartDir,
+		"repo remove kubernetes-github-io-ingress-nginx",
+	}, "\n") + "\n"
+	if string(calls) != want {
+		t.Errorf("expected helm calls:\n%s\ngot:\n%s", want, calls)
+	}
+}
+
+func TestDependencyReposSharedAcrossCharts(t *testing.T) {
+	helmDir := writeFakeCommand(t, "helm", `dir="$(dirname "$0")"
+echo "$@" >> "$dir/calls"
+case "$1 $2" in
+"repo list") if [ -f "$dir/repos" ]; then echo "[$(paste -sd, "$dir/repos")]"; else echo "[]"; fi ;;
+"repo add") echo "{\"name\":\"$3\",\"url\":\"$4\"}" >> "$dir/repos" ;;
+esac
+`)
+	ctx := context.Background()
+	repos := newDependencyRepos()
+	shared := ChartDependency{Name: "redis", Version: "17.0.0", Repository: "https://charts.example.com/stable"}
+	own := ChartDependency{Name: "nginx", Version: "1.0.0", Repository: "https://charts.example.com/web"}
+
+	_, api, err := repos.add(ctx, helmBinary{}, 0, []ChartDependency{shared})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	added, web, err := repos.add(ctx, helmBinary{}, 0, []ChartDependency{shared, own})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(added, []string{"charts-example-com-web"}) {
+		t.Errorf("expected only the web repository to be added, got %v", added)
+	}
+
+	// api finishing first must leave the repository web still uses
+	if err := repos.remove(ctx, helmBinary{}, 0, api); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repos.remove(ctx, helmBinary{}, 0, web); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(helmDir, "calls"))
+	want := strings.Join([]string{
+		"repo list -o json",
+		"repo add charts-example-com-stable https://charts.example.com/stable",
+		"repo update",
+		"repo list -o json",
+		"repo add charts-example-com-web https://charts.example.com/web",
+		"repo update",
+		"repo remove charts-example-com-web charts-example-com-stable",
+	}, "\n") + "\n"
+	if string(calls) != want {
+		t.Errorf("expected helm calls:\n%s\ngot:\n%s", want, calls)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// stageChart copies a chart directory into a temporary location so it can be
+// modified without touching the working tree. The copy keeps the original
+// directory name. The returned cleanup function removes the copy.
+func stageChart(chartPath string) (string, func(), error) {
+	tempDir, err := os.MkdirTemp("", "helm-stage-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(tempDir) }
+
+	absChart, err := filepath.Abs(chartPath)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to resolve chart path: %w", err)
+	}
+
+	dest := filepath.Join(tempDir, filepath.Base(absChart))
+	if err := copyDir(absChart, dest); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to stage chart: %w", err)
+	}
+
+	return dest, cleanup, nil
+}
+
+// copyDir recursively copies src to dst.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies a single regular file, preserving its permissions.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// mergeValues deep-merges overlay into base. Nested maps are merged recursively;
+// any other overlay value replaces the base value.
+func mergeValues(base, overlay map[string]any) map[string]any {
+	merged := make(map[string]any, len(base))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overlay {
+		if overlayMap, ok := v.(map[string]any); ok {
+			if baseMap, ok := merged[k].(map[string]any); ok {
+				merged[k] = mergeValues(baseMap, overlayMap)
+				continue
+			}
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// readValuesFile reads a YAML values file into a map. A missing file yields an empty map.
+func readValuesFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	values := map[string]any{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// environmentVersion suffixes a chart version with an environment name,
+// keeping any build metadata at the end (e.g. 1.0.0+b1 -> 1.0.0-staging+b1).
+func environmentVersion(version, env string) string {
+	base, build, hasBuild := strings.Cut(version, "+")
+	if hasBuild {
+		return fmt.Sprintf("%s-%s+%s", base, env, build)
+	}
+	return fmt.Sprintf("%s-%s", base, env)
+}
+
+// prepareEnvironmentChart stages a copy of the chart with the environment values
+// file merged into values.yaml and the version suffixed with the environment name.
+func prepareEnvironmentChart(chartPath, env, valuesFile, version string) (string, func(), error) {
+	if _, err := os.Stat(valuesFile); err != nil {
+		return "", nil, fmt.Errorf("values file for environment %s not found: %s", env, valuesFile)
+	}
+	overlay, err := readValuesFile(valuesFile)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, cleanup, err := stageChart(chartPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	valuesPath := filepath.Join(dir, "values.yaml")
+	base, err := readValuesFile(valuesPath)
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	data, err := yaml.Marshal(mergeValues(base, overlay))
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to marshal merged values: %w", err)
+	}
+	if err := os.WriteFile(valuesPath, data, 0644); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write merged values: %w", err)
+	}
+
+	if err := UpdateChartVersion(dir, environmentVersion(version, env), ""); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return dir, cleanup, nil
+}
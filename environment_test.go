@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeValues(t *testing.T) {
+	base := map[string]any{
+		"replicas": 1,
+		"image": map[string]any{
+			"repository": "nginx",
+			"tag":        "1.25",
+		},
+		"ingress": map[string]any{"enabled": false},
+	}
+	overlay := map[string]any{
+		"replicas": 3,
+		"image":    map[string]any{"tag": "1.26"},
+		"ingress":  "disabled",
+	}
+
+	merged := mergeValues(base, overlay)
+
+	if merged["replicas"] != 3 {
+		t.Errorf("expected replicas 3, got %v", merged["replicas"])
+	}
+	image := merged["image"].(map[string]any)
+	if image["repository"] != "nginx" || image["tag"] != "1.26" {
+		t.Errorf("unexpected merged image: %v", image)
+	}
+	if merged["ingress"] != "disabled" {
+		t.Errorf("expected scalar overlay to replace map, got %v", merged["ingress"])
+	}
+	if base["replicas"] != 1 {
+		t.Error("expected base values to be left untouched")
+	}
+}
+
+func TestEnvironmentVersion(t *testing.T) {
+	tests := []struct {
+		version, env, want string
+	}{
+		{"1.0.0", "staging", "1.0.0-staging"},
+		{"1.0.0-rc.1", "prod", "1.0.0-rc.1-prod"},
+		{"1.0.0+build.7", "prod", "1.0.0-prod+build.7"},
+	}
+
+	for _, tt := range tests {
+		if got := environmentVersion(tt.version, tt.env); got != tt.want {
+			t.Errorf("environmentVersion(%q, %q): expected '%s', got '%s'", tt.version, tt.env, tt.want, got)
+		}
+	}
+}
+
+func TestPrepareEnvironmentChart(t *testing.T) {
+	chartDir := filepath.Join(t.TempDir(), "my-app")
+	if err := os.MkdirAll(filepath.Join(chartDir, "templates"), 0755); err != nil {
+		t.Fatalf("failed to create chart: %v", err)
+	}
+	files := map[string]string{
+		"Chart.yaml":                "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+		"values.yaml":               "replicas: 1\nimage:\n  repository: nginx\n  tag: \"1.25\"\n",
+		"templates/deployment.yaml": "kind: Deployment\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(chartDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	envValues := filepath.Join(t.TempDir(), "values-staging.yaml")
+	if err := os.WriteFile(envValues, []byte("replicas: 2\nimage:\n  tag: \"1.26\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write env values: %v", err)
+	}
+
+	dir, cleanup, err := prepareEnvironmentChart(chartDir, "staging", envValues, "1.0.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cleanup()
+
+	if filepath.Base(dir) != "my-app" {
+		t.Errorf("expected staged chart directory 'my-app', got '%s'", filepath.Base(dir))
+	}
+
+	chart, err := ParseChart(dir)
+	if err != nil {
+		t.Fatalf("failed to parse staged chart: %v", err)
+	}
+	if chart.Version != "1.0.0-staging" {
+		t.Errorf("expected version '1.0.0-staging', got '%s'", chart.Version)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "values.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read merged values: %v", err)
+	}
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		t.Fatalf("failed to parse merged values: %v", err)
+	}
+	if values["replicas"] != 2 {
+		t.Errorf("expected replicas 2, got %v", values["replicas"])
+	}
+	image := values["image"].(map[string]any)
+	if image["repository"] != "nginx" || image["tag"] != "1.26" {
+		t.Errorf("unexpected merged image values: %v", image)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "templates", "deployment.yaml")); err != nil {
+		t.Errorf("expected templates to be staged: %v", err)
+	}
+
+	// The source chart must be untouched
+	original, _ := ParseChart(chartDir)
+	if original.Version != "1.0.0" {
+		t.Errorf("expected source chart version to remain '1.0.0', got '%s'", original.Version)
+	}
+
+	cleanup()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("expected staged chart to be removed by cleanup")
+	}
+}
+
+func TestPrepareEnvironmentChartMissingValues(t *testing.T) {
+	_, _, err := prepareEnvironmentChart(t.TempDir(), "prod", "/nonexistent/values-prod.yaml", "1.0.0")
+	if err == nil {
+		t.Error("expected error for missing values file")
+	}
+}
+
+func TestPackageChartsEnvironmentSchemas(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "Successfully packaged chart and saved it to: $3/my-app.tgz"`)
+
+	chartDir := filepath.Join(t.TempDir(), "my-app")
+	if err := os.MkdirAll(chartDir, 0755); err != nil {
+		t.Fatalf("failed to create chart: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte("replicas: 1\n"), 0644); err != nil {
+		t.Fatalf("failed to write values.yaml: %v", err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"values-staging.yaml": "replicas: 2\n",
+		"values-prod.yaml":    "replicas: 0\n",
+		"min-one.json":        `{"properties": {"replicas": {"type": "integer", "minimum": 1}}}`,
+		"min-three.json":      `{"properties": {"replicas": {"type": "integer", "minimum": 3}}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	environments := map[string]string{
+		"staging": filepath.Join(dir, "values-staging.yaml"),
+		"prod":    filepath.Join(dir, "values-prod.yaml"),
+	}
+
+	tests := []struct {
+		name       string
+		envSchemas map[string]string
+		wantErr    string
+	}{
+		{name: "no schemas"},
+		{name: "staging passes", envSchemas: map[string]string{"staging": filepath.Join(dir, "min-one.json")}},
+		{
+			name:       "prod fails",
+			envSchemas: map[string]string{"staging": filepath.Join(dir, "min-one.json"), "prod": filepath.Join(dir, "min-one.json")},
+			wantErr:    "environment prod: values.yaml does not match min-one.json:\n  - $.replicas: minimum: got 0, want 1",
+		},
+		{
+			name:       "staging fails stricter schema",
+			envSchemas: map[string]string{"staging": filepath.Join(dir, "min-three.json")},
+			wantErr:    "environment staging: values.yaml does not match min-three.json",
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Environments: environments, EnvSchemas: tt.envSchemas}
+			packages, err := packageCharts(context.Background(), cfg, chartDir, "my-app", "1.0.0", t.TempDir(), logger)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(packages) != 2 {
+					t.Errorf("expected 2 packages, got %d", len(packages))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrNoValuesExample is returned by ValidateValuesExample when the chart has no values.example.yaml.
+var ErrNoValuesExample = errors.New("values.example.yaml not found")
+
+// ValidateValuesExample compares the types of the top-level keys in the chart's
+// values.yaml with those documented in values.example.yaml, e.g. a field shown
+// as a list in the example but defaulted to a string. Keys missing from either
+// file and null values are not compared.
+func ValidateValuesExample(chartPath string) error {
+	exampleFile := filepath.Join(chartPath, "values.example.yaml")
+	if _, err := os.Stat(exampleFile); os.IsNotExist(err) {
+		return ErrNoValuesExample
+	}
+
+	example, err := loadValuesJSON(exampleFile)
+	if err != nil {
+		return err
+	}
+	values, err := loadValuesJSON(filepath.Join(chartPath, "values.yaml"))
+	if err != nil {
+		return err
+	}
+	exampleMap, _ := example.(map[string]any)
+	valuesMap, _ := values.(map[string]any)
+
+	keys := slices.Sorted(maps.Keys(valuesMap))
+
+	var mismatches []string
+	for _, key := range keys {
+		got, want := valueType(valuesMap[key]), valueType(exampleMap[key])
+		if got == "" || want == "" || got == want {
+			continue
+		}
+		mismatches = append(mismatches, fmt.Sprintf("%s: values.yaml has %s, values.example.yaml documents %s", jsonPath([]string{key}), got, want))
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d value type(s) differ from values.example.yaml:\n  - %s", len(mismatches), strings.Join(mismatches, "\n  - "))
+	}
+	return nil
+}
+
+// valueType names the type of a decoded values entry, or "" for null.
+func valueType(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "map"
+	case []any:
+		return "list"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case json.Number, float64, int:
+		return "number"
+	default:
+		return ""
+	}
+}
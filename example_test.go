@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateValuesExample(t *testing.T) {
+	const example = `replicaCount: 3
+image:
+  repository: registry.example.com/my-app
+  tag: "1.2.0"
+ingress:
+  enabled: true
+  hosts: [my-app.example.com]
+extraArgs: ["--verbose"]
+`
+	tests := []struct {
+		name     string
+		values   string
+		wantErrs []string
+	}{
+		{
+			name:   "matching types",
+			values: "replicaCount: 1\nimage:\n  repository: nginx\ningress:\n  enabled: false\nextraArgs: []\nonlyInValues: x\n",
+		},
+		{
+			name:   "null defaults are not compared",
+			values: "replicaCount: 1\nimage: null\nextraArgs:\n",
+		},
+		{
+			name:   "mismatched types",
+			values: "replicaCount: \"1\"\nimage: nginx:latest\ningress:\n  enabled: false\nextraArgs: --verbose\n",
+			wantErrs: []string{
+				"$.extraArgs: values.yaml has string, values.example.yaml documents list",
+				"$.image: values.yaml has string, values.example.yaml documents map",
+				"$.replicaCount: values.yaml has string, values.example.yaml documents number",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chartDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(chartDir, "values.example.yaml"), []byte(example), 0644); err != nil {
+				t.Fatalf("failed to write values.example.yaml: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte(tt.values), 0644); err != nil {
+				t.Fatalf("failed to write values.yaml: %v", err)
+			}
+
+			err := ValidateValuesExample(chartDir)
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if want := strings.Join(tt.wantErrs, "\n  - "); !strings.HasSuffix(err.Error(), want) {
+				t.Errorf("expected mismatches:\n  - %s\ngot:\n%v", want, err)
+			}
+		})
+	}
+}
+
+func TestValidateValuesExampleMissingExample(t *testing.T) {
+	if err := ValidateValuesExample(t.TempDir()); !errors.Is(err, ErrNoValuesExample) {
+		t.Errorf("expected ErrNoValuesExample, got %v", err)
+	}
+}
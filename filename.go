@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// outputFilenameData is the data output_filename templates are rendered with.
+type outputFilenameData struct {
+	Name        string
+	Version     string // the package's version, including any environment suffix
+	AppVersion  string
+	Environment string // empty without environments
+	Date        string // UTC, YYYY-MM-DD
+}
+
+// renderOutputFilename renders the output_filename template. The result must
+// be a plain file name ending in .tgz.
+func renderOutputFilename(format string, data outputFilenameData) (string, error) {
+	name, err := renderTemplate("output_filename", format, data)
+	if err != nil {
+		return "", err
+	}
+	if strings.ContainsAny(name, `/\`) || !strings.HasSuffix(name, ".tgz") || name == ".tgz" {
+		return "", fmt.Errorf("output_filename renders to %q, which is not a file name ending in .tgz", name)
+	}
+	return name, nil
+}
+
+// renamePackages renames each package to the rendered output_filename. Every
+// name is rendered before anything is renamed. Signed packages are refused, as
+// their provenance file signs the original name. In dry runs only the paths are
+// computed.
+func renamePackages(packages []chartPackage, format string, chart *Chart, now time.Time, dryRun bool) ([]chartPackage, error) {
+	renamed := make([]chartPackage, 0, len(packages))
+	seen := make(map[string]bool, len(packages))
+	for _, pkg := range packages {
+		name, err := renderOutputFilename(format, outputFilenameData{
+			Name:        chart.Name,
+			Version:     pkg.Version,
+			AppVersion:  chart.AppVersion,
+			Environment: pkg.Environment,
+			Date:        now.UTC().Format(time.DateOnly),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("output_filename renders to %s for more than one package; include {{.Environment}} or {{.Version}}", name)
+		}
+		seen[name] = true
+		pkg.Path = filepath.Join(filepath.Dir(pkg.Path), name)
+		renamed = append(renamed, pkg)
+	}
+	if dryRun {
+		return renamed, nil
+	}
+
+	for _, pkg := range packages {
+		if _, err := os.Stat(pkg.Path + provenanceSuffix); err == nil {
+			return nil, fmt.Errorf("can't rename signed package %s: its provenance file signs the original name", filepath.Base(pkg.Path))
+		}
+	}
+	for i, pkg := range packages {
+		path := renamed[i].Path
+		if path == pkg.Path {
+			continue
+		}
+		if err := os.Rename(pkg.Path, path); err != nil {
+			return nil, fmt.Errorf("failed to rename package: %w", err)
+		}
+	}
+	return renamed, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderOutputFilename(t *testing.T) {
+	data := outputFilenameData{Name: "my-app", Version: "1.2.0-prod", AppVersion: "2.0.1", Environment: "prod", Date: "2026-03-01"}
+
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr string
+	}{
+		{name: "environment and date", format: "{{.Name}}-{{.Environment}}-{{.Date}}.tgz", want: "my-app-prod-2026-03-01.tgz"},
+		{name: "app version", format: "{{.Name}}-{{.Version}}-app{{.AppVersion}}.tgz", want: "my-app-1.2.0-prod-app2.0.1.tgz"},
+		{name: "missing extension", format: "{{.Name}}-{{.Version}}", wantErr: "not a file name ending in .tgz"},
+		{name: "directory", format: "{{.Environment}}/{{.Name}}.tgz", wantErr: "not a file name ending in .tgz"},
+		{name: "unknown field", format: "{{.Chart}}.tgz", wantErr: "invalid output_filename"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderOutputFilename(tt.format, data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRenamePackages(t *testing.T) {
+	dir := t.TempDir()
+	var packages []chartPackage
+	for _, env := range []string{"dev", "prod"} {
+		path := filepath.Join(dir, "my-app-1.0.0-"+env+".tgz")
+		if err := os.WriteFile(path, []byte(env), 0644); err != nil {
+			t.Fatalf("failed to write package: %v", err)
+		}
+		packages = append(packages, chartPackage{Environment: env, Version: "1.0.0-" + env, Path: path})
+	}
+
+	chart := &Chart{Name: "my-app", AppVersion: "2.0.1"}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := renamePackages(packages, "{{.Name}}-{{.Date}}.tgz", chart, now, false); err == nil || !strings.Contains(err.Error(), "more than one package") {
+		t.Errorf("expected colliding names to be rejected, got %v", err)
+	}
+
+	// A signed package keeps its name, as renaming it breaks helm verify
+	provPath := packages[1].Path + provenanceSuffix
+	if err := os.WriteFile(provPath, []byte("signature"), 0644); err != nil {
+		t.Fatalf("failed to write provenance file: %v", err)
+	}
+	if _, err := renamePackages(packages, "{{.Name}}-{{.Environment}}.tgz", chart, now, false); err == nil || !strings.Contains(err.Error(), "can't rename signed package my-app-1.0.0-prod.tgz") {
+		t.Errorf("expected the signed package to be refused, got %v", err)
+	}
+	if _, err := os.Stat(packages[0].Path); err != nil {
+		t.Errorf("expected nothing to be renamed: %v", err)
+	}
+	if err := os.Remove(provPath); err != nil {
+		t.Fatalf("failed to remove provenance file: %v", err)
+	}
+
+	renamed, err := renamePackages(packages, "{{.Name}}-{{.Environment}}-app{{.AppVersion}}.tgz", chart, now, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	want := "my-app-dev-app2.0.1.tgz,my-app-prod-app2.0.1.tgz"
+	if strings.Join(names, ",") != want {
+		t.Errorf("expected files %s, got %v", want, names)
+	}
+	if renamed[1].Path != filepath.Join(dir, "my-app-prod-app2.0.1.tgz") || renamed[1].Version != "1.0.0-prod" {
+		t.Errorf("unexpected renamed package %+v", renamed[1])
+	}
+}
+
+func TestValidateOutputFilenameWithGPGSigning(t *testing.T) {
+	p := &HelmPlugin{}
+	for _, sign := range []bool{false, true} {
+		resp, err := p.Validate(context.Background(), map[string]any{
+			"output_filename": "{{.Name}}-{{.Version}}-signed.tgz",
+			"sign":            sign,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		found := false
+		for _, e := range resp.Errors {
+			found = found || (e.Field == "output_filename" && strings.Contains(e.Message, "gpg signing"))
+		}
+		if found != sign {
+			t.Errorf("sign=%v: expected output_filename error=%v, got %+v", sign, sign, resp.Errors)
+		}
+	}
+}
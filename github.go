@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// defaultGitHubAPIURL is the GitHub REST API base URL.
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// GitHubReleaseConfig defines settings for uploading packages as GitHub release assets.
+type GitHubReleaseConfig struct {
+	Enabled    bool   `json:"enabled"`
+	Repository string `json:"repository"` // owner/name, defaults to the release repository
+	Tag        string `json:"tag"`        // defaults to the release tag
+	Token      string `json:"token"`      // defaults to $GITHUB_TOKEN
+	APIURL     string `json:"api_url"`    // for GitHub Enterprise
+}
+
+// parseGitHubReleaseConfig parses the github_release config block.
+func parseGitHubReleaseConfig(raw any) GitHubReleaseConfig {
+	cfg := GitHubReleaseConfig{APIURL: defaultGitHubAPIURL}
+	releaseRaw, ok := raw.(map[string]any)
+	if !ok {
+		return cfg
+	}
+	if enabled, ok := releaseRaw["enabled"].(bool); ok {
+		cfg.Enabled = enabled
+	}
+	if repo, ok := releaseRaw["repository"].(string); ok {
+		cfg.Repository = repo
+	}
+	if tag, ok := releaseRaw["tag"].(string); ok {
+		cfg.Tag = tag
+	}
+	if token, ok := releaseRaw["token"].(string); ok {
+		cfg.Token = token
+	}
+	if apiURL, ok := releaseRaw["api_url"].(string); ok && apiURL != "" {
+		cfg.APIURL = apiURL
+	}
+	return cfg
+}
+
+// resolve fills in the repository from the release context and returns the
+// release tag: the configured tag, the release tag, or "v" + version.
+func (c GitHubReleaseConfig) resolve(releaseCtx *plugin.ReleaseContext) (GitHubReleaseConfig, string) {
+	if c.Repository == "" && releaseCtx.RepositoryOwner != "" && releaseCtx.RepositoryName != "" {
+		c.Repository = releaseCtx.RepositoryOwner + "/" + releaseCtx.RepositoryName
+	}
+
+	tag := c.Tag
+	if tag == "" {
+		tag = releaseCtx.TagName
+	}
+	if tag == "" {
+		tag = "v" + strings.TrimPrefix(releaseCtx.Version, "v")
+	}
+	return c, tag
+}
+
+// githubRelease is the subset of the GitHub release object we use.
+type githubRelease struct {
+	ID        int64         `json:"id"`
+	HTMLURL   string        `json:"html_url"`
+	UploadURL string        `json:"upload_url"`
+	Assets    []githubAsset `json:"assets"`
+}
+
+// githubAsset is the subset of the GitHub release asset object we use.
+type githubAsset struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// githubClient is a minimal GitHub REST API client for release assets.
+type githubClient struct {
+	apiURL     string
+	token      string
+	repository string
+	httpClient *http.Client
+}
+
+// newGitHubClient creates a client for the configured repository.
+func newGitHubClient(cfg GitHubReleaseConfig) *githubClient {
+	token := cfg.Token
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	return &githubClient{
+		apiURL:     strings.TrimSuffix(cfg.APIURL, "/"),
+		token:      token,
+		repository: cfg.Repository,
+		httpClient: &http.Client{Timeout: 120 * time.Second},
+	}
+}
+
+// ensureRelease returns the release for tag, creating it when missing. Charts
+// published concurrently may race to create the release, so when the create is
+// rejected because the release already exists it is looked up again.
+func (c *githubClient) ensureRelease(ctx context.Context, tag string) (*githubRelease, error) {
+	release, status, err := c.releaseByTag(ctx, tag)
+	if err == nil {
+		return release, nil
+	}
+	if status != http.StatusNotFound {
+		return nil, fmt.Errorf("failed to look up release %s: %w", tag, err)
+	}
+
+	body, err := json.Marshal(map[string]string{"tag_name": tag, "name": tag})
+	if err != nil {
+		return nil, err
+	}
+	release = &githubRelease{}
+	endpoint := fmt.Sprintf("%s/repos/%s/releases", c.apiURL, c.repository)
+	status, err = c.do(ctx, http.MethodPost, endpoint, "application/json", bytes.NewReader(body), release)
+	if status == http.StatusUnprocessableEntity && strings.Contains(err.Error(), "already_exists") {
+		if release, _, err = c.releaseByTag(ctx, tag); err != nil {
+			return nil, fmt.Errorf("failed to look up release %s: %w", tag, err)
+		}
+		return release, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create release %s: %w", tag, err)
+	}
+	return release, nil
+}
+
+// releaseByTag looks up the release for tag, returning the HTTP status
+// alongside any error.
+func (c *githubClient) releaseByTag(ctx context.Context, tag string) (*githubRelease, int, error) {
+	var release githubRelease
+	endpoint := fmt.Sprintf("%s/repos/%s/releases/tags/%s", c.apiURL, c.repository, url.PathEscape(tag))
+	status, err := c.do(ctx, http.MethodGet, endpoint, "", nil, &release)
+	if err != nil {
+		return nil, status, err
+	}
+	return &release, status, nil
+}
+
+// uploadAsset uploads a file to the release, replacing an existing asset with the same name.
+func (c *githubClient) uploadAsset(ctx context.Context, release *githubRelease, name string, content []byte, contentType string) error {
+	for _, asset := range release.Assets {
+		if asset.Name != name {
+			continue
+		}
+		endpoint := fmt.Sprintf("%s/repos/%s/releases/assets/%d", c.apiURL, c.repository, asset.ID)
+		if _, err := c.do(ctx, http.MethodDelete, endpoint, "", nil, nil); err != nil {
+			return fmt.Errorf("failed to replace asset %s: %w", name, err)
+		}
+	}
+
+	// upload_url is a URI template: https://uploads.github.com/repos/o/r/releases/1/assets{?name,label}
+	uploadURL, _, _ := strings.Cut(release.UploadURL, "{")
+	endpoint := uploadURL + "?name=" + url.QueryEscape(name)
+	if _, err := c.do(ctx, http.MethodPost, endpoint, contentType, bytes.NewReader(content), nil); err != nil {
+		return fmt.Errorf("failed to upload asset %s: %w", name, err)
+	}
+	return nil
+}
+
+// do performs an API request, decoding a JSON response into out when non-nil.
+// The HTTP status is returned alongside any error.
+func (c *githubClient) do(ctx context.Context, method, endpoint, contentType string, body io.Reader, out any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if r, ok := body.(*bytes.Reader); ok {
+		req.ContentLength = int64(r.Len())
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return resp.StatusCode, fmt.Errorf("%s %s returned %d: %s", method, endpoint, resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// uploadReleaseAssets uploads each package, its provenance file when present and
+// a sha256 digest file to the GitHub release for tag, returning the release URL.
+func uploadReleaseAssets(ctx context.Context, cfg GitHubReleaseConfig, tag string, packages []chartPackage) (string, error) {
+	client := newGitHubClient(cfg)
+	release, err := client.ensureRelease(ctx, tag)
+	if err != nil {
+		return "", err
+	}
+
+	for _, pkg := range packages {
+		name := filepath.Base(pkg.Path)
+		content, err := os.ReadFile(pkg.Path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read package: %w", err)
+		}
+		if err := client.uploadAsset(ctx, release, name, content, "application/gzip"); err != nil {
+			return "", err
+		}
+
+		if prov, err := os.ReadFile(pkg.Path + ".prov"); err == nil {
+			if err := client.uploadAsset(ctx, release, name+".prov", prov, "application/octet-stream"); err != nil {
+				return "", err
+			}
+		}
+
+		digest, err := fileDigest(pkg.Path)
+		if err != nil {
+			return "", err
+		}
+		sum := fmt.Sprintf("%s  %s\n", strings.TrimPrefix(digest, "sha256:"), name)
+		if err := client.uploadAsset(ctx, release, name+".sha256", []byte(sum), "text/plain"); err != nil {
+			return "", err
+		}
+	}
+	return release.HTMLURL, nil
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// fakeGitHub is a minimal in-memory GitHub releases API.
+type fakeGitHub struct {
+	mu       sync.Mutex
+	releases map[string]*githubRelease // tag -> release
+	uploads  map[string]string         // asset name -> content
+	deleted  []int64
+	requests []string
+	// lostRace makes release creation fail as if another publish created the
+	// release between the lookup and the create.
+	lostRace bool
+	server   *httptest.Server
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	t.Helper()
+
+	f := &fakeGitHub{releases: map[string]*githubRelease{}, uploads: map[string]string{}}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeGitHub) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/repos/myorg/charts/releases/tags/"):
+		release, ok := f.releases[strings.TrimPrefix(r.URL.Path, "/repos/myorg/charts/releases/tags/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(release)
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/myorg/charts/releases":
+		var req struct {
+			TagName string `json:"tag_name"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_, exists := f.releases[req.TagName]
+		id := int64(len(f.releases) + 1)
+		release := &githubRelease{
+			ID:        id,
+			HTMLURL:   "https://github.com/myorg/charts/releases/tag/" + req.TagName,
+			UploadURL: fmt.Sprintf("%s/uploads/%d/assets{?name,label}", f.server.URL, id),
+		}
+		if exists || f.lostRace {
+			if !exists {
+				f.releases[req.TagName] = release
+			}
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"message": "Validation Failed", "errors": [{"resource": "Release", "code": "already_exists", "field": "tag_name"}]}`))
+			return
+		}
+		f.releases[req.TagName] = release
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(release)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/repos/myorg/charts/releases/assets/"):
+		var id int64
+		_, _ = fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/repos/myorg/charts/releases/assets/"), "%d", &id)
+		f.deleted = append(f.deleted, id)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/uploads/"):
+		body, _ := io.ReadAll(r.Body)
+		f.uploads[r.URL.Query().Get("name")] = string(body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeGitHub) config() GitHubReleaseConfig {
+	return GitHubReleaseConfig{Enabled: true, Repository: "myorg/charts", Token: "test-token", APIURL: f.server.URL}
+}
+
+func writeReleasePackage(t *testing.T, withProv bool) chartPackage {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	if err := os.WriteFile(path, []byte("chart"), 0644); err != nil {
+		t.Fatalf("failed to write package: %v", err)
+	}
+	if withProv {
+		if err := os.WriteFile(path+".prov", []byte("signature"), 0644); err != nil {
+			t.Fatalf("failed to write provenance: %v", err)
+		}
+	}
+	return chartPackage{Version: "1.0.0", Path: path}
+}
+
+func TestUploadReleaseAssetsExistingRelease(t *testing.T) {
+	gh := newFakeGitHub(t)
+	gh.releases["v1.0.0"] = &githubRelease{
+		ID:        7,
+		HTMLURL:   "https://github.com/myorg/charts/releases/tag/v1.0.0",
+		UploadURL: gh.server.URL + "/uploads/7/assets{?name,label}",
+		Assets:    []githubAsset{{ID: 42, Name: "my-app-1.0.0.tgz"}, {ID: 43, Name: "notes.txt"}},
+	}
+
+	releaseURL, err := uploadReleaseAssets(context.Background(), gh.config(), "v1.0.0", []chartPackage{writeReleasePackage(t, false)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if releaseURL != "https://github.com/myorg/charts/releases/tag/v1.0.0" {
+		t.Errorf("unexpected release URL: %s", releaseURL)
+	}
+	for _, req := range gh.requests {
+		if req == "POST /repos/myorg/charts/releases" {
+			t.Error("existing release should not be recreated")
+		}
+	}
+	if len(gh.deleted) != 1 || gh.deleted[0] != 42 {
+		t.Errorf("expected only the stale package asset to be replaced, deleted %v", gh.deleted)
+	}
+	if gh.uploads["my-app-1.0.0.tgz"] != "chart" {
+		t.Errorf("package not uploaded: %v", gh.uploads)
+	}
+	if _, ok := gh.uploads["my-app-1.0.0.tgz.prov"]; ok {
+		t.Error("provenance uploaded without a .prov file")
+	}
+}
+
+func TestUploadReleaseAssetsCreatesRelease(t *testing.T) {
+	gh := newFakeGitHub(t)
+	pkg := writeReleasePackage(t, true)
+
+	releaseURL, err := uploadReleaseAssets(context.Background(), gh.config(), "v1.0.0", []chartPackage{pkg})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := gh.releases["v1.0.0"]; !ok {
+		t.Fatal("expected release to be created")
+	}
+	if releaseURL != "https://github.com/myorg/charts/releases/tag/v1.0.0" {
+		t.Errorf("unexpected release URL: %s", releaseURL)
+	}
+
+	digest, err := fileDigest(pkg.Path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"my-app-1.0.0.tgz":        "chart",
+		"my-app-1.0.0.tgz.prov":   "signature",
+		"my-app-1.0.0.tgz.sha256": strings.TrimPrefix(digest, "sha256:") + "  my-app-1.0.0.tgz\n",
+	}
+	for name, content := range want {
+		if gh.uploads[name] != content {
+			t.Errorf("expected asset %s with %q, got %q", name, content, gh.uploads[name])
+		}
+	}
+}
+
+func TestUploadReleaseAssetsReleaseCreatedConcurrently(t *testing.T) {
+	gh := newFakeGitHub(t)
+	gh.lostRace = true
+
+	releaseURL, err := uploadReleaseAssets(context.Background(), gh.config(), "v1.0.0", []chartPackage{writeReleasePackage(t, false)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if releaseURL != "https://github.com/myorg/charts/releases/tag/v1.0.0" {
+		t.Errorf("unexpected release URL: %s", releaseURL)
+	}
+	if gh.uploads["my-app-1.0.0.tgz"] != "chart" {
+		t.Errorf("expected the package to be uploaded to the existing release, got %v", gh.uploads)
+	}
+	want := []string{
+		"GET /repos/myorg/charts/releases/tags/v1.0.0",
+		"POST /repos/myorg/charts/releases",
+		"GET /repos/myorg/charts/releases/tags/v1.0.0",
+	}
+	if got := gh.requests[:3]; strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected the release to be looked up again, got requests %v", gh.requests)
+	}
+}
+
+func TestUploadReleaseAssetsError(t *testing.T) {
+	gh := newFakeGitHub(t)
+	cfg := gh.config()
+	cfg.Token = "wrong"
+
+	_, err := uploadReleaseAssets(context.Background(), cfg, "v1.0.0", []chartPackage{writeReleasePackage(t, false)})
+	if err == nil || !strings.Contains(err.Error(), "returned 401") {
+		t.Errorf("expected an authorization error, got %v", err)
+	}
+}
+
+func TestGitHubReleaseConfigResolve(t *testing.T) {
+	releaseCtx := &plugin.ReleaseContext{Version: "1.2.0", RepositoryOwner: "myorg", RepositoryName: "app"}
+
+	cfg, tag := parseGitHubReleaseConfig(map[string]any{"enabled": true}).resolve(releaseCtx)
+	if cfg.Repository != "myorg/app" || tag != "v1.2.0" {
+		t.Errorf("expected myorg/app@v1.2.0, got %s@%s", cfg.Repository, tag)
+	}
+	if cfg.APIURL != defaultGitHubAPIURL {
+		t.Errorf("expected default API URL, got %s", cfg.APIURL)
+	}
+
+	releaseCtx.TagName = "app-1.2.0"
+	if _, tag := cfg.resolve(releaseCtx); tag != "app-1.2.0" {
+		t.Errorf("expected release tag, got %s", tag)
+	}
+
+	cfg, tag = parseGitHubReleaseConfig(map[string]any{"repository": "other/charts", "tag": "charts-1.2.0"}).resolve(releaseCtx)
+	if cfg.Repository != "other/charts" || tag != "charts-1.2.0" {
+		t.Errorf("expected configured repository and tag, got %s@%s", cfg.Repository, tag)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// ChartGitConfig defines a git repository the chart is cloned from, instead of
+// being read from the working directory.
+type ChartGitConfig struct {
+	URL    string `json:"url"`
+	Ref    string `json:"ref"`    // branch, tag or commit; defaults to the remote's HEAD
+	Subdir string `json:"subdir"` // chart directory within the repository
+}
+
+// parseChartGitConfig parses the chart_git block.
+func parseChartGitConfig(raw any) ChartGitConfig {
+	var cfg ChartGitConfig
+	gitRaw, ok := raw.(map[string]any)
+	if !ok {
+		return cfg
+	}
+	if u, ok := gitRaw["url"].(string); ok {
+		cfg.URL = strings.TrimSpace(u)
+	}
+	if ref, ok := gitRaw["ref"].(string); ok {
+		cfg.Ref = strings.TrimSpace(ref)
+	}
+	if subdir, ok := gitRaw["subdir"].(string); ok {
+		cfg.Subdir = strings.TrimSpace(subdir)
+	}
+	return cfg
+}
+
+// ref returns the ref to check out.
+func (c ChartGitConfig) ref() string {
+	if c.Ref == "" {
+		return "HEAD"
+	}
+	return c.Ref
+}
+
+// chartPath returns the chart's path within a clone at dir.
+func (c ChartGitConfig) chartPath(dir string) string {
+	return filepath.Join(dir, filepath.FromSlash(c.Subdir))
+}
+
+// cloneChartRepo shallow-clones the chart repository at its ref into a
+// temporary directory and returns the directory. Fetching the ref by name,
+// rather than with clone --branch, also works for commit SHAs on servers that
+// allow them. cleanup removes the clone and must always be called.
+func cloneChartRepo(ctx context.Context, cfg ChartGitConfig) (dir string, cleanup func(), err error) {
+	dir, err = os.MkdirTemp("", "helm-chart-git-*")
+	if err != nil {
+		return "", func() {}, fmt.Errorf("failed to create clone directory: %w", err)
+	}
+	cleanup = func() { _ = os.RemoveAll(dir) }
+
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", cfg.URL},
+		{"fetch", "--quiet", "--depth", "1", "origin", cfg.ref()},
+		{"checkout", "--quiet", "--detach", "FETCH_HEAD"},
+	} {
+		if err := runGit(ctx, dir, args...); err != nil {
+			cleanup()
+			return "", func() {}, err
+		}
+	}
+	return dir, cleanup, nil
+}
+
+// runGit runs a git command in dir, never prompting for credentials.
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// validateChartGit validates the chart_git block by cloning the repository and
+// checking the chart in it.
+func validateChartGit(ctx context.Context, vb *helpers.ValidationBuilder, cfg *Config) []string {
+	if len(cfg.ChartPaths) > 0 {
+		vb.AddError("chart_git", "chart_git can't be combined with chart_paths; select the chart with chart_git.subdir")
+	}
+	if cfg.ChartGit.Subdir != "" && !filepath.IsLocal(filepath.FromSlash(cfg.ChartGit.Subdir)) {
+		vb.AddError("chart_git.subdir", fmt.Sprintf("Subdirectory must be a relative path within the repository: %s", cfg.ChartGit.Subdir))
+		return nil
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		vb.AddError("chart_git", "git not found in PATH (required to clone chart_git)")
+		return nil
+	}
+
+	dir, cleanup, err := cloneChartRepo(ctx, cfg.ChartGit)
+	defer cleanup()
+	if err != nil {
+		vb.AddError("chart_git", fmt.Sprintf("Failed to clone %s at %s: %v", cfg.ChartGit.URL, cfg.ChartGit.ref(), err))
+		return nil
+	}
+	return validateChartPath(vb, "chart_git.subdir", cfg.ChartGit.chartPath(dir), cfg.StrictNameCheck)
+}
+
+// withChartGit clones the chart_git repository and runs hook on the chart in
+// the clone, removing the clone afterwards.
+func (p *HelmPlugin) withChartGit(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, run *chartRun, logger *slog.Logger, hook chartHook) (*plugin.ExecuteResponse, error) {
+	logger.Info("Cloning chart repository", "url", cfg.ChartGit.URL, "ref", cfg.ChartGit.ref())
+	dir, cleanup, err := cloneChartRepo(ctx, cfg.ChartGit)
+	defer cleanup()
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to clone chart repository: %v", err),
+		}, nil
+	}
+
+	c := *cfg
+	c.ChartPath = cfg.ChartGit.chartPath(dir)
+	return p.forEachChart(ctx, releaseCtx, &c, run, logger, hook)
+}
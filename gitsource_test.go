@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// newChartRepo creates a git repository with charts/my-app at version 1.0.0,
+// tagged v1.0.0, then at 1.1.0 on main. It returns the repository's file URL
+// and the first commit's SHA.
+func newChartRepo(t *testing.T) (repoURL, firstCommit string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s failed: %v: %s", args[0], err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	writeChart := func(version string) {
+		t.Helper()
+		chartDir := filepath.Join(dir, "charts", "my-app")
+		if err := os.MkdirAll(chartDir, 0755); err != nil {
+			t.Fatalf("failed to create chart: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: "+version+"\n"), 0644); err != nil {
+			t.Fatalf("failed to write Chart.yaml: %v", err)
+		}
+	}
+
+	git("init", "--quiet", "--initial-branch", "main")
+	git("config", "uploadpack.allowAnySHA1InWant", "true")
+	writeChart("1.0.0")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "Add chart")
+	git("tag", "v1.0.0")
+	firstCommit = git("rev-parse", "HEAD")
+	writeChart("1.1.0")
+	git("commit", "--quiet", "-am", "Bump chart")
+	return "file://" + dir, firstCommit
+}
+
+func TestCloneChartRepo(t *testing.T) {
+	repoURL, firstCommit := newChartRepo(t)
+
+	tests := []struct {
+		name string
+		ref  string
+		want string
+	}{
+		{name: "default branch", want: "1.1.0"},
+		{name: "branch", ref: "main", want: "1.1.0"},
+		{name: "tag", ref: "v1.0.0", want: "1.0.0"},
+		{name: "commit", ref: firstCommit, want: "1.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := ChartGitConfig{URL: repoURL, Ref: tt.ref, Subdir: "charts/my-app"}
+			dir, cleanup, err := cloneChartRepo(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			chart, err := ParseChart(cfg.chartPath(dir))
+			if err != nil {
+				t.Fatalf("failed to parse cloned chart: %v", err)
+			}
+			if chart.Version != tt.want {
+				t.Errorf("expected version %s, got %s", tt.want, chart.Version)
+			}
+
+			cleanup()
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Errorf("expected the clone to be removed, got %v", err)
+			}
+		})
+	}
+
+	if _, _, err := cloneChartRepo(context.Background(), ChartGitConfig{URL: repoURL, Ref: "missing"}); err == nil {
+		t.Error("expected an error for a missing ref")
+	}
+}
+
+func TestValidateChartGit(t *testing.T) {
+	repoURL, _ := newChartRepo(t)
+
+	tests := []struct {
+		name      string
+		chartGit  map[string]any
+		wantValid bool
+	}{
+		{name: "chart at tag", chartGit: map[string]any{"url": repoURL, "ref": "v1.0.0", "subdir": "charts/my-app"}, wantValid: true},
+		{name: "missing ref", chartGit: map[string]any{"url": repoURL, "ref": "v9.9.9", "subdir": "charts/my-app"}},
+		{name: "no chart in subdir", chartGit: map[string]any{"url": repoURL, "subdir": "charts"}},
+		{name: "subdir outside the repository", chartGit: map[string]any{"url": repoURL, "subdir": "../charts"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &HelmPlugin{}
+			cfg := p.parseConfig(map[string]any{"chart_git": tt.chartGit})
+			vb := helpers.NewValidationBuilder()
+			validateChartGit(context.Background(), vb, cfg)
+			if resp := vb.Build(); resp.Valid != tt.wantValid {
+				t.Errorf("expected valid=%v, got %+v", tt.wantValid, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestExecutePostPublishChartGit(t *testing.T) {
+	repoURL, _ := newChartRepo(t)
+	writeFakeCommand(t, "helm", `case "$1" in
+package)
+	staging=$(mktemp -d)
+	mkdir "$staging/my-app"
+	cp "$2/Chart.yaml" "$staging/my-app/"
+	tar -czf "$4/my-app-1.2.0.tgz" -C "$staging" my-app
+	rm -r "$staging"
+	echo "Successfully packaged chart and saved it to: $4/my-app-1.2.0.tgz"
+	;;
+push) printf 'Pushed: registry.example.com/charts/my-app:1.2.0\nDigest: sha256:deadbeef\n' ;;
+esac
+`)
+	outputDir := t.TempDir()
+	// Clones go to the temporary directory; check that they're removed
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_git":    map[string]any{"url": repoURL, "ref": "v1.0.0", "subdir": "charts/my-app"},
+		"lint":         false,
+		"output_dir":   outputDir,
+		"dependencies": map[string]any{"update": false, "build": false},
+		"repository":   map[string]any{"type": "oci", "url": "oci://registry.example.com/charts"},
+	})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePostPublish(context.Background(), &plugin.ReleaseContext{Version: "1.2.0"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	// The clone is updated to the release version before packaging
+	chart, err := readPackagedChart(filepath.Join(outputDir, "my-app-1.2.0.tgz"))
+	if err != nil {
+		t.Fatalf("failed to read package: %v", err)
+	}
+	if chart.Version != "1.2.0" {
+		t.Errorf("expected the cloned chart updated to 1.2.0, got %s", chart.Version)
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+		t.Errorf("expected the clone to be removed, found %v", entries)
+	}
+}
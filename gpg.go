@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// importSigningKey imports a base64-encoded GPG secret key into a temporary
+// GnuPG home and exports it as a legacy secret keyring, the format helm package
+// --sign reads. It returns the keyring path and the key's first uid, usable as
+// --key. cleanup removes the temporary home and must always be called.
+func importSigningKey(ctx context.Context, encoded, passphraseFile string) (keyring, uid string, cleanup func(), err error) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		return "", "", func() {}, fmt.Errorf("gpg not found in PATH (required to import the signing key)")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", func() {}, fmt.Errorf("failed to decode signing key: %w", err)
+	}
+
+	home, err := os.MkdirTemp("", "helm-gpg-*")
+	if err != nil {
+		return "", "", func() {}, fmt.Errorf("failed to create temporary keyring: %w", err)
+	}
+	cleanup = func() { _ = os.RemoveAll(home) }
+
+	gpg := func(stdin []byte, args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, "gpg", append([]string{"--batch", "--homedir", home}, args...)...)
+		cmd.Env = append(os.Environ(), "GNUPGHOME="+home)
+		cmd.Stdin = bytes.NewReader(stdin)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("gpg %s failed: %w: %s", args[len(args)-1], err, strings.TrimSpace(stderr.String()))
+		}
+		return stdout.Bytes(), nil
+	}
+
+	if _, err := gpg(key, "--import"); err != nil {
+		cleanup()
+		return "", "", func() {}, err
+	}
+
+	listing, err := gpg(nil, "--with-colons", "--list-secret-keys")
+	if err != nil {
+		cleanup()
+		return "", "", func() {}, err
+	}
+	uid = firstUID(string(listing))
+	if uid == "" {
+		cleanup()
+		return "", "", func() {}, fmt.Errorf("signing key has no user ID")
+	}
+
+	exportArgs := []string{"--export-secret-keys"}
+	if passphraseFile != "" {
+		exportArgs = []string{"--pinentry-mode", "loopback", "--passphrase-file", passphraseFile, "--export-secret-keys"}
+	}
+	secring, err := gpg(nil, exportArgs...)
+	if err != nil {
+		cleanup()
+		return "", "", func() {}, err
+	}
+	keyring = filepath.Join(home, "secring.gpg")
+	if err := os.WriteFile(keyring, secring, 0600); err != nil {
+		cleanup()
+		return "", "", func() {}, fmt.Errorf("failed to write keyring: %w", err)
+	}
+	return keyring, uid, cleanup, nil
+}
+
+// firstUID returns the first user ID in gpg --with-colons output, where uid
+// records carry the user ID in their tenth field.
+func firstUID(listing string) string {
+	for _, line := range strings.Split(listing, "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) > 9 && fields[0] == "uid" {
+			return fields[9]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeGPG stands in for gpg, printing a secret key listing and exporting the
+// imported key unchanged.
+const fakeGPG = `home="$3"
+shift 3
+case "$*" in
+	--import) cat > "$home/imported" ;;
+	*--list-secret-keys) printf 'sec:u:4096:1:ABCDEF0123456789:1700000000:::u:::scESC:::+:::23::0:\nuid:u::::1700000000::0123456789ABCDEF::Release Bot <bot@example.com>::::::::::0:\n' ;;
+	*--export-secret-keys) cat "$home/imported" ;;
+esac
+`
+
+func TestImportSigningKey(t *testing.T) {
+	writeFakeCommand(t, "gpg", fakeGPG)
+
+	keyring, uid, cleanup, err := importSigningKey(context.Background(), base64.StdEncoding.EncodeToString([]byte("SECRET KEY")), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if uid != "Release Bot <bot@example.com>" {
+		t.Errorf("expected uid from the key listing, got %q", uid)
+	}
+	data, err := os.ReadFile(keyring)
+	if err != nil || string(data) != "SECRET KEY" {
+		t.Errorf("expected exported keyring, got %q (%v)", data, err)
+	}
+
+	cleanup()
+	if _, err := os.Stat(filepath.Dir(keyring)); !os.IsNotExist(err) {
+		t.Errorf("expected temporary keyring to be removed, got %v", err)
+	}
+
+	if _, _, cleanup, err := importSigningKey(context.Background(), "not base64!", ""); err == nil || !strings.Contains(err.Error(), "failed to decode signing key") {
+		t.Errorf("expected decode error, got %v", err)
+	} else {
+		cleanup()
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, _, cleanup, err := importSigningKey(context.Background(), "U0VDUkVU", ""); err == nil || !strings.Contains(err.Error(), "gpg not found in PATH") {
+		t.Errorf("expected missing gpg error, got %v", err)
+	} else {
+		cleanup()
+	}
+}
+
+func TestPackageChartsSignKeyEnv(t *testing.T) {
+	writeFakeCommand(t, "gpg", fakeGPG)
+	t.Setenv("HELM_SIGNING_KEY", base64.StdEncoding.EncodeToString([]byte("SECRET KEY")))
+
+	tests := []struct {
+		name    string
+		script  string
+		wantErr bool
+	}{
+		{name: "success", script: `echo "Successfully packaged chart and saved it to: $3/my-app-1.0.0.tgz"`},
+		{name: "package fails", script: `echo "Error: signing failed" >&2; exit 1`, wantErr: true},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFakeCommand(t, "helm", `echo "$@" > "$(dirname "$0")/$1.args"
+`+tt.script)
+
+			cfg := &Config{Sign: true, SignMode: "gpg", SignKeyEnv: "HELM_SIGNING_KEY"}
+			_, err := packageCharts(context.Background(), cfg, "./chart", "my-app", "1.0.0", t.TempDir(), logger)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+
+			args, _ := os.ReadFile(filepath.Join(dir, "package.args"))
+			fields := strings.Fields(string(args))
+			keyring := ""
+			for i, f := range fields {
+				if f == "--keyring" && i+1 < len(fields) {
+					keyring = fields[i+1]
+				}
+			}
+			if keyring == "" || !strings.Contains(string(args), "--key Release Bot <bot@example.com>") {
+				t.Fatalf("expected temporary keyring and uid as key, got %q", args)
+			}
+			if _, err := os.Stat(filepath.Dir(keyring)); !os.IsNotExist(err) {
+				t.Errorf("expected temporary keyring to be removed, got %v", err)
+			}
+
+			// The package is verified against the temporary keyring before it is removed
+			verifyArgs, _ := os.ReadFile(filepath.Join(dir, "verify.args"))
+			if !tt.wantErr && !strings.HasPrefix(string(verifyArgs), "verify --keyring "+keyring+" ") {
+				t.Errorf("expected package verified with the temporary keyring, got %q", verifyArgs)
+			}
+		})
+	}
+}
@@ -1,17 +1,44 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 )
 
+// DefaultCommandTimeout bounds each helm invocation unless configured otherwise.
+const DefaultCommandTimeout = 5 * time.Minute
+
+// packageRetryBackoff is the delay before the first package retry; it doubles
+// on each further attempt.
+var packageRetryBackoff = 2 * time.Second
+
+// ErrHelmTimeout is returned when a helm command, or a tool run alongside
+// helm such as kubeconform, crane, oras or cosign, exceeds its timeout.
+var ErrHelmTimeout = errors.New("timed out")
+
 // HelmCLI wraps Helm command-line operations.
 type HelmCLI struct {
-	chartPath string
+	chartPath      string
+	helm           helmBinary
+	timeout        time.Duration
+	packageRetries int
+	// packageDependencyUpdate passes --dependency-update to helm package.
+	packageDependencyUpdate bool
+	verifyKeyring           string
+	logger                  *slog.Logger
 }
 
 // SignOptions contains chart signing options.
@@ -25,47 +52,343 @@ type SignOptions struct {
 func NewHelmCLI(chartPath string) *HelmCLI {
 	return &HelmCLI{
 		chartPath: chartPath,
+		logger:    slog.Default(),
 	}
 }
 
-// Lint lints the chart.
-func (h *HelmCLI) Lint(ctx context.Context, strict bool) error {
+// SetHelmBinary sets the helm executable and environment used for each
+// helm invocation.
+func (h *HelmCLI) SetHelmBinary(helm helmBinary) {
+	h.helm = helm
+}
+
+// SetTimeout sets the timeout applied to each helm invocation.
+func (h *HelmCLI) SetTimeout(timeout time.Duration) {
+	h.timeout = timeout
+}
+
+// SetLogger sets the logger for progress messages such as package retries.
+func (h *HelmCLI) SetLogger(logger *slog.Logger) {
+	h.logger = logger
+}
+
+// SetPackageDependencyUpdate makes helm package update the chart's
+// dependencies before packaging, with --dependency-update.
+func (h *HelmCLI) SetPackageDependencyUpdate(update bool) {
+	h.packageDependencyUpdate = update
+}
+
+// SetPackageRetries sets how often packaging with dependency updates is retried
+// after a transient dependency fetch failure.
+func (h *HelmCLI) SetPackageRetries(retries int) {
+	h.packageRetries = retries
+}
+
+// SetDependencyVerify makes dependency update and build verify the provenance
+// of downloaded dependencies against keyring.
+func (h *HelmCLI) SetDependencyVerify(keyring string) {
+	h.verifyKeyring = keyring
+}
+
+// helmBinary is the helm executable to run and the environment variables
+// merged into its environment, e.g. HELM_CACHE_HOME. The zero value runs helm
+// from PATH with the plugin's environment.
+type helmBinary struct {
+	path string
+	env  map[string]string
+}
+
+// command prepares a helm command with args.
+func (b helmBinary) command(ctx context.Context, args ...string) *exec.Cmd {
+	path := b.path
+	if path == "" {
+		path = "helm"
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	if len(b.env) > 0 {
+		cmd.Env = os.Environ()
+		for _, name := range slices.Sorted(maps.Keys(b.env)) {
+			cmd.Env = append(cmd.Env, name+"="+b.env[name])
+		}
+	}
+	return cmd
+}
+
+// runHelm runs a helm command bounded by timeout (DefaultCommandTimeout when
+// zero). run receives the prepared command and executes it. If the timeout
+// expires the returned error wraps ErrHelmTimeout, e.g.
+// "helm dependency update timed out after 5m0s"; cancellation of ctx itself is
+// reported as-is.
+func runHelm(ctx context.Context, helm helmBinary, timeout time.Duration, args []string, run func(cmd *exec.Cmd) error) error {
+	newCmd := func(ctx context.Context) *exec.Cmd { return helm.command(ctx, args...) }
+	return runWithTimeout(ctx, "helm "+helmCommandName(args), timeout, newCmd, run)
+}
+
+// runWithTimeout runs the command built by newCmd bounded by timeout, the way
+// runHelm does for helm, so that tools run alongside helm honour
+// command_timeout too. name describes the command in the timeout error.
+func runWithTimeout(ctx context.Context, name string, timeout time.Duration, newCmd func(ctx context.Context) *exec.Cmd, run func(cmd *exec.Cmd) error) error {
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := newCmd(cmdCtx)
+	// Don't wait forever on output pipes held open by orphaned child processes
+	cmd.WaitDelay = time.Second
+	err := run(cmd)
+	if err != nil && ctx.Err() == nil && errors.Is(cmdCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s %w after %s", name, ErrHelmTimeout, timeout)
+	}
+	return err
+}
+
+// runWithOutput runs cmd with its output forwarded to the plugin's output.
+func runWithOutput(cmd *exec.Cmd) error {
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent writes, so one can
+// collect both output streams of a command.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// runCapturingOutput returns a run function that forwards cmd's output to the
+// plugin's output, like runWithOutput, and also copies it into output.
+func runCapturingOutput(output *syncBuffer) func(cmd *exec.Cmd) error {
+	return func(cmd *exec.Cmd) error {
+		cmd.Stdout = io.MultiWriter(os.Stdout, output)
+		cmd.Stderr = io.MultiWriter(os.Stderr, output)
+		return cmd.Run()
+	}
+}
+
+// helmFailure wraps a failed helm command error, leaving timeouts (which
+// already name the command) untouched.
+func helmFailure(command string, err error) error {
+	if errors.Is(err, ErrHelmTimeout) {
+		return err
+	}
+	return fmt.Errorf("helm %s failed: %w", command, err)
+}
+
+// helmCommandName returns the (sub)command name of helm args, e.g.
+// "dependency update" or "push".
+func helmCommandName(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	switch args[0] {
+	case "dependency", "registry", "plugin", "s3", "gcs", "repo":
+		if len(args) > 1 {
+			return args[0] + " " + args[1]
+		}
+	}
+	return args[0]
+}
+
+// Lint lints the chart and returns the messages helm reported. The error is
+// non-nil when helm lint exits unsuccessfully.
+func (h *HelmCLI) Lint(ctx context.Context, strict bool) ([]LintMessage, error) {
 	args := []string{"lint", h.chartPath}
 	if strict {
 		args = append(args, "--strict")
 	}
-	return h.run(ctx, args...)
+
+	var output []byte
+	err := runHelm(ctx, h.helm, h.timeout, args, func(cmd *exec.Cmd) error {
+		var err error
+		output, err = cmd.CombinedOutput()
+		return err
+	})
+	messages := parseLintOutput(string(output))
+	if err != nil {
+		return messages, classifyLintError(string(output), helmFailure("lint", err))
+	}
+	return messages, nil
 }
 
-// Template validates templates by rendering them.
-func (h *HelmCLI) Template(ctx context.Context, kubeVersion string, apiVersions []string) error {
-	args := []string{"template", "release-name", h.chartPath}
-	if kubeVersion != "" {
-		args = append(args, "--kube-version", kubeVersion)
+// TemplateOptions contains options for rendering chart templates.
+type TemplateOptions struct {
+	KubeVersion string
+	APIVersions []string
+	Namespace   string
+	ValuesFiles []string          // passed as -f, in order
+	Set         map[string]string // passed as --set, sorted by key
+	IncludeCRDs bool              // render the chart's crds/ alongside its templates
+}
+
+// Template validates templates by rendering them. When outputPath is set the
+// rendered manifests are written there, creating parent directories; otherwise
+// they are discarded.
+func (h *HelmCLI) Template(ctx context.Context, opts TemplateOptions, outputPath string) error {
+	rendered, err := h.Render(ctx, opts)
+	if err != nil || outputPath == "" {
+		return err
 	}
-	for _, api := range apiVersions {
-		args = append(args, "--api-versions", api)
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create template output directory: %w", err)
+	}
+	if err := os.WriteFile(outputPath, rendered, 0644); err != nil {
+		return fmt.Errorf("failed to write template output: %w", err)
 	}
+	return nil
+}
 
-	cmd := exec.CommandContext(ctx, "helm", args...)
-	cmd.Stdout = io.Discard // We just want to validate, not see output
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// Render renders the chart templates and returns the rendered manifests.
+func (h *HelmCLI) Render(ctx context.Context, opts TemplateOptions) ([]byte, error) {
+	var stdout bytes.Buffer
+	err := runHelm(ctx, h.helm, h.timeout, templateArgs(h.chartPath, opts), func(cmd *exec.Cmd) error {
+		cmd.Stdout = &stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// RenderMatrix renders the chart once per entry of matrix, layering the entry's
+// --set overrides over opts.Set, to catch templates that only break when a
+// conditional section is toggled. Every failing entry is reported.
+func (h *HelmCLI) RenderMatrix(ctx context.Context, opts TemplateOptions, matrix []map[string]string) error {
+	var failures []string
+	for _, overrides := range matrix {
+		entry := opts
+		entry.Set = make(map[string]string, len(opts.Set)+len(overrides))
+		maps.Copy(entry.Set, opts.Set)
+		maps.Copy(entry.Set, overrides)
+		if _, err := h.Render(ctx, entry); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", formatSet(overrides), err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d render(s) failed:\n  - %s", len(failures), len(matrix), strings.Join(failures, "\n  - "))
+	}
+	return nil
+}
+
+// formatSet formats --set overrides as sorted key=value pairs.
+func formatSet(set map[string]string) string {
+	pairs := make([]string, 0, len(set))
+	for _, key := range slices.Sorted(maps.Keys(set)) {
+		pairs = append(pairs, key+"="+set[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// templateArgs builds the helm template arguments.
+func templateArgs(chartPath string, opts TemplateOptions) []string {
+	args := []string{"template", "release-name", chartPath}
+	if opts.Namespace != "" {
+		args = append(args, "--namespace", opts.Namespace)
+	}
+	if opts.KubeVersion != "" {
+		args = append(args, "--kube-version", opts.KubeVersion)
+	}
+	if opts.IncludeCRDs {
+		args = append(args, "--include-crds")
+	}
+	for _, api := range opts.APIVersions {
+		args = append(args, "--api-versions", api)
+	}
+	for _, file := range opts.ValuesFiles {
+		args = append(args, "-f", file)
+	}
+	for _, key := range slices.Sorted(maps.Keys(opts.Set)) {
+		args = append(args, "--set", key+"="+opts.Set[key])
+	}
+	return args
 }
 
 // DependencyUpdate updates chart dependencies.
 func (h *HelmCLI) DependencyUpdate(ctx context.Context) error {
-	return h.run(ctx, "dependency", "update", h.chartPath)
+	return h.dependency(ctx, "update")
 }
 
 // DependencyBuild builds chart dependencies.
 func (h *HelmCLI) DependencyBuild(ctx context.Context) error {
-	return h.run(ctx, "dependency", "build", h.chartPath)
+	return h.dependency(ctx, "build")
+}
+
+// dependency runs a helm dependency subcommand. With provenance verification
+// enabled, a verification failure is reported with the offending dependency.
+func (h *HelmCLI) dependency(ctx context.Context, subcommand string) error {
+	if h.verifyKeyring == "" {
+		return h.run(ctx, "dependency", subcommand, h.chartPath)
+	}
+
+	args := []string{"dependency", subcommand, h.chartPath, "--verify", "--keyring", h.verifyKeyring}
+	var output syncBuffer
+	err := runHelm(ctx, h.helm, h.timeout, args, runCapturingOutput(&output))
+	if err != nil && !errors.Is(err, ErrHelmTimeout) {
+		if verifyErr := dependencyVerifyFailure(output.String()); verifyErr != nil {
+			return verifyErr
+		}
+	}
+	return err
+}
+
+// provenanceErrors are substrings of helm output indicating a dependency failed
+// provenance verification.
+var provenanceErrors = []string{"provenance", "openpgp", "signature", "keyring", "sha256 sum does not match"}
+
+var (
+	dependencyArchive = regexp.MustCompile(`([A-Za-z0-9._-]+?)-v?\d+\.\d+\.\d+[^/\s"]*\.tgz`)
+	downloadingChart  = regexp.MustCompile(`Downloading (\S+) from repo`)
+)
+
+// dependencyVerifyFailure returns an error naming the dependency that failed
+// provenance verification, or nil if output shows no verification failure.
+func dependencyVerifyFailure(output string) error {
+	for _, line := range strings.Split(output, "\n") {
+		failed := false
+		for _, e := range provenanceErrors {
+			failed = failed || strings.Contains(line, e)
+		}
+		if !failed || !strings.Contains(line, "Error") {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "Error:"))
+		name := "unknown"
+		if m := dependencyArchive.FindStringSubmatch(line); m != nil {
+			name = m[1]
+		} else if m := downloadingChart.FindAllStringSubmatch(output, -1); m != nil {
+			name = m[len(m)-1][1]
+		}
+		return fmt.Errorf("dependency %s failed provenance verification: %s", name, line)
+	}
+	return nil
 }
 
 // Package packages the chart.
 func (h *HelmCLI) Package(ctx context.Context, outputDir string, signOpts *SignOptions) (string, error) {
 	args := []string{"package", h.chartPath, "-d", outputDir}
+	retries := 0
+	if h.packageDependencyUpdate {
+		args = append(args, "--dependency-update")
+		retries = h.packageRetries
+	}
 
 	if signOpts != nil {
 		args = append(args, "--sign")
@@ -80,8 +403,31 @@ func (h *HelmCLI) Package(ctx context.Context, outputDir string, signOpts *SignO
 		}
 	}
 
-	cmd := exec.CommandContext(ctx, "helm", args...)
-	output, err := cmd.CombinedOutput()
+	var output []byte
+	var err error
+	backoff := packageRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err = runHelm(ctx, h.helm, h.timeout, args, func(cmd *exec.Cmd) error {
+			var err error
+			output, err = cmd.CombinedOutput()
+			return err
+		})
+		if err == nil || attempt >= retries || !isDependencyFetchFailure(string(output)) {
+			break
+		}
+
+		h.logger.Warn("helm package failed fetching dependencies, retrying",
+			"attempt", attempt+1, "retries", retries, "backoff", backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		backoff *= 2
+	}
+	if errors.Is(err, ErrHelmTimeout) {
+		return "", err
+	}
 	if err != nil {
 		return "", fmt.Errorf("helm package failed: %w\n%s", err, string(output))
 	}
@@ -91,16 +437,128 @@ func (h *HelmCLI) Package(ctx context.Context, outputDir string, signOpts *SignO
 	return extractPackagePath(string(output))
 }
 
+// Verify checks a signed package against its .prov file: the signature must
+// be made by a key in keyring (helm's default keyring when empty) and the
+// package must match the digest it records.
+func (h *HelmCLI) Verify(ctx context.Context, packagePath, keyring string) error {
+	args := []string{"verify"}
+	if keyring != "" {
+		args = append(args, "--keyring", keyring)
+	}
+	args = append(args, packagePath)
+
+	var output []byte
+	err := runHelm(ctx, h.helm, h.timeout, args, func(cmd *exec.Cmd) error {
+		var err error
+		output, err = cmd.CombinedOutput()
+		return err
+	})
+	if err == nil || errors.Is(err, ErrHelmTimeout) {
+		return err
+	}
+	return verifyFailure(string(output), err)
+}
+
+// verifyFailures are helm verify errors with a known cause.
+var verifyFailures = []struct {
+	match, cause string
+}{
+	{"signature made by unknown entity", "signed with a key that is not in the keyring"},
+	{"sha256 sum does not match", "package does not match the digest in its provenance file"},
+	{"invalid signature", "provenance file signature is invalid"},
+	{"no such file or directory", "provenance file is missing"},
+}
+
+// verifyFailure describes a failed helm verify from its output, naming the
+// cause when it is a known one.
+func verifyFailure(output string, err error) error {
+	var message string
+	for _, line := range strings.Split(output, "\n") {
+		if m, ok := strings.CutPrefix(strings.TrimSpace(line), "Error: "); ok {
+			message = m
+			break
+		}
+	}
+	if message == "" {
+		return fmt.Errorf("helm verify failed: %w\n%s", err, output)
+	}
+	for _, f := range verifyFailures {
+		if strings.Contains(message, f.match) {
+			return fmt.Errorf("%s: %s", f.cause, message)
+		}
+	}
+	return fmt.Errorf("helm verify failed: %s", message)
+}
+
+// dependencyFetchErrors are substrings of helm output indicating a transient
+// failure fetching chart dependencies rather than a problem with the chart.
+var dependencyFetchErrors = []string{
+	"could not download",
+	"failed to fetch",
+	"error downloading",
+	"connection refused",
+	"connection reset",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+	"504 Gateway Timeout",
+}
+
+// isDependencyFetchFailure reports whether helm package output indicates a
+// transient dependency fetch failure worth retrying.
+func isDependencyFetchFailure(output string) bool {
+	for _, e := range dependencyFetchErrors {
+		if strings.Contains(output, e) {
+			return true
+		}
+	}
+	return false
+}
+
 // Test runs helm test on the chart.
 func (h *HelmCLI) Test(ctx context.Context, releaseName string) error {
 	return h.run(ctx, "test", releaseName)
 }
 
+// UnitTest runs the chart's helm-unittest suites. The output is forwarded to
+// the plugin's output and its summary parsed; a non-nil result is returned
+// even when tests fail.
+func (h *HelmCLI) UnitTest(ctx context.Context) (*UnitTestResult, error) {
+	var output syncBuffer
+	err := runHelm(ctx, h.helm, h.timeout, []string{"unittest", h.chartPath}, runCapturingOutput(&output))
+	result := parseUnitTestOutput(output.String())
+	if err != nil {
+		return result, helmFailure("unittest", err)
+	}
+	return result, nil
+}
+
 func (h *HelmCLI) run(ctx context.Context, args ...string) error {
-	cmd := exec.CommandContext(ctx, "helm", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return runHelm(ctx, h.helm, h.timeout, args, runWithOutput)
+}
+
+// listHelmPlugins returns the names of the installed helm plugins.
+func listHelmPlugins(helm helmBinary) ([]string, error) {
+	output, err := helm.command(context.Background(), "plugin", "list").Output()
+	if err != nil {
+		return nil, fmt.Errorf("helm plugin list failed: %w", err)
+	}
+	return parseHelmPluginList(string(output)), nil
+}
+
+// parseHelmPluginList extracts plugin names from the first column of helm plugin
+// list output, skipping the header line.
+func parseHelmPluginList(output string) []string {
+	var names []string
+	for i, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || (i == 0 && fields[0] == "NAME") {
+			continue
+		}
+		names = append(names, fields[0])
+	}
+	return names
 }
 
 // extractPackagePath extracts the package path from helm package output.
@@ -1,9 +1,31 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
+// writeFakeCommand installs an executable shell script named name at the front
+// of PATH for the duration of the test and returns its directory.
+func writeFakeCommand(t *testing.T, name, script string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("failed to write fake %s: %v", name, err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
 func TestExtractPackagePath(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -70,3 +92,333 @@ func TestNewHelmCLI(t *testing.T) {
 		t.Errorf("expected chartPath '/path/to/chart', got '%s'", cli.chartPath)
 	}
 }
+
+func TestTemplateArgs(t *testing.T) {
+	args := templateArgs("./chart", TemplateOptions{
+		KubeVersion: "1.28.0",
+		APIVersions: []string{"apps/v1"},
+		Namespace:   "team-a",
+		ValuesFiles: []string{"values-prod.yaml", "values-eu.yaml"},
+		Set:         map[string]string{"replicas": "3", "image.tag": "1.2.0"},
+		IncludeCRDs: true,
+	})
+
+	want := "template release-name ./chart --namespace team-a --kube-version 1.28.0 --include-crds --api-versions apps/v1" +
+		" -f values-prod.yaml -f values-eu.yaml --set image.tag=1.2.0 --set replicas=3"
+	if got := strings.Join(args, " "); got != want {
+		t.Errorf("expected '%s', got '%s'", want, got)
+	}
+}
+
+func TestParseHelmPluginList(t *testing.T) {
+	output := "NAME   \tVERSION\tDESCRIPTION\n" +
+		"diff   \t3.9.4  \tPreview helm upgrade changes as a diff\n" +
+		"s3     \t0.16.0 \tManage chart repositories on Amazon S3\n"
+
+	got := parseHelmPluginList(output)
+	if strings.Join(got, ",") != "diff,s3" {
+		t.Errorf("expected [diff s3], got %v", got)
+	}
+}
+
+func TestHelmCLITimeout(t *testing.T) {
+	writeFakeCommand(t, "helm", `exec sleep 5`)
+
+	helm := NewHelmCLI("./chart")
+	helm.SetTimeout(50 * time.Millisecond)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		command string
+		run     func() error
+	}{
+		{name: "lint", command: "lint", run: func() error { _, err := helm.Lint(ctx, false); return err }},
+		{name: "render", command: "template", run: func() error { _, err := helm.Render(ctx, TemplateOptions{}); return err }},
+		{name: "template", command: "template", run: func() error { return helm.Template(ctx, TemplateOptions{}, "") }},
+		{name: "dependency update", command: "dependency update", run: func() error { return helm.DependencyUpdate(ctx) }},
+		{name: "dependency build", command: "dependency build", run: func() error { return helm.DependencyBuild(ctx) }},
+		{name: "package", command: "package", run: func() error { _, err := helm.Package(ctx, t.TempDir(), nil); return err }},
+		{name: "test", command: "test", run: func() error { return helm.Test(ctx, "my-release") }},
+		{name: "unittest", command: "unittest", run: func() error { _, err := helm.UnitTest(ctx); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			err := tt.run()
+			if !errors.Is(err, ErrHelmTimeout) {
+				t.Fatalf("expected ErrHelmTimeout, got %v", err)
+			}
+			if want := "helm " + tt.command + " timed out after 50ms"; err.Error() != want {
+				t.Errorf("expected '%s', got '%s'", want, err.Error())
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("command was not cancelled promptly (%s)", elapsed)
+			}
+		})
+	}
+}
+
+func TestHelmCLIParentCancellationIsNotTimeout(t *testing.T) {
+	writeFakeCommand(t, "helm", `exec sleep 5`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := NewHelmCLI("./chart").DependencyUpdate(ctx)
+	if err == nil || errors.Is(err, ErrHelmTimeout) {
+		t.Errorf("expected a non-timeout error, got %v", err)
+	}
+}
+
+func TestHelmCLITemplateWritesOutput(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "kind: ConfigMap"`)
+	outputPath := filepath.Join(t.TempDir(), "rendered", "my-app.yaml")
+
+	if err := NewHelmCLI("./chart").Template(context.Background(), TemplateOptions{KubeVersion: "1.28.0"}, outputPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("expected rendered output to be written: %v", err)
+	}
+	if string(data) != "kind: ConfigMap\n" {
+		t.Errorf("unexpected rendered output: %q", data)
+	}
+}
+
+func TestHelmCLIRenderMatrix(t *testing.T) {
+	// The chart only breaks once metrics are enabled
+	dir := writeFakeCommand(t, "helm", `echo "$@" >> "$(dirname "$0")/calls"
+case "$*" in
+*metrics.enabled=true*)
+	echo "Error: template: my-app/templates/servicemonitor.yaml:4:18: nil pointer evaluating interface {}.port" >&2
+	exit 1
+	;;
+esac
+echo "kind: ConfigMap"`)
+
+	opts := TemplateOptions{Set: map[string]string{"replicaCount": "3"}}
+	matrix := []map[string]string{
+		{"ingress.enabled": "true"},
+		{"metrics.enabled": "true", "metrics.interval": "30s"},
+	}
+	err := NewHelmCLI("./chart").RenderMatrix(context.Background(), opts, matrix)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 render(s) failed:\n  - metrics.enabled=true,metrics.interval=30s: exit status 1") {
+		t.Fatalf("expected the metrics render to fail, got %v", err)
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	if !strings.Contains(string(calls), "--set ingress.enabled=true --set replicaCount=3") {
+		t.Errorf("expected matrix entries to be layered over template_set, got:\n%s", calls)
+	}
+	if len(opts.Set) != 1 {
+		t.Errorf("expected base overrides to be left untouched, got %v", opts.Set)
+	}
+}
+
+func TestHelmCLIPackageRetriesDependencyFetchFailures(t *testing.T) {
+	backoff := packageRetryBackoff
+	packageRetryBackoff = time.Millisecond
+	t.Cleanup(func() { packageRetryBackoff = backoff })
+
+	// Fail with a fetch error on the first attempt, then succeed
+	dir := writeFakeCommand(t, "helm", `calls="$(dirname "$0")/calls"
+echo "$@" >> "$calls"
+if [ "$(wc -l < "$calls")" -eq 1 ]; then
+	echo "Error: could not download https://charts.example.com/redis-17.0.0.tgz: connection reset by peer"
+	exit 1
+fi
+echo "Successfully packaged chart and saved it to: /tmp/my-app-1.0.0.tgz"
+`)
+
+	helm := NewHelmCLI("./chart")
+	helm.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	helm.SetPackageDependencyUpdate(true)
+	helm.SetPackageRetries(2)
+	path, err := helm.Package(context.Background(), t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/tmp/my-app-1.0.0.tgz" {
+		t.Errorf("unexpected package path: %s", path)
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	if n := strings.Count(string(calls), "--dependency-update"); n != 2 {
+		t.Errorf("expected 2 package attempts with --dependency-update, got:\n%s", calls)
+	}
+
+	// Without dependency updates there is nothing to retry
+	_ = os.Remove(filepath.Join(dir, "calls"))
+	helm.SetPackageDependencyUpdate(false)
+	if _, err := helm.Package(context.Background(), t.TempDir(), nil); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	calls, _ = os.ReadFile(filepath.Join(dir, "calls"))
+	if strings.Count(string(calls), "\n") != 1 || strings.Contains(string(calls), "--dependency-update") {
+		t.Errorf("expected a single package attempt without --dependency-update, got:\n%s", calls)
+	}
+}
+
+func TestHelmCLIPackageDoesNotRetryChartErrors(t *testing.T) {
+	dir := writeFakeCommand(t, "helm", `echo x >> "$(dirname "$0")/calls"
+echo "Error: validation: chart.metadata.version is required"
+exit 1
+`)
+
+	helm := NewHelmCLI("./chart")
+	helm.SetPackageDependencyUpdate(true)
+	helm.SetPackageRetries(3)
+	if _, err := helm.Package(context.Background(), t.TempDir(), nil); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	if n := strings.Count(string(calls), "x"); n != 1 {
+		t.Errorf("expected a single package attempt, got %d", n)
+	}
+}
+
+func TestHelmCLIDependencyVerify(t *testing.T) {
+	dir := writeFakeCommand(t, "helm", `echo "$@" > "$(dirname "$0")/args"
+echo "Downloading redis from repo https://charts.example.com"
+echo "Error: could not download https://charts.example.com/redis-17.0.0.tgz: failed to fetch provenance \"https://charts.example.com/redis-17.0.0.tgz.prov\"" >&2
+exit 1
+`)
+
+	helm := NewHelmCLI("./chart")
+	helm.SetDependencyVerify("/keys/pubring.gpg")
+	err := helm.DependencyUpdate(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "dependency redis failed provenance verification") {
+		t.Errorf("expected verification failure for redis, got %v", err)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if want := "dependency update ./chart --verify --keyring /keys/pubring.gpg"; strings.TrimSpace(string(args)) != want {
+		t.Errorf("expected args %q, got %q", want, args)
+	}
+}
+
+func TestHelmCLIHelmBinary(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "helm3")
+	script := "#!/bin/sh\necho \"$HELM_CACHE_HOME $HELM_PLUGINS $@\" > \"$(dirname \"$0\")/args\"\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake helm: %v", err)
+	}
+	// A helm on PATH must not be used
+	writeFakeCommand(t, "helm", "exit 1")
+
+	helm := NewHelmCLI("./chart")
+	helm.SetHelmBinary(helmBinary{path: binary, env: map[string]string{"HELM_CACHE_HOME": "/cache", "HELM_PLUGINS": "/plugins"}})
+	if err := helm.DependencyUpdate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if want := "/cache /plugins dependency update ./chart"; strings.TrimSpace(string(args)) != want {
+		t.Errorf("expected %q, got %q", want, args)
+	}
+}
+
+func TestDependencyVerifyFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "unsigned dependency",
+			output: "Error: could not download https://charts.example.com/redis-cluster-9.0.0.tgz: failed to fetch provenance",
+			want:   "dependency redis-cluster failed provenance verification",
+		},
+		{
+			name:   "bad signature",
+			output: "Downloading nginx from repo https://charts.example.com\nError: openpgp: invalid signature: hash tag doesn't match",
+			want:   "dependency nginx failed provenance verification: openpgp: invalid signature",
+		},
+		{
+			name:   "unrelated failure",
+			output: "Error: no repository definition for https://charts.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dependencyVerifyFailure(tt.output)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected no verification failure, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestHelmCLIVerify(t *testing.T) {
+	dir := writeFakeCommand(t, "helm", `echo "$@" > "$(dirname "$0")/args"
+echo "Error: openpgp: signature made by unknown entity" >&2
+exit 1
+`)
+
+	helm := NewHelmCLI("./chart")
+	err := helm.Verify(context.Background(), "/out/my-app-1.0.0.tgz", "/keys/pubring.gpg")
+	if err == nil || err.Error() != "signed with a key that is not in the keyring: openpgp: signature made by unknown entity" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if want := "verify --keyring /keys/pubring.gpg /out/my-app-1.0.0.tgz"; strings.TrimSpace(string(args)) != want {
+		t.Errorf("expected %q, got %q", want, args)
+	}
+}
+
+func TestVerifyFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name:   "digest mismatch",
+			output: "Error: sha256 sum does not match for my-app-1.0.0.tgz: \"sha256:aaa\" != \"sha256:bbb\"\n",
+			want:   "package does not match the digest in its provenance file: sha256 sum does not match",
+		},
+		{
+			name:   "bad signature",
+			output: "Error: openpgp: invalid signature: hash tag doesn't match\n",
+			want:   "provenance file signature is invalid",
+		},
+		{
+			name:   "missing provenance file",
+			output: "Error: open /out/my-app-1.0.0.tgz.prov: no such file or directory\n",
+			want:   "provenance file is missing",
+		},
+		{
+			name:   "unknown error",
+			output: "Error: failed to load keyring: unexpected EOF\n",
+			want:   "helm verify failed: failed to load keyring: unexpected EOF",
+		},
+		{
+			name:   "no error line",
+			output: "Segmentation fault\n",
+			want:   "helm verify failed: exit status 1\nSegmentation fault",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyFailure(tt.output, errors.New("exit status 1"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// ErrHelmDocsNotFound is returned by runHelmDocs when helm-docs isn't installed.
+var ErrHelmDocsNotFound = errors.New("helm-docs not found in PATH")
+
+// helmDocsArgs returns the helm-docs arguments for regenerating the README of
+// the chart in chartPath (and its bundled subcharts).
+func helmDocsArgs(chartPath string) []string {
+	return []string{"--chart-search-root", chartPath}
+}
+
+// runHelmDocs regenerates the chart's README.md with helm-docs.
+func runHelmDocs(ctx context.Context, chartPath string) error {
+	if _, err := exec.LookPath("helm-docs"); err != nil {
+		return ErrHelmDocsNotFound
+	}
+
+	cmd := exec.CommandContext(ctx, "helm-docs", helmDocsArgs(chartPath)...)
+	if err := runWithOutput(cmd); err != nil {
+		return fmt.Errorf("helm-docs failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHelmDocsArgs(t *testing.T) {
+	got := strings.Join(helmDocsArgs("charts/my-app"), " ")
+	if got != "--chart-search-root charts/my-app" {
+		t.Errorf("unexpected args: %s", got)
+	}
+}
+
+func TestRunHelmDocs(t *testing.T) {
+	chartDir := t.TempDir()
+
+	t.Run("regenerates readme", func(t *testing.T) {
+		writeFakeCommand(t, "helm-docs", `echo "# my-app" > "$2/README.md"`)
+		if err := runHelmDocs(context.Background(), chartDir); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		readme, _ := os.ReadFile(filepath.Join(chartDir, "README.md"))
+		if string(readme) != "# my-app\n" {
+			t.Errorf("expected README to be generated, got %q", readme)
+		}
+	})
+
+	t.Run("fails", func(t *testing.T) {
+		writeFakeCommand(t, "helm-docs", `echo "level=fatal msg=\"template parse error\"" >&2; exit 1`)
+		if err := runHelmDocs(context.Background(), chartDir); err == nil || !strings.Contains(err.Error(), "helm-docs failed") {
+			t.Errorf("expected helm-docs failure, got %v", err)
+		}
+	})
+
+	t.Run("not installed", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		if err := runHelmDocs(context.Background(), chartDir); !errors.Is(err, ErrHelmDocsNotFound) {
+			t.Errorf("expected ErrHelmDocsNotFound, got %v", err)
+		}
+	})
+}
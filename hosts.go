@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+)
+
+// repositoryHost returns the host of a repository URL (oci://, http://,
+// https://, s3:// or gs://), without any port. For buckets it is the bucket
+// name.
+func repositoryHost(repoURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(repoURL))
+	if err != nil {
+		return "", fmt.Errorf("invalid repository URL %q: %w", repoURL, err)
+	}
+	switch u.Scheme {
+	case "oci", "http", "https", "s3", "gs":
+	default:
+		return "", fmt.Errorf("repository URL %q has no oci, http(s), s3 or gs scheme", repoURL)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return "", fmt.Errorf("repository URL %q has no host", repoURL)
+	}
+	return host, nil
+}
+
+// hostAllowed reports whether host matches one of the allowed patterns: a host
+// name, or "*." followed by a domain matching any host below it.
+func hostAllowed(host string, allowed []string) bool {
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRepositoryHost checks that the repository's host is allowed by
+// allowed_repository_hosts. Any host is allowed when the list is empty.
+func checkRepositoryHost(repo RepositoryConfig, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	host, err := repositoryHost(repo.URL)
+	if err != nil {
+		return err
+	}
+	if !hostAllowed(host, allowed) {
+		return fmt.Errorf("repository host %s is not in allowed_repository_hosts", host)
+	}
+	return nil
+}
+
+// validateRepositoryHost adds an error to vb if the repository's host isn't
+// allowed.
+func validateRepositoryHost(vb *helpers.ValidationBuilder, field string, repo RepositoryConfig, allowed []string) {
+	if err := checkRepositoryHost(repo, allowed); err != nil {
+		vb.AddError(field+".url", err.Error())
+	}
+}
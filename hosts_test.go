@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestCheckRepositoryHost(t *testing.T) {
+	allowed := []string{"ghcr.io", "*.internal.example.com", "release-charts"}
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{name: "oci", url: "oci://ghcr.io/myorg/charts"},
+		{name: "wildcard with port", url: "https://charts.internal.example.com:8443/api"},
+		{name: "nested wildcard", url: "oci://registry.eu.internal.example.com/charts"},
+		{name: "bucket", url: "s3://release-charts/stable"},
+		{name: "case insensitive", url: "oci://GHCR.io/myorg/charts"},
+		{name: "typo", url: "oci://ghcr.com/myorg/charts", wantErr: "repository host ghcr.com is not in allowed_repository_hosts"},
+		{name: "wildcard doesn't match the bare domain", url: "https://internal.example.com", wantErr: "not in allowed_repository_hosts"},
+		{name: "suffix without a dot", url: "https://evilinternal.example.com", wantErr: "not in allowed_repository_hosts"},
+		{name: "no scheme", url: "ghcr.io/myorg/charts", wantErr: "has no oci, http(s), s3 or gs scheme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRepositoryHost(RepositoryConfig{URL: tt.url}, allowed)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if err := checkRepositoryHost(RepositoryConfig{URL: "oci://docker.io/public"}, nil); err != nil {
+		t.Errorf("expected any host to be allowed without an allowlist, got %v", err)
+	}
+}
+
+func TestValidateRepositoryHost(t *testing.T) {
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"allowed_repository_hosts": []any{"ghcr.io"},
+		"repositories": []any{
+			map[string]any{"type": "oci", "url": "oci://ghcr.io/myorg/charts"},
+			map[string]any{"type": "oci", "url": "oci://docker.io/myorg"},
+		},
+	})
+
+	vb := helpers.NewValidationBuilder()
+	for i, repo := range cfg.Repositories {
+		validateRepositoryHost(vb, fmt.Sprintf("repositories[%d]", i), repo, cfg.AllowedRepositoryHosts)
+	}
+	resp := vb.Build()
+	if len(resp.Errors) != 1 || resp.Errors[0].Field != "repositories[1].url" {
+		t.Errorf("expected an error for the docker.io repository only, got %+v", resp.Errors)
+	}
+}
+
+func TestExecutePostPublishDisallowedHost(t *testing.T) {
+	// helm must never be run
+	writeFakeCommand(t, "helm", `exit 1`)
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_path":               t.TempDir(),
+		"allowed_repository_hosts": []any{"*.internal.example.com"},
+		"repository":               map[string]any{"type": "oci", "url": "oci://ghcr.io/myorg/charts"},
+	})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePostPublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success || !strings.Contains(resp.Message, "repository host ghcr.io is not in allowed_repository_hosts") {
+		t.Errorf("expected the publish to be refused, got %+v", resp)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// severityLevels are trivy's severities, lowest first.
+var severityLevels = []string{"UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+// ImageScanConfig defines scanning the chart's container images for
+// vulnerabilities with trivy.
+type ImageScanConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Source   string `json:"source"`   // manifests (rendered templates) or values (values.yaml)
+	Severity string `json:"severity"` // fail on vulnerabilities at or above this severity
+	Offline  bool   `json:"offline"`  // use the cached vulnerability database only
+	// IgnoreUnfixed skips vulnerabilities without a fixed version.
+	IgnoreUnfixed bool `json:"ignore_unfixed"`
+}
+
+// parseImageScanConfig parses the scan_images block.
+func parseImageScanConfig(raw any) ImageScanConfig {
+	cfg := ImageScanConfig{Source: "manifests", Severity: "HIGH"}
+	scanRaw, ok := raw.(map[string]any)
+	if !ok {
+		return cfg
+	}
+	if enabled, ok := scanRaw["enabled"].(bool); ok {
+		cfg.Enabled = enabled
+	}
+	if source, ok := scanRaw["source"].(string); ok {
+		cfg.Source = source
+	}
+	if severity, ok := scanRaw["severity"].(string); ok {
+		cfg.Severity = strings.ToUpper(severity)
+	}
+	if offline, ok := scanRaw["offline"].(bool); ok {
+		cfg.Offline = offline
+	}
+	if ignore, ok := scanRaw["ignore_unfixed"].(bool); ok {
+		cfg.IgnoreUnfixed = ignore
+	}
+	return cfg
+}
+
+// severities returns the threshold severity and every higher one.
+func (c ImageScanConfig) severities() []string {
+	i := slices.Index(severityLevels, c.Severity)
+	if i < 0 {
+		return nil
+	}
+	return severityLevels[i:]
+}
+
+// args returns the trivy arguments for scanning image.
+func (c ImageScanConfig) args(image string) []string {
+	args := []string{"image", "--quiet", "--format", "json", "--severity", strings.Join(c.severities(), ",")}
+	if c.Offline {
+		args = append(args, "--skip-db-update", "--offline-scan")
+	}
+	if c.IgnoreUnfixed {
+		args = append(args, "--ignore-unfixed")
+	}
+	return append(args, image)
+}
+
+// manifestImages returns the sorted, unique container images referenced by
+// the manifests' containers, init containers and ephemeral containers.
+func manifestImages(manifests []Manifest) []string {
+	seen := make(map[string]bool)
+	for _, m := range manifests {
+		collectContainerImages(m.Object, seen)
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// collectContainerImages walks a manifest object, so images are found in pod
+// templates at any depth (Deployments, CronJobs, ...).
+func collectContainerImages(node any, seen map[string]bool) {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if key == "containers" || key == "initContainers" || key == "ephemeralContainers" {
+				if containers, ok := child.([]any); ok {
+					for _, c := range containers {
+						if container, ok := c.(map[string]any); ok {
+							if image, ok := container["image"].(string); ok && image != "" {
+								seen[image] = true
+							}
+						}
+					}
+				}
+			}
+			collectContainerImages(child, seen)
+		}
+	case []any:
+		for _, child := range v {
+			collectContainerImages(child, seen)
+		}
+	}
+}
+
+// valuesImages returns the sorted, unique images declared in values, as
+// image blocks with a repository and tag (and optional registry). Blocks
+// without a tag default to the chart's appVersion, which the caller passes.
+func valuesImages(values any, appVersion string) []string {
+	seen := make(map[string]bool)
+	var walk func(node any)
+	walk = func(node any) {
+		switch v := node.(type) {
+		case map[string]any:
+			if repository, ok := v["repository"].(string); ok && repository != "" {
+				tag := fmt.Sprint(v["tag"])
+				if v["tag"] == nil || tag == "" {
+					tag = appVersion
+				}
+				image := repository
+				if registry, ok := v["registry"].(string); ok && registry != "" {
+					image = registry + "/" + repository
+				}
+				if tag != "" {
+					image += ":" + tag
+				}
+				seen[image] = true
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(values)
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// trivyReport is the part of trivy's JSON report needed to count findings.
+type trivyReport struct {
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID string `json:"VulnerabilityID"`
+			Severity        string `json:"Severity"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+// scanImages scans each image with trivy and fails listing every image with
+// vulnerabilities at or above the configured severity.
+func scanImages(ctx context.Context, cfg ImageScanConfig, images []string) error {
+	var problems []string
+	for _, image := range images {
+		cmd := exec.CommandContext(ctx, "trivy", cfg.args(image)...)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("trivy failed scanning %s: %w: %s", image, err, strings.TrimSpace(stderr.String()))
+		}
+
+		var report trivyReport
+		if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+			return fmt.Errorf("failed to parse trivy output for %s: %w", image, err)
+		}
+		var ids []string
+		for _, result := range report.Results {
+			for _, vuln := range result.Vulnerabilities {
+				if slices.Contains(cfg.severities(), vuln.Severity) {
+					ids = append(ids, vuln.VulnerabilityID)
+				}
+			}
+		}
+		if len(ids) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: %d vulnerability(ies): %s", image, len(ids), strings.Join(ids, ", ")))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d image(s) have vulnerabilities at or above %s:\n  - %s", len(problems), cfg.Severity, strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// chartImages returns the images to scan from the configured source.
+func chartImages(ctx context.Context, helm *HelmCLI, cfg *Config, chartPath string, chart *Chart) ([]string, error) {
+	if cfg.ScanImages.Source == "values" {
+		values, err := loadValuesJSON(filepath.Join(chartPath, "values.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		return valuesImages(values, chart.AppVersion), nil
+	}
+
+	rendered, err := helm.Render(ctx, cfg.templateOptions())
+	if err != nil {
+		return nil, err
+	}
+	manifests, err := ParseManifests(rendered)
+	if err != nil {
+		return nil, err
+	}
+	return manifestImages(manifests), nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifestImages(t *testing.T) {
+	manifests := mustParseManifests(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: ghcr.io/myorg/migrate:1.0.0
+      containers:
+        - name: app
+          image: ghcr.io/myorg/my-app:1.0.0
+        - name: proxy
+          image: envoyproxy/envoy:v1.29.0
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: cleanup
+              image: ghcr.io/myorg/my-app:1.0.0
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  image: not-a-container
+`)
+
+	got := manifestImages(manifests)
+	want := []string{"envoyproxy/envoy:v1.29.0", "ghcr.io/myorg/migrate:1.0.0", "ghcr.io/myorg/my-app:1.0.0"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestValuesImages(t *testing.T) {
+	dir := t.TempDir()
+	valuesFile := filepath.Join(dir, "values.yaml")
+	values := `image:
+  repository: ghcr.io/myorg/my-app
+  tag: ""
+sidecar:
+  image:
+    registry: docker.io
+    repository: envoyproxy/envoy
+    tag: v1.29.0
+jobs:
+  - image:
+      repository: busybox
+      tag: 1.36
+`
+	if err := os.WriteFile(valuesFile, []byte(values), 0644); err != nil {
+		t.Fatalf("failed to write values.yaml: %v", err)
+	}
+	parsed, err := loadValuesJSON(valuesFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := valuesImages(parsed, "1.2.0")
+	want := []string{"busybox:1.36", "docker.io/envoyproxy/envoy:v1.29.0", "ghcr.io/myorg/my-app:1.2.0"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestImageScanConfigArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  map[string]any
+		want string
+	}{
+		{
+			name: "defaults",
+			raw:  map[string]any{"enabled": true},
+			want: "image --quiet --format json --severity HIGH,CRITICAL nginx:1.25",
+		},
+		{
+			name: "offline medium",
+			raw:  map[string]any{"enabled": true, "severity": "medium", "offline": true, "ignore_unfixed": true},
+			want: "image --quiet --format json --severity MEDIUM,HIGH,CRITICAL --skip-db-update --offline-scan --ignore-unfixed nginx:1.25",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := parseImageScanConfig(tt.raw)
+			if got := strings.Join(cfg.args("nginx:1.25"), " "); got != tt.want {
+				t.Errorf("expected args %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if (ImageScanConfig{Severity: "SEVERE"}).severities() != nil {
+		t.Error("expected unknown severity to be rejected")
+	}
+}
+
+func TestScanImages(t *testing.T) {
+	writeFakeCommand(t, "trivy", `case "$*" in
+	*nginx*) echo '{"Results":[{"Vulnerabilities":[{"VulnerabilityID":"CVE-2024-0001","Severity":"CRITICAL"},{"VulnerabilityID":"CVE-2024-0002","Severity":"LOW"}]}]}' ;;
+	*offline*) echo "database not found" >&2; exit 1 ;;
+	*) echo '{"Results":[{"Vulnerabilities":null}]}' ;;
+esac
+`)
+
+	cfg := ImageScanConfig{Enabled: true, Severity: "HIGH"}
+	if err := scanImages(context.Background(), cfg, []string{"busybox:1.36"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err := scanImages(context.Background(), cfg, []string{"busybox:1.36", "nginx:1.25"})
+	want := "1 image(s) have vulnerabilities at or above HIGH:\n  - nginx:1.25: 1 vulnerability(ies): CVE-2024-0001"
+	if err == nil || err.Error() != want {
+		t.Errorf("expected %q, got %v", want, err)
+	}
+
+	err = scanImages(context.Background(), ImageScanConfig{Severity: "HIGH", Offline: true}, []string{"busybox:1.36"})
+	if err == nil || !strings.Contains(err.Error(), "database not found") {
+		t.Errorf("expected trivy failure to be reported, got %v", err)
+	}
+}
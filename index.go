@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// indexURL returns where an http repository's index.yaml is read from and
+// written to: index_location if set, otherwise next to the uploaded packages.
+func (r *Repository) indexURL() string {
+	if r.config.IndexLocation != "" {
+		return r.config.IndexLocation + "/index.yaml"
+	}
+	return r.packageDirURL() + "/index.yaml"
+}
+
+// updateIndex regenerates an http repository's index.yaml after packagePath
+// was uploaded: the current index is downloaded, merged with the new package
+// by "helm repo index --merge" and uploaded again. Without a current index (the
+// first publish) a new one is created.
+func (r *Repository) updateIndex(ctx context.Context, packagePath string) error {
+	current, err := r.getObject(ctx, r.indexURL())
+	if err != nil {
+		return fmt.Errorf("failed to download index: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "helm-index-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	// helm indexes every package in the directory, so it holds only the new one
+	packageDir := filepath.Join(workDir, "packages")
+	if err := os.Mkdir(packageDir, 0755); err != nil {
+		return fmt.Errorf("failed to create package dir: %w", err)
+	}
+	if err := copyFile(packagePath, filepath.Join(packageDir, filepath.Base(packagePath))); err != nil {
+		return fmt.Errorf("failed to copy package: %w", err)
+	}
+
+	args := []string{"repo", "index", packageDir, "--url", r.packageDirURL()}
+	if current != nil {
+		mergeFile := filepath.Join(workDir, "index.yaml")
+		if err := os.WriteFile(mergeFile, current, 0644); err != nil {
+			return fmt.Errorf("failed to write current index: %w", err)
+		}
+		args = append(args, "--merge", mergeFile)
+	}
+	if err := runHelm(ctx, r.helm, r.timeout, args, runWithOutput); err != nil {
+		return helmFailure("repo index", err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(packageDir, "index.yaml"))
+	if err != nil {
+		return fmt.Errorf("failed to read regenerated index: %w", err)
+	}
+	return r.putObject(ctx, r.indexURL(), string(index))
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepositoryPushHTTPUpdatesIndex(t *testing.T) {
+	// Fake helm repo index: the new index lists the current one (if merged) and
+	// the packages in the directory
+	writeFakeCommand(t, "helm", `entries="$( [ "$6" = "--merge" ] && cat "$7"; echo "- $(cd "$3" && ls *.tgz) from $5")"
+echo "$entries" > "$3/index.yaml"`)
+
+	tests := []struct {
+		name          string
+		current       string
+		indexLocation string
+		wantIndexPath string
+		wantIndex     string
+	}{
+		{
+			name:          "first publish",
+			wantIndexPath: "/helm/index.yaml",
+			wantIndex:     "- my-app-1.1.0.tgz from {{server}}/helm\n",
+		},
+		{
+			name:          "merge into separate index",
+			current:       "- my-app-1.0.0.tgz\n",
+			indexLocation: "/index",
+			wantIndexPath: "/index/index.yaml",
+			wantIndex:     "- my-app-1.0.0.tgz\n- my-app-1.1.0.tgz from {{server}}/helm\n",
+		},
+	}
+
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.1.0.tgz")
+	content := writeTestPackage(t, packagePath)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := map[string]string{}
+			if tt.current != "" {
+				objects[tt.wantIndexPath] = tt.current
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodGet:
+					content, ok := objects[r.URL.Path]
+					if !ok {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					_, _ = w.Write([]byte(content))
+				case http.MethodPut:
+					body, _ := io.ReadAll(r.Body)
+					objects[r.URL.Path] = string(body)
+					w.WriteHeader(http.StatusCreated)
+				}
+			}))
+			defer server.Close()
+
+			config := RepositoryConfig{Type: "http", URL: server.URL + "/helm/my-app-1.1.0.tgz", Index: true}
+			if tt.indexLocation != "" {
+				config.IndexLocation = server.URL + tt.indexLocation
+			}
+			if _, err := NewRepository(config).Push(context.Background(), packagePath); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if objects["/helm/my-app-1.1.0.tgz"] != content {
+				t.Errorf("expected the package at the upload URL, got %v", objects)
+			}
+			want := strings.ReplaceAll(tt.wantIndex, "{{server}}", server.URL)
+			if got := objects[tt.wantIndexPath]; got != want {
+				t.Errorf("expected index at %s:\n%s\ngot:\n%s", tt.wantIndexPath, want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// KubeconformConfig defines settings for validating rendered templates against
+// Kubernetes API schemas with kubeconform.
+type KubeconformConfig struct {
+	Enabled           bool   `json:"enabled"`
+	Strict            bool   `json:"strict"`             // reject unknown fields
+	SchemaLocation    string `json:"schema_location"`    // extra schema location, e.g. for CRDs
+	KubernetesVersion string `json:"kubernetes_version"` // defaults to kube_version
+}
+
+// parseKubeconformConfig parses the kubeconform block.
+func parseKubeconformConfig(raw any) KubeconformConfig {
+	var cfg KubeconformConfig
+	kcRaw, ok := raw.(map[string]any)
+	if !ok {
+		return cfg
+	}
+	if enabled, ok := kcRaw["enabled"].(bool); ok {
+		cfg.Enabled = enabled
+	}
+	if strict, ok := kcRaw["strict"].(bool); ok {
+		cfg.Strict = strict
+	}
+	if location, ok := kcRaw["schema_location"].(string); ok {
+		cfg.SchemaLocation = location
+	}
+	if version, ok := kcRaw["kubernetes_version"].(string); ok {
+		cfg.KubernetesVersion = version
+	}
+	return cfg
+}
+
+// args returns the kubeconform arguments for validating manifests read from stdin.
+func (c KubeconformConfig) args() []string {
+	args := []string{"-output", "json"}
+	if c.Strict {
+		args = append(args, "-strict")
+	}
+	if c.SchemaLocation != "" {
+		args = append(args, "-schema-location", "default", "-schema-location", c.SchemaLocation)
+	}
+	if c.KubernetesVersion != "" {
+		args = append(args, "-kubernetes-version", strings.TrimPrefix(c.KubernetesVersion, "v"))
+	}
+	return append(args, "-")
+}
+
+// kubeconformResource is a resource reported by kubeconform -output json.
+type kubeconformResource struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Status  string `json:"status"`
+	Msg     string `json:"msg"`
+}
+
+// String identifies the resource and its validation message.
+func (r kubeconformResource) String() string {
+	return fmt.Sprintf("%s/%s (%s): %s", r.Kind, r.Name, r.Version, r.Msg)
+}
+
+// parseKubeconformOutput returns the resources kubeconform found invalid or
+// couldn't validate.
+func parseKubeconformOutput(output []byte) ([]kubeconformResource, error) {
+	var result struct {
+		Resources []kubeconformResource `json:"resources"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconform output: %w", err)
+	}
+
+	var violations []kubeconformResource
+	for _, r := range result.Resources {
+		if r.Status == "statusInvalid" || r.Status == "statusError" {
+			violations = append(violations, r)
+		}
+	}
+	return violations, nil
+}
+
+// TemplateAndValidate renders the chart templates and streams them to
+// kubeconform, failing with every resource that violates its API schema.
+func (h *HelmCLI) TemplateAndValidate(ctx context.Context, opts TemplateOptions, kc KubeconformConfig) error {
+	rendered, err := h.Render(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if kc.KubernetesVersion == "" {
+		kc.KubernetesVersion = opts.KubeVersion
+	}
+
+	var stdout bytes.Buffer
+	newCmd := func(ctx context.Context) *exec.Cmd { return exec.CommandContext(ctx, "kubeconform", kc.args()...) }
+	runErr := runWithTimeout(ctx, "kubeconform", h.timeout, newCmd, func(cmd *exec.Cmd) error {
+		cmd.Stdin = bytes.NewReader(rendered)
+		cmd.Stdout = &stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	})
+	if errors.Is(runErr, ErrHelmTimeout) {
+		return runErr
+	}
+
+	// kubeconform exits non-zero when resources are invalid, so look at the
+	// report before treating the exit status as a failure to run
+	violations, err := parseKubeconformOutput(stdout.Bytes())
+	if err != nil {
+		var exitErr *exec.ExitError
+		if runErr != nil && !errors.As(runErr, &exitErr) {
+			return fmt.Errorf("kubeconform failed: %w", runErr)
+		}
+		return err
+	}
+	if len(violations) > 0 {
+		lines := make([]string, 0, len(violations))
+		for _, v := range violations {
+			lines = append(lines, v.String())
+		}
+		return fmt.Errorf("%d resource(s) failed schema validation:\n  - %s", len(violations), strings.Join(lines, "\n  - "))
+	}
+	if runErr != nil {
+		return fmt.Errorf("kubeconform failed: %w", runErr)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKubeconformConfig(t *testing.T) {
+	cfg := parseKubeconformConfig(map[string]any{
+		"enabled":            true,
+		"strict":             true,
+		"schema_location":    "https://schemas.example.com/{{.ResourceKind}}.json",
+		"kubernetes_version": "v1.29.0",
+	})
+	want := []string{"-output", "json", "-strict", "-schema-location", "default", "-schema-location", "https://schemas.example.com/{{.ResourceKind}}.json", "-kubernetes-version", "1.29.0", "-"}
+	if !cfg.Enabled || strings.Join(cfg.args(), " ") != strings.Join(want, " ") {
+		t.Errorf("expected args %v, got %v", want, cfg.args())
+	}
+}
+
+func TestHelmCLITemplateAndValidate(t *testing.T) {
+	writeFakeCommand(t, "helm", `printf 'apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: my-app\n'`)
+
+	tests := []struct {
+		name    string
+		script  string
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			script: `echo '{"resources":[]}'`,
+		},
+		{
+			name: "schema violations",
+			script: `echo '{"resources":[
+  {"filename":"stdin","kind":"Deployment","name":"my-app","version":"apps/v1","status":"statusInvalid","msg":"For field spec: Required value"},
+  {"filename":"stdin","kind":"Widget","name":"default","version":"example.com/v1","status":"statusError","msg":"could not find schema for Widget"}
+]}'
+exit 1`,
+			wantErr: "2 resource(s) failed schema validation:\n  - Deployment/my-app (apps/v1): For field spec: Required value\n  - Widget/default (example.com/v1): could not find schema for Widget",
+		},
+		{
+			name:    "kubeconform crashes",
+			script:  `echo "panic" >&2; exit 2`,
+			wantErr: "failed to parse kubeconform output",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFakeCommand(t, "kubeconform", `cat > "$(dirname "$0")/stdin"
+echo "$@" > "$(dirname "$0")/args"
+`+tt.script)
+
+			err := NewHelmCLI("./chart").TemplateAndValidate(context.Background(), TemplateOptions{KubeVersion: "1.28.0"}, KubeconformConfig{Enabled: true})
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+
+			stdin, _ := os.ReadFile(filepath.Join(dir, "stdin"))
+			if !strings.Contains(string(stdin), "kind: Deployment") {
+				t.Errorf("expected rendered manifests on stdin, got %q", stdin)
+			}
+			args, _ := os.ReadFile(filepath.Join(dir, "args"))
+			if !strings.Contains(string(args), "-kubernetes-version 1.28.0") {
+				t.Errorf("expected kube_version to be passed through, got %q", args)
+			}
+		})
+	}
+}
+
+func TestHelmCLITemplateAndValidateTimeout(t *testing.T) {
+	writeFakeCommand(t, "helm", `printf 'apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: my-app\n'`)
+	writeFakeCommand(t, "kubeconform", `exec sleep 5`)
+
+	helm := NewHelmCLI("./chart")
+	helm.SetTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	err := helm.TemplateAndValidate(context.Background(), TemplateOptions{}, KubeconformConfig{Enabled: true})
+	if !errors.Is(err, ErrHelmTimeout) {
+		t.Fatalf("expected ErrHelmTimeout, got %v", err)
+	}
+	if want := "kubeconform timed out after 50ms"; err.Error() != want {
+		t.Errorf("expected '%s', got '%s'", want, err.Error())
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("kubeconform was not cancelled promptly (%s)", elapsed)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// licenseAnnotations are the Chart.yaml annotations a chart's license is read
+// from, in order of preference.
+var licenseAnnotations = []string{"artifacthub.io/license", "licenses"}
+
+// chartLicense returns the license declared in the chart's annotations, or "".
+func chartLicense(chart *Chart) string {
+	for _, key := range licenseAnnotations {
+		if license := strings.TrimSpace(chart.Annotations[key]); license != "" {
+			return license
+		}
+	}
+	return ""
+}
+
+// loadDependencyCharts reads the metadata of the dependencies vendored in the
+// chart's charts/ directory, either as packaged archives or unpacked directories.
+func loadDependencyCharts(chartPath string) ([]*Chart, error) {
+	entries, err := os.ReadDir(filepath.Join(chartPath, "charts"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read charts directory: %w", err)
+	}
+
+	var deps []*Chart
+	for _, entry := range entries {
+		path := filepath.Join(chartPath, "charts", entry.Name())
+		var dep *Chart
+		switch {
+		case entry.IsDir():
+			dep, err = ParseChart(path)
+		case strings.HasSuffix(entry.Name(), ".tgz"):
+			dep, err = readPackagedChart(path)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dependency %s: %w", entry.Name(), err)
+		}
+		deps = append(deps, dep)
+	}
+	return deps, nil
+}
+
+// CheckDependencyLicenses checks the license of every vendored dependency
+// against allowlist (compared case-insensitively). Dependencies without license
+// metadata, or declared in Chart.yaml but not vendored, are returned as
+// warnings; dependencies with a license that isn't allowed fail the check.
+func CheckDependencyLicenses(chartPath string, allowlist []string) ([]string, error) {
+	chart, err := ParseChart(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := loadDependencyCharts(chartPath)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[string]bool, len(allowlist))
+	for _, license := range allowlist {
+		allowed[strings.ToLower(strings.TrimSpace(license))] = true
+	}
+
+	var warnings, violations []string
+	vendored := make(map[string]bool, len(deps))
+	for _, dep := range deps {
+		vendored[dep.Name] = true
+		license := chartLicense(dep)
+		switch {
+		case license == "":
+			warnings = append(warnings, fmt.Sprintf("%s %s has no license metadata", dep.Name, dep.Version))
+		case !allowed[strings.ToLower(license)]:
+			violations = append(violations, fmt.Sprintf("%s %s is licensed %s", dep.Name, dep.Version, license))
+		}
+	}
+	for _, dep := range chart.Dependencies {
+		if !vendored[dep.Name] {
+			warnings = append(warnings, fmt.Sprintf("%s is not vendored in charts/, so its license was not checked", dep.Name))
+		}
+	}
+
+	sort.Strings(warnings)
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return warnings, fmt.Errorf("%d dependency license(s) not in license_allowlist:\n  - %s", len(violations), strings.Join(violations, "\n  - "))
+	}
+	return warnings, nil
+}
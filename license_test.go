@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeChartWithDependencies writes a chart declaring deps, vendoring each one
+// in charts/ as a packaged archive with the given license annotation.
+func writeChartWithDependencies(t *testing.T, licenses map[string]string) string {
+	t.Helper()
+
+	chartDir := filepath.Join(t.TempDir(), "my-app")
+	if err := os.MkdirAll(filepath.Join(chartDir, "charts"), 0755); err != nil {
+		t.Fatalf("failed to create chart: %v", err)
+	}
+
+	chartYAML := "apiVersion: v2\nname: my-app\nversion: 1.0.0\ndependencies:\n"
+	for name, license := range licenses {
+		chartYAML += "  - name: " + name + "\n    version: 1.0.0\n"
+		if license == "-" {
+			continue // declared but not vendored
+		}
+		depYAML := "apiVersion: v2\nname: " + name + "\nversion: 1.0.0\n"
+		if license != "" {
+			depYAML += "annotations:\n  artifacthub.io/license: " + license + "\n"
+		}
+		writeChartArchive(t, filepath.Join(chartDir, "charts", name+"-1.0.0.tgz"), map[string]string{name + "/Chart.yaml": depYAML})
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chartYAML), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+	return chartDir
+}
+
+func TestCheckDependencyLicenses(t *testing.T) {
+	allowlist := []string{"Apache-2.0", "MIT"}
+
+	tests := []struct {
+		name         string
+		licenses     map[string]string
+		wantErr      string
+		wantWarnings []string
+	}{
+		{
+			name:     "compliant",
+			licenses: map[string]string{"redis": "Apache-2.0", "nginx": "mit"},
+		},
+		{
+			name:     "non-compliant",
+			licenses: map[string]string{"redis": "Apache-2.0", "mongodb": "SSPL-1.0"},
+			wantErr:  "mongodb 1.0.0 is licensed SSPL-1.0",
+		},
+		{
+			name:         "missing license metadata",
+			licenses:     map[string]string{"redis": "Apache-2.0", "internal": ""},
+			wantWarnings: []string{"internal 1.0.0 has no license metadata"},
+		},
+		{
+			name:         "not vendored",
+			licenses:     map[string]string{"redis": "-"},
+			wantWarnings: []string{"redis is not vendored in charts/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chartDir := writeChartWithDependencies(t, tt.licenses)
+
+			warnings, err := CheckDependencyLicenses(chartDir, allowlist)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("expected warnings %v, got %v", tt.wantWarnings, warnings)
+			}
+			for i, want := range tt.wantWarnings {
+				if !strings.Contains(warnings[i], want) {
+					t.Errorf("expected warning containing %q, got %q", want, warnings[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDependencyLicensesUnpackedDependency(t *testing.T) {
+	chartDir := filepath.Join(t.TempDir(), "my-app")
+	depDir := filepath.Join(chartDir, "charts", "common")
+	if err := os.MkdirAll(depDir, 0755); err != nil {
+		t.Fatalf("failed to create chart: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(depDir, "Chart.yaml"), []byte("apiVersion: v2\nname: common\nversion: 2.0.0\nannotations:\n  licenses: GPL-3.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write dependency Chart.yaml: %v", err)
+	}
+
+	_, err := CheckDependencyLicenses(chartDir, []string{"Apache-2.0"})
+	if err == nil || !strings.Contains(err.Error(), "common 2.0.0 is licensed GPL-3.0") {
+		t.Errorf("expected license violation, got %v", err)
+	}
+}
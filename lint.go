@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Lint message severities reported by helm lint.
+const (
+	LintSeverityError   = "ERROR"
+	LintSeverityWarning = "WARNING"
+	LintSeverityInfo    = "INFO"
+)
+
+// ErrChartNotFound is returned by HelmCLI.Lint when helm can't find the chart
+// to lint, as opposed to the chart failing lint.
+var ErrChartNotFound = errors.New("chart not found")
+
+// chartNotFoundOutputs are substrings of helm lint output reporting that there
+// is no chart at the linted path.
+var chartNotFoundOutputs = []string{
+	"no chart directory",
+	"Chart.yaml file is missing",
+	"unable to check Chart.yaml file in chart",
+	"Chart.yaml: file does not exist",
+	"chart metadata (Chart.yaml) missing",
+}
+
+// classifyLintError classifies a failed helm lint from its combined output:
+// a missing chart wraps ErrChartNotFound together with the helm line reporting
+// it, anything else is returned unchanged as a lint failure.
+func classifyLintError(output string, err error) error {
+	if err == nil || errors.Is(err, ErrHelmTimeout) {
+		return err
+	}
+	for _, line := range strings.Split(output, "\n") {
+		for _, s := range chartNotFoundOutputs {
+			if strings.Contains(line, s) {
+				return fmt.Errorf("%w: %s", ErrChartNotFound, strings.TrimSpace(line))
+			}
+		}
+	}
+	return err
+}
+
+// lintLinePattern matches lines like "[WARNING] templates/deployment.yaml: message".
+var lintLinePattern = regexp.MustCompile(`^\[(ERROR|WARNING|INFO)\]\s*(.*)$`)
+
+// LintMessage is a single message reported by helm lint.
+type LintMessage struct {
+	Severity string
+	Path     string
+	Message  string
+	// Line is the full output line the message was parsed from.
+	Line string
+}
+
+// String formats the message as helm prints it.
+func (m LintMessage) String() string {
+	return m.Line
+}
+
+// parseLintOutput extracts the severity-prefixed messages from helm lint output.
+func parseLintOutput(output string) []LintMessage {
+	var messages []LintMessage
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		m := lintLinePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		msg := LintMessage{Severity: m[1], Message: m[2], Line: line}
+		if path, text, ok := strings.Cut(m[2], ": "); ok && !strings.Contains(path, " ") {
+			msg.Path = path
+			msg.Message = text
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// countLintMessages returns the number of errors and warnings.
+func countLintMessages(messages []LintMessage) (errors, warnings int) {
+	for _, m := range messages {
+		switch m.Severity {
+		case LintSeverityError:
+			errors++
+		case LintSeverityWarning:
+			warnings++
+		}
+	}
+	return errors, warnings
+}
+
+// lintSummary formats the error and warning counts.
+func lintSummary(messages []LintMessage) string {
+	errors, warnings := countLintMessages(messages)
+	return fmt.Sprintf("%d error(s), %d warning(s)", errors, warnings)
+}
+
+// compileLintIgnore compiles lint ignore patterns.
+func compileLintIgnore(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lint_ignore pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// filterLintMessages splits messages into those kept and those ignored. Patterns
+// are matched against the full message line, e.g. "[INFO] Chart.yaml: icon is recommended".
+func filterLintMessages(messages []LintMessage, ignore []*regexp.Regexp) (kept, ignored []LintMessage) {
+	for _, m := range messages {
+		matched := false
+		for _, re := range ignore {
+			if re.MatchString(m.Line) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			ignored = append(ignored, m)
+		} else {
+			kept = append(kept, m)
+		}
+	}
+	return kept, ignored
+}
+
+// lintFailed decides whether linting failed once ignored messages have been
+// filtered out. Remaining errors always fail; remaining warnings fail in strict
+// mode. If helm failed without reporting any message (e.g. the chart couldn't be
+// loaded) the failure stands.
+func lintFailed(kept, ignored []LintMessage, strict bool, lintErr error) bool {
+	if lintErr != nil && len(kept) == 0 && len(ignored) == 0 {
+		return true
+	}
+	errs, warnings := countLintMessages(kept)
+	return errs > 0 || (strict && warnings > 0)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+const sampleLintOutput = `==> Linting ./charts/my-app
+[INFO] Chart.yaml: icon is recommended
+[WARNING] templates/deployment.yaml: object name does not conform to Kubernetes naming requirements
+[ERROR] templates/service.yaml: unable to parse YAML: error converting YAML to JSON
+[WARNING] /charts/my-app: chart directory is missing these dependencies: redis
+
+Error: 1 chart(s) linted, 1 chart(s) failed
+`
+
+func TestParseLintOutput(t *testing.T) {
+	messages := parseLintOutput(sampleLintOutput)
+
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(messages))
+	}
+
+	want := []LintMessage{
+		{Severity: "INFO", Path: "Chart.yaml", Message: "icon is recommended"},
+		{Severity: "WARNING", Path: "templates/deployment.yaml", Message: "object name does not conform to Kubernetes naming requirements"},
+		{Severity: "ERROR", Path: "templates/service.yaml", Message: "unable to parse YAML: error converting YAML to JSON"},
+		{Severity: "WARNING", Path: "/charts/my-app", Message: "chart directory is missing these dependencies: redis"},
+	}
+	for i, w := range want {
+		got := messages[i]
+		if got.Severity != w.Severity || got.Path != w.Path || got.Message != w.Message {
+			t.Errorf("message %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+
+	if messages[0].Line != "[INFO] Chart.yaml: icon is recommended" {
+		t.Errorf("unexpected raw line: %s", messages[0].Line)
+	}
+}
+
+func TestParseLintOutputWithoutPath(t *testing.T) {
+	messages := parseLintOutput("[ERROR] Chart.yaml file is missing\n")
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if messages[0].Path != "" || messages[0].Message != "Chart.yaml file is missing" {
+		t.Errorf("unexpected message: %+v", messages[0])
+	}
+}
+
+func TestCountLintMessages(t *testing.T) {
+	errors, warnings := countLintMessages(parseLintOutput(sampleLintOutput))
+	if errors != 1 || warnings != 2 {
+		t.Errorf("expected 1 error and 2 warnings, got %d and %d", errors, warnings)
+	}
+
+	if got := lintSummary(parseLintOutput(sampleLintOutput)); got != "1 error(s), 2 warning(s)" {
+		t.Errorf("unexpected summary: %s", got)
+	}
+}
+
+func TestHelmLintReturnsMessages(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "[WARNING] templates/: a warning"
+echo "[ERROR] templates/x.yaml: broken"
+exit 1
+`)
+
+	messages, err := NewHelmCLI("./chart").Lint(context.Background(), false)
+	if err == nil {
+		t.Error("expected error from failing helm lint")
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	if messages[1].Severity != LintSeverityError {
+		t.Errorf("expected second message to be an error, got %s", messages[1].Severity)
+	}
+}
+
+func TestFilterLintMessages(t *testing.T) {
+	ignore, err := compileLintIgnore([]string{`naming requirements$`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	messages := parseLintOutput(sampleLintOutput)
+	kept, ignored := filterLintMessages(messages, ignore)
+	if len(ignored) != 1 || ignored[0].Path != "templates/deployment.yaml" {
+		t.Fatalf("expected only the deployment warning to be ignored, got %+v", ignored)
+	}
+	if _, warnings := countLintMessages(kept); warnings != 1 {
+		t.Errorf("expected 1 remaining warning, got %d", warnings)
+	}
+}
+
+func TestLintFailed(t *testing.T) {
+	warning := LintMessage{Severity: LintSeverityWarning, Line: "[WARNING] Chart.yaml: icon is recommended"}
+	lintErr := fmt.Errorf("helm lint failed")
+
+	tests := []struct {
+		name    string
+		kept    []LintMessage
+		ignored []LintMessage
+		strict  bool
+		err     error
+		want    bool
+	}{
+		{name: "strict warnings fail", kept: []LintMessage{warning}, strict: true, err: lintErr, want: true},
+		{name: "ignored warnings pass", ignored: []LintMessage{warning}, strict: true, err: lintErr, want: false},
+		{name: "non-strict warnings pass", kept: []LintMessage{warning}, want: false},
+		{name: "failure without messages", err: lintErr, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lintFailed(tt.kept, tt.ignored, tt.strict, tt.err); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCompileLintIgnoreInvalid(t *testing.T) {
+	if _, err := compileLintIgnore([]string{"("}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestClassifyLintError(t *testing.T) {
+	failure := errors.New("helm lint failed: exit status 1")
+	tests := []struct {
+		name         string
+		output       string
+		err          error
+		wantNotFound string // expected ErrChartNotFound detail, or "" for a lint failure
+	}{
+		{
+			name:   "lint failures",
+			output: "==> Linting ./chart\n[ERROR] templates/x.yaml: broken\n\nError: 1 chart(s) linted, 1 chart(s) failed\n",
+			err:    failure,
+		},
+		{
+			name:         "missing directory",
+			output:       "==> Linting ./nope\nError unable to check Chart.yaml file in chart: stat nope/Chart.yaml: no such file or directory\n\nError: 1 chart(s) linted, 1 chart(s) failed\n",
+			err:          failure,
+			wantNotFound: "Error unable to check Chart.yaml file in chart: stat nope/Chart.yaml: no such file or directory",
+		},
+		{
+			name:         "no chart directory",
+			output:       "Error: no chart directory found in ./nope\n",
+			err:          failure,
+			wantNotFound: "Error: no chart directory found in ./nope",
+		},
+		{
+			name:         "Chart.yaml missing",
+			output:       "==> Linting ./chart\n[ERROR] Chart.yaml: Chart.yaml file is missing\n",
+			err:          failure,
+			wantNotFound: "[ERROR] Chart.yaml: Chart.yaml file is missing",
+		},
+		{
+			name:   "timeout",
+			output: "Error: no chart directory found in ./nope\n",
+			err:    fmt.Errorf("helm lint %w after 5m0s", ErrHelmTimeout),
+		},
+		{
+			name:   "success",
+			output: "==> Linting ./chart\n1 chart(s) linted, 0 chart(s) failed\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyLintError(tt.output, tt.err)
+			if tt.wantNotFound == "" {
+				if err != tt.err {
+					t.Errorf("expected %v unchanged, got %v", tt.err, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrChartNotFound) || err.Error() != "chart not found: "+tt.wantNotFound {
+				t.Errorf("expected ErrChartNotFound with %q, got %v", tt.wantNotFound, err)
+			}
+		})
+	}
+}
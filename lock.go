@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChartLock represents Chart.lock contents.
+type ChartLock struct {
+	Dependencies []ChartDependency `yaml:"dependencies"`
+	Digest       string            `yaml:"digest"`
+	Generated    time.Time         `yaml:"generated"`
+}
+
+// ErrLockMissing is returned by VerifyLock when a chart with dependencies has no Chart.lock.
+var ErrLockMissing = errors.New("Chart.lock not found, run helm dependency update to generate it")
+
+// ParseChartLock parses a Chart.lock file.
+func ParseChartLock(chartPath string) (*ChartLock, error) {
+	data, err := os.ReadFile(filepath.Join(chartPath, "Chart.lock"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrLockMissing
+		}
+		return nil, fmt.Errorf("failed to read Chart.lock: %w", err)
+	}
+
+	var lock ChartLock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse Chart.lock: %w", err)
+	}
+	return &lock, nil
+}
+
+// VerifyLock checks that Chart.lock is in sync with the dependencies declared in
+// Chart.yaml. Versions are compared when Chart.yaml pins an exact version; ranges
+// such as "~17.0" are resolved by helm and only checked for presence.
+func VerifyLock(chartPath string) error {
+	chart, err := ParseChart(chartPath)
+	if err != nil {
+		return err
+	}
+	if !chart.HasDependencies() {
+		return nil
+	}
+
+	lock, err := ParseChartLock(chartPath)
+	if err != nil {
+		return err
+	}
+
+	// A chart may use the same subchart more than once under different
+	// aliases, so Chart.lock entries are matched as a multiset: each Chart.yaml
+	// dependency takes an unused entry of the same name, preferring one that
+	// matches it.
+	locked := make(map[string][]ChartDependency, len(lock.Dependencies))
+	for _, dep := range lock.Dependencies {
+		locked[dep.Name] = append(locked[dep.Name], dep)
+	}
+
+	var mismatches []string
+	for _, dep := range chart.Dependencies {
+		label := dep.Name
+		if dep.Alias != "" {
+			label = fmt.Sprintf("%s (alias %s)", dep.Name, dep.Alias)
+		}
+		entries := locked[dep.Name]
+		if len(entries) == 0 {
+			mismatches = append(mismatches, fmt.Sprintf("%s: missing from Chart.lock", label))
+			continue
+		}
+		i := slices.IndexFunc(entries, func(l ChartDependency) bool { return len(lockMismatches(label, dep, l)) == 0 })
+		if i < 0 {
+			i = 0
+		}
+		mismatches = append(mismatches, lockMismatches(label, dep, entries[i])...)
+		locked[dep.Name] = slices.Delete(entries, i, i+1)
+	}
+	for _, dep := range lock.Dependencies {
+		if entries := locked[dep.Name]; len(entries) > 0 {
+			mismatches = append(mismatches, fmt.Sprintf("%s: in Chart.lock but not in Chart.yaml", dep.Name))
+			locked[dep.Name] = entries[1:]
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("Chart.lock is out of sync with Chart.yaml:\n  - %s", strings.Join(mismatches, "\n  - "))
+	}
+	return nil
+}
+
+// lockMismatches describes how the Chart.lock entry l differs from the
+// Chart.yaml dependency dep.
+func lockMismatches(label string, dep, l ChartDependency) []string {
+	var mismatches []string
+	if _, err := ParseSemVer(dep.Version); err == nil && dep.Version != l.Version {
+		mismatches = append(mismatches, fmt.Sprintf("%s: version %s in Chart.yaml, %s in Chart.lock", label, dep.Version, l.Version))
+	}
+	if strings.TrimSuffix(dep.Repository, "/") != strings.TrimSuffix(l.Repository, "/") {
+		mismatches = append(mismatches, fmt.Sprintf("%s: repository %s in Chart.yaml, %s in Chart.lock", label, dep.Repository, l.Repository))
+	}
+	return mismatches
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyLock(t *testing.T) {
+	chartYAML := `apiVersion: v2
+name: my-app
+version: 1.0.0
+dependencies:
+  - name: redis
+    version: 17.0.0
+    repository: https://charts.bitnami.com/bitnami
+  - name: postgresql
+    version: "~12.1"
+    repository: https://charts.bitnami.com/bitnami
+`
+
+	tests := []struct {
+		name    string
+		lock    string
+		wantErr string
+	}{
+		{
+			name: "in sync",
+			lock: `dependencies:
+- name: redis
+  repository: https://charts.bitnami.com/bitnami
+  version: 17.0.0
+- name: postgresql
+  repository: https://charts.bitnami.com/bitnami
+  version: 12.1.5
+digest: sha256:abc
+generated: "2024-01-01T00:00:00Z"
+`,
+		},
+		{
+			name: "version mismatch",
+			lock: `dependencies:
+- name: redis
+  repository: https://charts.bitnami.com/bitnami
+  version: 16.0.0
+- name: postgresql
+  repository: https://charts.bitnami.com/bitnami
+  version: 12.1.5
+`,
+			wantErr: "redis: version 17.0.0 in Chart.yaml, 16.0.0 in Chart.lock",
+		},
+		{
+			name: "repository mismatch",
+			lock: `dependencies:
+- name: redis
+  repository: https://charts.bitnami.com/bitnami
+  version: 17.0.0
+- name: postgresql
+  repository: oci://registry-1.docker.io/bitnamicharts
+  version: 12.1.5
+`,
+			wantErr: "postgresql: repository",
+		},
+		{
+			name: "missing dependency",
+			lock: `dependencies:
+- name: redis
+  repository: https://charts.bitnami.com/bitnami
+  version: 17.0.0
+`,
+			wantErr: "postgresql: missing from Chart.lock",
+		},
+		{
+			name:    "no lock",
+			wantErr: ErrLockMissing.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(chartYAML), 0644); err != nil {
+				t.Fatalf("failed to write Chart.yaml: %v", err)
+			}
+			if tt.lock != "" {
+				if err := os.WriteFile(filepath.Join(dir, "Chart.lock"), []byte(tt.lock), 0644); err != nil {
+					t.Fatalf("failed to write Chart.lock: %v", err)
+				}
+			}
+
+			err := VerifyLock(dir)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVerifyLockWithoutDependencies(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	if err := VerifyLock(dir); err != nil {
+		t.Errorf("expected no error for chart without dependencies, got %v", err)
+	}
+}
+
+func TestVerifyLockAliases(t *testing.T) {
+	chartYAML := `apiVersion: v2
+name: my-app
+version: 1.0.0
+dependencies:
+  - name: redis
+    alias: cache
+    version: 17.0.0
+    repository: https://charts.bitnami.com/bitnami
+  - name: redis
+    alias: queue
+    version: 18.0.0
+    repository: https://charts.bitnami.com/bitnami
+`
+
+	tests := []struct {
+		name    string
+		lock    string
+		wantErr string
+	}{
+		{
+			name: "in sync",
+			lock: `dependencies:
+- name: redis
+  repository: https://charts.bitnami.com/bitnami
+  version: 18.0.0
+- name: redis
+  repository: https://charts.bitnami.com/bitnami
+  version: 17.0.0
+`,
+		},
+		{
+			name: "one entry",
+			lock: `dependencies:
+- name: redis
+  repository: https://charts.bitnami.com/bitnami
+  version: 17.0.0
+`,
+			wantErr: "redis (alias queue): missing from Chart.lock",
+		},
+		{
+			name: "version mismatch",
+			lock: `dependencies:
+- name: redis
+  repository: https://charts.bitnami.com/bitnami
+  version: 17.0.0
+- name: redis
+  repository: https://charts.bitnami.com/bitnami
+  version: 17.0.0
+`,
+			wantErr: "redis (alias queue): version 18.0.0 in Chart.yaml, 17.0.0 in Chart.lock",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(chartYAML), 0644); err != nil {
+				t.Fatalf("failed to write Chart.yaml: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "Chart.lock"), []byte(tt.lock), 0644); err != nil {
+				t.Fatalf("failed to write Chart.lock: %v", err)
+			}
+
+			err := VerifyLock(dir)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
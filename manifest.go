@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// releaseNamespaceSentinel is the namespace charts are rendered into when
+// validating manifests, so resources using {{ .Release.Namespace }} can be told
+// apart from ones with a hardcoded namespace.
+const releaseNamespaceSentinel = "relicta-release-namespace"
+
+var documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
+// Manifest is a single rendered Kubernetes resource.
+type Manifest struct {
+	Source     string
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+	Object     map[string]any
+}
+
+// String identifies the manifest for reporting.
+func (m Manifest) String() string {
+	id := fmt.Sprintf("%s/%s", m.Kind, m.Name)
+	if m.Source != "" {
+		id += fmt.Sprintf(" (%s)", m.Source)
+	}
+	return id
+}
+
+// ParseManifests parses multi-document rendered helm output. Empty documents are skipped.
+func ParseManifests(data []byte) ([]Manifest, error) {
+	var manifests []Manifest
+	for _, doc := range documentSeparator.Split(string(data), -1) {
+		source := ""
+		for _, line := range strings.Split(doc, "\n") {
+			if strings.HasPrefix(line, "# Source: ") {
+				source = strings.TrimSpace(strings.TrimPrefix(line, "# Source: "))
+				break
+			}
+		}
+
+		var obj map[string]any
+		if err := yaml.NewDecoder(bytes.NewReader([]byte(doc))).Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to parse rendered manifest %s: %w", source, err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+
+		m := Manifest{Source: source, Object: obj}
+		m.APIVersion, _ = obj["apiVersion"].(string)
+		m.Kind, _ = obj["kind"].(string)
+		if metadata, ok := obj["metadata"].(map[string]any); ok {
+			m.Name, _ = metadata["name"].(string)
+			m.Namespace, _ = metadata["namespace"].(string)
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+// findHardcodedNamespaces reports resources whose metadata.namespace isn't the
+// release namespace they were rendered into.
+func findHardcodedNamespaces(manifests []Manifest, releaseNamespace string) []string {
+	var offenders []string
+	for _, m := range manifests {
+		if m.Namespace != "" && m.Namespace != releaseNamespace {
+			offenders = append(offenders, fmt.Sprintf("%s has hardcoded namespace %q", m, m.Namespace))
+		}
+	}
+	return offenders
+}
+
+// findInlineSecrets reports Secret resources that carry their values inline in
+// data or stringData, rather than referencing a pre-existing Secret.
+// Service account token secrets are populated by Kubernetes and are skipped.
+func findInlineSecrets(manifests []Manifest) []string {
+	var offenders []string
+	for _, m := range manifests {
+		if m.Kind != "Secret" {
+			continue
+		}
+		if t, _ := m.Object["type"].(string); t == "kubernetes.io/service-account-token" {
+			continue
+		}
+		for _, field := range []string{"data", "stringData"} {
+			if values, ok := m.Object[field].(map[string]any); ok && len(values) > 0 {
+				offenders = append(offenders, fmt.Sprintf("%s defines inline %s; consider referencing an existingSecret instead", m, field))
+				break
+			}
+		}
+	}
+	return offenders
+}
+
+// ImagePolicyConfig defines checks on the container images rendered templates
+// reference.
+type ImagePolicyConfig struct {
+	// RequirePinnedTags fails on images without a tag or tagged latest, which
+	// make releases irreproducible.
+	RequirePinnedTags bool `json:"require_pinned_tags"`
+}
+
+// parseImagePolicyConfig parses the image_policy block.
+func parseImagePolicyConfig(raw any) ImagePolicyConfig {
+	var cfg ImagePolicyConfig
+	policyRaw, ok := raw.(map[string]any)
+	if !ok {
+		return cfg
+	}
+	if pinned, ok := policyRaw["require_pinned_tags"].(bool); ok {
+		cfg.RequirePinnedTags = pinned
+	}
+	return cfg
+}
+
+// imageTag returns the tag of an image reference, or "" if it has none. The
+// port of a registry host, as in localhost:5000/app, is not a tag.
+func imageTag(image string) string {
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return ""
+}
+
+// findUnpinnedImages reports container images without a tag or tagged latest.
+// Images pinned by digest are accepted whatever their tag.
+func findUnpinnedImages(manifests []Manifest) []string {
+	var offenders []string
+	for _, m := range manifests {
+		images := make(map[string]bool)
+		collectContainerImages(m.Object, images)
+		for _, image := range slices.Sorted(maps.Keys(images)) {
+			if strings.Contains(image, "@") {
+				continue
+			}
+			switch imageTag(image) {
+			case "":
+				offenders = append(offenders, fmt.Sprintf("%s uses image %q without a tag", m, image))
+			case "latest":
+				offenders = append(offenders, fmt.Sprintf("%s uses image %q with the latest tag", m, image))
+			}
+		}
+	}
+	return offenders
+}
+
+// ErrEmptyTemplate is returned by validateManifests when fail_on_empty_template
+// is set and the chart renders no Kubernetes documents.
+var ErrEmptyTemplate = errors.New("helm template rendered no Kubernetes documents")
+
+// validateManifests runs the configured checks against rendered manifests.
+// It returns warnings for soft findings and an error listing hard failures.
+func validateManifests(cfg *Config, manifests []Manifest) ([]string, error) {
+	if cfg.FailOnEmptyTemplate && len(manifests) == 0 {
+		return nil, ErrEmptyTemplate
+	}
+
+	var warnings, failures []string
+	if cfg.ForbidHardcodedNamespace {
+		failures = append(failures, findHardcodedNamespaces(manifests, cfg.manifestNamespace())...)
+	}
+	if cfg.DiscourageInlineSecrets {
+		warnings = append(warnings, findInlineSecrets(manifests)...)
+	}
+	if cfg.ImagePolicy.RequirePinnedTags {
+		failures = append(failures, findUnpinnedImages(manifests)...)
+	}
+
+	if len(failures) > 0 {
+		return warnings, fmt.Errorf("%d manifest check(s) failed:\n  - %s", len(failures), strings.Join(failures, "\n  - "))
+	}
+	return warnings, nil
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+const compliantManifests = `---
+# Source: my-app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: my-app
+  namespace: relicta-release-namespace
+spec:
+  ports:
+    - port: 80
+---
+# Source: my-app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+`
+
+const hardcodedNamespaceManifests = `---
+# Source: my-app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: my-app
+  namespace: relicta-release-namespace
+---
+# Source: my-app/templates/monitor.yaml
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: my-app
+  namespace: monitoring
+`
+
+func TestParseManifests(t *testing.T) {
+	manifests, err := ParseManifests([]byte(compliantManifests + "---\n# Source: my-app/templates/empty.yaml\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(manifests) != 2 {
+		t.Fatalf("expected 2 manifests, got %d", len(manifests))
+	}
+
+	svc := manifests[0]
+	if svc.Kind != "Service" || svc.Name != "my-app" || svc.APIVersion != "v1" {
+		t.Errorf("unexpected service manifest: %+v", svc)
+	}
+	if svc.Source != "my-app/templates/service.yaml" {
+		t.Errorf("expected source 'my-app/templates/service.yaml', got '%s'", svc.Source)
+	}
+	if svc.Namespace != releaseNamespaceSentinel {
+		t.Errorf("expected namespace '%s', got '%s'", releaseNamespaceSentinel, svc.Namespace)
+	}
+}
+
+func TestParseManifestsInvalid(t *testing.T) {
+	if _, err := ParseManifests([]byte("kind: [broken")); err == nil {
+		t.Error("expected error for invalid YAML")
+	}
+}
+
+// mustParseManifests parses rendered output, failing the test on error.
+func mustParseManifests(t *testing.T, rendered string) []Manifest {
+	t.Helper()
+
+	manifests, err := ParseManifests([]byte(rendered))
+	if err != nil {
+		t.Fatalf("failed to parse manifests: %v", err)
+	}
+	return manifests
+}
+
+func TestFindHardcodedNamespaces(t *testing.T) {
+	tests := []struct {
+		name      string
+		manifests string
+		want      int
+	}{
+		{name: "compliant", manifests: compliantManifests, want: 0},
+		{name: "hardcoded", manifests: hardcodedNamespaceManifests, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests, err := ParseManifests([]byte(tt.manifests))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			offenders := findHardcodedNamespaces(manifests, releaseNamespaceSentinel)
+			if len(offenders) != tt.want {
+				t.Fatalf("expected %d offenders, got %v", tt.want, offenders)
+			}
+			if tt.want > 0 && !strings.Contains(offenders[0], "ServiceMonitor/my-app") {
+				t.Errorf("expected offender to name the resource, got '%s'", offenders[0])
+			}
+		})
+	}
+}
+
+func TestValidateManifestsForbidHardcodedNamespace(t *testing.T) {
+	cfg := &Config{ForbidHardcodedNamespace: true}
+
+	if _, err := validateManifests(cfg, mustParseManifests(t, compliantManifests)); err != nil {
+		t.Errorf("unexpected error for compliant manifests: %v", err)
+	}
+
+	_, err := validateManifests(cfg, mustParseManifests(t, hardcodedNamespaceManifests))
+	if err == nil {
+		t.Fatal("expected error for hardcoded namespace")
+	}
+	if !strings.Contains(err.Error(), `hardcoded namespace "monitoring"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.ForbidHardcodedNamespace = false
+	if _, err := validateManifests(cfg, mustParseManifests(t, hardcodedNamespaceManifests)); err != nil {
+		t.Errorf("expected check to be disabled, got: %v", err)
+	}
+}
+
+func TestExecutePrePublishTemplateNamespace(t *testing.T) {
+	// Render the Service into the namespace passed to helm, like
+	// {{ .Release.Namespace }} would
+	dir := writeFakeCommand(t, "helm", `echo "$@" >> "$(dirname "$0")/args"
+namespace=default
+while [ $# -gt 0 ]; do
+	[ "$1" = "--namespace" ] && namespace=$2
+	shift
+done
+printf 'apiVersion: v1\nkind: Service\nmetadata:\n  name: my-app\n  namespace: %s\n' "$namespace"
+`)
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		want      string
+	}{
+		{name: "sentinel", want: "--namespace " + releaseNamespaceSentinel},
+		{name: "configured", namespace: "team-a", want: "--namespace team-a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Remove(filepath.Join(dir, "args"))
+			p := &HelmPlugin{}
+			cfg := p.parseConfig(map[string]any{
+				"chart_path":                 chartDir,
+				"lint":                       false,
+				"template_namespace":         tt.namespace,
+				"forbid_hardcoded_namespace": true,
+				"version":                    map[string]any{"update_chart": false},
+				"dependencies":               map[string]any{"update": false, "build": false},
+			})
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !resp.Success {
+				t.Fatalf("expected success, got: %s", resp.Message)
+			}
+			args, _ := os.ReadFile(filepath.Join(dir, "args"))
+			if !strings.Contains(string(args), "template release-name "+chartDir+" "+tt.want) {
+				t.Errorf("expected templates rendered with %s, got:\n%s", tt.want, args)
+			}
+		})
+	}
+}
+
+const inlineSecretManifests = `---
+# Source: my-app/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: my-app-db
+stringData:
+  password: hunter2
+---
+# Source: my-app/templates/token.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: my-app-token
+type: kubernetes.io/service-account-token
+data:
+  token: ZmFrZQ==
+`
+
+const existingSecretManifests = `---
+# Source: my-app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          env:
+            - name: DB_PASSWORD
+              valueFrom:
+                secretKeyRef:
+                  name: my-existing-secret
+                  key: password
+`
+
+func TestValidateManifestsDiscourageInlineSecrets(t *testing.T) {
+	cfg := &Config{DiscourageInlineSecrets: true}
+
+	warnings, err := validateManifests(cfg, mustParseManifests(t, inlineSecretManifests))
+	if err != nil {
+		t.Fatalf("inline secrets should only warn, got error: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Secret/my-app-db") || !strings.Contains(warnings[0], "stringData") {
+		t.Errorf("expected one warning for Secret/my-app-db, got %v", warnings)
+	}
+
+	warnings, err = validateManifests(cfg, mustParseManifests(t, existingSecretManifests))
+	if err != nil || len(warnings) != 0 {
+		t.Errorf("expected no warnings for existingSecret reference, got %v (err %v)", warnings, err)
+	}
+
+	cfg.DiscourageInlineSecrets = false
+	if warnings, _ := validateManifests(cfg, mustParseManifests(t, inlineSecretManifests)); len(warnings) != 0 {
+		t.Errorf("expected check to be disabled, got %v", warnings)
+	}
+}
+
+func TestValidateManifestsFailOnEmptyTemplate(t *testing.T) {
+	// Templates gated off by default values still emit their source comments
+	const emptyRender = "---\n# Source: my-app/templates/ingress.yaml\n---\n# Source: my-app/templates/hpa.yaml\n"
+
+	cfg := &Config{FailOnEmptyTemplate: true}
+	if _, err := validateManifests(cfg, mustParseManifests(t, emptyRender)); !errors.Is(err, ErrEmptyTemplate) {
+		t.Errorf("expected ErrEmptyTemplate, got %v", err)
+	}
+	if _, err := validateManifests(cfg, mustParseManifests(t, "")); !errors.Is(err, ErrEmptyTemplate) {
+		t.Errorf("expected ErrEmptyTemplate for no output, got %v", err)
+	}
+	if _, err := validateManifests(cfg, mustParseManifests(t, compliantManifests)); err != nil {
+		t.Errorf("unexpected error for rendered resources: %v", err)
+	}
+
+	cfg.FailOnEmptyTemplate = false
+	if _, err := validateManifests(cfg, mustParseManifests(t, emptyRender)); err != nil {
+		t.Errorf("expected check to be disabled, got: %v", err)
+	}
+}
+
+const unpinnedImageManifests = `---
+# Source: my-app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: ghcr.io/myorg/migrate:1.4.0
+      containers:
+        - name: app
+          image: ghcr.io/myorg/app:latest
+        - name: proxy
+          image: localhost:5000/proxy
+        - name: agent
+          image: ghcr.io/myorg/agent:latest@sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945
+---
+# Source: my-app/templates/cronjob.yaml
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: my-app-cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: cleanup
+              image: busybox
+`
+
+func TestValidateManifestsRequirePinnedTags(t *testing.T) {
+	cfg := &Config{ImagePolicy: ImagePolicyConfig{RequirePinnedTags: true}}
+
+	_, err := validateManifests(cfg, mustParseManifests(t, unpinnedImageManifests))
+	if err == nil {
+		t.Fatal("expected unpinned images to fail")
+	}
+	for _, want := range []string{
+		`Deployment/my-app (my-app/templates/deployment.yaml) uses image "ghcr.io/myorg/app:latest" with the latest tag`,
+		`Deployment/my-app (my-app/templates/deployment.yaml) uses image "localhost:5000/proxy" without a tag`,
+		`CronJob/my-app-cleanup (my-app/templates/cronjob.yaml) uses image "busybox" without a tag`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got: %v", want, err)
+		}
+	}
+	if !strings.HasPrefix(err.Error(), "3 manifest check(s) failed") || strings.Contains(err.Error(), "migrate") || strings.Contains(err.Error(), "agent") {
+		t.Errorf("expected only the unpinned images reported, got: %v", err)
+	}
+
+	cfg.ImagePolicy.RequirePinnedTags = false
+	if _, err := validateManifests(cfg, mustParseManifests(t, unpinnedImageManifests)); err != nil {
+		t.Errorf("expected check to be disabled, got: %v", err)
+	}
+}
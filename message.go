@@ -0,0 +1,54 @@
+package main
+
+import "github.com/relicta-tech/relicta-plugin-sdk/plugin"
+
+// messageData is the data message_template is rendered with.
+type messageData struct {
+	Hook          string // pre-publish or post-publish
+	Success       bool
+	Message       string // the default message
+	DryRun        bool
+	ChartName     string
+	Version       string
+	AppVersion    string
+	PackagePath   string
+	RepositoryURL string // comma-separated with several repositories
+	Digest        string // SHA256 digest of the package
+	OCIDigest     string // manifest digest reported by helm push
+	Pushed        bool
+	Charts        []messageData // per-chart data with chart_paths
+}
+
+// newMessageData builds the template data from a hook response and its
+// outputs.
+func newMessageData(hook plugin.Hook, resp *plugin.ExecuteResponse, dryRun bool) messageData {
+	data := messageDataFromReport(newOutputReport(resp), hook, dryRun)
+	data.OCIDigest, _ = resp.Outputs["oci_digest"].(string)
+	return data
+}
+
+// messageDataFromReport copies the fields of a report into template data.
+func messageDataFromReport(report outputReport, hook plugin.Hook, dryRun bool) messageData {
+	data := messageData{
+		Hook:          string(hook),
+		Success:       report.Success,
+		Message:       report.Message,
+		DryRun:        dryRun,
+		ChartName:     report.Chart,
+		Version:       report.Version,
+		AppVersion:    report.AppVersion,
+		PackagePath:   report.PackagePath,
+		RepositoryURL: report.Repository,
+		Digest:        report.Digest,
+		Pushed:        report.Pushed,
+	}
+	for _, chart := range report.Charts {
+		data.Charts = append(data.Charts, messageDataFromReport(chart, hook, dryRun))
+	}
+	return data
+}
+
+// renderMessage renders the message_template.
+func renderMessage(format string, data messageData) (string, error) {
+	return renderTemplate("message_template", format, data)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestRenderMessage(t *testing.T) {
+	data := messageData{
+		Hook:          "post-publish",
+		Success:       true,
+		Message:       "Published my-app-1.2.0.tgz",
+		DryRun:        true,
+		ChartName:     "my-app",
+		Version:       "1.2.0",
+		RepositoryURL: "oci://ghcr.io/myorg/charts",
+		Digest:        "sha256:abc",
+		Charts:        []messageData{{ChartName: "my-app"}, {ChartName: "my-lib"}},
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{name: "fields", format: "{{.ChartName}}@{{.Version}} -> {{.RepositoryURL}} ({{.Digest}})", want: "my-app@1.2.0 -> oci://ghcr.io/myorg/charts (sha256:abc)"},
+		{name: "conditionals", format: "{{if .DryRun}}[dry-run] {{end}}{{if .Success}}ok{{else}}failed{{end}}: {{.Message}}", want: "[dry-run] ok: Published my-app-1.2.0.tgz"},
+		{name: "charts", format: "{{range .Charts}}{{.ChartName}} {{end}}", want: "my-app my-lib "},
+		{name: "unknown field", format: "{{.Chart}}", wantErr: true},
+		{name: "syntax error", format: "{{.ChartName", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderMessage(tt.format, data)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid message_template") {
+					t.Errorf("expected an invalid message_template error, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateMessageTemplate(t *testing.T) {
+	p := &HelmPlugin{}
+	for format, wantErr := range map[string]bool{
+		"{{.ChartName}} {{.Version}}":                false,
+		"{{range .Charts}}{{.ChartName}}{{end}}":     false,
+		"{{.ChartName":                               true,
+		"{{.RepositoryURL}} {{.NotAField}}":          true,
+		"{{range .Charts}}{{.RepositoryUrl}}{{end}}": true,
+	} {
+		resp, err := p.Validate(context.Background(), map[string]any{"message_template": format})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		gotErr := false
+		for _, e := range resp.Errors {
+			if e.Field == "message_template" {
+				gotErr = true
+			}
+		}
+		if gotErr != wantErr {
+			t.Errorf("%q: expected message_template error=%v, got %+v", format, wantErr, resp.Errors)
+		}
+	}
+}
+
+func TestExecuteMessageTemplate(t *testing.T) {
+	chartDir := filepath.Join(t.TempDir(), "my-app")
+	if err := os.MkdirAll(chartDir, 0755); err != nil {
+		t.Fatalf("failed to create chart: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	writeTestPackage(t, packagePath)
+	digest, _ := fileDigest(packagePath)
+	writeFakeCommand(t, "helm", `case "$1" in
+package) echo "Successfully packaged chart and saved it to: `+packagePath+`" ;;
+push) printf 'Pushed: ghcr.io/myorg/charts/my-app:1.0.0\nDigest: sha256:deadbeef\n' ;;
+esac
+`)
+
+	p := &HelmPlugin{}
+	execute := func(hook plugin.Hook, dryRun bool, format string) *plugin.ExecuteResponse {
+		t.Helper()
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook: hook,
+			Config: map[string]any{
+				"chart_path":        chartDir,
+				"output_dir":        t.TempDir(),
+				"message_template":  format,
+				"lint":              false,
+				"template_validate": false,
+				"version":           map[string]any{"update_chart": false},
+				"dependencies":      map[string]any{"update": false, "build": false},
+				"repository":        map[string]any{"url": "oci://ghcr.io/myorg/charts"},
+			},
+			Context: plugin.ReleaseContext{Version: "1.0.0"},
+			DryRun:  dryRun,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.Success {
+			t.Fatalf("expected success, got: %s", resp.Message)
+		}
+		return resp
+	}
+
+	resp := execute(plugin.HookPostPublish, false, "{{.ChartName}} {{.Version}} published to {{.RepositoryURL}} ({{.Digest}}, {{.OCIDigest}})")
+	if want := "my-app 1.0.0 published to oci://ghcr.io/myorg/charts (" + digest + ", sha256:deadbeef)"; resp.Message != want {
+		t.Errorf("expected %q, got %q", want, resp.Message)
+	}
+
+	resp = execute(plugin.HookPostPublish, true, "{{if .DryRun}}Would publish{{else}}Published{{end}} {{.ChartName}}")
+	if resp.Message != "Would publish my-app" {
+		t.Errorf("unexpected dry-run message: %q", resp.Message)
+	}
+
+	resp = execute(plugin.HookPrePublish, false, "{{.Hook}}: {{.ChartName}} {{.Version}} is ready")
+	if resp.Message != "pre-publish: my-app 1.0.0 is ready" {
+		t.Errorf("unexpected PrePublish message: %q", resp.Message)
+	}
+
+	// A template failing at execute time keeps the default message
+	resp = execute(plugin.HookPrePublish, false, "{{index .Charts 3}}")
+	if resp.Message == "" || strings.Contains(resp.Message, "index") {
+		t.Errorf("expected the default message, got %q", resp.Message)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stepDurationBuckets are the histogram buckets, in seconds, for step durations.
+var stepDurationBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// MetricsConfig defines optional metrics export settings.
+type MetricsConfig struct {
+	Enabled        bool   `json:"enabled"`
+	PushgatewayURL string `json:"pushgateway_url"`
+	File           string `json:"file"`
+	Job            string `json:"job"`
+}
+
+// Metrics collects publish outcome counters and step duration histograms.
+// A nil *Metrics is valid and records nothing, so callers don't need to
+// check whether metrics are enabled.
+type Metrics struct {
+	mu        sync.Mutex
+	publishes map[[2]string]float64 // (repository_type, outcome) -> count
+	steps     map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64 // cumulative per bucket
+	count  uint64
+	sum    float64
+}
+
+// NewMetrics creates an empty metrics registry.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		publishes: map[[2]string]float64{},
+		steps:     map[string]*histogram{},
+	}
+}
+
+// IncPublish counts a publish attempt to a repository of the given type.
+func (m *Metrics) IncPublish(repoType, outcome string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.publishes[[2]string{repoType, outcome}]++
+}
+
+// ObserveStep records the duration of a step.
+func (m *Metrics) ObserveStep(step string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.steps[step]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(stepDurationBuckets))}
+		m.steps[step] = h
+	}
+
+	seconds := d.Seconds()
+	for i, bound := range stepDurationBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// Exposition renders the metrics in the Prometheus text exposition format.
+func (m *Metrics) Exposition() string {
+	if m == nil {
+		return ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# HELP helm_plugin_publish_total Chart publish attempts by repository type and outcome.\n")
+	b.WriteString("# TYPE helm_plugin_publish_total counter\n")
+	keys := make([][2]string, 0, len(m.publishes))
+	for k := range m.publishes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, k := range keys {
+		fmt.Fprintf(&b, "helm_plugin_publish_total{repository_type=%q,outcome=%q} %g\n", k[0], k[1], m.publishes[k])
+	}
+
+	b.WriteString("# HELP helm_plugin_step_duration_seconds Duration of plugin steps.\n")
+	b.WriteString("# TYPE helm_plugin_step_duration_seconds histogram\n")
+	steps := slices.Sorted(maps.Keys(m.steps))
+	for _, step := range steps {
+		h := m.steps[step]
+		for i, bound := range stepDurationBuckets {
+			fmt.Fprintf(&b, "helm_plugin_step_duration_seconds_bucket{step=%q,le=\"%g\"} %d\n", step, bound, h.counts[i])
+		}
+		fmt.Fprintf(&b, "helm_plugin_step_duration_seconds_bucket{step=%q,le=\"+Inf\"} %d\n", step, h.count)
+		fmt.Fprintf(&b, "helm_plugin_step_duration_seconds_sum{step=%q} %g\n", step, h.sum)
+		fmt.Fprintf(&b, "helm_plugin_step_duration_seconds_count{step=%q} %d\n", step, h.count)
+	}
+	return b.String()
+}
+
+// Flush writes the metrics to the configured file and/or pushes them to a
+// Prometheus Pushgateway.
+func (m *Metrics) Flush(ctx context.Context, cfg MetricsConfig) error {
+	if m == nil || !cfg.Enabled {
+		return nil
+	}
+	body := m.Exposition()
+
+	if cfg.File != "" {
+		if err := os.WriteFile(cfg.File, []byte(body), 0644); err != nil {
+			return fmt.Errorf("failed to write metrics file: %w", err)
+		}
+	}
+
+	if cfg.PushgatewayURL != "" {
+		job := cfg.Job
+		if job == "" {
+			job = "relicta_helm"
+		}
+		endpoint := strings.TrimSuffix(cfg.PushgatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+
+		req, err := http.NewRequestWithContext(ctx, "PUT", endpoint, bytes.NewBufferString(body))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+		client := &http.Client{Timeout: 10 * time.Second}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to push metrics: %w", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			respBody, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("pushgateway returned status %d: %s", resp.StatusCode, string(respBody))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsAfterSimulatedPublishes(t *testing.T) {
+	m := NewMetrics()
+
+	m.IncPublish("oci", "success")
+	m.IncPublish("oci", "success")
+	m.IncPublish("chartmuseum", "failure")
+	m.ObserveStep("package", 300*time.Millisecond)
+	m.ObserveStep("package", 3*time.Second)
+	m.ObserveStep("push", 45*time.Second)
+
+	out := m.Exposition()
+
+	expected := []string{
+		`helm_plugin_publish_total{repository_type="chartmuseum",outcome="failure"} 1`,
+		`helm_plugin_publish_total{repository_type="oci",outcome="success"} 2`,
+		`helm_plugin_step_duration_seconds_bucket{step="package",le="0.5"} 1`,
+		`helm_plugin_step_duration_seconds_bucket{step="package",le="5"} 2`,
+		`helm_plugin_step_duration_seconds_bucket{step="package",le="+Inf"} 2`,
+		`helm_plugin_step_duration_seconds_sum{step="package"} 3.3`,
+		`helm_plugin_step_duration_seconds_count{step="package"} 2`,
+		`helm_plugin_step_duration_seconds_bucket{step="push",le="30"} 0`,
+		`helm_plugin_step_duration_seconds_bucket{step="push",le="60"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("expected exposition to contain %q\n%s", line, out)
+		}
+	}
+}
+
+func TestMetricsNilIsNoop(t *testing.T) {
+	var m *Metrics
+	m.IncPublish("oci", "success")
+	m.ObserveStep("lint", time.Second)
+
+	if out := m.Exposition(); out != "" {
+		t.Errorf("expected empty exposition, got %q", out)
+	}
+	if err := m.Flush(context.Background(), MetricsConfig{Enabled: true, File: "/nonexistent/dir/metrics.prom"}); err != nil {
+		t.Errorf("expected nil metrics flush to be a no-op, got %v", err)
+	}
+}
+
+func TestMetricsFlushToFile(t *testing.T) {
+	m := NewMetrics()
+	m.IncPublish("http", "success")
+
+	path := filepath.Join(t.TempDir(), "metrics.prom")
+	if err := m.Flush(context.Background(), MetricsConfig{Enabled: true, File: path}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read metrics file: %v", err)
+	}
+	if !strings.Contains(string(data), `helm_plugin_publish_total{repository_type="http",outcome="success"} 1`) {
+		t.Errorf("unexpected metrics file content:\n%s", data)
+	}
+}
+
+func TestMetricsFlushToPushgateway(t *testing.T) {
+	var receivedPath, receivedMethod, receivedBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedMethod = r.Method
+		body, _ := io.ReadAll(r.Body)
+		receivedBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	m := NewMetrics()
+	m.IncPublish("oci", "success")
+
+	cfg := MetricsConfig{Enabled: true, PushgatewayURL: server.URL + "/", Job: "charts"}
+	if err := m.Flush(context.Background(), cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if receivedMethod != "PUT" || receivedPath != "/metrics/job/charts" {
+		t.Errorf("unexpected request %s %s", receivedMethod, receivedPath)
+	}
+	if !strings.Contains(receivedBody, "helm_plugin_publish_total") {
+		t.Errorf("expected metrics in body, got %q", receivedBody)
+	}
+}
+
+func TestPluginMetricsDisabledByDefault(t *testing.T) {
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{})
+	if p.metricsFor(cfg) != nil {
+		t.Error("expected metrics to be disabled by default")
+	}
+
+	cfg = p.parseConfig(map[string]any{"metrics": map[string]any{"enabled": true, "file": "m.prom"}})
+	if cfg.Metrics.File != "m.prom" {
+		t.Errorf("expected metrics file 'm.prom', got '%s'", cfg.Metrics.File)
+	}
+	if p.metricsFor(cfg) == nil || p.metricsFor(cfg) != p.metricsFor(cfg) {
+		t.Error("expected a single shared metrics registry when enabled")
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// MirrorConfig defines settings for re-publishing a chart that already exists in
+// an OCI registry, without local chart sources.
+type MirrorConfig struct {
+	Enabled bool             `json:"enabled"`
+	Source  RepositoryConfig `json:"source"`
+	Chart   string           `json:"chart"`
+	Version string           `json:"version"` // defaults to the release version
+}
+
+// parseMirrorConfig parses the mirror config block.
+func parseMirrorConfig(raw any) MirrorConfig {
+	var cfg MirrorConfig
+	mirrorRaw, ok := raw.(map[string]any)
+	if !ok {
+		return cfg
+	}
+	if enabled, ok := mirrorRaw["enabled"].(bool); ok {
+		cfg.Enabled = enabled
+	}
+	if sourceRaw, ok := mirrorRaw["source"].(map[string]any); ok {
+		cfg.Source = parseRepositoryConfig(sourceRaw)
+	}
+	if chart, ok := mirrorRaw["chart"].(string); ok {
+		cfg.Chart = chart
+	}
+	if version, ok := mirrorRaw["version"].(string); ok {
+		cfg.Version = version
+	}
+	return cfg
+}
+
+// validateMirrorConfig validates the mirror config block.
+func validateMirrorConfig(vb *helpers.ValidationBuilder, cfg MirrorConfig) {
+	if cfg.Chart == "" {
+		vb.AddError("mirror.chart", "Chart name is required for mirroring")
+	}
+	if cfg.Source.URL == "" {
+		vb.AddError("mirror.source.url", "Source repository URL is required for mirroring")
+	}
+	if cfg.Source.Type != "oci" {
+		vb.AddError("mirror.source.type", "Mirroring requires an oci source repository")
+	}
+}
+
+// executeMirror pulls a chart from the mirror source and pushes it unchanged to
+// the configured repositories.
+func (p *HelmPlugin) executeMirror(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	version := cfg.Mirror.Version
+	if version == "" {
+		version = strings.TrimPrefix(releaseCtx.Version, "v")
+	}
+	chartName := cfg.Mirror.Chart
+	source := NewRepository(cfg.Mirror.Source)
+	logger = logger.With("chart", chartName, "version", version, "source", cfg.Mirror.Source.URL)
+
+	logins := newLoginLimiter(cfg.LoginConcurrency)
+	source.SetLoginLimiter(logins)
+	source.SetHelmBinary(cfg.helmBinary())
+	source.SetTimeout(cfg.commandTimeout())
+	repos := cfg.newTargetRepositories(logins)
+
+	if cfg.DryRun {
+		var pushTargets []string
+		for _, repo := range repos {
+			pushTargets = append(pushTargets, repo.PushTarget(chartName, version))
+		}
+		logger.Info("[DRY-RUN] Would mirror chart",
+			"from", source.OCIReference(chartName, version),
+			"to", pushTargets)
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: fmt.Sprintf("[DRY-RUN] Would mirror %s-%s to %s", chartName, version, strings.Join(pushTargets, ", ")),
+			Outputs: map[string]any{
+				"push_targets": pushTargets,
+			},
+		}, nil
+	}
+
+	outputDir := cfg.OutputDir
+	if outputDir == "" {
+		outputDir = ".helm-packages"
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to create output directory: %v", err),
+		}, nil
+	}
+
+	logger.Info("Pulling chart for mirroring")
+	packagePath, err := source.Pull(ctx, chartName, version, outputDir)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to pull chart: %v", err),
+		}, nil
+	}
+
+	results := pushToRepositories(ctx, repos, packagePath, cfg.Concurrency, cfg.FailFast, cfg.FailIfExists, logger)
+	if err := joinPushErrors(results); err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to push chart: %v", err),
+		}, nil
+	}
+
+	packages := []chartPackage{{Version: version, Path: packagePath}}
+	outputs, err := packageOutputs(packages, results)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to compute package digest: %v", err),
+		}, nil
+	}
+	artifacts, err := packageArtifacts(packages)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to list artifacts: %v", err),
+		}, nil
+	}
+	outputs["artifacts"] = artifactPaths(artifacts)
+
+	logger.Info("Mirror completed successfully")
+	return &plugin.ExecuteResponse{
+		Success:   true,
+		Message:   fmt.Sprintf("Mirrored %s-%s to %s", chartName, version, repositoryURLs(repos)),
+		Outputs:   outputs,
+		Artifacts: artifacts,
+	}, nil
+}
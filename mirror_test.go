@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestParseMirrorConfig(t *testing.T) {
+	cfg := parseMirrorConfig(map[string]any{
+		"enabled": true,
+		"chart":   "my-app",
+		"version": "1.2.3",
+		"source": map[string]any{
+			"url": "oci://source.example.com/charts",
+		},
+	})
+
+	if !cfg.Enabled || cfg.Chart != "my-app" || cfg.Version != "1.2.3" {
+		t.Errorf("unexpected mirror config: %+v", cfg)
+	}
+	if cfg.Source.Type != "oci" || cfg.Source.URL != "oci://source.example.com/charts" {
+		t.Errorf("unexpected source config: %+v", cfg.Source)
+	}
+}
+
+func TestExecuteMirrorPullsThenPushes(t *testing.T) {
+	dir := writeFakeCommand(t, "helm", `echo "$@" >> "$(dirname "$0")/calls"
+if [ "$1" = "pull" ]; then
+	echo "chart" > "$6/my-app-1.2.3.tgz"
+fi
+if [ "$1" = "push" ]; then
+	echo "Pushed: dest.example.com/charts/my-app:1.2.3"
+	echo "Digest: sha256:deadbeef"
+fi
+`)
+	outputDir := t.TempDir()
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"output_dir": outputDir,
+		"repository": map[string]any{
+			"url": "oci://dest.example.com/charts",
+		},
+		"mirror": map[string]any{
+			"enabled": true,
+			"chart":   "my-app",
+			"source": map[string]any{
+				"url": "oci://source.example.com/charts",
+			},
+		},
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePostPublish(context.Background(), &plugin.ReleaseContext{Version: "v1.2.3"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+	if resp.Outputs["oci_digest"] != "sha256:deadbeef" {
+		t.Errorf("expected oci_digest output, got %v", resp.Outputs["oci_digest"])
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	packagePath := filepath.Join(outputDir, "my-app-1.2.3.tgz")
+	want := []string{
+		"pull oci://source.example.com/charts/my-app --version 1.2.3 --destination " + outputDir,
+		"push " + packagePath + " oci://dest.example.com/charts",
+	}
+	if got := strings.Split(strings.TrimSpace(string(calls)), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected helm calls:\n%s", calls)
+	}
+}
+
+func TestExecuteMirrorSignsWithCosign(t *testing.T) {
+	writeFakeCommand(t, "helm", `if [ "$1" = "pull" ]; then
+	echo "chart" > "$6/my-app-1.2.3.tgz"
+fi
+if [ "$1" = "push" ]; then
+	echo "Pushed: dest.example.com/charts/my-app:1.2.3"
+	echo "Digest: sha256:deadbeef"
+fi
+`)
+	cosignDir := writeFakeCommand(t, "cosign", `echo "$@" >> "$(dirname "$0")/args"`)
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"output_dir": t.TempDir(),
+		"sign":       true,
+		"sign_mode":  "cosign",
+		"cosign_key": "cosign.key",
+		"repository": map[string]any{"url": "oci://dest.example.com/charts"},
+		"mirror": map[string]any{
+			"enabled": true,
+			"chart":   "my-app",
+			"source":  map[string]any{"url": "oci://source.example.com/charts"},
+		},
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePostPublish(context.Background(), &plugin.ReleaseContext{Version: "1.2.3"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(cosignDir, "args"))
+	if got := strings.TrimSpace(string(args)); got != "sign --yes --key cosign.key dest.example.com/charts/my-app@sha256:deadbeef" {
+		t.Errorf("expected the mirrored chart to be signed, got cosign args: %q", got)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// NotifyConfig defines the webhook notified after a successful publish.
+type NotifyConfig struct {
+	URL      string            `json:"url"`
+	Method   string            `json:"method"` // defaults to POST
+	Headers  map[string]string `json:"headers"`
+	Required bool              `json:"required"` // fail the hook when the notification fails
+}
+
+// parseNotifyConfig parses the notify config block.
+func parseNotifyConfig(raw any) NotifyConfig {
+	cfg := NotifyConfig{Method: http.MethodPost}
+	notifyRaw, ok := raw.(map[string]any)
+	if !ok {
+		return cfg
+	}
+	if url, ok := notifyRaw["url"].(string); ok {
+		cfg.URL = url
+	}
+	if method, ok := notifyRaw["method"].(string); ok && method != "" {
+		cfg.Method = strings.ToUpper(method)
+	}
+	cfg.Headers = parseStringMap(notifyRaw["headers"])
+	if required, ok := notifyRaw["required"].(bool); ok {
+		cfg.Required = required
+	}
+	return cfg
+}
+
+// notifyPayload is the chart metadata sent to the notification webhook.
+type notifyPayload struct {
+	Chart          string             `json:"chart"`
+	Version        string             `json:"version"`
+	AppVersion     string             `json:"app_version,omitempty"`
+	ReleaseVersion string             `json:"release_version"`
+	Digest         string             `json:"digest,omitempty"`
+	Packages       []notifyPackage    `json:"packages"`
+	Repositories   []notifyRepository `json:"repositories"`
+}
+
+// notifyPackage describes a published package.
+type notifyPackage struct {
+	Environment string `json:"environment,omitempty"`
+	Version     string `json:"version"`
+	Digest      string `json:"digest,omitempty"`
+}
+
+// notifyRepository describes a repository the chart was published to.
+type notifyRepository struct {
+	URL    string `json:"url"`
+	Type   string `json:"type"`
+	Digest string `json:"digest,omitempty"` // manifest digest reported by helm push (oci only)
+}
+
+// newNotifyPayload builds the notification payload. Package digests are only
+// computed when withDigests is set, as dry runs have no packages on disk.
+func newNotifyPayload(chart *Chart, packages []chartPackage, repos []*Repository, results []pushStatus, releaseCtx *plugin.ReleaseContext, withDigests bool) (notifyPayload, error) {
+	payload := notifyPayload{
+		Chart:          chart.Name,
+		Version:        chart.Version,
+		AppVersion:     chart.AppVersion,
+		ReleaseVersion: releaseCtx.Version,
+	}
+	for i, pkg := range packages {
+		entry := notifyPackage{Environment: pkg.Environment, Version: pkg.Version}
+		if withDigests {
+			digest, err := fileDigest(pkg.Path)
+			if err != nil {
+				return payload, err
+			}
+			entry.Digest = digest
+		}
+		if i == 0 {
+			payload.Version = entry.Version
+			payload.Digest = entry.Digest
+		}
+		payload.Packages = append(payload.Packages, entry)
+	}
+
+	for _, repo := range repos {
+		entry := notifyRepository{URL: repo.config.URL, Type: repo.config.Type}
+		for _, r := range results {
+			if r.URL == repo.config.URL && r.Digest != "" && len(packages) > 0 && r.Package == filepath.Base(packages[0].Path) {
+				entry.Digest = r.Digest
+				break
+			}
+		}
+		payload.Repositories = append(payload.Repositories, entry)
+	}
+	return payload, nil
+}
+
+// sendNotification delivers the payload to the notification webhook and
+// returns an error for transport failures and non-2xx responses.
+func sendNotification(ctx context.Context, cfg NotifyConfig, payload notifyPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("notification webhook returned %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+	return nil
+}
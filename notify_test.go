@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestParseNotifyConfig(t *testing.T) {
+	cfg := parseNotifyConfig(map[string]any{
+		"url":      "https://catalog.example.com/hooks/helm",
+		"method":   "put",
+		"headers":  map[string]any{"Authorization": "Bearer abc"},
+		"required": true,
+	})
+	if cfg.URL != "https://catalog.example.com/hooks/helm" || cfg.Method != http.MethodPut || !cfg.Required {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.Headers["Authorization"] != "Bearer abc" {
+		t.Errorf("expected headers to be parsed, got %v", cfg.Headers)
+	}
+
+	if cfg := parseNotifyConfig(nil); cfg.Method != http.MethodPost {
+		t.Errorf("expected default method POST, got %s", cfg.Method)
+	}
+}
+
+func TestSendNotification(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr string
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "accepted", status: http.StatusAccepted},
+		{name: "server error", status: http.StatusBadGateway, wantErr: "returned 502: upstream down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got notifyPayload
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPut {
+					t.Errorf("expected PUT, got %s", r.Method)
+				}
+				if r.Header.Get("X-Catalog-Token") != "secret" {
+					t.Errorf("expected custom header, got %q", r.Header.Get("X-Catalog-Token"))
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("failed to decode payload: %v", err)
+				}
+				w.WriteHeader(tt.status)
+				if tt.status >= 300 {
+					_, _ = w.Write([]byte("upstream down"))
+				}
+			}))
+			defer server.Close()
+
+			cfg := NotifyConfig{URL: server.URL, Method: http.MethodPut, Headers: map[string]string{"X-Catalog-Token": "secret"}}
+			err := sendNotification(context.Background(), cfg, notifyPayload{Chart: "my-app", Version: "1.0.0"})
+
+			if got.Chart != "my-app" || got.Version != "1.0.0" {
+				t.Errorf("unexpected payload: %+v", got)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewNotifyPayload(t *testing.T) {
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.2.0.tgz")
+	if err := os.WriteFile(packagePath, []byte("chart"), 0644); err != nil {
+		t.Fatalf("failed to write package: %v", err)
+	}
+	digest, _ := fileDigest(packagePath)
+
+	chart := &Chart{Name: "my-app", Version: "1.2.0", AppVersion: "v1.2.0"}
+	packages := []chartPackage{{Version: "1.2.0", Path: packagePath}}
+	repos := []*Repository{
+		NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg/charts"}),
+		NewRepository(RepositoryConfig{Type: "chartmuseum", URL: "https://charts.example.com"}),
+	}
+	results := []pushStatus{
+		{Package: "my-app-1.2.0.tgz", Type: "oci", URL: "oci://ghcr.io/myorg/charts", Digest: "sha256:manifest"},
+		{Package: "my-app-1.2.0.tgz", Type: "chartmuseum", URL: "https://charts.example.com"},
+	}
+	releaseCtx := &plugin.ReleaseContext{Version: "1.2.0"}
+
+	payload, err := newNotifyPayload(chart, packages, repos, results, releaseCtx, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payload.Chart != "my-app" || payload.Version != "1.2.0" || payload.AppVersion != "v1.2.0" || payload.ReleaseVersion != "1.2.0" {
+		t.Errorf("unexpected chart metadata: %+v", payload)
+	}
+	if payload.Digest != digest {
+		t.Errorf("expected digest %s, got %s", digest, payload.Digest)
+	}
+	if len(payload.Repositories) != 2 || payload.Repositories[0].Digest != "sha256:manifest" || payload.Repositories[1].Type != "chartmuseum" {
+		t.Errorf("unexpected repositories: %+v", payload.Repositories)
+	}
+
+	// Dry runs have no package on disk
+	packages[0].Path = filepath.Join(t.TempDir(), "missing.tgz")
+	payload, err = newNotifyPayload(chart, packages, repos, nil, releaseCtx, false)
+	if err != nil || payload.Digest != "" {
+		t.Errorf("expected payload without digest, got %+v (err %v)", payload, err)
+	}
+}
+
+func TestPostPublishNotification(t *testing.T) {
+	chartDir := filepath.Join(t.TempDir(), "my-app")
+	if err := os.MkdirAll(chartDir, 0755); err != nil {
+		t.Fatalf("failed to create chart: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\nappVersion: \"1.0.0\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	writeChartArchive(t, packagePath, map[string]string{"my-app/Chart.yaml": "apiVersion: v2\nname: my-app\nversion: 1.0.0\n"})
+	writeFakeCommand(t, "helm", `case "$1" in
+package) echo "Successfully packaged chart and saved it to: `+packagePath+`" ;;
+push) echo "Digest: sha256:deadbeef" ;;
+esac
+`)
+
+	tests := []struct {
+		name        string
+		status      int
+		required    bool
+		wantSuccess bool
+	}{
+		{name: "delivered", status: http.StatusNoContent, wantSuccess: true},
+		{name: "soft failure", status: http.StatusInternalServerError, wantSuccess: true},
+		{name: "required failure", status: http.StatusInternalServerError, required: true, wantSuccess: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got notifyPayload
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			p := &HelmPlugin{}
+			cfg := p.parseConfig(map[string]any{
+				"chart_path": chartDir,
+				"output_dir": t.TempDir(),
+				"repository": map[string]any{"url": "oci://ghcr.io/myorg/charts"},
+				"notify":     map[string]any{"url": server.URL, "required": tt.required},
+			})
+
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			resp, err := p.executePostPublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got: %s", tt.wantSuccess, resp.Message)
+			}
+			if got.Chart != "my-app" || got.AppVersion != "1.0.0" || len(got.Repositories) != 1 || got.Repositories[0].Digest != "sha256:deadbeef" {
+				t.Errorf("unexpected payload: %+v", got)
+			}
+		})
+	}
+}
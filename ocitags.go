@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// ociTagTools are the CLIs able to add a tag to a manifest already in a
+// registry, in order of preference. Helm itself always tags a chart with its
+// version, so additional tags are added by copying the pushed manifest.
+var ociTagTools = []string{"crane", "oras"}
+
+// manifestNotFound are substrings of crane/oras output reporting a missing tag.
+var manifestNotFound = []string{"MANIFEST_UNKNOWN", "NAME_UNKNOWN", "not found", "404"}
+
+// ociTagData is the data additional_tags templates are rendered with.
+type ociTagData struct {
+	Version string // the chart's own OCI tag, e.g. 1.2.3
+	Major   int64
+	Minor   int64
+	Patch   int64
+}
+
+// findOCITagTool returns the first of ociTagTools found on PATH.
+func findOCITagTool() (string, error) {
+	for _, name := range ociTagTools {
+		if _, err := exec.LookPath(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("additional_tags require %s on PATH", strings.Join(ociTagTools, " or "))
+}
+
+// renderOCITags renders additional_tags templates such as "{{.Major}}.{{.Minor}}"
+// or "latest" for version. Tags equal to the version's own tag or repeated are
+// dropped.
+func renderOCITags(formats []string, version string) ([]string, error) {
+	v, err := ParseSemVer(version)
+	if err != nil {
+		return nil, err
+	}
+	data := ociTagData{Version: OCITag(version), Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+
+	var tags []string
+	for _, format := range formats {
+		tag, err := renderTemplate(fmt.Sprintf("additional tag %q", format), format, data)
+		if err != nil {
+			return nil, err
+		}
+		if !ociTagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("additional tag %q renders to %q, which is not a valid OCI tag", format, tag)
+		}
+		if tag != data.Version && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// additionalTags returns the tags version should also be published under.
+// Prereleases get none, so moving tags such as latest only ever point at
+// stable releases.
+func (r *Repository) additionalTags(version string) ([]string, error) {
+	if len(r.config.AdditionalTags) == 0 {
+		return nil, nil
+	}
+	if v, err := ParseSemVer(version); err == nil && v.Prerelease != "" {
+		return nil, nil
+	}
+	return renderOCITags(r.config.AdditionalTags, version)
+}
+
+// AddTags points the repository's additional tags at the chart version just
+// pushed, copying its manifest with crane or oras. A tag that already exists
+// is only moved with overwrite; otherwise it is left alone and reported as
+// skipped. It returns the references tagged and skipped.
+func (r *Repository) AddTags(ctx context.Context, chartName, version string) (tagged, skipped []string, err error) {
+	tags, err := r.additionalTags(version)
+	if err != nil || len(tags) == 0 {
+		return nil, nil, err
+	}
+	tool, err := findOCITagTool()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := r.loginRegistryTool(ctx, tool); err != nil {
+		return nil, nil, err
+	}
+
+	source := strings.TrimPrefix(r.OCIReference(chartName, version), "oci://")
+	chartRef := strings.TrimPrefix(r.ociChart(chartName), "oci://")
+	for _, tag := range tags {
+		target := chartRef + ":" + tag
+		exists, err := r.tagExists(ctx, tool, target)
+		if err != nil {
+			return tagged, skipped, fmt.Errorf("failed to look up %s: %w", target, err)
+		}
+		if exists && !r.config.Overwrite {
+			skipped = append(skipped, target)
+			continue
+		}
+		if _, err := r.runRegistryTool(ctx, tool, nil, "tag", source, tag); err != nil {
+			if exists {
+				return tagged, skipped, fmt.Errorf("failed to move %s (the registry may not allow overwriting tags): %w", target, err)
+			}
+			return tagged, skipped, fmt.Errorf("failed to tag %s: %w", target, err)
+		}
+		tagged = append(tagged, target)
+	}
+	return tagged, skipped, nil
+}
+
+// tagExists reports whether ref resolves in the registry.
+func (r *Repository) tagExists(ctx context.Context, tool, ref string) (bool, error) {
+	command := "resolve"
+	if tool == "crane" {
+		command = "digest"
+	}
+	output, err := r.runRegistryTool(ctx, tool, nil, command, ref)
+	if err == nil {
+		return true, nil
+	}
+	for _, s := range manifestNotFound {
+		if strings.Contains(output, s) {
+			return false, nil
+		}
+	}
+	return false, err
+}
+
+// loginRegistryTool logs tool in to the registry if credentials are provided.
+// crane and oras keep their own credentials, separate from helm's registry
+// config.
+func (r *Repository) loginRegistryTool(ctx context.Context, tool string) error {
+	username, password, err := r.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	if username == "" || password == "" {
+		return nil
+	}
+
+	registryHost := strings.SplitN(strings.TrimPrefix(r.config.URL, "oci://"), "/", 2)[0]
+	args := []string{"login", registryHost, "--username", username, "--password-stdin"}
+	if tool == "crane" {
+		args = append([]string{"auth"}, args...)
+	}
+	_, err = r.runRegistryTool(ctx, tool, strings.NewReader(password), args...)
+	return err
+}
+
+// runRegistryTool runs tool with args, applying the repository's TLS settings
+// and command timeout, and returns its combined output.
+func (r *Repository) runRegistryTool(ctx context.Context, tool string, stdin io.Reader, args ...string) (string, error) {
+	if r.config.Insecure {
+		args = append(args, "--insecure")
+	}
+	var output bytes.Buffer
+	newCmd := func(ctx context.Context) *exec.Cmd { return exec.CommandContext(ctx, tool, args...) }
+	err := runWithTimeout(ctx, tool+" "+args[0], r.timeout, newCmd, func(cmd *exec.Cmd) error {
+		cmd.Stdin = stdin
+		if r.config.CAFile != "" {
+			// Both tools are Go programs, which take their trusted roots from SSL_CERT_FILE
+			cmd.Env = append(os.Environ(), "SSL_CERT_FILE="+r.config.CAFile)
+		}
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		return cmd.Run()
+	})
+	if errors.Is(err, ErrHelmTimeout) {
+		return output.String(), err
+	}
+	if err != nil {
+		return output.String(), fmt.Errorf("%s %s failed: %w: %s", tool, args[0], err, strings.TrimSpace(output.String()))
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+)
+
+func TestRenderOCITags(t *testing.T) {
+	tests := []struct {
+		name    string
+		formats []string
+		version string
+		want    []string
+		wantErr string
+	}{
+		{name: "moving tags", formats: []string{"{{.Major}}", "{{.Major}}.{{.Minor}}", "latest"}, version: "1.2.3", want: []string{"1", "1.2", "latest"}},
+		{name: "own tag and repeats dropped", formats: []string{"{{.Version}}", "latest", "latest"}, version: "1.2.3", want: []string{"latest"}},
+		{name: "build metadata", formats: []string{"{{.Version}}-stable"}, version: "1.2.3+build.7", want: []string{"1.2.3_build.7-stable"}},
+		{name: "unknown field", formats: []string{"{{.Build}}"}, version: "1.2.3", wantErr: "invalid additional tag"},
+		{name: "invalid tag", formats: []string{"v{{.Major}}/stable"}, version: "1.2.3", wantErr: "not a valid OCI tag"},
+		{name: "invalid version", formats: []string{"latest"}, version: "main", wantErr: "invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := renderOCITags(tt.formats, tt.version)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(tags, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, tags)
+			}
+		})
+	}
+}
+
+func TestRepositoryAddTags(t *testing.T) {
+	// Fake crane backed by a file listing the tags already in the registry
+	dir := writeFakeCommand(t, "crane", `dir=$(dirname "$0")
+echo "$@" >> "$dir/calls"
+case "$1" in
+auth) cat > "$dir/password" ;;
+digest)
+	grep -qx "${2##*:}" "$dir/tags" 2>/dev/null || { echo "MANIFEST_UNKNOWN: manifest unknown" >&2; exit 1; }
+	echo "sha256:deadbeef"
+	;;
+tag) echo "$3" >> "$dir/tags" ;;
+esac
+`)
+
+	tests := []struct {
+		name        string
+		version     string
+		overwrite   bool
+		wantTagged  []string
+		wantSkipped []string
+	}{
+		{name: "existing tag kept", version: "1.2.3", wantTagged: []string{"1", "1.2"}, wantSkipped: []string{"latest"}},
+		{name: "existing tag moved with overwrite", version: "1.2.3", overwrite: true, wantTagged: []string{"1", "1.2", "latest"}},
+		{name: "prerelease", version: "1.3.0-rc.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, content := range map[string]string{"tags": "latest\n", "calls": ""} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+
+			repo := NewRepository(RepositoryConfig{
+				Type:           "oci",
+				URL:            "oci://registry.example.com/charts",
+				Username:       "ci",
+				Password:       "secret",
+				Overwrite:      tt.overwrite,
+				AdditionalTags: []string{"{{.Major}}", "{{.Major}}.{{.Minor}}", "latest"},
+			})
+			tagged, skipped, err := repo.AddTags(context.Background(), "my-app", tt.version)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ref := func(tags []string) string {
+				refs := make([]string, 0, len(tags))
+				for _, tag := range tags {
+					refs = append(refs, "registry.example.com/charts/my-app:"+tag)
+				}
+				return strings.Join(refs, ",")
+			}
+			if strings.Join(tagged, ",") != ref(tt.wantTagged) {
+				t.Errorf("expected tagged %v, got %v", tt.wantTagged, tagged)
+			}
+			if strings.Join(skipped, ",") != ref(tt.wantSkipped) {
+				t.Errorf("expected skipped %v, got %v", tt.wantSkipped, skipped)
+			}
+
+			calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+			if len(tt.wantTagged) == 0 {
+				if len(calls) > 0 {
+					t.Errorf("expected no crane calls, got:\n%s", calls)
+				}
+				return
+			}
+			if !strings.Contains(string(calls), "auth login registry.example.com --username ci --password-stdin") {
+				t.Errorf("expected crane to log in, got:\n%s", calls)
+			}
+			if !strings.Contains(string(calls), "tag registry.example.com/charts/my-app:1.2.3 1.2") {
+				t.Errorf("expected tags copied from the pushed version, got:\n%s", calls)
+			}
+			if password, _ := os.ReadFile(filepath.Join(dir, "password")); string(password) != "secret" {
+				t.Errorf("expected password on stdin, got %q", password)
+			}
+		})
+	}
+}
+
+func TestRepositoryAddTagsTimeout(t *testing.T) {
+	writeFakeCommand(t, "crane", `exec sleep 5`)
+
+	repo := NewRepository(RepositoryConfig{
+		Type:           "oci",
+		URL:            "oci://registry.example.com/charts",
+		AdditionalTags: []string{"latest"},
+	})
+	repo.SetTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	_, _, err := repo.AddTags(context.Background(), "my-app", "1.2.3")
+	if !errors.Is(err, ErrHelmTimeout) || !strings.Contains(err.Error(), "crane digest timed out after 50ms") {
+		t.Fatalf("expected crane to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("crane was not cancelled promptly (%s)", elapsed)
+	}
+}
+
+func TestValidateAdditionalTags(t *testing.T) {
+	writeFakeCommand(t, "crane", "exit 0\n")
+
+	tests := []struct {
+		name      string
+		repo      RepositoryConfig
+		wantValid bool
+	}{
+		{name: "oci with overwrite", repo: RepositoryConfig{Type: "oci", AdditionalTags: []string{"latest"}, Overwrite: true}, wantValid: true},
+		{name: "oci overwrite without additional tags", repo: RepositoryConfig{Type: "oci", Overwrite: true}},
+		{name: "http repository", repo: RepositoryConfig{Type: "http", AdditionalTags: []string{"latest"}}},
+		{name: "invalid template", repo: RepositoryConfig{Type: "oci", AdditionalTags: []string{"{{.Major"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.repo.URL = "oci://ghcr.io/myorg/charts"
+			vb := helpers.NewValidationBuilder()
+			validateRepositoryConfig(vb, "repository", tt.repo, helmBinary{}, "")
+			if resp := vb.Build(); resp.Valid != tt.wantValid {
+				t.Errorf("expected valid=%v, got %+v", tt.wantValid, resp.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Output formats for ExecuteResponse.Message.
+const (
+	OutputFormatText = "text"
+	OutputFormatJSON = "json"
+)
+
+// outputReport is the machine-readable form of a hook response, emitted as the
+// response message when output_format is json.
+type outputReport struct {
+	Success      bool           `json:"success"`
+	Message      string         `json:"message"`
+	Chart        string         `json:"chart"`
+	Version      string         `json:"version"`
+	AppVersion   string         `json:"app_version"`
+	PackagePath  string         `json:"package_path"`
+	Digest       string         `json:"digest"`
+	Repository   string         `json:"repository"`
+	Pushed       bool           `json:"pushed"`
+	LintWarnings int            `json:"lint_warnings"`
+	LintErrors   int            `json:"lint_errors"`
+	Plan         *Plan          `json:"plan,omitempty"`   // dry-run release plan
+	Charts       []outputReport `json:"charts,omitempty"` // per-chart reports with chart_paths
+}
+
+// newOutputReport builds a report from a response and its outputs. Combined
+// chart_paths responses report each chart under "charts".
+func newOutputReport(resp *plugin.ExecuteResponse) outputReport {
+	report := reportFromOutputs(resp.Success, resp.Message, resp.Outputs)
+	if charts, ok := resp.Outputs["charts"].([]map[string]any); ok {
+		for _, entry := range charts {
+			success, _ := entry["success"].(bool)
+			message, _ := entry["message"].(string)
+			report.Charts = append(report.Charts, reportFromOutputs(success, message, entry))
+		}
+	}
+	return report
+}
+
+// reportFromOutputs fills a report from the well-known hook outputs.
+func reportFromOutputs(success bool, message string, outputs map[string]any) outputReport {
+	report := outputReport{Success: success, Message: message}
+	report.Chart, _ = outputs["chart"].(string)
+	report.Version, _ = outputs["chart_version"].(string)
+	report.AppVersion, _ = outputs["app_version"].(string)
+	report.PackagePath, _ = outputs["chart_package"].(string)
+	report.Digest, _ = outputs["chart_digest"].(string)
+	report.Repository, _ = outputs["repository"].(string)
+	report.Pushed, _ = outputs["pushed"].(bool)
+	report.LintWarnings, _ = outputs["lint_warnings"].(int)
+	report.LintErrors, _ = outputs["lint_errors"].(int)
+	report.Plan, _ = outputs["plan"].(*Plan)
+	return report
+}
+
+// formatResponse rewrites the response message in the configured output format.
+func formatResponse(resp *plugin.ExecuteResponse, format string) {
+	if resp == nil || format != OutputFormatJSON {
+		return
+	}
+	body, err := json.Marshal(newOutputReport(resp))
+	if err != nil {
+		return
+	}
+	resp.Message = string(body)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestExecuteJSONOutput(t *testing.T) {
+	chartDir := filepath.Join(t.TempDir(), "my-app")
+	if err := os.MkdirAll(chartDir, 0755); err != nil {
+		t.Fatalf("failed to create chart: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\nappVersion: \"1.0.0\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	writeChartArchive(t, packagePath, map[string]string{"my-app/Chart.yaml": "apiVersion: v2\nname: my-app\nversion: 1.0.0\n"})
+	digest, _ := fileDigest(packagePath)
+	writeFakeCommand(t, "helm", `case "$1" in
+lint) echo "[WARNING] templates/: a warning" ;;
+package) echo "Successfully packaged chart and saved it to: `+packagePath+`" ;;
+esac
+`)
+
+	p := &HelmPlugin{}
+	execute := func(hook plugin.Hook) outputReport {
+		t.Helper()
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook: hook,
+			Config: map[string]any{
+				"chart_path":        chartDir,
+				"output_dir":        t.TempDir(),
+				"output_format":     "json",
+				"template_validate": false,
+				"version":           map[string]any{"update_chart": false},
+				"dependencies":      map[string]any{"update": false, "build": false},
+				"repository":        map[string]any{"url": "oci://ghcr.io/myorg/charts"},
+			},
+			Context: plugin.ReleaseContext{Version: "1.0.0"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var report outputReport
+		if err := json.Unmarshal([]byte(resp.Message), &report); err != nil {
+			t.Fatalf("expected JSON message, got %q: %v", resp.Message, err)
+		}
+		if report.Success != resp.Success || !report.Success {
+			t.Fatalf("expected success, got: %+v", report)
+		}
+		return report
+	}
+
+	pre := execute(plugin.HookPrePublish)
+	if pre.Chart != "my-app" || pre.Version != "1.0.0" || pre.AppVersion != "1.0.0" || pre.LintWarnings != 1 || pre.LintErrors != 0 {
+		t.Errorf("unexpected PrePublish report: %+v", pre)
+	}
+
+	post := execute(plugin.HookPostPublish)
+	if post.Chart != "my-app" || post.Version != "1.0.0" || post.PackagePath != packagePath || post.Digest != digest {
+		t.Errorf("unexpected PostPublish report: %+v", post)
+	}
+	if post.Repository != "oci://ghcr.io/myorg/charts" || !post.Pushed {
+		t.Errorf("expected pushed to oci://ghcr.io/myorg/charts, got: %+v", post)
+	}
+}
+
+func TestFormatResponse(t *testing.T) {
+	resp := &plugin.ExecuteResponse{Success: false, Message: "Chart linting failed", Outputs: map[string]any{"chart": "my-app", "lint_errors": 2}}
+
+	formatResponse(resp, OutputFormatText)
+	if resp.Message != "Chart linting failed" {
+		t.Errorf("expected text message to be unchanged, got %q", resp.Message)
+	}
+
+	formatResponse(resp, OutputFormatJSON)
+	var report outputReport
+	if err := json.Unmarshal([]byte(resp.Message), &report); err != nil {
+		t.Fatalf("expected JSON message: %v", err)
+	}
+	if report.Success || report.Message != "Chart linting failed" || report.Chart != "my-app" || report.LintErrors != 2 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	combined := combineChartResponses([]string{"charts/a", "charts/b"}, []*plugin.ExecuteResponse{
+		{Success: true, Message: "ok", Outputs: map[string]any{"chart": "a"}},
+		{Success: true, Message: "ok", Outputs: map[string]any{"chart": "b"}},
+	})
+	formatResponse(combined, OutputFormatJSON)
+	report = outputReport{}
+	if err := json.Unmarshal([]byte(combined.Message), &report); err != nil {
+		t.Fatalf("expected JSON message: %v", err)
+	}
+	if len(report.Charts) != 2 || report.Charts[1].Chart != "b" {
+		t.Errorf("expected per-chart reports, got %+v", report.Charts)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+)
+
+// DefaultPlaceholders are values left behind by chart scaffolding and examples.
+var DefaultPlaceholders = []string{
+	"example.com",
+	"example.org",
+	"example.net",
+	"A Helm chart for Kubernetes",
+}
+
+// PlaceholderConfig defines the check for placeholder values in Chart.yaml
+// metadata.
+type PlaceholderConfig struct {
+	Enabled bool     `json:"enabled"`
+	Fail    bool     `json:"fail"`   // fail PrePublish instead of warning
+	Values  []string `json:"values"` // replaces DefaultPlaceholders
+}
+
+// parsePlaceholderConfig parses the metadata_placeholders block.
+func parsePlaceholderConfig(raw any) PlaceholderConfig {
+	cfg := PlaceholderConfig{Values: DefaultPlaceholders}
+	phRaw, ok := raw.(map[string]any)
+	if !ok {
+		return cfg
+	}
+	parser := helpers.NewConfigParser(phRaw)
+	cfg.Enabled = parser.GetBool("enabled", false)
+	cfg.Fail = parser.GetBool("fail", false)
+	cfg.Values = parser.GetStringSlice("values", DefaultPlaceholders)
+	return cfg
+}
+
+// findPlaceholders returns the chart metadata fields containing one of the
+// placeholder values, compared case-insensitively.
+func findPlaceholders(chart *Chart, placeholders []string) []string {
+	fields := [][2]string{
+		{"description", chart.Description},
+		{"home", chart.Home},
+		{"icon", chart.Icon},
+	}
+	for i, source := range chart.Sources {
+		fields = append(fields, [2]string{fmt.Sprintf("sources[%d]", i), source})
+	}
+	for i, m := range chart.Maintainers {
+		fields = append(fields,
+			[2]string{fmt.Sprintf("maintainers[%d].email", i), m.Email},
+			[2]string{fmt.Sprintf("maintainers[%d].url", i), m.URL},
+		)
+	}
+
+	var found []string
+	for _, field := range fields {
+		value := strings.ToLower(field[1])
+		for _, placeholder := range placeholders {
+			if placeholder != "" && strings.Contains(value, strings.ToLower(placeholder)) {
+				found = append(found, fmt.Sprintf("%s: %s", field[0], field[1]))
+				break
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestFindPlaceholders(t *testing.T) {
+	tests := []struct {
+		name         string
+		chart        Chart
+		placeholders []string
+		want         []string
+	}{
+		{
+			name: "real metadata",
+			chart: Chart{
+				Description: "Runs my-app on Kubernetes",
+				Home:        "https://my-app.dev",
+				Icon:        "https://my-app.dev/logo.svg",
+				Sources:     []string{"https://github.com/myorg/my-app"},
+				Maintainers: []Maintainer{{Name: "Platform", Email: "platform@my-app.dev"}},
+			},
+			placeholders: DefaultPlaceholders,
+		},
+		{
+			name: "scaffolding values",
+			chart: Chart{
+				Description: "A Helm chart for Kubernetes",
+				Home:        "https://charts.example.com",
+				Icon:        "https://EXAMPLE.com/icon.png",
+				Sources:     []string{"https://github.com/myorg/my-app", "https://example.org/src"},
+				Maintainers: []Maintainer{{Name: "Me", Email: "me@example.net"}},
+			},
+			placeholders: DefaultPlaceholders,
+			want: []string{
+				"description: A Helm chart for Kubernetes",
+				"home: https://charts.example.com",
+				"icon: https://EXAMPLE.com/icon.png",
+				"sources[1]: https://example.org/src",
+				"maintainers[0].email: me@example.net",
+			},
+		},
+		{
+			name:         "custom list",
+			chart:        Chart{Home: "https://example.com", Icon: "TODO"},
+			placeholders: []string{"todo"},
+			want:         []string{"icon: TODO"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findPlaceholders(&tt.chart, tt.placeholders)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExecutePrePublishMetadataPlaceholders(t *testing.T) {
+	chartDir := t.TempDir()
+	chartYAML := "apiVersion: v2\nname: my-app\nversion: 1.0.0\nhome: https://example.com\n"
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chartYAML), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	for _, fail := range []bool{false, true} {
+		p := &HelmPlugin{}
+		cfg := p.parseConfig(map[string]any{
+			"chart_path":            chartDir,
+			"lint":                  false,
+			"template_validate":     false,
+			"metadata_placeholders": map[string]any{"enabled": true, "fail": fail},
+			"version":               map[string]any{"update_chart": false},
+			"dependencies":          map[string]any{"update": false, "build": false},
+		})
+
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Success == fail {
+			t.Errorf("fail=%v: expected success=%v, got: %s", fail, !fail, resp.Message)
+		}
+		if fail && !strings.Contains(resp.Message, "1 placeholder value(s):\n  - home: https://example.com") {
+			t.Errorf("expected the placeholder to be named, got: %s", resp.Message)
+		}
+	}
+}
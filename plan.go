@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Plan is everything a release would do to a chart, as reported by a dry run.
+type Plan struct {
+	Chart        string     `json:"chart"`
+	Version      PlanChange `json:"version"`
+	AppVersion   PlanChange `json:"app_version"`
+	Dependencies []string   `json:"dependencies,omitempty"` // dependencies that would be updated or built
+	Steps        []string   `json:"steps"`                  // validation and publish steps, in order
+	Packages     []string   `json:"packages"`
+	PushTargets  []string   `json:"push_targets"`
+}
+
+// PlanChange is a value before and after the release. From equals To when unchanged.
+type PlanChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// String describes the change, e.g. "1.0.0 -> 1.1.0" or "1.0.0 (unchanged)".
+func (c PlanChange) String() string {
+	if c.From == c.To {
+		return c.From + " (unchanged)"
+	}
+	return c.From + " -> " + c.To
+}
+
+// newPlan builds the release plan for chart without side effects.
+func newPlan(cfg *Config, releaseCtx *plugin.ReleaseContext, chart *Chart, packages []chartPackage, pushTargets []string) *Plan {
+	plan := &Plan{
+		Chart:       chart.Name,
+		Version:     PlanChange{From: chart.Version, To: chart.Version},
+		AppVersion:  PlanChange{From: chart.AppVersion, To: chart.AppVersion},
+		PushTargets: pushTargets,
+	}
+
+	if cfg.Version.UpdateChart {
+		if version, err := cfg.Version.chartVersion(releaseCtx.Version); err == nil {
+			plan.Version.To = version
+		}
+		if appVersion, err := cfg.Version.appVersion(releaseCtx, time.Now()); err == nil && appVersion != "" {
+			plan.AppVersion.To = appVersion
+		}
+	}
+
+	if cfg.Dependencies.Update || cfg.Dependencies.Build {
+		for _, dep := range chart.Dependencies {
+			plan.Dependencies = append(plan.Dependencies, dep.Name+" "+dep.Version)
+		}
+	}
+
+	for _, step := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"dependency update", cfg.Dependencies.Update && chart.HasDependencies()},
+		{"dependency build", cfg.Dependencies.Build && chart.HasDependencies()},
+		{"values schema validation", cfg.ValidateValuesSchema},
+		{"lint", cfg.Lint},
+		{"chart policy", cfg.ChartPolicy.enabled()},
+		{"unittest", cfg.UnitTest},
+		{"template validation", cfg.TemplateValidate},
+		{"kubeconform", cfg.Kubeconform.Enabled},
+		{"image scan", cfg.ScanImages.Enabled},
+		{"sign", cfg.Sign},
+		{"provenance attestation", cfg.Provenance.Attestation},
+		{"approval", cfg.ApprovalWebhook != ""},
+		{"push", true},
+		{"github release", cfg.GitHubRelease.Enabled},
+		{"notify", cfg.Notify.URL != ""},
+	} {
+		if step.enabled {
+			plan.Steps = append(plan.Steps, step.name)
+		}
+	}
+
+	for _, pkg := range packages {
+		plan.Packages = append(plan.Packages, pkg.Path)
+	}
+	return plan
+}
+
+// String renders the plan as an indented, human-readable summary.
+func (p *Plan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan for %s:\n", p.Chart)
+	fmt.Fprintf(&b, "  version: %s\n", p.Version)
+	fmt.Fprintf(&b, "  appVersion: %s\n", p.AppVersion)
+	if len(p.Dependencies) > 0 {
+		fmt.Fprintf(&b, "  dependencies: %s\n", strings.Join(p.Dependencies, ", "))
+	}
+	fmt.Fprintf(&b, "  steps: %s\n", strings.Join(p.Steps, ", "))
+	fmt.Fprintf(&b, "  packages: %s\n", strings.Join(p.Packages, ", "))
+	fmt.Fprintf(&b, "  push: %s", strings.Join(p.PushTargets, ", "))
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestExecutePostPublishDryRunPlan(t *testing.T) {
+	chartDir := t.TempDir()
+	chartYAML := `apiVersion: v2
+name: my-app
+version: 1.1.0
+appVersion: 1.1.0
+dependencies:
+  - name: redis
+    version: 17.0.0
+    repository: https://charts.bitnami.com/bitnami
+`
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chartYAML), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	execute := func(config map[string]any) *plugin.ExecuteResponse {
+		t.Helper()
+		config["chart_path"] = chartDir
+		config["repository"] = map[string]any{"url": "oci://ghcr.io/myorg/charts"}
+		p := &HelmPlugin{}
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPostPublish,
+			DryRun:  true,
+			Config:  config,
+			Context: plugin.ReleaseContext{Version: "1.2.0", CommitSHA: "0123456789abcdef"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.Success {
+			t.Fatalf("expected success, got: %s", resp.Message)
+		}
+		return resp
+	}
+
+	resp := execute(map[string]any{
+		"version":           map[string]any{"app_version_format": "{{.Version}}-{{.ShortSHA}}"},
+		"template_validate": false,
+	})
+	want := `Plan for my-app:
+  version: 1.1.0 -> 1.2.0
+  appVersion: 1.1.0 -> 1.2.0-0123456
+  dependencies: redis 17.0.0
+  steps: dependency update, dependency build, lint, push
+  packages: .helm-packages/my-app-1.2.0.tgz
+  push: oci://ghcr.io/myorg/charts/my-app:1.2.0`
+	if !strings.HasSuffix(resp.Message, "\n"+want) {
+		t.Errorf("expected message to end with the plan:\n%s\ngot:\n%s", want, resp.Message)
+	}
+	if plan, ok := resp.Outputs["plan"].(*Plan); !ok || plan.Version.To != "1.2.0" {
+		t.Errorf("expected plan output, got %#v", resp.Outputs["plan"])
+	}
+
+	// Versions left alone show as unchanged, and JSON output carries the plan
+	resp = execute(map[string]any{
+		"version":                map[string]any{"update_chart": false},
+		"allow_version_mismatch": true,
+		"dependencies":           map[string]any{"update": false, "build": false},
+		"output_format":          "json",
+	})
+	var report struct {
+		Plan Plan `json:"plan"`
+	}
+	if err := json.Unmarshal([]byte(resp.Message), &report); err != nil {
+		t.Fatalf("expected JSON message, got %q: %v", resp.Message, err)
+	}
+	if report.Plan.Version.String() != "1.1.0 (unchanged)" || len(report.Plan.Dependencies) != 0 || report.Plan.Packages[0] != ".helm-packages/my-app-1.1.0.tgz" {
+		t.Errorf("unexpected plan: %+v", report.Plan)
+	}
+}
@@ -2,12 +2,22 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
 	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
 	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
@@ -18,33 +28,180 @@ var Version = "0.1.0"
 
 // Config represents Helm plugin configuration.
 type Config struct {
-	ChartPath        string           `json:"chart_path"`
-	Repository       RepositoryConfig `json:"repository"`
-	Version          VersionConfig    `json:"version"`
-	Lint             bool             `json:"lint"`
-	LintStrict       bool             `json:"lint_strict"`
-	TemplateValidate bool             `json:"template_validate"`
-	Test             bool             `json:"test"`
-	KubeVersion      string           `json:"kube_version"`
-	APIVersions      []string         `json:"api_versions"`
-	Dependencies     DependencyConfig `json:"dependencies"`
-	Sign             bool             `json:"sign"`
-	SignKey          string           `json:"sign_key"`
-	Keyring          string           `json:"keyring"`
-	PassphraseFile   string           `json:"passphrase_file"`
-	OutputDir        string           `json:"output_dir"`
-	ContextPath      string           `json:"context_path"`
-	DryRun           bool             `json:"dry_run"`
+	ChartPath                string              `json:"chart_path"`
+	ChartPaths               []string            `json:"chart_paths"`       // glob patterns, e.g. charts/*
+	ChartGit                 ChartGitConfig      `json:"chart_git"`         // clone the chart from git instead
+	StrictNameCheck          bool                `json:"strict_name_check"` // fail, rather than warn, when the chart directory isn't named after the chart
+	Repository               RepositoryConfig    `json:"repository"`
+	Repositories             []RepositoryConfig  `json:"repositories"`
+	AllowedRepositoryHosts   []string            `json:"allowed_repository_hosts"` // e.g. ghcr.io, *.internal.example.com
+	FailFast                 bool                `json:"fail_fast"`
+	FailIfExists             bool                `json:"fail_if_exists"`    // fail, rather than skip, when the version is already published
+	AtomicMultiPush          bool                `json:"atomic_multi_push"` // check auth to every repository before pushing to any
+	LoginConcurrency         int                 `json:"login_concurrency"`
+	Concurrency              int                 `json:"concurrency"`      // charts packaged and repositories pushed to at once
+	ApprovalWebhook          string              `json:"approval_webhook"` // POSTed chart metadata before pushing
+	CommandTimeout           string              `json:"command_timeout"`  // duration bounding each helm command, e.g. "5m"
+	PackageRetries           int                 `json:"package_retries"`  // retries after transient dependency fetch failures
+	Metrics                  MetricsConfig       `json:"metrics"`
+	MinHelmVersion           string              `json:"min_helm_version"`
+	HelmBinary               string              `json:"helm_binary"` // helm executable, by name or path
+	HelmEnv                  map[string]string   `json:"helm_env"`    // merged into the environment of every helm command
+	Mirror                   MirrorConfig        `json:"mirror"`
+	GitHubRelease            GitHubReleaseConfig `json:"github_release"`
+	Provenance               ProvenanceConfig    `json:"provenance"` // in-toto attestation written for each package
+	Notify                   NotifyConfig        `json:"notify"`
+	Environments             map[string]string   `json:"environments"` // environment name -> values file
+	EnvSchemas               map[string]string   `json:"env_schemas"`  // environment name -> JSON schema for merged values
+	Version                  VersionConfig       `json:"version"`
+	RequireVersion           bool                `json:"require_version"`        // fail, rather than release the chart's own version, without a release version
+	AllowVersionMismatch     bool                `json:"allow_version_mismatch"` // warn, rather than fail, when Chart.yaml isn't updated and differs from the release version
+	Lint                     bool                `json:"lint"`
+	LintStrict               bool                `json:"lint_strict"`
+	LintIgnore               []string            `json:"lint_ignore"` // regexes matched against full lint message lines
+	MetadataPlaceholders     PlaceholderConfig   `json:"metadata_placeholders"`
+	ChartPolicy              ChartPolicy         `json:"chart_policy"`
+	UnitTest                 bool                `json:"unittest"` // run helm-unittest suites
+	TemplateValidate         bool                `json:"template_validate"`
+	ValidateValuesSchema     bool                `json:"validate_values_schema"`
+	ValidateValuesExample    bool                `json:"validate_values_example"` // compare values.yaml types with values.example.yaml
+	Test                     bool                `json:"test"`
+	KubeVersion              string              `json:"kube_version"`
+	ForbidHardcodedNamespace bool                `json:"forbid_hardcoded_namespace"`
+	DiscourageInlineSecrets  bool                `json:"discourage_inline_secrets"`
+	FailOnEmptyTemplate      bool                `json:"fail_on_empty_template"`
+	Kubeconform              KubeconformConfig   `json:"kubeconform"`
+	ScanImages               ImageScanConfig     `json:"scan_images"`
+	ImagePolicy              ImagePolicyConfig   `json:"image_policy"`
+	ValidateCRConsistency    bool                `json:"validate_cr_consistency"` // custom resources must match the chart's CRDs
+	TemplateIncludeCRDs      bool                `json:"template_include_crds"`   // render crds/ with the templates and check its files
+	TemplateOutput           string              `json:"template_output"`         // file to save rendered manifests to
+	TemplateNamespace        string              `json:"template_namespace"`      // .Release.Namespace templates are rendered with
+	APIVersions              []string            `json:"api_versions"`
+	TemplateValues           []string            `json:"template_values"` // values files rendered with during validation
+	TemplateSet              map[string]string   `json:"template_set"`    // --set overrides rendered with during validation
+	RenderMatrix             []map[string]string `json:"render_matrix"`   // extra --set combinations rendered during validation
+	ValuesUpdates            map[string]string   `json:"values_updates"`  // values.yaml paths set from templates, e.g. image.tag
+	ValuesUpdatesCreate      bool                `json:"values_updates_create"`
+	ChartAnnotations         map[string]string   `json:"chart_annotations"`  // Chart.yaml annotations set from templates, e.g. artifacthub.io/changes
+	ReleaseNotes             string              `json:"release_notes"`      // notes file; defaults to the release's generated notes
+	ReleaseNotesMode         string              `json:"release_notes_mode"` // readme_append, annotation, file
+	Dependencies             DependencyConfig    `json:"dependencies"`
+	LicenseAllowlist         []string            `json:"license_allowlist"`       // allowed dependency licenses, e.g. Apache-2.0
+	RunHelmDocs              bool                `json:"run_helm_docs"`           // regenerate README.md before packaging
+	HelmDocsFailOnError      bool                `json:"helm_docs_fail_on_error"` // fail when helm-docs is missing or errors
+	Sign                     bool                `json:"sign"`
+	SignKey                  string              `json:"sign_key"`
+	SignMode                 string              `json:"sign_mode"` // gpg, cosign
+	CosignKey                string              `json:"cosign_key"`
+	CosignKeyless            bool                `json:"cosign_keyless"`
+	Keyring                  string              `json:"keyring"`
+	PassphraseFile           string              `json:"passphrase_file"`
+	SignKeyEnv               string              `json:"sign_key_env"` // env var holding a base64-encoded GPG secret key
+	OutputDir                string              `json:"output_dir"`
+	OCIAnnotations           bool                `json:"oci_annotations"` // write OpenContainers annotations into OCI packages
+	OutputFilename           string              `json:"output_filename"` // package file name template, e.g. {{.Name}}-{{.Environment}}-{{.Version}}.tgz
+	PackagePath              string              `json:"package_path"`    // pre-built package to push instead of packaging
+	ContextPath              string              `json:"context_path"`
+	DebugTimings             bool                `json:"debug_timings"`    // report per-step durations in the outputs
+	OutputFormat             string              `json:"output_format"`    // text, json
+	MessageTemplate          string              `json:"message_template"` // Go template for the hook response message
+	DryRun                   bool                `json:"dry_run"`
 }
 
 // RepositoryConfig defines repository settings.
 type RepositoryConfig struct {
-	Type           string `json:"type"` // oci, http, chartmuseum
+	Type           string `json:"type"` // oci, http, chartmuseum, artifactory, cloudsmith, s3, gcs
 	URL            string `json:"url"`
 	Name           string `json:"name"`
 	Username       string `json:"username"`
 	Password       string `json:"password"`
 	RegistryConfig string `json:"registry_config"`
+	HealthCheck    bool   `json:"health_check"`
+	HealthPath     string `json:"health_path"` // defaults to /health for chartmuseum
+	RetainVersions int    `json:"retain_versions"`
+	Prune          bool   `json:"prune"`
+	// RetentionKeep deletes all but the newest RetentionKeep versions after each
+	// publish; versions created within RetentionWindow (e.g. "720h") are kept too.
+	RetentionKeep   int    `json:"retention_keep"`
+	RetentionWindow string `json:"retention_window"`
+	AuthMode        string `json:"auth_mode"` // static, ecr
+	// Credential sources, resolved at execute time with precedence file > env > literal.
+	UsernameEnv  string `json:"username_env"`
+	UsernameFile string `json:"username_file"`
+	PasswordEnv  string `json:"password_env"`
+	PasswordFile string `json:"password_file"`
+	// CredentialCommand is run to obtain credentials as JSON
+	// {"username": "...", "password": "...", "token": "..."}.
+	CredentialCommand string `json:"credential_command"`
+	VerifyAfterPush   bool   `json:"verify_after_push"` // pull the chart back and compare digests (oci only)
+	Reindex           bool   `json:"reindex"`           // regenerate the bucket index after push (s3 only)
+	// APIKey and Token authenticate artifactory and cloudsmith repositories
+	// instead of a username and password: the API key in the provider's header
+	// (X-JFrog-Art-Api or X-Api-Key), the token as a bearer token.
+	APIKey    string `json:"api_key"`
+	APIKeyEnv string `json:"api_key_env"`
+	Token     string `json:"token"`
+	TokenEnv  string `json:"token_env"`
+	// Index regenerates index.yaml after each upload to an http repository.
+	Index bool `json:"index"`
+	// IndexLocation publishes the bucket's index.yaml to a separate bucket path
+	// or HTTP location after each push, for indexes served from another host.
+	// For http repositories with index it is where index.yaml is read and written.
+	IndexLocation string `json:"index_location"`
+	// CAFile is a PEM bundle trusted in addition to the system roots, and
+	// Insecure skips certificate verification, for registries with private CAs.
+	CAFile   string `json:"ca_file"`
+	Insecure bool   `json:"insecure"`
+	// Method overrides the upload method for http (default PUT) and
+	// chartmuseum (default POST) repositories.
+	Method string `json:"method"`
+	// UploadFormat is how http and chartmuseum uploads carry the package: "raw"
+	// sends it as the request body, "multipart" as multipart/form-data with the
+	// package under UploadField (default "chart") and a provenance file, if
+	// any, under "prov", as Harbor and Nexus expect.
+	UploadFormat string `json:"upload_format"`
+	UploadField  string `json:"upload_field"`
+	// Overwrite replaces an existing chart version (chartmuseum, via
+	// ?force=true) or moves existing additional tags (oci).
+	Overwrite bool `json:"overwrite"`
+	// AdditionalTags are templates for moving tags the chart version is also
+	// published under after the push, e.g. "{{.Major}}", "{{.Major}}.{{.Minor}}"
+	// or "latest" (oci only, requires crane or oras).
+	AdditionalTags []string `json:"additional_tags"`
+	// PreflightMediaCheck probes the registry before pushing and warns if it may
+	// not accept Helm OCI artifacts (oci only).
+	PreflightMediaCheck bool `json:"preflight_media_check"`
+	// OCITagBuildMetadata controls versions with build metadata on OCI registries:
+	// "underscore" pushes 1.2.3+build.7 as tag 1.2.3_build.7 (helm's behavior),
+	// "reject" fails the publish instead.
+	OCITagBuildMetadata string `json:"oci_tag_build_metadata"`
+	// StripChartName pushes to a URL ending in the chart name as-is, instead of
+	// helm appending the chart name a second time (oci only).
+	StripChartName bool `json:"strip_chart_name"`
+	// UpdateStablePointer maintains a stable.txt next to the packages naming the
+	// newest non-prerelease version (http, s3 and gcs only).
+	UpdateStablePointer bool `json:"update_stable_pointer"`
+	// Annotations override the OCI annotations written into the packaged Chart.yaml.
+	Annotations map[string]string `json:"annotations"`
+	// Transport timeouts for http and chartmuseum uploads, e.g. "10s". Setting
+	// any of them replaces the blanket request timeout.
+	DialTimeout           string `json:"dial_timeout"`
+	TLSHandshakeTimeout   string `json:"tls_handshake_timeout"`
+	ResponseHeaderTimeout string `json:"response_header_timeout"`
+	// HTTPTimeout bounds API requests such as listing, deleting and health
+	// checks, and UploadTimeout bounds chart uploads (defaults vary per request,
+	// e.g. 60s for ChartMuseum and 120s for HTTP uploads).
+	HTTPTimeout   string `json:"http_timeout"`
+	UploadTimeout string `json:"upload_timeout"`
+	// MaxIdleConns caps the idle connections kept open to the repository.
+	MaxIdleConns int `json:"max_idle_conns"`
+	// WaitAvailable polls the repository after each push, every WaitInterval
+	// (default 5s) for up to WaitTimeout (default 2m), until it serves the
+	// pushed version (oci and chartmuseum only).
+	WaitAvailable bool   `json:"wait_available"`
+	WaitTimeout   string `json:"wait_timeout"`
+	WaitInterval  string `json:"wait_interval"`
 }
 
 // VersionConfig defines version update settings.
@@ -52,16 +209,40 @@ type VersionConfig struct {
 	UpdateChart      bool   `json:"update_chart"`
 	UpdateAppVersion bool   `json:"update_app_version"`
 	AppVersionFormat string `json:"app_version_format"`
+	// AppVersionPattern is a regex the computed appVersion must fully match.
+	AppVersionPattern string `json:"app_version_pattern"`
+	// StripPrerelease and StripBuildMetadata drop those semver components from
+	// the chart version. appVersion is unaffected.
+	StripPrerelease    bool `json:"strip_prerelease"`
+	StripBuildMetadata bool `json:"strip_build_metadata"`
 }
 
 // DependencyConfig defines dependency management settings.
 type DependencyConfig struct {
-	Update bool `json:"update"`
-	Build  bool `json:"build"`
+	Update     bool `json:"update"`
+	Build      bool `json:"build"`
+	VerifyLock bool `json:"verify_lock"`
+	// ValidateNames fails if a dependency name or alias is not a DNS-1123 label.
+	ValidateNames bool `json:"validate_names"`
+	Verify        bool `json:"verify"` // verify dependency provenance against keyring
+	// CheckRepos confirms dependency repositories are reachable before update/build.
+	CheckRepos        bool   `json:"check_repos"`
+	CheckReposTimeout string `json:"check_repos_timeout"` // per repository, e.g. "10s"
+	// AutoAddRepos adds http(s) dependency repositories with helm repo add
+	// before update and build, and CleanupRepos removes them again afterwards.
+	AutoAddRepos bool `json:"auto_add_repos"`
+	CleanupRepos bool `json:"cleanup_repos"`
+	// PackageUpdate updates dependencies while packaging, with helm package
+	// --dependency-update; PackageRetries applies to it.
+	PackageUpdate bool `json:"package_update"`
 }
 
 // HelmPlugin implements the Helm chart plugin.
-type HelmPlugin struct{}
+type HelmPlugin struct {
+	// metrics accumulates across executions for long-running plugin processes.
+	metricsOnce sync.Once
+	metrics     *Metrics
+}
 
 // GetInfo returns plugin metadata.
 func (p *HelmPlugin) GetInfo() plugin.Info {
@@ -82,40 +263,242 @@ func (p *HelmPlugin) Validate(ctx context.Context, config map[string]any) (*plug
 	vb := helpers.NewValidationBuilder()
 
 	// Check Helm installation
-	helmVersion, err := getHelmVersion()
-	if err != nil {
-		vb.AddError("helm", "Helm CLI not found in PATH")
+	helmField := "helm"
+	if cfg.HelmBinary != "helm" {
+		helmField = "helm_binary"
+	}
+	var helmVersion string
+	if _, err := exec.LookPath(cfg.HelmBinary); err != nil {
+		if cfg.HelmBinary == "helm" {
+			vb.AddError(helmField, "Helm CLI not found in PATH")
+		} else {
+			vb.AddError(helmField, fmt.Sprintf("Helm binary not found or not executable: %s", cfg.HelmBinary))
+		}
+	} else if helmVersion, err = getHelmVersion(cfg.helmBinary()); err != nil {
+		vb.AddError(helmField, fmt.Sprintf("Failed to run %s version: %v", cfg.HelmBinary, err))
 	} else if !strings.HasPrefix(helmVersion, "v3") {
-		vb.AddError("helm", "Helm 3.x required for OCI support")
+		vb.AddError(helmField, "Helm 3.x required for OCI support")
+	} else if cfg.MinHelmVersion != "" {
+		if err := checkMinHelmVersion(helmVersion, cfg.MinHelmVersion); err != nil {
+			vb.AddError("min_helm_version", err.Error())
+		}
 	}
 
 	// Check chart exists
-	chartPath := cfg.ChartPath
-	if chartPath == "" {
-		chartPath = "."
+	var warnings []string
+	if cfg.Mirror.Enabled {
+		// Mirroring republishes an existing chart; there are no local sources
+		validateMirrorConfig(vb, cfg.Mirror)
+	} else if cfg.PackagePath != "" {
+		// A pre-built package is pushed as-is; chart sources aren't needed
+		validatePackagePath(vb, cfg)
+	} else if cfg.ChartGit.URL != "" {
+		// The chart is cloned at execute time; clone it now to check it
+		warnings = append(warnings, validateChartGit(ctx, vb, cfg)...)
+	} else if len(cfg.ChartPaths) == 0 {
+		warnings = append(warnings, validateChartPath(vb, "chart_path", cfg.chartPath(), cfg.StrictNameCheck)...)
+	} else if chartPaths, err := resolveChartPaths(cfg.ChartPaths); err != nil {
+		vb.AddError("chart_paths", err.Error())
+	} else {
+		for _, chartPath := range chartPaths {
+			warnings = append(warnings, validateChartPath(vb, "chart_paths", chartPath, cfg.StrictNameCheck)...)
+		}
 	}
 
-	chartFile := filepath.Join(chartPath, "Chart.yaml")
-	if _, err := os.Stat(chartFile); os.IsNotExist(err) {
-		vb.AddError("chart_path", "Chart.yaml not found")
-	} else {
-		// Validate chart
-		chart, err := ParseChart(chartPath)
-		if err != nil {
-			vb.AddError("chart_path", fmt.Sprintf("Invalid Chart.yaml: %v", err))
-		} else if chart.Name == "" {
-			vb.AddError("chart_path", "Chart name is required")
+	if cfg.ChartGit.URL == "" && (cfg.ChartGit.Ref != "" || cfg.ChartGit.Subdir != "") {
+		vb.AddError("chart_git.url", "Repository URL is required to clone the chart")
+	}
+
+	if cfg.RunHelmDocs && cfg.HelmDocsFailOnError {
+		if _, err := exec.LookPath("helm-docs"); err != nil {
+			vb.AddError("run_helm_docs", "helm-docs not found in PATH (required to generate chart docs)")
+		}
+	}
+
+	if cfg.Kubeconform.Enabled {
+		if _, err := exec.LookPath("kubeconform"); err != nil {
+			vb.AddError("kubeconform", "kubeconform not found in PATH (required for schema validation)")
+		}
+	}
+
+	if cfg.ScanImages.Enabled {
+		if cfg.ScanImages.Source != "manifests" && cfg.ScanImages.Source != "values" {
+			vb.AddError("scan_images.source", fmt.Sprintf("Unsupported image source: %s (expected manifests or values)", cfg.ScanImages.Source))
+		}
+		if cfg.ScanImages.severities() == nil {
+			vb.AddError("scan_images.severity", fmt.Sprintf("Unsupported severity: %s (expected one of %s)", cfg.ScanImages.Severity, strings.Join(severityLevels, ", ")))
+		}
+		if _, err := exec.LookPath("trivy"); err != nil {
+			vb.AddError("scan_images", "trivy not found in PATH (required for image scanning)")
+		}
+	}
+
+	if cfg.Dependencies.Verify {
+		if cfg.Keyring == "" {
+			vb.AddError("dependencies.verify", "keyring is required to verify dependency provenance")
+		} else if _, err := os.Stat(cfg.Keyring); err != nil {
+			vb.AddError("keyring", fmt.Sprintf("Keyring not found: %s", cfg.Keyring))
+		}
+	}
+
+	if cfg.Dependencies.CheckReposTimeout != "" {
+		if d, err := time.ParseDuration(cfg.Dependencies.CheckReposTimeout); err != nil || d <= 0 {
+			vb.AddError("dependencies.check_repos_timeout", fmt.Sprintf("Invalid duration: %s", cfg.Dependencies.CheckReposTimeout))
+		}
+	}
+	if cfg.Dependencies.CleanupRepos && !cfg.Dependencies.AutoAddRepos {
+		vb.AddError("dependencies.cleanup_repos", "cleanup_repos removes repositories added by auto_add_repos, which is disabled")
+	}
+
+	switch cfg.OutputFormat {
+	case OutputFormatText, OutputFormatJSON:
+	default:
+		vb.AddError("output_format", fmt.Sprintf("Unsupported output format: %s", cfg.OutputFormat))
+	}
+	if cfg.MessageTemplate != "" {
+		chart := messageData{Success: true, Message: "Published my-app-1.2.3.tgz", ChartName: "my-app", Version: "1.2.3", AppVersion: "1.2.3"}
+		sample := chart
+		sample.Hook = string(plugin.HookPostPublish)
+		sample.Charts = []messageData{chart}
+		if _, err := renderMessage(cfg.MessageTemplate, sample); err != nil {
+			vb.AddError("message_template", err.Error())
+		}
+	}
+
+	if cfg.CommandTimeout != "" {
+		if d, err := time.ParseDuration(cfg.CommandTimeout); err != nil || d <= 0 {
+			vb.AddError("command_timeout", fmt.Sprintf("Invalid duration: %s", cfg.CommandTimeout))
+		}
+	}
+
+	if cfg.UnitTest {
+		validateHelmPlugin(vb, cfg.helmBinary(), "unittest", "unittest")
+	}
+
+	if cfg.Notify.URL != "" {
+		switch cfg.Notify.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			vb.AddError("notify.method", fmt.Sprintf("Unsupported notify method: %s (use POST, PUT or PATCH)", cfg.Notify.Method))
+		}
+	}
+
+	if cfg.GitHubRelease.Enabled && cfg.GitHubRelease.Token == "" && os.Getenv("GITHUB_TOKEN") == "" {
+		vb.AddError("github_release.token", "A token or GITHUB_TOKEN is required to upload GitHub release assets")
+	}
+
+	if cfg.Provenance.Push {
+		if !cfg.Provenance.Attestation {
+			vb.AddError("provenance.push", "Pushing attestations requires provenance.attestation")
+		}
+		if _, err := exec.LookPath("oras"); err != nil {
+			vb.AddError("provenance.push", "oras not found in PATH (required to push attestations)")
+		}
+	}
+
+	if cfg.PackageRetries < 0 {
+		vb.AddError("package_retries", "package_retries must not be negative")
+	} else if cfg.PackageRetries > 0 && !cfg.Dependencies.PackageUpdate {
+		vb.AddError("package_retries", "package_retries retries dependency fetches while packaging, which requires dependencies.package_update")
+	}
+
+	if cfg.LoginConcurrency < 1 {
+		vb.AddError("login_concurrency", "login_concurrency must be at least 1")
+	}
+
+	if cfg.Concurrency < 1 {
+		vb.AddError("concurrency", "concurrency must be at least 1")
+	}
+
+	if cfg.Version.AppVersionFormat != "" {
+		if _, err := renderAppVersion(cfg.Version.AppVersionFormat, appVersionData{}); err != nil {
+			vb.AddError("version.app_version_format", err.Error())
+		}
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(cfg.ValuesUpdates)) {
+		if _, err := renderTemplate("values_updates."+path, cfg.ValuesUpdates[path], appVersionData{}); err != nil {
+			vb.AddError("values_updates."+path, err.Error())
+		}
+	}
+
+	if cfg.OutputFilename != "" {
+		sample := outputFilenameData{Name: "my-app", Version: "1.2.3", AppVersion: "1.2.3", Environment: "prod", Date: "2006-01-02"}
+		if _, err := renderOutputFilename(cfg.OutputFilename, sample); err != nil {
+			vb.AddError("output_filename", err.Error())
+		}
+		if cfg.Sign && cfg.SignMode == "gpg" {
+			vb.AddError("output_filename", "output_filename can't be combined with gpg signing: the provenance file signs the original package name, so helm verify fails on the renamed package")
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(cfg.ChartAnnotations)) {
+		if _, err := renderTemplate("chart_annotations."+key, cfg.ChartAnnotations[key], appVersionData{}); err != nil {
+			vb.AddError("chart_annotations."+key, err.Error())
+		}
+	}
+
+	switch cfg.ReleaseNotesMode {
+	case releaseNotesReadmeAppend, releaseNotesAnnotation, releaseNotesFileMode:
+	case "":
+		if cfg.ReleaseNotes != "" {
+			vb.AddError("release_notes_mode", "release_notes requires release_notes_mode (readme_append, annotation or file)")
+		}
+	default:
+		vb.AddError("release_notes_mode", fmt.Sprintf("Unsupported release notes mode: %s (expected readme_append, annotation or file)", cfg.ReleaseNotesMode))
+	}
+
+	if cfg.Version.AppVersionPattern != "" {
+		if _, err := regexp.Compile(cfg.Version.AppVersionPattern); err != nil {
+			vb.AddError("version.app_version_pattern", fmt.Sprintf("Invalid regex: %v", err))
+		}
+	}
+
+	if _, err := compileLintIgnore(cfg.LintIgnore); err != nil {
+		vb.AddError("lint_ignore", err.Error())
+	}
+
+	if cfg.Sign {
+		validateSigning(vb, cfg)
+	}
+
+	// Check environment values files
+	for _, env := range slices.Sorted(maps.Keys(cfg.Environments)) {
+		if _, err := os.Stat(cfg.Environments[env]); err != nil {
+			vb.AddError("environments."+env, fmt.Sprintf("Values file not found: %s", cfg.Environments[env]))
+		}
+	}
+	for _, env := range slices.Sorted(maps.Keys(cfg.EnvSchemas)) {
+		if _, ok := cfg.Environments[env]; !ok {
+			vb.AddError("env_schemas."+env, fmt.Sprintf("No environment named %s is configured", env))
+		} else if _, err := os.Stat(cfg.EnvSchemas[env]); err != nil {
+			vb.AddError("env_schemas."+env, fmt.Sprintf("Schema file not found: %s", cfg.EnvSchemas[env]))
+		}
+	}
+	for _, file := range cfg.TemplateValues {
+		if _, err := os.Stat(file); err != nil {
+			vb.AddError("template_values", fmt.Sprintf("Values file not found: %s", file))
 		}
 	}
 
 	// Check repository configuration
-	if cfg.Repository.URL == "" {
-		vb.AddError("repository.url", "Repository URL is required")
+	if cfg.Repository.URL != "" || len(cfg.Repositories) == 0 {
+		validateRepositoryConfig(vb, "repository", cfg.Repository, cfg.helmBinary(), helmVersion)
+		validateRepositoryHost(vb, "repository", cfg.Repository, cfg.AllowedRepositoryHosts)
+		validateRepositoryAnnotations(vb, "repository", cfg.Repository, cfg.OCIAnnotations)
+		warnings = append(warnings, credentialSourceWarnings("repository", cfg.Repository)...)
+	}
+	for i, repo := range cfg.Repositories {
+		field := fmt.Sprintf("repositories[%d]", i)
+		validateRepositoryConfig(vb, field, repo, cfg.helmBinary(), helmVersion)
+		validateRepositoryHost(vb, field, repo, cfg.AllowedRepositoryHosts)
+		validateRepositoryAnnotations(vb, field, repo, cfg.OCIAnnotations)
+		warnings = append(warnings, credentialSourceWarnings(field, repo)...)
 	}
 
-	// For OCI, verify Helm version supports it
-	if cfg.Repository.Type == "oci" && err == nil && !strings.HasPrefix(helmVersion, "v3") {
-		vb.AddError("repository.type", "OCI requires Helm 3.x")
+	// The validation response has no warnings, so log them instead
+	for _, w := range warnings {
+		slog.Default().Warn("Configuration warning", "plugin", "helm", "warning", w)
 	}
 
 	return vb.Build(), nil
@@ -127,28 +510,71 @@ func (p *HelmPlugin) Execute(ctx context.Context, req plugin.ExecuteRequest) (*p
 	cfg.DryRun = cfg.DryRun || req.DryRun
 	logger := slog.Default().With("plugin", "helm", "hook", req.Hook)
 
+	var resp *plugin.ExecuteResponse
+	var err error
 	switch req.Hook {
 	case plugin.HookPrePublish:
-		return p.executePrePublish(ctx, &req.Context, cfg, logger)
+		resp, err = p.executePrePublish(ctx, &req.Context, cfg, logger)
 	case plugin.HookPostPublish:
-		return p.executePostPublish(ctx, &req.Context, cfg, logger)
+		resp, err = p.executePostPublish(ctx, &req.Context, cfg, logger)
 	default:
 		return &plugin.ExecuteResponse{
 			Success: true,
 			Message: fmt.Sprintf("Hook %s not handled by helm plugin", req.Hook),
 		}, nil
 	}
+
+	if flushErr := p.metricsFor(cfg).Flush(ctx, cfg.Metrics); flushErr != nil {
+		logger.Warn("Failed to export metrics", "error", flushErr)
+	}
+	if resp != nil && cfg.MessageTemplate != "" {
+		if message, renderErr := renderMessage(cfg.MessageTemplate, newMessageData(req.Hook, resp, cfg.DryRun)); renderErr != nil {
+			logger.Warn("Failed to render message_template, keeping the default message", "error", renderErr)
+		} else {
+			resp.Message = message
+		}
+	}
+	formatResponse(resp, cfg.OutputFormat)
+	return resp, err
 }
 
-func (p *HelmPlugin) executePrePublish(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
-	version := releaseCtx.Version
-	logger = logger.With("version", version)
+// metricsFor returns the plugin's metrics registry, or nil when metrics are disabled.
+func (p *HelmPlugin) metricsFor(cfg *Config) *Metrics {
+	if !cfg.Metrics.Enabled {
+		return nil
+	}
+	p.metricsOnce.Do(func() {
+		p.metrics = NewMetrics()
+	})
+	return p.metrics
+}
 
-	chartPath := cfg.ChartPath
-	if chartPath == "" {
-		chartPath = "."
+func (p *HelmPlugin) executePrePublish(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	if resp := checkReleaseVersion(releaseCtx, cfg); resp != nil {
+		return resp, nil
+	}
+	if cfg.Mirror.Enabled {
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: "Mirror mode enabled, skipping chart validation",
+		}, nil
+	}
+	if cfg.PackagePath != "" {
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: "Pre-built package configured, skipping chart validation",
+		}, nil
+	}
+	run := newChartRun(cfg)
+	if cfg.ChartGit.URL != "" {
+		return p.withChartGit(ctx, releaseCtx, cfg, run, logger, p.prePublishChart)
 	}
 
+	return p.forEachChart(ctx, releaseCtx, cfg, run, logger, p.prePublishChart)
+}
+
+// prePublishChart updates and validates a single chart.
+func (p *HelmPlugin) prePublishChart(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, run *chartRun, chartPath string, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
 	// Parse chart to get name
 	chart, err := ParseChart(chartPath)
 	if err != nil {
@@ -158,294 +584,2412 @@ func (p *HelmPlugin) executePrePublish(ctx context.Context, releaseCtx *plugin.R
 		}, nil
 	}
 
-	logger = logger.With("chart", chart.Name)
+	if strings.TrimSpace(releaseCtx.Version) == "" {
+		logger.Info("No release version provided, using the chart's existing version", "chart", chart.Name, "version", chart.Version)
+		releaseCtx, cfg = useChartVersion(releaseCtx, cfg, chart)
+	}
+	version := releaseCtx.Version
+	logger = logger.With("version", version, "chart", chart.Name)
+
+	if err := checkChartVersion(chart, version, cfg.Version); err != nil {
+		if !cfg.AllowVersionMismatch {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: err.Error(),
+			}, nil
+		}
+		logger.Warn("Chart version does not match the release version", "chartVersion", chart.Version)
+	}
+
+	if mismatch := chartNameMismatch(chartPath, chart); mismatch != "" {
+		if cfg.StrictNameCheck {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: mismatch,
+			}, nil
+		}
+		logger.Warn(mismatch)
+	}
 
 	helm := NewHelmCLI(chartPath)
+	helm.SetHelmBinary(cfg.helmBinary())
+	helm.SetTimeout(cfg.commandTimeout())
+	if cfg.Dependencies.Verify {
+		helm.SetDependencyVerify(cfg.Keyring)
+	}
+	steps := newStepTimings(logger, p.metricsFor(cfg), cfg.DebugTimings)
 
 	// Update version in Chart.yaml
 	if cfg.Version.UpdateChart {
 		logger.Info("Updating version in Chart.yaml")
-		appVersion := ""
-		if cfg.Version.UpdateAppVersion {
-			appVersion = version
-			if cfg.Version.AppVersionFormat != "" {
-				appVersion = strings.ReplaceAll(cfg.Version.AppVersionFormat, "{{.Version}}", version)
+		chartVersion, err := cfg.Version.chartVersion(version)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Release version %q is not a valid semantic version", version),
+			}, nil
+		}
+		appVersion, err := cfg.Version.appVersion(releaseCtx, time.Now())
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to render appVersion: %v", err),
+			}, nil
+		}
+		if appVersion != "" && cfg.Version.AppVersionPattern != "" {
+			if err := checkAppVersion(appVersion, cfg.Version.AppVersionPattern); err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Invalid appVersion: %v", err),
+				}, nil
 			}
 		}
 
 		if cfg.DryRun {
-			logger.Info("[DRY-RUN] Would update Chart.yaml", "from", chart.Version, "to", version, "appVersion", appVersion)
+			logger.Info("[DRY-RUN] Would update Chart.yaml", "from", chart.Version, "to", chartVersion, "appVersion", appVersion)
 		} else {
-			if err := UpdateChartVersion(chartPath, version, appVersion); err != nil {
+			if err := UpdateChartVersion(chartPath, chartVersion, appVersion); err != nil {
 				return &plugin.ExecuteResponse{
 					Success: false,
 					Message: fmt.Sprintf("Failed to update Chart.yaml version: %v", err),
 				}, nil
 			}
 		}
+		chart.Version = chartVersion
+		if appVersion != "" {
+			chart.AppVersion = appVersion
+		}
 	}
 
-	// Update dependencies
-	if cfg.Dependencies.Update {
-		logger.Info("Updating chart dependencies")
-		if cfg.DryRun {
-			logger.Info("[DRY-RUN] Would run helm dependency update")
-		} else {
-			if err := helm.DependencyUpdate(ctx); err != nil {
+	if len(cfg.ValuesUpdates) > 0 {
+		logger.Info("Updating values.yaml")
+		data := newAppVersionData(releaseCtx, time.Now())
+		updates := make(map[string]string, len(cfg.ValuesUpdates))
+		for _, path := range slices.Sorted(maps.Keys(cfg.ValuesUpdates)) {
+			value, err := renderTemplate("values_updates."+path, cfg.ValuesUpdates[path], data)
+			if err != nil {
 				return &plugin.ExecuteResponse{
 					Success: false,
-					Message: fmt.Sprintf("Failed to update dependencies: %v", err),
+					Message: fmt.Sprintf("Failed to render values update: %v", err),
 				}, nil
 			}
+			updates[path] = value
+		}
+
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would update values.yaml", "values", updates)
+		} else if err := UpdateValuesFile(chartPath, updates, cfg.ValuesUpdatesCreate); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to update values.yaml: %v", err),
+			}, nil
 		}
 	}
 
-	// Build dependencies
-	if cfg.Dependencies.Build {
-		logger.Info("Building chart dependencies")
+	if cfg.ReleaseNotesMode != "" {
+		notes, err := loadReleaseNotes(cfg.ReleaseNotes, releaseCtx)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to load release notes: %v", err),
+			}, nil
+		}
+
+		if notes == "" {
+			logger.Warn("No release notes to add to the chart")
+		} else if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would add release notes to the chart", "mode", cfg.ReleaseNotesMode)
+		} else if err := writeReleaseNotes(chartPath, cfg.ReleaseNotesMode, version, notes); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to add release notes: %v", err),
+			}, nil
+		} else {
+			logger.Info("Added release notes to the chart", "mode", cfg.ReleaseNotesMode)
+		}
+	}
+
+	if len(cfg.ChartAnnotations) > 0 {
+		logger.Info("Updating Chart.yaml annotations")
+		annotations, err := renderChartAnnotations(cfg.ChartAnnotations, newAppVersionData(releaseCtx, time.Now()))
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to render chart annotations: %v", err),
+			}, nil
+		}
+
 		if cfg.DryRun {
-			logger.Info("[DRY-RUN] Would run helm dependency build")
+			logger.Info("[DRY-RUN] Would update Chart.yaml annotations", "annotations", annotations)
+		} else if err := UpdateChartAnnotations(chartPath, annotations); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to update Chart.yaml annotations: %v", err),
+			}, nil
+		}
+		if chart.Annotations == nil {
+			chart.Annotations = map[string]string{}
+		}
+		maps.Copy(chart.Annotations, annotations)
+	}
+
+	if cfg.Dependencies.ValidateNames {
+		if err := ValidateDependencyNames(chart.Dependencies); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Dependency name validation failed: %v", err),
+			}, nil
+		}
+	}
+
+	if cfg.Dependencies.CheckRepos && chart.HasDependencies() {
+		logger.Info("Checking dependency repositories")
+		if err := checkDependencyRepos(ctx, chartPath, chart.Dependencies, cfg.helmBinary(), cfg.Dependencies.checkReposTimeout()); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Dependency repository check failed: %v", err),
+			}, nil
+		}
+	}
+
+	if cfg.Dependencies.AutoAddRepos && (cfg.Dependencies.Update || cfg.Dependencies.Build) && chart.HasDependencies() {
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would add dependency repositories")
 		} else {
-			if err := helm.DependencyBuild(ctx); err != nil {
+			logger.Info("Adding dependency repositories")
+			added, held, err := run.depRepos.add(ctx, cfg.helmBinary(), cfg.commandTimeout(), chart.Dependencies)
+			if cfg.Dependencies.CleanupRepos {
+				defer func() {
+					if err := run.depRepos.remove(ctx, cfg.helmBinary(), cfg.commandTimeout(), held); err != nil {
+						logger.Warn("Failed to remove dependency repositories", "repositories", held, "error", err)
+					}
+				}()
+			}
+			if err != nil {
 				return &plugin.ExecuteResponse{
 					Success: false,
-					Message: fmt.Sprintf("Failed to build dependencies: %v", err),
+					Message: fmt.Sprintf("Failed to add dependency repositories: %v", err),
 				}, nil
 			}
+			if len(added) > 0 {
+				logger.Info("Added dependency repositories", "repositories", added)
+			}
 		}
 	}
 
-	// Lint chart
-	if cfg.Lint {
-		logger.Info("Linting chart", "strict", cfg.LintStrict)
+	// Update dependencies
+	if cfg.Dependencies.Update {
+		logger.Info("Updating chart dependencies")
 		if cfg.DryRun {
-			logger.Info("[DRY-RUN] Would run helm lint")
+			logger.Info("[DRY-RUN] Would run helm dependency update")
 		} else {
-			if err := helm.Lint(ctx, cfg.LintStrict); err != nil {
+			start := time.Now()
+			err := helm.DependencyUpdate(ctx)
+			steps.observe("dependency_update", time.Since(start))
+			if err != nil {
 				return &plugin.ExecuteResponse{
 					Success: false,
-					Message: fmt.Sprintf("Chart linting failed: %v", err),
+					Message: fmt.Sprintf("Failed to update dependencies: %v", err),
 				}, nil
 			}
 		}
 	}
 
-	// Template validation
-	if cfg.TemplateValidate {
-		logger.Info("Validating chart templates", "kubeVersion", cfg.KubeVersion)
+	// Build dependencies
+	if cfg.Dependencies.Build {
+		logger.Info("Building chart dependencies")
 		if cfg.DryRun {
-			logger.Info("[DRY-RUN] Would run helm template validation")
+			logger.Info("[DRY-RUN] Would run helm dependency build")
 		} else {
-			if err := helm.Template(ctx, cfg.KubeVersion, cfg.APIVersions); err != nil {
+			start := time.Now()
+			err := helm.DependencyBuild(ctx)
+			steps.observe("dependency_build", time.Since(start))
+			if err != nil {
 				return &plugin.ExecuteResponse{
 					Success: false,
-					Message: fmt.Sprintf("Template validation failed: %v", err),
+					Message: fmt.Sprintf("Failed to build dependencies: %v", err),
 				}, nil
 			}
 		}
 	}
 
-	logger.Info("PrePublish completed successfully")
-	return &plugin.ExecuteResponse{
-		Success: true,
-		Message: fmt.Sprintf("Chart %s validated successfully", chart.Name),
-	}, nil
-}
-
-func (p *HelmPlugin) executePostPublish(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
-	version := releaseCtx.Version
-	logger = logger.With("version", version)
-
-	chartPath := cfg.ChartPath
-	if chartPath == "" {
-		chartPath = "."
-	}
-
-	// Parse chart to get name
-	chart, err := ParseChart(chartPath)
-	if err != nil {
-		return &plugin.ExecuteResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to parse Chart.yaml: %v", err),
-		}, nil
+	if cfg.Dependencies.VerifyLock {
+		logger.Info("Verifying Chart.lock")
+		if err := VerifyLock(chartPath); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Dependency lock verification failed: %v", err),
+			}, nil
+		}
 	}
 
-	logger = logger.With("chart", chart.Name)
-
-	helm := NewHelmCLI(chartPath)
-
-	// Ensure output directory exists
-	outputDir := cfg.OutputDir
-	if outputDir == "" {
-		outputDir = ".helm-packages"
+	if cfg.ValidateValuesSchema {
+		logger.Info("Validating values against values.schema.json")
+		if err := ValidateValuesSchema(chartPath); errors.Is(err, ErrNoValuesSchema) {
+			logger.Info("No values.schema.json found, skipping values schema validation")
+		} else if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Values schema validation failed: %v", err),
+			}, nil
+		}
 	}
 
-	if !cfg.DryRun {
-		if err := os.MkdirAll(outputDir, 0755); err != nil {
+	if cfg.ValidateValuesExample {
+		logger.Info("Comparing values against values.example.yaml")
+		if err := ValidateValuesExample(chartPath); errors.Is(err, ErrNoValuesExample) {
+			logger.Info("No values.example.yaml found, skipping values example check")
+		} else if err != nil {
 			return &plugin.ExecuteResponse{
 				Success: false,
-				Message: fmt.Sprintf("Failed to create output directory: %v", err),
+				Message: fmt.Sprintf("Values example check failed: %v", err),
 			}, nil
 		}
 	}
 
-	// Package chart
-	logger.Info("Packaging chart", "outputDir", outputDir)
-	var packagePath string
-	if cfg.DryRun {
-		logger.Info("[DRY-RUN] Would package chart",
-			"sign", cfg.Sign,
-			"outputDir", outputDir)
-		packagePath = filepath.Join(outputDir, fmt.Sprintf("%s-%s.tgz", chart.Name, version))
-	} else {
-		var signOpts *SignOptions
-		if cfg.Sign {
-			signOpts = &SignOptions{
-				Keyring:        cfg.Keyring,
-				Key:            cfg.SignKey,
-				PassphraseFile: cfg.PassphraseFile,
-			}
+	if len(cfg.LicenseAllowlist) > 0 {
+		logger.Info("Checking dependency licenses")
+		licenseWarnings, err := CheckDependencyLicenses(chartPath, cfg.LicenseAllowlist)
+		for _, w := range licenseWarnings {
+			logger.Warn("Dependency license warning", "warning", w)
 		}
-
-		packagePath, err = helm.Package(ctx, outputDir, signOpts)
 		if err != nil {
 			return &plugin.ExecuteResponse{
 				Success: false,
-				Message: fmt.Sprintf("Failed to package chart: %v", err),
+				Message: fmt.Sprintf("Dependency license check failed: %v", err),
 			}, nil
 		}
 	}
 
-	// Push to repository
-	logger.Info("Pushing chart to repository",
-		"type", cfg.Repository.Type,
-		"url", cfg.Repository.URL)
-
-	repo := NewRepository(cfg.Repository)
-	repo.SetContextPath(cfg.ContextPath)
+	// Lint chart
+	var lintMessages []LintMessage
+	if cfg.Lint {
+		logger.Info("Linting chart", "strict", cfg.LintStrict)
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would run helm lint")
+		} else {
+			ignore, err := compileLintIgnore(cfg.LintIgnore)
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Chart linting failed: %v", err),
+				}, nil
+			}
 
-	if cfg.DryRun {
-		logger.Info("[DRY-RUN] Would push chart",
-			"package", packagePath,
-			"repository", cfg.Repository.URL)
-	} else {
-		if err := repo.Push(ctx, packagePath); err != nil {
+			start := time.Now()
+			messages, lintErr := helm.Lint(ctx, cfg.LintStrict)
+			steps.observe("lint", time.Since(start))
+			if errors.Is(lintErr, ErrChartNotFound) {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("No chart found to lint at %s (check chart_path): %v", chartPath, lintErr),
+				}, nil
+			}
+
+			var ignored []LintMessage
+			lintMessages, ignored = filterLintMessages(messages, ignore)
+			logLintMessages(logger, lintMessages)
+			if len(ignored) > 0 {
+				logger.Info("Ignored lint messages", "count", len(ignored))
+			}
+			if lintFailed(lintMessages, ignored, cfg.LintStrict, lintErr) {
+				if lintErr == nil {
+					lintErr = fmt.Errorf("unignored lint messages remain")
+				}
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Chart linting failed with %s: %v", lintSummary(lintMessages), lintErr),
+					Outputs: validationOutputs(chart, lintMessages),
+				}, nil
+			}
+		}
+	}
+
+	// Enforce chart authoring standards
+	if cfg.ChartPolicy.enabled() {
+		if err := ValidateChart(chart, cfg.ChartPolicy); err != nil {
+			violations := strings.Split(err.Error(), "\n")
 			return &plugin.ExecuteResponse{
 				Success: false,
-				Message: fmt.Sprintf("Failed to push chart: %v", err),
+				Message: fmt.Sprintf("Chart policy check failed: %d violation(s):\n  - %s", len(violations), strings.Join(violations, "\n  - ")),
 			}, nil
 		}
 	}
 
-	var msg string
-	if cfg.DryRun {
-		msg = fmt.Sprintf("[DRY-RUN] Would publish %s@%s to %s", chart.Name, version, cfg.Repository.URL)
-	} else {
-		msg = fmt.Sprintf("Published %s@%s to %s", chart.Name, version, cfg.Repository.URL)
+	// Flag scaffolding values left in Chart.yaml
+	if cfg.MetadataPlaceholders.Enabled {
+		placeholders := findPlaceholders(chart, cfg.MetadataPlaceholders.Values)
+		if len(placeholders) > 0 && cfg.MetadataPlaceholders.Fail {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Chart metadata check failed: %d placeholder value(s):\n  - %s", len(placeholders), strings.Join(placeholders, "\n  - ")),
+			}, nil
+		}
+		for _, placeholder := range placeholders {
+			logger.Warn("Chart metadata contains a placeholder value", "placeholder", placeholder)
+		}
 	}
 
-	logger.Info("PostPublish completed successfully")
+	// Run chart unit tests
+	var unitTests *UnitTestResult
+	if cfg.UnitTest {
+		logger.Info("Running chart unit tests")
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would run helm unittest")
+		} else {
+			start := time.Now()
+			result, err := helm.UnitTest(ctx)
+			steps.observe("unittest", time.Since(start))
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Chart unit tests failed (%s): %v", result.Summary(), err),
+				}, nil
+			}
+			unitTests = result
+		}
+	}
+
+	// Template validation
+	var warnings []string
+	outputs := validationOutputs(chart, lintMessages)
+	manifestChecks := cfg.ForbidHardcodedNamespace || cfg.DiscourageInlineSecrets || cfg.FailOnEmptyTemplate || cfg.ValidateCRConsistency || cfg.ImagePolicy.RequirePinnedTags
+	if cfg.TemplateValidate || manifestChecks || cfg.TemplateOutput != "" || cfg.Kubeconform.Enabled || cfg.TemplateIncludeCRDs {
+		logger.Info("Validating chart templates", "kubeVersion", cfg.KubeVersion)
+		templateOutput := strings.ReplaceAll(cfg.TemplateOutput, "{{.Chart}}", chart.Name)
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would run helm template validation", "output", templateOutput, "kubeconform", cfg.Kubeconform.Enabled, "renderMatrix", len(cfg.RenderMatrix))
+		} else {
+			start := time.Now()
+
+			// CRDs are installed without templating, so check the files themselves
+			if cfg.TemplateIncludeCRDs {
+				if err := validateCRDFiles(chartPath); err != nil {
+					return &plugin.ExecuteResponse{
+						Success: false,
+						Message: fmt.Sprintf("CRD validation failed: %v", err),
+					}, nil
+				}
+			}
+
+			// Rendered output is saved as-is for diffing; manifest checks render
+			// separately, into the sentinel namespace unless one is configured
+			if templateOutput != "" {
+				if err := helm.Template(ctx, cfg.templateOptions(), templateOutput); err != nil {
+					return &plugin.ExecuteResponse{
+						Success: false,
+						Message: fmt.Sprintf("Template validation failed: %v", err),
+					}, nil
+				}
+				outputs["template_output"] = templateOutput
+			}
+
+			// kubeconform renders on its own, so a plain render is only needed
+			// for the manifest checks
+			if manifestChecks || (cfg.TemplateValidate && templateOutput == "" && !cfg.Kubeconform.Enabled) {
+				opts := cfg.templateOptions()
+				opts.Namespace = cfg.manifestNamespace()
+				rendered, err := helm.Render(ctx, opts)
+				if err != nil {
+					return &plugin.ExecuteResponse{
+						Success: false,
+						Message: fmt.Sprintf("Template validation failed: %v", err),
+					}, nil
+				}
+
+				manifests, err := ParseManifests(rendered)
+				if err == nil {
+					logger.Info("Rendered chart templates", "documents", len(manifests))
+					warnings, err = validateManifests(cfg, manifests)
+				}
+				if err == nil && cfg.ValidateCRConsistency {
+					err = checkCRConsistency(chartPath, manifests)
+				}
+				if err != nil {
+					return &plugin.ExecuteResponse{
+						Success: false,
+						Message: fmt.Sprintf("Template validation failed: %v", err),
+					}, nil
+				}
+				for _, w := range warnings {
+					logger.Warn("Manifest check warning", "warning", w)
+				}
+			}
+
+			if cfg.Kubeconform.Enabled {
+				logger.Info("Validating manifests against Kubernetes API schemas", "strict", cfg.Kubeconform.Strict)
+				err := helm.TemplateAndValidate(ctx, cfg.templateOptions(), cfg.Kubeconform)
+				if err != nil {
+					return &plugin.ExecuteResponse{
+						Success: false,
+						Message: fmt.Sprintf("Schema validation failed: %v", err),
+					}, nil
+				}
+			}
+
+			if cfg.TemplateValidate && len(cfg.RenderMatrix) > 0 {
+				logger.Info("Rendering chart templates with toggled values", "combinations", len(cfg.RenderMatrix))
+				if err := helm.RenderMatrix(ctx, cfg.templateOptions(), cfg.RenderMatrix); err != nil {
+					return &plugin.ExecuteResponse{
+						Success: false,
+						Message: fmt.Sprintf("Template validation failed: %v", err),
+					}, nil
+				}
+			}
+			steps.observe("template", time.Since(start))
+		}
+	}
+
+	if cfg.ScanImages.Enabled {
+		logger.Info("Scanning chart images for vulnerabilities", "source", cfg.ScanImages.Source, "severity", cfg.ScanImages.Severity, "offline", cfg.ScanImages.Offline)
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would scan chart images with trivy")
+		} else {
+			start := time.Now()
+			images, err := chartImages(ctx, helm, cfg, chartPath, chart)
+			if err == nil {
+				logger.Info("Found chart images", "images", images)
+				err = scanImages(ctx, cfg.ScanImages, images)
+			}
+			steps.observe("scan_images", time.Since(start))
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Image scan failed: %v", err),
+				}, nil
+			}
+		}
+	}
+
+	msg := fmt.Sprintf("Chart %s validated successfully", chart.Name)
+	if cfg.Lint && !cfg.DryRun {
+		msg += fmt.Sprintf(" (lint: %s)", lintSummary(lintMessages))
+	}
+	if unitTests != nil {
+		msg += fmt.Sprintf(" (unittest: %s)", unitTests.Summary())
+	}
+	if len(warnings) > 0 {
+		msg += fmt.Sprintf(" with %d warning(s)", len(warnings))
+	}
+
+	steps.addOutputs(outputs)
+	logger.Info("PrePublish completed successfully")
 	return &plugin.ExecuteResponse{
 		Success: true,
 		Message: msg,
+		Outputs: outputs,
 	}, nil
 }
 
-func (p *HelmPlugin) parseConfig(raw map[string]any) *Config {
-	parser := helpers.NewConfigParser(raw)
+func (p *HelmPlugin) executePostPublish(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	if resp := checkReleaseVersion(releaseCtx, cfg); resp != nil {
+		return resp, nil
+	}
+	// Checked again here since nothing guarantees Validate ran first
+	for _, repo := range cfg.targetRepositories() {
+		if err := checkRepositoryHost(repo, cfg.AllowedRepositoryHosts); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Refusing to publish to %s: %v", repo.URL, err),
+			}, nil
+		}
+	}
+	if cfg.Mirror.Enabled {
+		return p.executeMirror(ctx, releaseCtx, cfg, logger)
+	}
+	run := newChartRun(cfg)
+	if cfg.PackagePath != "" {
+		return p.postPublishChart(ctx, releaseCtx, cfg, run, "", logger)
+	}
+	if cfg.ChartGit.URL != "" {
+		// PostPublish clones afresh, so the chart is updated and validated
+		// again before it's packaged
+		return p.withChartGit(ctx, releaseCtx, cfg, run, logger, p.prepareAndPublishChart)
+	}
 
-	// Parse repository config
-	repoConfig := RepositoryConfig{
-		Type: "oci",
+	return p.forEachChart(ctx, releaseCtx, cfg, run, logger, p.postPublishChart)
+}
+
+// prepareAndPublishChart runs the PrePublish steps on a chart and, when they
+// succeed, publishes it.
+func (p *HelmPlugin) prepareAndPublishChart(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, run *chartRun, chartPath string, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	resp, err := p.prePublishChart(ctx, releaseCtx, cfg, run, chartPath, logger)
+	if err != nil || !resp.Success {
+		return resp, err
 	}
-	if repoRaw, ok := raw["repository"].(map[string]any); ok {
-		if t, ok := repoRaw["type"].(string); ok {
-			repoConfig.Type = t
+	return p.postPublishChart(ctx, releaseCtx, cfg, run, chartPath, logger)
+}
+
+// postPublishChart packages and publishes a single chart. With package_path
+// the pre-built package is published instead and chartPath is unused.
+func (p *HelmPlugin) postPublishChart(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, run *chartRun, chartPath string, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	// Parse chart to get name
+	var chart *Chart
+	var err error
+	if cfg.PackagePath != "" {
+		chart, err = readPrebuiltPackage(cfg.PackagePath, cfg.Version, releaseCtx.Version)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Invalid package_path: %v", err),
+			}, nil
 		}
-		if url, ok := repoRaw["url"].(string); ok {
-			repoConfig.URL = url
+	} else if chart, err = ParseChart(chartPath); err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to parse Chart.yaml: %v", err),
+		}, nil
+	}
+
+	if strings.TrimSpace(releaseCtx.Version) == "" {
+		logger.Info("No release version provided, using the chart's existing version", "chart", chart.Name, "version", chart.Version)
+		releaseCtx, cfg = useChartVersion(releaseCtx, cfg, chart)
+	}
+	version := releaseCtx.Version
+	logger = logger.With("version", version, "chart", chart.Name)
+
+	// A pre-built package's version is checked against the release when it's read
+	if cfg.PackagePath == "" {
+		if err := checkChartVersion(chart, version, cfg.Version); err != nil {
+			if !cfg.AllowVersionMismatch {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: err.Error(),
+				}, nil
+			}
+			logger.Warn("Chart version does not match the release version", "chartVersion", chart.Version)
 		}
-		if name, ok := repoRaw["name"].(string); ok {
-			repoConfig.Name = name
+	}
+
+	repos := cfg.newTargetRepositories(run.logins)
+
+	// Verify repository health before doing any packaging work
+	for _, repo := range repos {
+		if !repo.config.HealthCheck {
+			continue
 		}
-		if username, ok := repoRaw["username"].(string); ok {
-			repoConfig.Username = username
+		logger.Info("Checking repository health", "url", repo.config.URL)
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would check repository health", "path", repo.healthPath())
+		} else if err := repo.CheckHealth(ctx); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Repository %s is not healthy: %v", repo.config.URL, err),
+			}, nil
 		}
-		if password, ok := repoRaw["password"].(string); ok {
-			repoConfig.Password = password
+	}
+
+	// Warn about registries that may reject Helm's OCI artifacts before pushing
+	for _, repo := range repos {
+		if !repo.config.PreflightMediaCheck || repo.config.Type != "oci" {
+			continue
 		}
-		if regConfig, ok := repoRaw["registry_config"].(string); ok {
-			repoConfig.RegistryConfig = regConfig
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would check registry support for Helm OCI artifacts", "url", repo.config.URL)
+		} else if err := repo.CheckOCISupport(ctx, chart.Name, chart.Version); err != nil {
+			logger.Warn("Registry may not support Helm OCI artifacts", "url", repo.config.URL, "error", err)
 		}
 	}
 
-	// Parse version config
-	versionConfig := VersionConfig{
-		UpdateChart:      true,
-		UpdateAppVersion: true,
+	// Ensure output directory exists
+	outputDir := cfg.OutputDir
+	if outputDir == "" {
+		outputDir = ".helm-packages"
 	}
-	if versionRaw, ok := raw["version"].(map[string]any); ok {
-		if update, ok := versionRaw["update_chart"].(bool); ok {
-			versionConfig.UpdateChart = update
+
+	if !cfg.DryRun {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to create output directory: %v", err),
+			}, nil
 		}
-		if updateApp, ok := versionRaw["update_app_version"].(bool); ok {
-			versionConfig.UpdateAppVersion = updateApp
+	}
+
+	// Helm turns Chart.yaml annotations into OCI manifest annotations, so write
+	// them into a staged copy of the chart before packaging
+	if overrides, hasOCI := annotationOverrides(repos); cfg.OCIAnnotations && hasOCI && cfg.PackagePath == "" {
+		annotations := ociAnnotations(chart, releaseCtx, overrides, annotationCreatedTime(ctx, releaseCtx, chartPath))
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would add OCI annotations", "annotations", annotations)
+		} else {
+			stagedPath, cleanup, err := stageChart(chartPath)
+			if err == nil {
+				defer cleanup()
+				err = UpdateChartAnnotations(stagedPath, annotations)
+			}
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Failed to add OCI annotations: %v", err),
+				}, nil
+			}
+			chartPath = stagedPath
 		}
-		if format, ok := versionRaw["app_version_format"].(string); ok {
-			versionConfig.AppVersionFormat = format
+	}
+
+	// Regenerate the README so the package ships current docs
+	if cfg.RunHelmDocs && cfg.PackagePath == "" {
+		logger.Info("Generating chart README with helm-docs")
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would run helm-docs", "args", helmDocsArgs(chartPath))
+		} else if err := runHelmDocs(ctx, chartPath); err != nil {
+			if cfg.HelmDocsFailOnError {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Failed to generate chart docs: %v", err),
+				}, nil
+			}
+			logger.Warn("Failed to generate chart docs, packaging the existing README", "error", err)
 		}
 	}
 
-	// Parse dependency config
-	depConfig := DependencyConfig{
-		Update: true,
-		Build:  true,
+	// Package chart
+	baseVersion := chart.Version
+	if cfg.DryRun && cfg.Version.UpdateChart {
+		baseVersion = version
+		if chartVersion, err := cfg.Version.chartVersion(version); err == nil {
+			baseVersion = chartVersion
+		}
 	}
-	if depRaw, ok := raw["dependencies"].(map[string]any); ok {
-		if update, ok := depRaw["update"].(bool); ok {
-			depConfig.Update = update
+	metrics := p.metricsFor(cfg)
+	steps := newStepTimings(logger, metrics, cfg.DebugTimings)
+	start := time.Now()
+	var packages []chartPackage
+	if cfg.PackagePath != "" {
+		logger.Info("Using pre-built package", "package", cfg.PackagePath)
+		baseVersion = chart.Version
+		packages = []chartPackage{{Version: chart.Version, Path: cfg.PackagePath}}
+	} else {
+		packages, err = packageCharts(ctx, cfg, chartPath, chart.Name, baseVersion, outputDir, logger)
+	}
+	if !cfg.DryRun && cfg.PackagePath == "" {
+		steps.observe("package", time.Since(start))
+	}
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to package chart: %v", err),
+		}, nil
+	}
+	if cfg.OutputFilename != "" && cfg.PackagePath == "" {
+		if packages, err = renamePackages(packages, cfg.OutputFilename, chart, time.Now(), cfg.DryRun); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to name package: %v", err),
+			}, nil
 		}
-		if build, ok := depRaw["build"].(bool); ok {
-			depConfig.Build = build
+	}
+	if !cfg.DryRun {
+		for _, pkg := range packages {
+			if err := verifyPackagedChart(pkg.Path, chart.Name, pkg.Version); err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Packaged chart verification failed: %v", err),
+				}, nil
+			}
 		}
 	}
 
-	// Parse API versions
-	var apiVersions []string
-	if apiRaw, ok := raw["api_versions"].([]any); ok {
-		for _, v := range apiRaw {
-			if s, ok := v.(string); ok {
-				apiVersions = append(apiVersions, s)
+	// Record where each package came from, next to the packages
+	var attestations []string
+	if cfg.Provenance.Attestation {
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would write provenance attestations", "builder", cfg.Provenance.BuilderID, "outputDir", outputDir)
+		} else if attestations, err = writeAttestations(cfg.Provenance, chart, packages, releaseCtx, outputDir, time.Now()); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to write provenance attestation: %v", err),
+			}, nil
+		}
+	}
+
+	// Resolve the OCI tags helm will push under, so sanitized or invalid tags
+	// show up before anything is published
+	for _, repo := range repos {
+		if repo.config.Type != "oci" {
+			continue
+		}
+		for _, pkg := range packages {
+			tag, err := repo.ociTag(pkg.Version)
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Invalid OCI tag for %s: %v", repo.config.URL, err),
+				}, nil
+			}
+			if tag != pkg.Version {
+				logger.Info("Chart version is not a valid OCI tag, pushing under sanitized tag", "version", pkg.Version, "tag", tag)
 			}
 		}
 	}
 
-	return &Config{
-		ChartPath:        parser.GetString("chart_path", "", "."),
-		Repository:       repoConfig,
-		Version:          versionConfig,
-		Lint:             parser.GetBool("lint", true),
-		LintStrict:       parser.GetBool("lint_strict", false),
-		TemplateValidate: parser.GetBool("template_validate", true),
-		Test:             parser.GetBool("test", false),
-		KubeVersion:      parser.GetString("kube_version", "", ""),
-		APIVersions:      apiVersions,
-		Dependencies:     depConfig,
-		Sign:             parser.GetBool("sign", false),
-		SignKey:          parser.GetString("sign_key", "", ""),
-		Keyring:          parser.GetString("keyring", "", ""),
-		PassphraseFile:   parser.GetString("passphrase_file", "", ""),
-		OutputDir:        parser.GetString("output_dir", "", ".helm-packages"),
-		ContextPath:      parser.GetString("context_path", "", ""),
-		DryRun:           parser.GetBool("dry_run", false),
-	}
-}
-
-func getHelmVersion() (string, error) {
-	cmd := exec.Command("helm", "version", "--short")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
+	// Wait for approval before pushing
+	if cfg.ApprovalWebhook != "" {
+		logger.Info("Requesting publish approval")
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would request publish approval")
+		} else {
+			req, err := newApprovalRequest(chart.Name, packages, repos, releaseCtx)
+			if err == nil {
+				err = requestApproval(ctx, cfg.ApprovalWebhook, req)
+			}
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Publish aborted: %v", err),
+				}, nil
+			}
+		}
 	}
-	return strings.TrimSpace(string(output)), nil
+
+	// Confirm every repository accepts its credentials before pushing to any, so
+	// a bad credential can't leave the chart published to only some of them
+	if cfg.AtomicMultiPush {
+		logger.Info("Checking repository authentication", "repositories", len(repos))
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would check authentication to all repositories")
+		} else if err := checkRepositoryAuth(ctx, repos); err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Aborted before pushing: %v", err),
+			}, nil
+		}
+	}
+
+	// Push to repositories
+	if cfg.DryRun {
+		var pushTargets []string
+		for _, pkg := range packages {
+			for _, repo := range repos {
+				target := repo.PushTarget(chart.Name, pkg.Version)
+				logger.Info("[DRY-RUN] Would push chart",
+					"package", pkg.Path,
+					"type", repo.config.Type,
+					"target", target)
+				if repo.config.WaitAvailable {
+					timeout, _ := repo.config.waitSettings()
+					logger.Info("[DRY-RUN] Would wait for the chart to be available", "target", target, "timeout", timeout)
+				}
+
+				pushTargets = append(pushTargets, target)
+			}
+		}
+		for _, repo := range repos {
+			if _, err := pruneOldVersions(ctx, repo, chart.Name, packageVersions(packages), true, logger); err != nil {
+				logger.Warn("[DRY-RUN] Could not determine versions to prune", "url", repo.config.URL, "error", err)
+			}
+			if repo.config.UpdateStablePointer {
+				logger.Info("[DRY-RUN] Would update stable pointer if newer", "url", repo.stablePointerURL(), "version", baseVersion)
+			}
+			for _, pkg := range packages {
+				if tags, err := repo.additionalTags(pkg.Version); err != nil {
+					logger.Warn("[DRY-RUN] Invalid additional tags", "url", repo.config.URL, "error", err)
+				} else if len(tags) > 0 {
+					logger.Info("[DRY-RUN] Would add tags", "target", repo.OCIReference(chart.Name, pkg.Version), "tags", tags, "overwrite", repo.config.Overwrite)
+				}
+			}
+			if cfg.Provenance.Push && repo.config.Type == "oci" {
+				logger.Info("[DRY-RUN] Would attach provenance attestations", "url", repo.config.URL)
+			}
+		}
+		if cfg.GitHubRelease.Enabled {
+			ghCfg, tag := cfg.GitHubRelease.resolve(releaseCtx)
+			logger.Info("[DRY-RUN] Would upload GitHub release assets", "repository", ghCfg.Repository, "tag", tag)
+		}
+		if cfg.Notify.URL != "" {
+			payload, _ := newNotifyPayload(chart, packages, repos, nil, releaseCtx, false)
+			body, _ := json.Marshal(payload)
+			logger.Info("[DRY-RUN] Would send publish notification", "url", cfg.Notify.URL, "method", cfg.Notify.Method, "payload", string(body))
+		}
+
+		outputs := map[string]any{
+			"push_targets":  pushTargets,
+			"chart_package": packages[0].Path,
+			"chart":         chart.Name,
+			"chart_version": baseVersion,
+			"app_version":   chart.AppVersion,
+			"repository":    repositoryURLs(repos),
+			"pushed":        false,
+		}
+		for _, repo := range repos {
+			if repo.config.Type == "oci" {
+				outputs["oci_tag"] = OCITag(packages[0].Version)
+			}
+		}
+		plan := newPlan(cfg, releaseCtx, chart, packages, pushTargets)
+		outputs["plan"] = plan
+
+		logger.Info("PostPublish completed successfully")
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: fmt.Sprintf("[DRY-RUN] Would publish %s to %s\n%s", packageNames(chart.Name, packages), strings.Join(pushTargets, ", "), plan),
+			Outputs: outputs,
+		}, nil
+	}
+
+	var results []pushStatus
+	start = time.Now()
+	for _, pkg := range packages {
+		results = append(results, pushToRepositories(ctx, repos, pkg.Path, cfg.Concurrency, cfg.FailFast, cfg.FailIfExists, logger)...)
+		if cfg.FailFast && joinPushErrors(results) != nil {
+			break
+		}
+	}
+	steps.observe("push", time.Since(start))
+	for _, r := range results {
+		switch {
+		case r.Skipped, r.Exists:
+			metrics.IncPublish(r.Type, "skipped")
+		case r.Err != nil:
+			metrics.IncPublish(r.Type, "failure")
+		default:
+			metrics.IncPublish(r.Type, "success")
+		}
+	}
+	if err := joinPushErrors(results); err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to push chart: %v", err),
+		}, nil
+	}
+
+	var pruned []string
+	for _, repo := range repos {
+		deleted, err := pruneOldVersions(ctx, repo, chart.Name, packageVersions(packages), false, logger)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Published %s but failed to prune old versions from %s: %v", packageNames(chart.Name, packages), repo.config.URL, err),
+			}, nil
+		}
+		pruned = append(pruned, deleted...)
+	}
+
+	for _, repo := range repos {
+		if !repo.config.UpdateStablePointer {
+			continue
+		}
+		updated, err := repo.UpdateStablePointer(ctx, chart.Version)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Published %s but failed to update the stable pointer in %s: %v", packageNames(chart.Name, packages), repo.config.URL, err),
+			}, nil
+		}
+		if updated {
+			logger.Info("Updated stable pointer", "url", repo.stablePointerURL(), "version", chart.Version)
+		} else {
+			logger.Info("Stable pointer not updated (prerelease or not newer)", "url", repo.stablePointerURL(), "version", chart.Version)
+		}
+	}
+
+	// Attaching attestations is best effort, as not every registry accepts referrers
+	if cfg.Provenance.Push {
+		for _, r := range results {
+			repo := repositoryByURL(repos, r.URL)
+			if r.Digest == "" || repo == nil || repo.config.Type != "oci" {
+				continue
+			}
+			path := attestationPath(outputDir, r.Package)
+			if err := repo.AttachAttestation(ctx, chart.Name, r.Digest, path); err != nil {
+				logger.Warn("Failed to attach provenance attestation", "url", r.URL, "package", r.Package, "error", err)
+			} else {
+				logger.Info("Attached provenance attestation", "url", r.URL, "package", r.Package)
+			}
+		}
+	}
+
+	// Point moving tags such as latest at the versions just pushed
+	var additionalTags []string
+	for _, repo := range repos {
+		if len(repo.config.AdditionalTags) == 0 {
+			continue
+		}
+		for _, pkg := range packages {
+			tagged, skipped, err := repo.AddTags(ctx, chart.Name, pkg.Version)
+			if err != nil {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Published %s but failed to add tags in %s: %v", packageNames(chart.Name, packages), repo.config.URL, err),
+				}, nil
+			}
+			for _, ref := range tagged {
+				logger.Info("Added tag", "reference", ref)
+			}
+			for _, ref := range skipped {
+				logger.Warn("Tag already exists, leaving it in place (set overwrite to move it)", "reference", ref)
+			}
+			additionalTags = append(additionalTags, tagged...)
+		}
+	}
+
+	outputs, err := packageOutputs(packages, results)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to compute package digest: %v", err),
+		}, nil
+	}
+	artifacts, err := packageArtifacts(packages)
+	if err != nil {
+		return &plugin.ExecuteResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to list artifacts: %v", err),
+		}, nil
+	}
+	for _, path := range attestations {
+		artifact, err := fileArtifact(path)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Failed to list artifacts: %v", err),
+			}, nil
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	outputs["artifacts"] = artifactPaths(artifacts)
+	if len(additionalTags) > 0 {
+		outputs["additional_tags"] = additionalTags
+	}
+	pushed := false
+	for _, r := range results {
+		pushed = pushed || !r.Exists
+	}
+	outputs["chart"] = chart.Name
+	outputs["app_version"] = chart.AppVersion
+	outputs["repository"] = repositoryURLs(repos)
+	outputs["pushed"] = pushed
+
+	if cfg.GitHubRelease.Enabled {
+		ghCfg, tag := cfg.GitHubRelease.resolve(releaseCtx)
+		logger.Info("Uploading GitHub release assets", "repository", ghCfg.Repository, "tag", tag)
+		releaseURL, err := uploadReleaseAssets(ctx, ghCfg, tag, packages)
+		if err != nil {
+			return &plugin.ExecuteResponse{
+				Success: false,
+				Message: fmt.Sprintf("Published %s but failed to upload GitHub release assets: %v", packageNames(chart.Name, packages), err),
+			}, nil
+		}
+		outputs["github_release_url"] = releaseURL
+	}
+
+	if cfg.Notify.URL != "" {
+		logger.Info("Sending publish notification", "url", cfg.Notify.URL)
+		payload, err := newNotifyPayload(chart, packages, repos, results, releaseCtx, true)
+		if err == nil {
+			err = sendNotification(ctx, cfg.Notify, payload)
+		}
+		if err != nil {
+			if cfg.Notify.Required {
+				return &plugin.ExecuteResponse{
+					Success: false,
+					Message: fmt.Sprintf("Published %s but failed to send notification: %v", packageNames(chart.Name, packages), err),
+				}, nil
+			}
+			logger.Warn("Publish notification failed", "url", cfg.Notify.URL, "error", err)
+		}
+	}
+
+	msg := fmt.Sprintf("Published %s to %s", packageNames(chart.Name, packages), repositoryURLs(repos))
+	if len(results) > 1 {
+		msg = fmt.Sprintf("Published %s:\n%s", packageNames(chart.Name, packages), formatPushResults(results))
+	}
+	if len(pruned) > 0 {
+		msg += fmt.Sprintf(" (pruned %d old versions)", len(pruned))
+	}
+
+	steps.addOutputs(outputs)
+	logger.Info("PostPublish completed successfully")
+	return &plugin.ExecuteResponse{
+		Success:   true,
+		Message:   msg,
+		Outputs:   outputs,
+		Artifacts: artifacts,
+	}, nil
+}
+
+// validationOutputs returns the PrePublish outputs describing the validated chart.
+func validationOutputs(chart *Chart, lintMessages []LintMessage) map[string]any {
+	lintErrors, lintWarnings := countLintMessages(lintMessages)
+	return map[string]any{
+		"chart":         chart.Name,
+		"chart_version": chart.Version,
+		"app_version":   chart.AppVersion,
+		"lint_errors":   lintErrors,
+		"lint_warnings": lintWarnings,
+	}
+}
+
+// logLintMessages logs each lint message at a level matching its severity.
+func logLintMessages(logger *slog.Logger, messages []LintMessage) {
+	for _, m := range messages {
+		switch m.Severity {
+		case LintSeverityError:
+			logger.Error("Lint error", "path", m.Path, "message", m.Message)
+		case LintSeverityWarning:
+			logger.Warn("Lint warning", "path", m.Path, "message", m.Message)
+		default:
+			logger.Info("Lint info", "path", m.Path, "message", m.Message)
+		}
+	}
+}
+
+// chartPackage describes a packaged chart ready to be pushed.
+type chartPackage struct {
+	Environment string
+	Version     string
+	Path        string
+}
+
+// packageCharts packages the chart, producing one package per configured
+// environment or a single package when no environments are configured.
+// In dry-run mode the expected package paths are computed without packaging.
+func packageCharts(ctx context.Context, cfg *Config, chartPath, chartName, version, outputDir string, logger *slog.Logger) ([]chartPackage, error) {
+	var signOpts *SignOptions
+	if cfg.Sign && cfg.SignMode == "gpg" {
+		signOpts = &SignOptions{
+			Keyring:        cfg.Keyring,
+			Key:            cfg.SignKey,
+			PassphraseFile: cfg.PassphraseFile,
+		}
+	}
+
+	// Keys injected through the environment are imported into a temporary
+	// keyring that only lives as long as packaging.
+	if signOpts != nil && cfg.SignKeyEnv != "" && !cfg.DryRun {
+		keyring, uid, cleanup, err := importSigningKey(ctx, os.Getenv(cfg.SignKeyEnv), cfg.PassphraseFile)
+		defer cleanup()
+		if err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", cfg.SignKeyEnv, err)
+		}
+		signOpts.Keyring = keyring
+		if signOpts.Key == "" {
+			signOpts.Key = uid
+		}
+	}
+
+	if len(cfg.Environments) == 0 {
+		logger.Info("Packaging chart", "outputDir", outputDir)
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would package chart",
+				"sign", cfg.Sign,
+				"outputDir", outputDir)
+			return []chartPackage{{
+				Version: version,
+				Path:    filepath.Join(outputDir, fmt.Sprintf("%s-%s.tgz", chartName, version)),
+			}}, nil
+		}
+
+		helm := NewHelmCLI(chartPath)
+		helm.SetHelmBinary(cfg.helmBinary())
+		helm.SetTimeout(cfg.commandTimeout())
+		helm.SetLogger(logger)
+		helm.SetPackageDependencyUpdate(cfg.Dependencies.PackageUpdate)
+		helm.SetPackageRetries(cfg.PackageRetries)
+		packagePath, err := packageChart(ctx, helm, outputDir, signOpts, cfg.Concurrency > 1)
+		if err == nil {
+			err = verifySignature(ctx, helm, packagePath, signOpts, logger)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []chartPackage{{Version: version, Path: packagePath}}, nil
+	}
+
+	packages := make([]chartPackage, 0, len(cfg.Environments))
+	for _, env := range slices.Sorted(maps.Keys(cfg.Environments)) {
+		envVersion := environmentVersion(version, env)
+		logger.Info("Packaging chart for environment", "environment", env, "version", envVersion, "outputDir", outputDir)
+
+		if cfg.DryRun {
+			logger.Info("[DRY-RUN] Would package chart for environment",
+				"environment", env,
+				"values", cfg.Environments[env],
+				"schema", cfg.EnvSchemas[env],
+				"sign", cfg.Sign)
+			packages = append(packages, chartPackage{
+				Environment: env,
+				Version:     envVersion,
+				Path:        filepath.Join(outputDir, fmt.Sprintf("%s-%s.tgz", chartName, envVersion)),
+			})
+			continue
+		}
+
+		envChartPath, cleanup, err := prepareEnvironmentChart(chartPath, env, cfg.Environments[env], version)
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %w", env, err)
+		}
+		if schemaFile := cfg.EnvSchemas[env]; schemaFile != "" {
+			logger.Info("Validating merged values against environment schema", "environment", env, "schema", schemaFile)
+			if err := validateValuesFile(schemaFile, filepath.Join(envChartPath, "values.yaml")); err != nil {
+				cleanup()
+				return nil, fmt.Errorf("environment %s: %w", env, err)
+			}
+		}
+		helm := NewHelmCLI(envChartPath)
+		helm.SetHelmBinary(cfg.helmBinary())
+		helm.SetTimeout(cfg.commandTimeout())
+		helm.SetLogger(logger)
+		helm.SetPackageDependencyUpdate(cfg.Dependencies.PackageUpdate)
+		helm.SetPackageRetries(cfg.PackageRetries)
+		packagePath, err := packageChart(ctx, helm, outputDir, signOpts, cfg.Concurrency > 1)
+		cleanup()
+		if err == nil {
+			err = verifySignature(ctx, helm, packagePath, signOpts, logger)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %w", env, err)
+		}
+		packages = append(packages, chartPackage{Environment: env, Version: envVersion, Path: packagePath})
+	}
+	return packages, nil
+}
+
+// verifySignature runs helm verify on a package signed with signOpts, so a
+// chart whose signature is broken is never pushed. Unsigned packages are left
+// alone.
+func verifySignature(ctx context.Context, helm *HelmCLI, packagePath string, signOpts *SignOptions, logger *slog.Logger) error {
+	if signOpts == nil {
+		return nil
+	}
+	logger.Info("Verifying package signature", "package", packagePath)
+	if err := helm.Verify(ctx, packagePath, signOpts.Keyring); err != nil {
+		return fmt.Errorf("signature verification of %s failed: %w", filepath.Base(packagePath), err)
+	}
+	return nil
+}
+
+// packageChart runs helm package into outputDir. When isolate is set the chart
+// is packaged into its own temporary directory inside outputDir and the results
+// (package and provenance file) are moved into place afterwards, so concurrent
+// helm runs sharing outputDir never see each other's partial files.
+func packageChart(ctx context.Context, helm *HelmCLI, outputDir string, signOpts *SignOptions, isolate bool) (string, error) {
+	if !isolate {
+		return helm.Package(ctx, outputDir, signOpts)
+	}
+
+	dir, err := os.MkdirTemp(outputDir, ".package-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create package directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	packagePath, err := helm.Package(ctx, dir, signOpts)
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read package directory: %w", err)
+	}
+	for _, entry := range entries {
+		if err := os.Rename(filepath.Join(dir, entry.Name()), filepath.Join(outputDir, entry.Name())); err != nil {
+			return "", fmt.Errorf("failed to move %s: %w", entry.Name(), err)
+		}
+	}
+	return filepath.Join(outputDir, filepath.Base(packagePath)), nil
+}
+
+// packageOutputs builds the structured outputs describing the published packages.
+// The chart_* and oci_digest keys describe the first package; when several
+// packages were produced each one is also listed under "packages".
+func packageOutputs(packages []chartPackage, results []pushStatus) (map[string]any, error) {
+	outputs := map[string]any{}
+	var all []map[string]any
+	for i, pkg := range packages {
+		digest, err := fileDigest(pkg.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := map[string]any{
+			"chart_digest":  digest,
+			"chart_package": pkg.Path,
+			"chart_version": pkg.Version,
+		}
+		for _, r := range results {
+			if r.Package == filepath.Base(pkg.Path) && r.Type == "oci" {
+				entry["oci_tag"] = OCITag(pkg.Version)
+				if r.Digest != "" {
+					entry["oci_digest"] = r.Digest
+				}
+			}
+		}
+		if pkg.Environment != "" {
+			entry["environment"] = pkg.Environment
+		}
+
+		if i == 0 {
+			for k, v := range entry {
+				outputs[k] = v
+			}
+		}
+		all = append(all, entry)
+	}
+
+	if len(all) > 1 {
+		outputs["packages"] = all
+	}
+	return outputs, nil
+}
+
+// packageNames returns a comma-separated list of name@version for the packages.
+func packageNames(chartName string, packages []chartPackage) string {
+	names := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		names = append(names, chartName+"@"+pkg.Version)
+	}
+	return strings.Join(names, ", ")
+}
+
+// retention returns how many versions to keep and since when versions are kept
+// regardless, from retention_keep and retention_window or else from prune and
+// retain_versions. keep is 0 when old versions are never deleted.
+func (c RepositoryConfig) retention(now time.Time) (keep int, keepSince time.Time) {
+	if c.RetentionKeep > 0 {
+		if d, err := time.ParseDuration(c.RetentionWindow); err == nil && d > 0 {
+			keepSince = now.Add(-d)
+		}
+		return c.RetentionKeep, keepSince
+	}
+	if c.Prune && c.RetainVersions > 0 {
+		return c.RetainVersions, time.Time{}
+	}
+	return 0, time.Time{}
+}
+
+// packageVersions returns the chart versions of the packages.
+func packageVersions(packages []chartPackage) []string {
+	versions := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		versions = append(versions, pkg.Version)
+	}
+	return versions
+}
+
+// pruneOldVersions deletes chart versions beyond the repository's retention count,
+// never deleting the just-published versions. It is a no-op unless retention is
+// explicitly configured. In dry-run mode the versions that would be deleted are
+// only logged.
+func pruneOldVersions(ctx context.Context, repo *Repository, chartName string, published []string, dryRun bool, logger *slog.Logger) ([]string, error) {
+	keep, keepSince := repo.config.retention(time.Now())
+	if keep < 1 {
+		return nil, nil
+	}
+
+	versions, err := repo.ListVersions(ctx, chartName)
+	if err != nil {
+		return nil, err
+	}
+
+	prune := versionsToPrune(versions, keep, keepSince, published)
+	if dryRun {
+		for _, v := range prune {
+			logger.Info("[DRY-RUN] Would delete old chart version", "url", repo.config.URL, "version", v)
+		}
+		return prune, nil
+	}
+
+	var deleted []string
+	for _, v := range prune {
+		logger.Info("Deleting old chart version", "url", repo.config.URL, "version", v)
+		if err := repo.Delete(ctx, chartName, v); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s@%s: %w", chartName, v, err)
+		}
+		deleted = append(deleted, v)
+	}
+	return deleted, nil
+}
+
+// pushStatus records the outcome of pushing a chart to a single repository.
+type pushStatus struct {
+	Package string
+	Type    string
+	URL     string
+	Digest  string
+	Err     error
+	Skipped bool
+	Exists  bool // version was already published and failIfExists was off
+}
+
+// pushToRepositories pushes the package to each repository, up to concurrency
+// at once. Failures are collected so one broken mirror doesn't prevent the
+// others from being updated, unless failFast is set, in which case repositories
+// not yet pushed to are skipped. Repositories that already hold the version fail
+// the push only if failIfExists is set; otherwise they are reported as existing
+// and left untouched. Results are in repository order.
+func pushToRepositories(ctx context.Context, repos []*Repository, packagePath string, concurrency int, failFast, failIfExists bool, logger *slog.Logger) []pushStatus {
+	results := make([]pushStatus, len(repos))
+	var failed atomic.Bool
+	group := newWorkerGroup(concurrency)
+	for i, repo := range repos {
+		group.Go(nil, func() {
+			results[i] = pushToRepository(ctx, repo, packagePath, failFast && failed.Load(), failIfExists, logger)
+			if results[i].Err != nil {
+				failed.Store(true)
+			}
+		})
+	}
+	group.Wait()
+	return results
+}
+
+// pushToRepository pushes the package to a single repository, or records it as
+// skipped if skip is set.
+func pushToRepository(ctx context.Context, repo *Repository, packagePath string, skip, failIfExists bool, logger *slog.Logger) pushStatus {
+	result := pushStatus{Package: filepath.Base(packagePath), Type: repo.config.Type, URL: repo.config.URL}
+	if skip {
+		result.Skipped = true
+		return result
+	}
+
+	logger.Info("Pushing chart to repository",
+		"type", repo.config.Type,
+		"name", repo.config.Name,
+		"url", repo.config.URL)
+
+	pushed, err := repo.Push(ctx, packagePath)
+	if errors.Is(err, ErrVersionExists) && !failIfExists {
+		logger.Warn("Chart version already exists, skipping", "url", repo.config.URL)
+		result.Exists = true
+	} else if err != nil {
+		logger.Error("Push failed", "url", repo.config.URL, "error", err)
+		result.Err = err
+	} else {
+		if pushed.Digest != "" {
+			logger.Info("Chart pushed", "url", repo.config.URL, "digest", pushed.Digest)
+			result.Digest = pushed.Digest
+		}
+		if repo.config.WaitAvailable {
+			logger.Info("Waiting for pushed chart to be available", "url", repo.config.URL)
+			if err := waitPushed(ctx, repo, packagePath, logger); err != nil {
+				logger.Error("Pushed chart is not available", "url", repo.config.URL, "error", err)
+				result.Err = err
+				return result
+			}
+		}
+		if repo.config.VerifyAfterPush {
+			logger.Info("Verifying pushed chart", "url", repo.config.URL)
+			if err := verifyPush(ctx, repo, packagePath); err != nil {
+				logger.Error("Push verification failed", "url", repo.config.URL, "error", err)
+				result.Err = fmt.Errorf("push verification failed: %w", err)
+			}
+		}
+	}
+	return result
+}
+
+// checkRepositoryAuth checks authentication to every repository, returning the
+// combined failures or nil if all repositories accepted their credentials.
+func checkRepositoryAuth(ctx context.Context, repos []*Repository) error {
+	var failures []string
+	for _, repo := range repos {
+		if err := repo.CheckAuth(ctx); err != nil {
+			failures = append(failures, fmt.Sprintf("  - %s: %v", repo.config.URL, err))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d repositories failed authentication:\n%s", len(failures), len(repos), strings.Join(failures, "\n"))
+}
+
+// waitPushed waits until repo serves the chart version in the pushed package.
+func waitPushed(ctx context.Context, repo *Repository, packagePath string, logger *slog.Logger) error {
+	chart, err := readPackagedChart(packagePath)
+	if err != nil {
+		return err
+	}
+	return waitAvailable(ctx, repo, chart.Name, chart.Version, logger)
+}
+
+// verifyPush pulls the pushed package back from repo and compares it with the local package.
+func verifyPush(ctx context.Context, repo *Repository, packagePath string) error {
+	chart, err := readPackagedChart(packagePath)
+	if err != nil {
+		return err
+	}
+	digest, err := fileDigest(packagePath)
+	if err != nil {
+		return err
+	}
+	return repo.VerifyPushed(ctx, chart.Name, chart.Version, digest)
+}
+
+// joinPushErrors combines the errors of all failed pushes, or returns nil if none failed.
+func joinPushErrors(results []pushStatus) error {
+	var failures []string
+	for _, r := range results {
+		if r.Err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", r.URL, r.Err))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	if len(results) == 1 {
+		return results[0].Err
+	}
+	return fmt.Errorf("%d of %d repositories failed:\n%s", len(failures), len(results), formatPushResults(results))
+}
+
+// formatPushResults renders a per-repository status summary.
+func formatPushResults(results []pushStatus) string {
+	lines := make([]string, 0, len(results))
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			lines = append(lines, fmt.Sprintf("  - %s -> %s: skipped", r.Package, r.URL))
+		case r.Exists:
+			lines = append(lines, fmt.Sprintf("  - %s -> %s: already exists", r.Package, r.URL))
+		case r.Err != nil:
+			lines = append(lines, fmt.Sprintf("  - %s -> %s: failed: %v", r.Package, r.URL, r.Err))
+		default:
+			lines = append(lines, fmt.Sprintf("  - %s -> %s: ok", r.Package, r.URL))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// repositoryURLs returns a comma-separated list of repository URLs.
+func repositoryURLs(repos []*Repository) string {
+	urls := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		urls = append(urls, repo.config.URL)
+	}
+	return strings.Join(urls, ", ")
+}
+
+// repositoryByURL returns the repository configured with url, or nil.
+func repositoryByURL(repos []*Repository, url string) *Repository {
+	for _, repo := range repos {
+		if repo.config.URL == url {
+			return repo
+		}
+	}
+	return nil
+}
+
+func (p *HelmPlugin) parseConfig(raw map[string]any) *Config {
+	parser := helpers.NewConfigParser(raw)
+
+	// Parse repository config
+	repoConfig := RepositoryConfig{
+		Type: "oci",
+	}
+	if repoRaw, ok := raw["repository"].(map[string]any); ok {
+		repoConfig = parseRepositoryConfig(repoRaw)
+	}
+
+	var repositories []RepositoryConfig
+	if reposRaw, ok := raw["repositories"].([]any); ok {
+		for _, r := range reposRaw {
+			if repoRaw, ok := r.(map[string]any); ok {
+				repositories = append(repositories, parseRepositoryConfig(repoRaw))
+			}
+		}
+	}
+
+	// Parse version config
+	versionConfig := VersionConfig{
+		UpdateChart:      true,
+		UpdateAppVersion: true,
+	}
+	if versionRaw, ok := raw["version"].(map[string]any); ok {
+		if update, ok := versionRaw["update_chart"].(bool); ok {
+			versionConfig.UpdateChart = update
+		}
+		if updateApp, ok := versionRaw["update_app_version"].(bool); ok {
+			versionConfig.UpdateAppVersion = updateApp
+		}
+		if format, ok := versionRaw["app_version_format"].(string); ok {
+			versionConfig.AppVersionFormat = format
+		}
+		if pattern, ok := versionRaw["app_version_pattern"].(string); ok {
+			versionConfig.AppVersionPattern = pattern
+		}
+		if strip, ok := versionRaw["strip_prerelease"].(bool); ok {
+			versionConfig.StripPrerelease = strip
+		}
+		if strip, ok := versionRaw["strip_build_metadata"].(bool); ok {
+			versionConfig.StripBuildMetadata = strip
+		}
+	}
+
+	// Parse dependency config
+	depConfig := DependencyConfig{
+		Update: true,
+		Build:  true,
+	}
+	if depRaw, ok := raw["dependencies"].(map[string]any); ok {
+		if update, ok := depRaw["update"].(bool); ok {
+			depConfig.Update = update
+		}
+		if build, ok := depRaw["build"].(bool); ok {
+			depConfig.Build = build
+		}
+		if verify, ok := depRaw["verify_lock"].(bool); ok {
+			depConfig.VerifyLock = verify
+		}
+		if verify, ok := depRaw["verify"].(bool); ok {
+			depConfig.Verify = verify
+		}
+		if validate, ok := depRaw["validate_names"].(bool); ok {
+			depConfig.ValidateNames = validate
+		}
+		if check, ok := depRaw["check_repos"].(bool); ok {
+			depConfig.CheckRepos = check
+		}
+		if timeout, ok := depRaw["check_repos_timeout"].(string); ok {
+			depConfig.CheckReposTimeout = timeout
+		}
+		if add, ok := depRaw["auto_add_repos"].(bool); ok {
+			depConfig.AutoAddRepos = add
+		}
+		if cleanup, ok := depRaw["cleanup_repos"].(bool); ok {
+			depConfig.CleanupRepos = cleanup
+		}
+		if update, ok := depRaw["package_update"].(bool); ok {
+			depConfig.PackageUpdate = update
+		}
+	}
+
+	// Parse metrics config
+	var metricsConfig MetricsConfig
+	if metricsRaw, ok := raw["metrics"].(map[string]any); ok {
+		if enabled, ok := metricsRaw["enabled"].(bool); ok {
+			metricsConfig.Enabled = enabled
+		}
+		if pushgateway, ok := metricsRaw["pushgateway_url"].(string); ok {
+			metricsConfig.PushgatewayURL = pushgateway
+		}
+		if file, ok := metricsRaw["file"].(string); ok {
+			metricsConfig.File = file
+		}
+		if job, ok := metricsRaw["job"].(string); ok {
+			metricsConfig.Job = job
+		}
+	}
+
+	// Parse API versions
+	var apiVersions []string
+	if apiRaw, ok := raw["api_versions"].([]any); ok {
+		for _, v := range apiRaw {
+			if s, ok := v.(string); ok {
+				apiVersions = append(apiVersions, s)
+			}
+		}
+	}
+
+	chartPaths := parser.GetStringSlice("chart_paths", nil)
+	for i, chartPath := range chartPaths {
+		chartPaths[i] = normalizeChartPath(chartPath)
+	}
+
+	return &Config{
+		ChartPath:                normalizeChartPath(parser.GetString("chart_path", "", ".")),
+		ChartPaths:               chartPaths,
+		ChartGit:                 parseChartGitConfig(raw["chart_git"]),
+		StrictNameCheck:          parser.GetBool("strict_name_check", false),
+		Repository:               repoConfig,
+		Repositories:             repositories,
+		FailFast:                 parser.GetBool("fail_fast", false),
+		FailIfExists:             parser.GetBool("fail_if_exists", true),
+		AtomicMultiPush:          parser.GetBool("atomic_multi_push", false),
+		LoginConcurrency:         parser.GetInt("login_concurrency", 1),
+		Concurrency:              parser.GetInt("concurrency", 1),
+		ApprovalWebhook:          parser.GetString("approval_webhook", "", ""),
+		CommandTimeout:           parser.GetString("command_timeout", "", ""),
+		PackageRetries:           parser.GetInt("package_retries", 0),
+		Metrics:                  metricsConfig,
+		Environments:             parseStringMap(raw["environments"]),
+		EnvSchemas:               parseStringMap(raw["env_schemas"]),
+		Version:                  versionConfig,
+		RequireVersion:           parser.GetBool("require_version", false),
+		AllowVersionMismatch:     parser.GetBool("allow_version_mismatch", false),
+		Lint:                     parser.GetBool("lint", true),
+		LintStrict:               parser.GetBool("lint_strict", false),
+		LintIgnore:               parser.GetStringSlice("lint_ignore", nil),
+		MetadataPlaceholders:     parsePlaceholderConfig(raw["metadata_placeholders"]),
+		ChartPolicy:              parseChartPolicy(raw["chart_policy"]),
+		LicenseAllowlist:         parser.GetStringSlice("license_allowlist", nil),
+		AllowedRepositoryHosts:   parser.GetStringSlice("allowed_repository_hosts", nil),
+		TemplateValues:           parser.GetStringSlice("template_values", nil),
+		TemplateSet:              parseStringMap(raw["template_set"]),
+		RenderMatrix:             parseRenderMatrix(raw["render_matrix"]),
+		ValuesUpdates:            parseStringMap(raw["values_updates"]),
+		ValuesUpdatesCreate:      parser.GetBool("values_updates_create", false),
+		ChartAnnotations:         parseStringMap(raw["chart_annotations"]),
+		ReleaseNotes:             parser.GetString("release_notes", "", ""),
+		ReleaseNotesMode:         parser.GetString("release_notes_mode", "", ""),
+		UnitTest:                 parser.GetBool("unittest", false),
+		TemplateValidate:         parser.GetBool("template_validate", true),
+		ValidateValuesSchema:     parser.GetBool("validate_values_schema", false),
+		ValidateValuesExample:    parser.GetBool("validate_values_example", false),
+		Test:                     parser.GetBool("test", false),
+		KubeVersion:              parser.GetString("kube_version", "", ""),
+		MinHelmVersion:           parser.GetString("min_helm_version", "", ""),
+		HelmBinary:               parser.GetString("helm_binary", "", "helm"),
+		HelmEnv:                  parseStringMap(raw["helm_env"]),
+		Mirror:                   parseMirrorConfig(raw["mirror"]),
+		GitHubRelease:            parseGitHubReleaseConfig(raw["github_release"]),
+		Provenance:               parseProvenanceConfig(raw["provenance"]),
+		Notify:                   parseNotifyConfig(raw["notify"]),
+		Kubeconform:              parseKubeconformConfig(raw["kubeconform"]),
+		ScanImages:               parseImageScanConfig(raw["scan_images"]),
+		ImagePolicy:              parseImagePolicyConfig(raw["image_policy"]),
+		ForbidHardcodedNamespace: parser.GetBool("forbid_hardcoded_namespace", false),
+		DiscourageInlineSecrets:  parser.GetBool("discourage_inline_secrets", false),
+		FailOnEmptyTemplate:      parser.GetBool("fail_on_empty_template", false),
+		ValidateCRConsistency:    parser.GetBool("validate_cr_consistency", false),
+		TemplateIncludeCRDs:      parser.GetBool("template_include_crds", false),
+		TemplateOutput:           parser.GetString("template_output", "", ""),
+		TemplateNamespace:        parser.GetString("template_namespace", "", ""),
+		APIVersions:              apiVersions,
+		Dependencies:             depConfig,
+		RunHelmDocs:              parser.GetBool("run_helm_docs", false),
+		HelmDocsFailOnError:      parser.GetBool("helm_docs_fail_on_error", true),
+		Sign:                     parser.GetBool("sign", false),
+		SignKey:                  parser.GetString("sign_key", "", ""),
+		SignMode:                 parser.GetString("sign_mode", "", "gpg"),
+		DebugTimings:             parser.GetBool("debug_timings", false),
+		OutputFormat:             parser.GetString("output_format", "", OutputFormatText),
+		MessageTemplate:          parser.GetString("message_template", "", ""),
+		CosignKey:                parser.GetString("cosign_key", "", ""),
+		CosignKeyless:            parser.GetBool("cosign_keyless", false),
+		Keyring:                  parser.GetString("keyring", "", ""),
+		PassphraseFile:           parser.GetString("passphrase_file", "", ""),
+		SignKeyEnv:               parser.GetString("sign_key_env", "", ""),
+		OutputDir:                parser.GetString("output_dir", "", ".helm-packages"),
+		OCIAnnotations:           parser.GetBool("oci_annotations", false),
+		OutputFilename:           parser.GetString("output_filename", "", ""),
+		PackagePath:              parser.GetString("package_path", "", ""),
+		ContextPath:              parser.GetString("context_path", "", ""),
+		DryRun:                   parser.GetBool("dry_run", false),
+	}
+}
+
+// validatePackagePath checks that package_path is a chart package and isn't
+// combined with options that only apply while packaging.
+func validatePackagePath(vb *helpers.ValidationBuilder, cfg *Config) {
+	if _, err := os.Stat(cfg.PackagePath); err != nil {
+		vb.AddError("package_path", fmt.Sprintf("Package not found: %s", cfg.PackagePath))
+	} else if _, err := readPackagedChart(cfg.PackagePath); err != nil {
+		vb.AddError("package_path", fmt.Sprintf("Not a chart package: %v", err))
+	}
+
+	if len(cfg.ChartPaths) > 0 {
+		vb.AddError("package_path", "package_path can't be combined with chart_paths")
+	}
+	if cfg.ChartGit.URL != "" {
+		vb.AddError("package_path", "package_path can't be combined with chart_git")
+	}
+	if len(cfg.Environments) > 0 {
+		vb.AddError("package_path", "package_path can't be combined with environments, which are packaged separately")
+	}
+	if cfg.Sign && cfg.SignMode == "gpg" {
+		vb.AddError("package_path", "GPG signing happens while packaging; sign the package when building it or use sign_mode: cosign")
+	}
+	if cfg.OutputFilename != "" {
+		vb.AddError("output_filename", "output_filename names packaged charts and can't be combined with package_path")
+	}
+}
+
+// validateChartPath checks that chartPath contains a valid Chart.yaml.
+func validateChartPath(vb *helpers.ValidationBuilder, field, chartPath string, strictName bool) []string {
+	chartFile := filepath.Join(chartPath, "Chart.yaml")
+	if _, err := os.Stat(chartFile); os.IsNotExist(err) {
+		vb.AddError(field, fmt.Sprintf("Chart.yaml not found in %s", chartPath))
+		return nil
+	}
+
+	chart, err := ParseChart(chartPath)
+	if err != nil {
+		vb.AddError(field, fmt.Sprintf("Invalid Chart.yaml in %s: %v", chartPath, err))
+	} else if chart.Name == "" {
+		vb.AddError(field, fmt.Sprintf("Chart name is required in %s", chartPath))
+	} else if mismatch := chartNameMismatch(chartPath, chart); mismatch != "" {
+		if strictName {
+			vb.AddError(field, mismatch)
+		} else {
+			return []string{mismatch}
+		}
+	}
+	return nil
+}
+
+// chartNameMismatch describes a chart whose directory isn't named after it, as
+// helm expects, or returns "". The current directory is never reported.
+func chartNameMismatch(chartPath string, chart *Chart) string {
+	chartPath = normalizeChartPath(chartPath)
+	if chartPath == "." || filepath.Base(chartPath) == chart.Name {
+		return ""
+	}
+	return fmt.Sprintf("Chart directory %s does not match chart name %q", chartPath, chart.Name)
+}
+
+// validateHelmPlugin checks that the helm plugin required by a repository type is installed.
+func validateHelmPlugin(vb *helpers.ValidationBuilder, helm helmBinary, field, name string) {
+	plugins, err := listHelmPlugins(helm)
+	if err != nil {
+		vb.AddError(field, fmt.Sprintf("Cannot determine installed helm plugins: %v", err))
+		return
+	}
+	for _, p := range plugins {
+		if p == name {
+			return
+		}
+	}
+	vb.AddError(field, fmt.Sprintf("helm %s plugin is not installed", name))
+}
+
+// validateSigning validates the signing configuration.
+func validateSigning(vb *helpers.ValidationBuilder, cfg *Config) {
+	switch cfg.SignMode {
+	case "gpg":
+		if cfg.SignKeyEnv == "" {
+			break
+		}
+		if cfg.Keyring != "" {
+			vb.AddError("sign_key_env", "sign_key_env and keyring are mutually exclusive")
+		}
+		if os.Getenv(cfg.SignKeyEnv) == "" {
+			vb.AddError("sign_key_env", fmt.Sprintf("Environment variable %s is not set", cfg.SignKeyEnv))
+		}
+	case "cosign":
+		for _, repo := range cfg.targetRepositories() {
+			if repo.Type != "oci" {
+				vb.AddError("sign_mode", fmt.Sprintf("cosign signing requires oci repositories, got %s", repo.Type))
+				break
+			}
+		}
+		if cfg.CosignKey == "" && !cfg.CosignKeyless {
+			vb.AddError("cosign_key", "cosign_key or cosign_keyless is required for cosign signing")
+		}
+		if cfg.CosignKey != "" && cfg.CosignKeyless {
+			vb.AddError("cosign_keyless", "cosign_key and cosign_keyless are mutually exclusive")
+		}
+		if _, err := exec.LookPath("cosign"); err != nil {
+			vb.AddError("sign_mode", "cosign not found in PATH (required for cosign signing)")
+		}
+	default:
+		vb.AddError("sign_mode", fmt.Sprintf("Unsupported sign mode: %s", cfg.SignMode))
+	}
+}
+
+// validateRepositoryConfig validates a single repository configuration block.
+func validateRepositoryConfig(vb *helpers.ValidationBuilder, field string, repo RepositoryConfig, helm helmBinary, helmVersion string) {
+	if repo.URL == "" {
+		vb.AddError(field+".url", "Repository URL is required")
+	}
+
+	if repo.HealthCheck && repo.HealthPath == "" && repo.Type != "chartmuseum" {
+		vb.AddError(field+".health_path", "Health path is required for non-chartmuseum repositories")
+	}
+
+	credentialFiles := map[string]string{"username_file": repo.UsernameFile, "password_file": repo.PasswordFile}
+	for _, name := range slices.Sorted(maps.Keys(credentialFiles)) {
+		file := credentialFiles[name]
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			vb.AddError(field+"."+name, fmt.Sprintf("Credential file not found: %s", file))
+		}
+	}
+
+	if repo.CAFile != "" {
+		if _, err := os.Stat(repo.CAFile); err != nil {
+			vb.AddError(field+".ca_file", fmt.Sprintf("CA file not found: %s", repo.CAFile))
+		}
+	}
+	if (repo.CAFile != "" || repo.Insecure) && (repo.Type == "s3" || repo.Type == "gcs") {
+		vb.AddError(field+".ca_file", "TLS settings are not supported for s3 and gcs repositories")
+	}
+
+	if repo.Overwrite && repo.Type != "chartmuseum" && repo.Type != "cloudsmith" && (repo.Type != "oci" || len(repo.AdditionalTags) == 0) {
+		vb.AddError(field+".overwrite", "Overwriting is only supported for chartmuseum and cloudsmith repositories and oci additional_tags")
+	}
+	if len(repo.AdditionalTags) > 0 {
+		if repo.Type != "oci" {
+			vb.AddError(field+".additional_tags", "Additional tags are only supported for oci repositories")
+		} else if _, err := findOCITagTool(); err != nil {
+			vb.AddError(field+".additional_tags", "crane or oras not found in PATH (required for additional_tags)")
+		}
+		if _, err := renderOCITags(repo.AdditionalTags, "1.2.3"); err != nil {
+			vb.AddError(field+".additional_tags", err.Error())
+		}
+	}
+	if repo.StripChartName && repo.Type != "oci" {
+		vb.AddError(field+".strip_chart_name", "strip_chart_name only applies to oci repositories")
+	}
+	if repo.PreflightMediaCheck && repo.Type != "oci" {
+		vb.AddError(field+".preflight_media_check", "The media type check is only supported for oci repositories")
+	}
+
+	if repo.Method != "" {
+		if repo.Type != "http" && repo.Type != "chartmuseum" {
+			vb.AddError(field+".method", "Upload method is only configurable for http and chartmuseum repositories")
+		} else if !slices.Contains(uploadMethods, strings.ToUpper(repo.Method)) {
+			vb.AddError(field+".method", fmt.Sprintf("Unsupported upload method %q (expected one of %s)", repo.Method, strings.Join(uploadMethods, ", ")))
+		}
+	}
+
+	switch repo.UploadFormat {
+	case "", "raw", "multipart":
+		if repo.UploadFormat != "" && repo.Type != "http" && repo.Type != "chartmuseum" {
+			vb.AddError(field+".upload_format", "Upload format is only configurable for http and chartmuseum repositories")
+		}
+	default:
+		vb.AddError(field+".upload_format", fmt.Sprintf("Unsupported upload format %q (expected raw or multipart)", repo.UploadFormat))
+	}
+	if repo.UploadField != "" && repo.UploadFormat != "multipart" {
+		vb.AddError(field+".upload_field", "upload_field only applies to upload_format: multipart")
+	}
+
+	switch repo.AuthMode {
+	case "", "static":
+	case "ecr":
+		if repo.Type != "oci" {
+			vb.AddError(field+".auth_mode", "ECR auth mode requires an oci repository")
+		}
+		if repo.CredentialCommand != "" {
+			vb.AddError(field+".credential_command", "credential_command cannot be combined with ecr auth mode")
+		}
+		if _, err := exec.LookPath("aws"); err != nil {
+			vb.AddError(field+".auth_mode", "AWS CLI not found in PATH (required for ecr auth mode)")
+		}
+	default:
+		vb.AddError(field+".auth_mode", fmt.Sprintf("Unsupported auth mode: %s", repo.AuthMode))
+	}
+
+	hasAPIKey := repo.APIKey != "" || repo.APIKeyEnv != ""
+	hasToken := repo.Token != "" || repo.TokenEnv != ""
+	if (hasAPIKey || hasToken) && apiKeyHeaders[repo.Type] == "" {
+		vb.AddError(field+".api_key", "api_key and token are only supported for artifactory and cloudsmith repositories")
+	} else if hasAPIKey && hasToken {
+		vb.AddError(field+".token", "api_key and token are mutually exclusive")
+	}
+	if repo.Type == "cloudsmith" && repo.URL != "" {
+		if _, _, err := cloudsmithRepository(repo.URL); err != nil {
+			vb.AddError(field+".url", err.Error())
+		}
+	}
+
+	switch repo.Type {
+	case "s3", "gcs":
+		validateHelmPlugin(vb, helm, field+".type", repo.Type)
+		if repo.Reindex && repo.Type != "s3" {
+			vb.AddError(field+".reindex", "Reindexing is only supported for s3 repositories; helm gcs push updates the index itself")
+		}
+		if repo.IndexLocation != "" && !validIndexLocation(repo.Type, repo.IndexLocation) {
+			vb.AddError(field+".index_location", fmt.Sprintf("index_location must be an http(s) URL or a %s URL", bucketScheme(repo.Type)))
+		}
+	default:
+		if repo.Reindex {
+			vb.AddError(field+".reindex", "Reindexing is only supported for s3 repositories")
+		}
+		if repo.Index && repo.Type != "http" {
+			vb.AddError(field+".index", "Index generation is only supported for http repositories")
+		}
+		switch {
+		case repo.Type == "http" && repo.Index:
+			if repo.IndexLocation != "" && !validIndexLocation(repo.Type, repo.IndexLocation) {
+				vb.AddError(field+".index_location", "index_location must be an http(s) URL")
+			}
+		case repo.IndexLocation != "":
+			vb.AddError(field+".index_location", "A separate index location is only supported for s3 and gcs repositories, and http repositories with index")
+		}
+	}
+
+	durations := map[string]string{
+		"dial_timeout":            repo.DialTimeout,
+		"tls_handshake_timeout":   repo.TLSHandshakeTimeout,
+		"response_header_timeout": repo.ResponseHeaderTimeout,
+		"http_timeout":            repo.HTTPTimeout,
+		"upload_timeout":          repo.UploadTimeout,
+		"wait_timeout":            repo.WaitTimeout,
+		"wait_interval":           repo.WaitInterval,
+	}
+	for _, name := range slices.Sorted(maps.Keys(durations)) {
+		value := durations[name]
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			vb.AddError(field+"."+name, fmt.Sprintf("Invalid duration: %s", value))
+		}
+	}
+
+	if repo.MaxIdleConns < 0 {
+		vb.AddError(field+".max_idle_conns", "max_idle_conns must not be negative")
+	}
+
+	if repo.WaitAvailable && repo.Type != "oci" && repo.Type != "chartmuseum" {
+		vb.AddError(field+".wait_available", "Waiting for pushed charts is only supported for oci and chartmuseum repositories")
+	}
+
+	if repo.VerifyAfterPush && repo.Type != "oci" {
+		vb.AddError(field+".verify_after_push", "Push verification is only supported for oci repositories")
+	}
+
+	switch repo.OCITagBuildMetadata {
+	case "", "underscore", "reject":
+		if repo.OCITagBuildMetadata != "" && repo.Type != "oci" {
+			vb.AddError(field+".oci_tag_build_metadata", "OCI tag handling only applies to oci repositories")
+		}
+	default:
+		vb.AddError(field+".oci_tag_build_metadata", fmt.Sprintf("Unsupported value %q (expected underscore or reject)", repo.OCITagBuildMetadata))
+	}
+
+	if repo.UpdateStablePointer && repo.Type != "http" && repo.Type != "s3" && repo.Type != "gcs" {
+		vb.AddError(field+".update_stable_pointer", "Stable pointers are only supported for http, s3 and gcs repositories")
+	}
+
+	if repo.Prune {
+		if repo.Type != "chartmuseum" {
+			vb.AddError(field+".prune", "Pruning old versions is only supported for chartmuseum repositories")
+		}
+		if repo.RetainVersions < 1 {
+			vb.AddError(field+".retain_versions", "retain_versions must be at least 1 when prune is enabled")
+		}
+	}
+	if repo.RetentionKeep < 0 {
+		vb.AddError(field+".retention_keep", "retention_keep must not be negative")
+	} else if repo.RetentionKeep > 0 && repo.Type != "chartmuseum" {
+		vb.AddError(field+".retention_keep", "Retention cleanup is only supported for chartmuseum repositories")
+	}
+	if repo.RetentionWindow != "" {
+		if d, err := time.ParseDuration(repo.RetentionWindow); err != nil || d <= 0 {
+			vb.AddError(field+".retention_window", fmt.Sprintf("Invalid duration: %s", repo.RetentionWindow))
+		} else if repo.RetentionKeep == 0 {
+			vb.AddError(field+".retention_window", "retention_window requires retention_keep")
+		}
+	}
+
+	// For OCI, verify Helm version supports it
+	if repo.Type == "oci" && helmVersion != "" && !strings.HasPrefix(helmVersion, "v3") {
+		vb.AddError(field+".type", "OCI requires Helm 3.x")
+	}
+}
+
+// parseStringMap converts a raw config map into a map of strings, ignoring non-string values.
+func parseStringMap(raw any) map[string]string {
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return nil
+	}
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			result[k] = s
+		}
+	}
+	return result
+}
+
+// parseRenderMatrix parses the render_matrix list of --set override maps.
+func parseRenderMatrix(raw any) []map[string]string {
+	entries, ok := raw.([]any)
+	if !ok {
+		return nil
+	}
+	matrix := make([]map[string]string, 0, len(entries))
+	for _, entry := range entries {
+		if set := parseStringMap(entry); len(set) > 0 {
+			matrix = append(matrix, set)
+		}
+	}
+	return matrix
+}
+
+// parseRepositoryConfig parses a single repository configuration block.
+func parseRepositoryConfig(repoRaw map[string]any) RepositoryConfig {
+	repoConfig := RepositoryConfig{
+		Type:     "oci",
+		AuthMode: "static",
+	}
+	if t, ok := repoRaw["type"].(string); ok {
+		repoConfig.Type = t
+	}
+	if url, ok := repoRaw["url"].(string); ok {
+		repoConfig.URL = url
+	}
+	if name, ok := repoRaw["name"].(string); ok {
+		repoConfig.Name = name
+	}
+	if username, ok := repoRaw["username"].(string); ok {
+		repoConfig.Username = username
+	}
+	if password, ok := repoRaw["password"].(string); ok {
+		repoConfig.Password = password
+	}
+	if usernameEnv, ok := repoRaw["username_env"].(string); ok {
+		repoConfig.UsernameEnv = usernameEnv
+	}
+	if usernameFile, ok := repoRaw["username_file"].(string); ok {
+		repoConfig.UsernameFile = usernameFile
+	}
+	if passwordEnv, ok := repoRaw["password_env"].(string); ok {
+		repoConfig.PasswordEnv = passwordEnv
+	}
+	if passwordFile, ok := repoRaw["password_file"].(string); ok {
+		repoConfig.PasswordFile = passwordFile
+	}
+	if regConfig, ok := repoRaw["registry_config"].(string); ok {
+		repoConfig.RegistryConfig = regConfig
+	}
+	if healthCheck, ok := repoRaw["health_check"].(bool); ok {
+		repoConfig.HealthCheck = healthCheck
+	}
+	if healthPath, ok := repoRaw["health_path"].(string); ok {
+		repoConfig.HealthPath = healthPath
+	}
+	if authMode, ok := repoRaw["auth_mode"].(string); ok {
+		repoConfig.AuthMode = authMode
+	}
+	if credCommand, ok := repoRaw["credential_command"].(string); ok {
+		repoConfig.CredentialCommand = credCommand
+	}
+	if apiKey, ok := repoRaw["api_key"].(string); ok {
+		repoConfig.APIKey = apiKey
+	}
+	if apiKeyEnv, ok := repoRaw["api_key_env"].(string); ok {
+		repoConfig.APIKeyEnv = apiKeyEnv
+	}
+	if token, ok := repoRaw["token"].(string); ok {
+		repoConfig.Token = token
+	}
+	if tokenEnv, ok := repoRaw["token_env"].(string); ok {
+		repoConfig.TokenEnv = tokenEnv
+	}
+	repoConfig.RetainVersions = helpers.NewConfigParser(repoRaw).GetInt("retain_versions", 0)
+	repoConfig.RetentionKeep = helpers.NewConfigParser(repoRaw).GetInt("retention_keep", 0)
+	repoConfig.AdditionalTags = helpers.NewConfigParser(repoRaw).GetStringSlice("additional_tags", nil)
+	if window, ok := repoRaw["retention_window"].(string); ok {
+		repoConfig.RetentionWindow = window
+	}
+	if prune, ok := repoRaw["prune"].(bool); ok {
+		repoConfig.Prune = prune
+	}
+	if verify, ok := repoRaw["verify_after_push"].(bool); ok {
+		repoConfig.VerifyAfterPush = verify
+	}
+	if reindex, ok := repoRaw["reindex"].(bool); ok {
+		repoConfig.Reindex = reindex
+	}
+	if mode, ok := repoRaw["oci_tag_build_metadata"].(string); ok {
+		repoConfig.OCITagBuildMetadata = mode
+	}
+	if strip, ok := repoRaw["strip_chart_name"].(bool); ok {
+		repoConfig.StripChartName = strip
+	}
+	if index, ok := repoRaw["index"].(bool); ok {
+		repoConfig.Index = index
+	}
+	if location, ok := repoRaw["index_location"].(string); ok {
+		repoConfig.IndexLocation = strings.TrimSuffix(location, "/")
+	}
+	if caFile, ok := repoRaw["ca_file"].(string); ok {
+		repoConfig.CAFile = caFile
+	}
+	if insecure, ok := repoRaw["insecure"].(bool); ok {
+		repoConfig.Insecure = insecure
+	}
+	if method, ok := repoRaw["method"].(string); ok {
+		repoConfig.Method = method
+	}
+	if format, ok := repoRaw["upload_format"].(string); ok {
+		repoConfig.UploadFormat = format
+	}
+	if field, ok := repoRaw["upload_field"].(string); ok {
+		repoConfig.UploadField = field
+	}
+	if overwrite, ok := repoRaw["overwrite"].(bool); ok {
+		repoConfig.Overwrite = overwrite
+	}
+	if preflight, ok := repoRaw["preflight_media_check"].(bool); ok {
+		repoConfig.PreflightMediaCheck = preflight
+	}
+	if stable, ok := repoRaw["update_stable_pointer"].(bool); ok {
+		repoConfig.UpdateStablePointer = stable
+	}
+	repoConfig.Annotations = parseStringMap(repoRaw["annotations"])
+	if dial, ok := repoRaw["dial_timeout"].(string); ok {
+		repoConfig.DialTimeout = dial
+	}
+	if tls, ok := repoRaw["tls_handshake_timeout"].(string); ok {
+		repoConfig.TLSHandshakeTimeout = tls
+	}
+	if header, ok := repoRaw["response_header_timeout"].(string); ok {
+		repoConfig.ResponseHeaderTimeout = header
+	}
+	if timeout, ok := repoRaw["http_timeout"].(string); ok {
+		repoConfig.HTTPTimeout = timeout
+	}
+	if timeout, ok := repoRaw["upload_timeout"].(string); ok {
+		repoConfig.UploadTimeout = timeout
+	}
+	repoConfig.MaxIdleConns = helpers.NewConfigParser(repoRaw).GetInt("max_idle_conns", 0)
+	if wait, ok := repoRaw["wait_available"].(bool); ok {
+		repoConfig.WaitAvailable = wait
+	}
+	if timeout, ok := repoRaw["wait_timeout"].(string); ok {
+		repoConfig.WaitTimeout = timeout
+	}
+	if interval, ok := repoRaw["wait_interval"].(string); ok {
+		repoConfig.WaitInterval = interval
+	}
+	return repoConfig
+}
+
+// templateOptions returns the options templates are rendered with during validation.
+func (c *Config) templateOptions() TemplateOptions {
+	return TemplateOptions{
+		KubeVersion: c.KubeVersion,
+		APIVersions: c.APIVersions,
+		Namespace:   c.TemplateNamespace,
+		ValuesFiles: c.TemplateValues,
+		Set:         c.TemplateSet,
+		IncludeCRDs: c.TemplateIncludeCRDs,
+	}
+}
+
+// manifestNamespace returns the namespace charts are rendered into for the
+// manifest checks: template_namespace, or releaseNamespaceSentinel when unset.
+func (c *Config) manifestNamespace() string {
+	if c.TemplateNamespace != "" {
+		return c.TemplateNamespace
+	}
+	return releaseNamespaceSentinel
+}
+
+// checkReposTimeout returns the configured dependency repository check timeout,
+// falling back to DefaultRepoCheckTimeout when unset or invalid.
+func (c DependencyConfig) checkReposTimeout() time.Duration {
+	d, err := time.ParseDuration(c.CheckReposTimeout)
+	if err != nil || d <= 0 {
+		return DefaultRepoCheckTimeout
+	}
+	return d
+}
+
+// commandTimeout returns the configured helm command timeout, falling back to
+// DefaultCommandTimeout when unset or invalid.
+func (c *Config) commandTimeout() time.Duration {
+	d, err := time.ParseDuration(c.CommandTimeout)
+	if err != nil || d <= 0 {
+		return DefaultCommandTimeout
+	}
+	return d
+}
+
+// helmBinary returns the helm executable and environment every helm command runs with.
+func (c *Config) helmBinary() helmBinary {
+	return helmBinary{path: c.HelmBinary, env: c.HelmEnv}
+}
+
+// targetRepositories returns every repository the chart should be published to.
+// The single repository entry is kept for backward compatibility and comes first.
+func (c *Config) targetRepositories() []RepositoryConfig {
+	var repos []RepositoryConfig
+	if c.Repository.URL != "" || len(c.Repositories) == 0 {
+		repos = append(repos, c.Repository)
+	}
+	return append(repos, c.Repositories...)
+}
+
+// newTargetRepositories creates the repositories charts are pushed to, sharing
+// logins. With sign_mode cosign every OCI push is signed.
+func (c *Config) newTargetRepositories(logins loginLimiter) []*Repository {
+	targets := c.targetRepositories()
+	repos := make([]*Repository, 0, len(targets))
+	for _, target := range targets {
+		repo := NewRepository(target)
+		repo.SetContextPath(c.ContextPath)
+		repo.SetLoginLimiter(logins)
+		repo.SetHelmBinary(c.helmBinary())
+		repo.SetTimeout(c.commandTimeout())
+		if c.Sign && c.SignMode == "cosign" && target.Type == "oci" {
+			repo.SetCosign(&CosignOptions{Key: c.CosignKey, Keyless: c.CosignKeyless})
+		}
+		repos = append(repos, repo)
+	}
+	return repos
+}
+
+func getHelmVersion(helm helmBinary) (string, error) {
+	cmd := helm.command(context.Background(), "version", "--short")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// chartVersion validates the release version as semver 2.0 and strips the
+// prerelease and build metadata components when configured.
+func (c VersionConfig) chartVersion(version string) (string, error) {
+	v, err := ParseSemVer(version)
+	if err != nil {
+		return "", err
+	}
+	if !c.StripPrerelease && !c.StripBuildMetadata {
+		return version, nil
+	}
+
+	if c.StripPrerelease {
+		v.Prerelease = ""
+	}
+	if c.StripBuildMetadata {
+		v.Build = ""
+	}
+	if strings.HasPrefix(version, "v") {
+		return "v" + v.String(), nil
+	}
+	return v.String(), nil
+}
+
+// appVersionData is the release metadata available to app_version_format.
+type appVersionData struct {
+	Version   string
+	CommitSHA string
+	ShortSHA  string // first 7 characters of CommitSHA
+	BuildDate string // UTC, YYYY-MM-DD
+	Branch    string
+	Tag       string
+	// ReleaseNotes and Changes are multi-line: the release notes as generated,
+	// and the release's commits as an artifacthub.io/changes list.
+	ReleaseNotes string
+	Changes      string
+}
+
+// newAppVersionData collects the app_version_format fields from the release context.
+func newAppVersionData(releaseCtx *plugin.ReleaseContext, now time.Time) appVersionData {
+	shortSHA := releaseCtx.CommitSHA
+	if len(shortSHA) > 7 {
+		shortSHA = shortSHA[:7]
+	}
+	return appVersionData{
+		Version:   releaseCtx.Version,
+		CommitSHA: releaseCtx.CommitSHA,
+		ShortSHA:  shortSHA,
+		BuildDate: now.UTC().Format(time.DateOnly),
+		Branch:    releaseCtx.Branch,
+		Tag:       releaseCtx.TagName,
+
+		ReleaseNotes: strings.TrimSpace(releaseCtx.ReleaseNotes),
+		Changes:      artifactHubChanges(releaseCtx.Changes),
+	}
+}
+
+// checkReleaseVersion fails the hook when require_version is set and the
+// release provides no version.
+func checkReleaseVersion(releaseCtx *plugin.ReleaseContext, cfg *Config) *plugin.ExecuteResponse {
+	if !cfg.RequireVersion || strings.TrimSpace(releaseCtx.Version) != "" {
+		return nil
+	}
+	return &plugin.ExecuteResponse{
+		Success: false,
+		Message: "No release version available: require_version is set but the release provided no version",
+	}
+}
+
+// checkChartVersion returns an error when Chart.yaml isn't updated with the
+// release version and its version differs from it, as the package would then
+// be published under a release it doesn't match.
+func checkChartVersion(chart *Chart, version string, cfg VersionConfig) error {
+	if cfg.UpdateChart {
+		return nil
+	}
+	want := version
+	if v, err := cfg.chartVersion(version); err == nil {
+		want = v
+	}
+	if strings.TrimPrefix(chart.Version, "v") == strings.TrimPrefix(want, "v") {
+		return nil
+	}
+	return fmt.Errorf("Chart.yaml version %s does not match release version %s and version.update_chart is disabled; update Chart.yaml, enable version.update_chart or set allow_version_mismatch", chart.Version, want)
+}
+
+// useChartVersion releases chart under the version already in its Chart.yaml,
+// for runs where the release provides none. It returns copies of releaseCtx
+// carrying the chart's version and of cfg with version updates turned off, so
+// Chart.yaml is left as is and packages are named after its version.
+func useChartVersion(releaseCtx *plugin.ReleaseContext, cfg *Config, chart *Chart) (*plugin.ReleaseContext, *Config) {
+	rc := *releaseCtx
+	rc.Version = chart.Version
+	c := *cfg
+	c.Version.UpdateChart = false
+	return &rc, &c
+}
+
+// appVersion returns the appVersion to write to Chart.yaml, or "" if it isn't updated.
+func (c VersionConfig) appVersion(releaseCtx *plugin.ReleaseContext, now time.Time) (string, error) {
+	if !c.UpdateAppVersion {
+		return "", nil
+	}
+	if c.AppVersionFormat == "" {
+		return releaseCtx.Version, nil
+	}
+	return renderAppVersion(c.AppVersionFormat, newAppVersionData(releaseCtx, now))
+}
+
+// renderAppVersion executes the app_version_format template.
+func renderAppVersion(format string, data appVersionData) (string, error) {
+	return renderTemplate("app_version_format", format, data)
+}
+
+// renderTemplate executes a template configured under name with data, such as
+// appVersionData for release metadata. Referencing a field data doesn't have is
+// an error.
+func renderTemplate(name, format string, data any) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(format)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", name, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// checkAppVersion returns an error if appVersion doesn't fully match pattern.
+func checkAppVersion(appVersion, pattern string) error {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return fmt.Errorf("invalid app_version_pattern %q: %w", pattern, err)
+	}
+	if !re.MatchString(appVersion) {
+		return fmt.Errorf("%q does not match pattern %q", appVersion, pattern)
+	}
+	return nil
+}
+
+// partialVersionPattern matches a major or major.minor version, such as "v3"
+// or "3.14".
+var partialVersionPattern = regexp.MustCompile(`^v?\d+(\.\d+)?$`)
+
+// checkMinHelmVersion returns an error if the detected helm version (as printed by
+// "helm version --short", e.g. "v3.14.2+gc309b6f") is lower than minimum. A
+// partial minimum is padded with zeros, so "3.14" means "3.14.0".
+func checkMinHelmVersion(detected, minimum string) error {
+	minimum = strings.TrimSpace(minimum)
+	if partialVersionPattern.MatchString(minimum) {
+		minimum += strings.Repeat(".0", 2-strings.Count(minimum, "."))
+	}
+	minVersion, err := ParseSemVer(minimum)
+	if err != nil {
+		return fmt.Errorf("invalid minimum helm version: %w", err)
+	}
+	detectedVersion, err := ParseSemVer(detected)
+	if err != nil {
+		return fmt.Errorf("cannot determine helm version from %q", detected)
+	}
+	if detectedVersion.Compare(minVersion) < 0 {
+		return fmt.Errorf("helm %s is older than the required minimum %s", detectedVersion, minVersion)
+	}
+	return nil
 }
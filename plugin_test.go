@@ -1,8 +1,19 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
 	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
 )
 
@@ -80,8 +91,8 @@ func TestParseConfig(t *testing.T) {
 				"output_dir":   "./dist",
 			},
 			validate: func(t *testing.T, cfg *Config) {
-				if cfg.ChartPath != "./charts/my-app" {
-					t.Errorf("expected chart_path './charts/my-app', got '%s'", cfg.ChartPath)
+				if cfg.ChartPath != "charts/my-app" {
+					t.Errorf("expected chart_path 'charts/my-app', got '%s'", cfg.ChartPath)
 				}
 				if cfg.Lint {
 					t.Error("expected lint to be false")
@@ -247,3 +258,1166 @@ func TestRepositoryConfigDefaults(t *testing.T) {
 		t.Errorf("expected repository.type to be 'oci' by default, got '%s'", cfg.Repository.Type)
 	}
 }
+
+func TestParseConfigRepositories(t *testing.T) {
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"repository": map[string]any{
+			"url": "oci://ghcr.io/myorg/charts",
+		},
+		"repositories": []any{
+			map[string]any{
+				"type": "chartmuseum",
+				"url":  "https://charts.example.com",
+			},
+		},
+		"fail_fast": true,
+	})
+
+	if !cfg.FailFast {
+		t.Error("expected fail_fast to be true")
+	}
+
+	targets := cfg.targetRepositories()
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 target repositories, got %d", len(targets))
+	}
+	if targets[0].Type != "oci" || targets[0].URL != "oci://ghcr.io/myorg/charts" {
+		t.Errorf("unexpected first target: %+v", targets[0])
+	}
+	if targets[1].Type != "chartmuseum" || targets[1].URL != "https://charts.example.com" {
+		t.Errorf("unexpected second target: %+v", targets[1])
+	}
+}
+
+func TestTargetRepositoriesWithoutLegacyEntry(t *testing.T) {
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"repositories": []any{
+			map[string]any{"type": "http", "url": "https://a.example.com"},
+			map[string]any{"type": "http", "url": "https://b.example.com"},
+		},
+	})
+
+	targets := cfg.targetRepositories()
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 target repositories, got %d", len(targets))
+	}
+	if targets[0].URL != "https://a.example.com" {
+		t.Errorf("expected first target 'https://a.example.com', got '%s'", targets[0].URL)
+	}
+}
+
+func TestPushToRepositories(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	var received int
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	packagePath := filepath.Join(t.TempDir(), "test-chart-1.0.0.tgz")
+	writeTestPackage(t, packagePath)
+
+	repos := []*Repository{
+		NewRepository(RepositoryConfig{Type: "http", URL: failing.URL}),
+		NewRepository(RepositoryConfig{Type: "http", URL: healthy.URL}),
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("continue on failure", func(t *testing.T) {
+		received = 0
+		results := pushToRepositories(context.Background(), repos, packagePath, 1, false, true, logger)
+
+		if len(results) != 2 {
+			t.Fatalf("expected 2 results, got %d", len(results))
+		}
+		if results[0].Err == nil {
+			t.Error("expected first push to fail")
+		}
+		if results[1].Err != nil || results[1].Skipped {
+			t.Errorf("expected second push to succeed, got %+v", results[1])
+		}
+		if received != 1 {
+			t.Errorf("expected healthy mirror to receive 1 push, got %d", received)
+		}
+
+		err := joinPushErrors(results)
+		if err == nil {
+			t.Fatal("expected aggregated error")
+		}
+		if !strings.Contains(err.Error(), "1 of 2 repositories failed") {
+			t.Errorf("unexpected error message: %v", err)
+		}
+		if !strings.Contains(err.Error(), healthy.URL+": ok") {
+			t.Errorf("expected per-repository status in error, got: %v", err)
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		received = 0
+		results := pushToRepositories(context.Background(), repos, packagePath, 1, true, true, logger)
+
+		if !results[1].Skipped {
+			t.Error("expected second push to be skipped")
+		}
+		if received != 0 {
+			t.Errorf("expected no pushes after failure, got %d", received)
+		}
+		if !strings.Contains(formatPushResults(results), healthy.URL+": skipped") {
+			t.Errorf("expected skipped status, got: %s", formatPushResults(results))
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		received = 0
+		results := pushToRepositories(context.Background(), repos, packagePath, 2, false, true, logger)
+
+		if len(results) != 2 || results[0].URL != failing.URL || results[1].URL != healthy.URL {
+			t.Fatalf("expected results in repository order, got %+v", results)
+		}
+		if results[0].Err == nil || results[1].Err != nil {
+			t.Errorf("expected only the first push to fail, got: %s", formatPushResults(results))
+		}
+	})
+}
+
+func TestExecutePostPublishDryRunReportsOCIReference(t *testing.T) {
+	chartDir := t.TempDir()
+	chartYAML := "apiVersion: v2\nname: my-app\nversion: 0.1.0\n"
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(chartYAML), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	p := &HelmPlugin{}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"chart_path": chartDir,
+			"repository": map[string]any{
+				"type": "oci",
+				"url":  "oci://ghcr.io/myorg/charts/",
+			},
+		},
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	want := "oci://ghcr.io/myorg/charts/my-app:1.2.3"
+	if !strings.Contains(resp.Message, want) {
+		t.Errorf("expected message to contain '%s', got '%s'", want, resp.Message)
+	}
+	targets, ok := resp.Outputs["push_targets"].([]string)
+	if !ok || len(targets) != 1 || targets[0] != want {
+		t.Errorf("expected push_targets output [%s], got %v", want, resp.Outputs["push_targets"])
+	}
+}
+
+func TestPruneOldVersions(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			_, _ = w.Write([]byte(`[{"version":"1.0.0"},{"version":"1.1.0"},{"version":"1.2.0"}]`))
+			return
+		}
+		deleted = append(deleted, r.URL.Path)
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := NewRepository(RepositoryConfig{
+		Type:           "chartmuseum",
+		URL:            server.URL,
+		RetainVersions: 1,
+		Prune:          true,
+	})
+
+	wouldDelete, err := pruneOldVersions(context.Background(), repo, "my-app", nil, true, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(wouldDelete) != 2 || len(deleted) != 0 {
+		t.Errorf("expected dry-run to report 2 versions without deleting, got %v / %v", wouldDelete, deleted)
+	}
+
+	if _, err := pruneOldVersions(context.Background(), repo, "my-app", nil, false, logger); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 2 || deleted[0] != "/api/charts/my-app/1.1.0" || deleted[1] != "/api/charts/my-app/1.0.0" {
+		t.Errorf("unexpected deletions: %v", deleted)
+	}
+
+	repo.config.Prune = false
+	deleted = nil
+	if _, err := pruneOldVersions(context.Background(), repo, "my-app", nil, false, logger); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("expected no deletions when prune is disabled, got %v", deleted)
+	}
+}
+
+func TestPruneOldVersionsRetention(t *testing.T) {
+	now := time.Now()
+	versions := fmt.Sprintf(`[
+		{"version":"2.0.0","created":%q},
+		{"version":"1.3.0","created":%q},
+		{"version":"1.2.0","created":%q},
+		{"version":"1.1.0","created":%q},
+		{"version":"1.0.0","created":%q},
+		{"version":"0.9.0","created":%q}
+	]`,
+		now.Add(-time.Hour).Format(time.RFC3339),
+		now.Add(-48*time.Hour).Format(time.RFC3339),
+		now.Add(-72*time.Hour).Format(time.RFC3339),
+		now.Add(-200*time.Hour).Format(time.RFC3339),
+		now.Add(-300*time.Hour).Format(time.RFC3339),
+		now.Add(-400*time.Hour).Format(time.RFC3339))
+
+	tests := []struct {
+		name      string
+		keep      int
+		window    string
+		published []string
+		want      []string
+	}{
+		{name: "keep newest", keep: 2, want: []string{"1.2.0", "1.1.0", "1.0.0", "0.9.0"}},
+		{name: "keep within window", keep: 1, window: "100h", want: []string{"1.1.0", "1.0.0", "0.9.0"}},
+		{name: "published backport is kept", keep: 2, published: []string{"1.0.0"}, want: []string{"1.2.0", "1.1.0", "0.9.0"}},
+		{name: "disabled", keep: 0},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" {
+					_, _ = w.Write([]byte(versions))
+					return
+				}
+				deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/api/charts/my-app/"))
+			}))
+			defer server.Close()
+
+			repo := NewRepository(RepositoryConfig{Type: "chartmuseum", URL: server.URL, RetentionKeep: tt.keep, RetentionWindow: tt.window})
+			if _, err := pruneOldVersions(context.Background(), repo, "my-app", tt.published, false, logger); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(deleted, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected deletions %v, got %v", tt.want, deleted)
+			}
+		})
+	}
+}
+
+func TestExecutePostPublishDryRunReportsOCITag(t *testing.T) {
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 0.1.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	execute := func(mode string) *plugin.ExecuteResponse {
+		t.Helper()
+		p := &HelmPlugin{}
+		resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:   plugin.HookPostPublish,
+			DryRun: true,
+			Config: map[string]any{
+				"chart_path": chartDir,
+				"repository": map[string]any{"url": "oci://ghcr.io/myorg/charts", "oci_tag_build_metadata": mode},
+			},
+			Context: plugin.ReleaseContext{Version: "1.2.3+build.7"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp
+	}
+
+	resp := execute("underscore")
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+	if resp.Outputs["oci_tag"] != "1.2.3_build.7" {
+		t.Errorf("expected oci_tag 1.2.3_build.7, got %v", resp.Outputs["oci_tag"])
+	}
+	if !strings.Contains(resp.Message, "oci://ghcr.io/myorg/charts/my-app:1.2.3_build.7") {
+		t.Errorf("expected sanitized reference in message, got: %s", resp.Message)
+	}
+
+	if resp := execute("reject"); resp.Success || !strings.Contains(resp.Message, "has build metadata") {
+		t.Errorf("expected build metadata to be rejected, got: %s", resp.Message)
+	}
+}
+
+func TestExecutePostPublishDryRunEnvironments(t *testing.T) {
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 0.1.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	p := &HelmPlugin{}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"chart_path": chartDir,
+			"repository": map[string]any{"url": "oci://ghcr.io/myorg"},
+			"environments": map[string]any{
+				"staging": "values-staging.yaml",
+				"prod":    "values-prod.yaml",
+			},
+		},
+		Context: plugin.ReleaseContext{Version: "1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	targets := resp.Outputs["push_targets"].([]string)
+	want := []string{"oci://ghcr.io/myorg/my-app:1.0.0-prod", "oci://ghcr.io/myorg/my-app:1.0.0-staging"}
+	if len(targets) != len(want) {
+		t.Fatalf("expected targets %v, got %v", want, targets)
+	}
+	for i := range want {
+		if targets[i] != want[i] {
+			t.Errorf("expected targets %v, got %v", want, targets)
+		}
+	}
+}
+
+func TestPackageChartIsolated(t *testing.T) {
+	writeFakeCommand(t, "helm", `touch "$4/my-app-1.0.0.tgz" "$4/my-app-1.0.0.tgz.prov"
+echo "Successfully packaged chart and saved it to: $4/my-app-1.0.0.tgz"`)
+
+	outputDir := t.TempDir()
+	packagePath, err := packageChart(context.Background(), NewHelmCLI("./my-app"), outputDir, nil, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(outputDir, "my-app-1.0.0.tgz"); packagePath != want {
+		t.Errorf("expected package %s, got %s", want, packagePath)
+	}
+
+	entries, _ := os.ReadDir(outputDir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if got := strings.Join(names, ","); got != "my-app-1.0.0.tgz,my-app-1.0.0.tgz.prov" {
+		t.Errorf("expected package and provenance file moved into place, got %s", got)
+	}
+}
+
+func TestExecutePostPublishPackagePath(t *testing.T) {
+	dir := writeFakeCommand(t, "helm", `echo "$@" >> "$(dirname "$0")/calls"
+[ "$1" = "push" ] && echo "Digest: sha256:deadbeef"
+exit 0
+`)
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.2.3.tgz")
+	writeChartArchive(t, packagePath, map[string]string{"my-app/Chart.yaml": "apiVersion: v2\nname: my-app\nversion: 1.2.3\n"})
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_path":   filepath.Join(t.TempDir(), "missing"),
+		"package_path": packagePath,
+		"repository":   map[string]any{"url": "oci://ghcr.io/myorg/charts"},
+	})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	resp, err := p.executePostPublish(context.Background(), &plugin.ReleaseContext{Version: "v1.2.3"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+	if resp.Outputs["chart_package"] != packagePath || resp.Outputs["oci_digest"] != "sha256:deadbeef" {
+		t.Errorf("unexpected outputs: %v", resp.Outputs)
+	}
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	if want := "push " + packagePath + " oci://ghcr.io/myorg/charts\n"; string(calls) != want {
+		t.Errorf("expected only a push of the pre-built package, got:\n%s", calls)
+	}
+
+	resp, err = p.executePostPublish(context.Background(), &plugin.ReleaseContext{Version: "1.3.0"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success || !strings.Contains(resp.Message, "expected release version 1.3.0") {
+		t.Errorf("expected version mismatch, got: %s", resp.Message)
+	}
+}
+
+func TestPackageOutputs(t *testing.T) {
+	dir := t.TempDir()
+	packagePath := filepath.Join(dir, "my-app-1.0.0.tgz")
+	if err := os.WriteFile(packagePath, []byte("hello"), 0644); err != nil {
+		t.Fatalf("failed to write package: %v", err)
+	}
+
+	outputs, err := packageOutputs(
+		[]chartPackage{{Version: "1.0.0", Path: packagePath}},
+		[]pushStatus{
+			{Package: "my-app-1.0.0.tgz", Type: "chartmuseum", URL: "https://charts.example.com"},
+			{Package: "my-app-1.0.0.tgz", Type: "oci", URL: "oci://ghcr.io/myorg", Digest: "sha256:abc"},
+		},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if outputs["chart_digest"] != "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("unexpected chart_digest: %v", outputs["chart_digest"])
+	}
+	if outputs["chart_package"] != packagePath {
+		t.Errorf("unexpected chart_package: %v", outputs["chart_package"])
+	}
+	if outputs["chart_version"] != "1.0.0" {
+		t.Errorf("unexpected chart_version: %v", outputs["chart_version"])
+	}
+	if outputs["oci_digest"] != "sha256:abc" {
+		t.Errorf("unexpected oci_digest: %v", outputs["oci_digest"])
+	}
+	if _, ok := outputs["packages"]; ok {
+		t.Error("expected no packages list for a single package")
+	}
+}
+
+func TestCheckMinHelmVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		detected string
+		minimum  string
+		wantErr  bool
+	}{
+		{name: "newer", detected: "v3.14.2+gc309b6f", minimum: "3.12.0"},
+		{name: "equal", detected: "v3.12.0+g1234567", minimum: "v3.12.0"},
+		{name: "older", detected: "v3.10.3+g835b733", minimum: "3.12.0", wantErr: true},
+		{name: "older major", detected: "v2.17.0+ga690bad", minimum: "3.0.0", wantErr: true},
+		{name: "unparseable detected", detected: "unknown", minimum: "3.0.0", wantErr: true},
+		{name: "partial minimum", detected: "v3.14.2+gc309b6f", minimum: "3.14"},
+		{name: "major only minimum", detected: "v3.0.0+g1234567", minimum: "v3"},
+		{name: "older than partial minimum", detected: "v3.13.3+gc8b9489", minimum: "3.14", wantErr: true},
+		{name: "invalid minimum", detected: "v3.14.2", minimum: "3.x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMinHelmVersion(tt.detected, tt.minimum)
+			if tt.wantErr && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateChartPathNameCheck(t *testing.T) {
+	root := t.TempDir()
+	writeChart(t, filepath.Join(root, "my-app"), "my-app", "1.0.0")
+	writeChart(t, filepath.Join(root, "old-name"), "my-app", "1.0.0")
+
+	tests := []struct {
+		name         string
+		chartPath    string
+		strict       bool
+		wantWarnings int
+		wantValid    bool
+	}{
+		{name: "matching directory", chartPath: filepath.Join(root, "my-app"), strict: true, wantValid: true},
+		{name: "mismatch warns", chartPath: filepath.Join(root, "old-name"), wantWarnings: 1, wantValid: true},
+		{name: "mismatch fails when strict", chartPath: filepath.Join(root, "old-name") + "/", strict: true, wantValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vb := helpers.NewValidationBuilder()
+			warnings := validateChartPath(vb, "chart_path", tt.chartPath, tt.strict)
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("expected %d warning(s), got %v", tt.wantWarnings, warnings)
+			}
+			resp := vb.Build()
+			if resp.Valid != tt.wantValid {
+				t.Fatalf("expected valid=%v, got errors %v", tt.wantValid, resp.Errors)
+			}
+			if !tt.wantValid && !strings.Contains(resp.Errors[0].Message, `does not match chart name "my-app"`) {
+				t.Errorf("unexpected error: %s", resp.Errors[0].Message)
+			}
+		})
+	}
+
+	// The current directory is never checked
+	if mismatch := chartNameMismatch(".", &Chart{Name: "my-app"}); mismatch != "" {
+		t.Errorf("expected no mismatch for ., got %q", mismatch)
+	}
+}
+
+func TestValidateSigningCosignRequiresOCI(t *testing.T) {
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"sign":           true,
+		"sign_mode":      "cosign",
+		"cosign_keyless": true,
+		"repository": map[string]any{
+			"type": "chartmuseum",
+			"url":  "https://charts.example.com",
+		},
+	})
+
+	vb := helpers.NewValidationBuilder()
+	validateSigning(vb, cfg)
+	resp := vb.Build()
+	if resp.Valid {
+		t.Fatal("expected validation to fail")
+	}
+	found := false
+	for _, e := range resp.Errors {
+		if e.Field == "sign_mode" && strings.Contains(e.Message, "oci") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected sign_mode error about oci, got %+v", resp.Errors)
+	}
+}
+
+func TestValidatePackageRetriesRequiresPackageUpdate(t *testing.T) {
+	p := &HelmPlugin{}
+	for _, packageUpdate := range []bool{false, true} {
+		resp, err := p.Validate(context.Background(), map[string]any{
+			"package_retries": 2,
+			"dependencies":    map[string]any{"package_update": packageUpdate},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		found := false
+		for _, e := range resp.Errors {
+			found = found || e.Field == "package_retries"
+		}
+		if found == packageUpdate {
+			t.Errorf("package_update=%v: expected package_retries error=%v, got %+v", packageUpdate, !packageUpdate, resp.Errors)
+		}
+	}
+}
+
+func TestValidateHelmBinary(t *testing.T) {
+	dir := t.TempDir()
+	for name, version := range map[string]string{"helm3": "v3.14.0+g1234567", "helm2": "v2.17.0+ga690bad"} {
+		script := "#!/bin/sh\necho " + version + "\n"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatalf("failed to write fake %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		binary  string
+		wantErr string
+	}{
+		{name: "helm 3", binary: filepath.Join(dir, "helm3")},
+		{name: "helm 2", binary: filepath.Join(dir, "helm2"), wantErr: "Helm 3.x required"},
+		{name: "missing", binary: filepath.Join(dir, "missing"), wantErr: "Helm binary not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &HelmPlugin{}
+			resp, err := p.Validate(context.Background(), map[string]any{"helm_binary": tt.binary})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, e := range resp.Errors {
+				if e.Field == "helm_binary" {
+					got = append(got, e.Message)
+				}
+			}
+			if tt.wantErr == "" && len(got) > 0 {
+				t.Errorf("unexpected helm_binary errors: %v", got)
+			}
+			if tt.wantErr != "" && (len(got) != 1 || !strings.Contains(got[0], tt.wantErr)) {
+				t.Errorf("expected helm_binary error containing %q, got %v", tt.wantErr, got)
+			}
+		})
+	}
+}
+
+func TestCheckAppVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		appVersion string
+		pattern    string
+		wantErr    bool
+	}{
+		{name: "conforming build number", appVersion: "1.2.3-build.42", pattern: `\d+\.\d+\.\d+-build\.\d+`},
+		{name: "missing build number", appVersion: "1.2.3", pattern: `\d+\.\d+\.\d+-build\.\d+`, wantErr: true},
+		{name: "partial match is rejected", appVersion: "v1.2.3", pattern: `\d+\.\d+\.\d+`, wantErr: true},
+		{name: "unrendered template", appVersion: "{{.Version}}-rc", pattern: `[0-9.]+(-rc)?`, wantErr: true},
+		{name: "invalid pattern", appVersion: "1.2.3", pattern: `(`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAppVersion(tt.appVersion, tt.pattern)
+			if tt.wantErr && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRenderAppVersion(t *testing.T) {
+	releaseCtx := &plugin.ReleaseContext{
+		Version:   "1.2.3",
+		TagName:   "v1.2.3",
+		Branch:    "main",
+		CommitSHA: "0123456789abcdef0123456789abcdef01234567",
+	}
+	data := newAppVersionData(releaseCtx, time.Date(2024, 3, 9, 23, 30, 0, 0, time.FixedZone("PST", -8*3600)))
+
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr string
+	}{
+		{name: "version", format: "{{.Version}}", want: "1.2.3"},
+		{name: "short sha", format: "{{.Version}}-{{.ShortSHA}}", want: "1.2.3-0123456"},
+		{name: "release metadata", format: "{{.Tag}}+{{.Branch}}.{{.BuildDate}}.{{.CommitSHA}}", want: "v1.2.3+main.2024-03-10.0123456789abcdef0123456789abcdef01234567"},
+		{name: "unknown field", format: "{{.Version}}-{{.Build}}", wantErr: "can't evaluate field Build"},
+		{name: "malformed", format: "{{.Version", wantErr: "invalid app_version_format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderAppVersion(tt.format, data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseConfigNormalizesChartPath(t *testing.T) {
+	p := &HelmPlugin{}
+	for _, chartPath := range []string{"charts/my-app", "./charts/my-app/", "  charts/my-app  ", "charts//my-app"} {
+		cfg := p.parseConfig(map[string]any{
+			"chart_path":  chartPath,
+			"chart_paths": []any{chartPath},
+		})
+		if cfg.ChartPath != "charts/my-app" {
+			t.Errorf("chart_path %q: expected 'charts/my-app', got '%s'", chartPath, cfg.ChartPath)
+		}
+		if len(cfg.ChartPaths) != 1 || cfg.ChartPaths[0] != "charts/my-app" {
+			t.Errorf("chart_paths %q: expected [charts/my-app], got %v", chartPath, cfg.ChartPaths)
+		}
+	}
+
+	cfg := p.parseConfig(map[string]any{"chart_path": "   "})
+	if cfg.ChartPath != "." {
+		t.Errorf("expected whitespace chart_path to default to '.', got '%s'", cfg.ChartPath)
+	}
+}
+
+func TestExecutePrePublishTemplateOutput(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "kind: ConfigMap"`)
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+	outputDir := t.TempDir()
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_path":      chartDir,
+		"lint":            false,
+		"template_output": filepath.Join(outputDir, "{{.Chart}}.yaml"),
+		"version":         map[string]any{"update_chart": false},
+		"dependencies":    map[string]any{"update": false, "build": false},
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	want := filepath.Join(outputDir, "my-app.yaml")
+	if resp.Outputs["template_output"] != want {
+		t.Errorf("expected template_output %s, got %v", want, resp.Outputs["template_output"])
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("expected rendered output file: %v", err)
+	}
+}
+
+func TestExecutePrePublishTemplateValues(t *testing.T) {
+	dir := writeFakeCommand(t, "helm", `echo "$@" > "$(dirname "$0")/args"
+echo "kind: ConfigMap"`)
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+	valuesFile := filepath.Join(chartDir, "values-prod.yaml")
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_path":      chartDir,
+		"lint":            false,
+		"template_values": []any{valuesFile},
+		"template_set":    map[string]any{"ingress.enabled": "true"},
+		"version":         map[string]any{"update_chart": false},
+		"dependencies":    map[string]any{"update": false, "build": false},
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if want := "-f " + valuesFile + " --set ingress.enabled=true"; !strings.Contains(string(args), want) {
+		t.Errorf("expected template args to contain %q, got %q", want, args)
+	}
+}
+
+func TestExecutePrePublishRenderMatrix(t *testing.T) {
+	writeFakeCommand(t, "helm", `case "$*" in
+*worker.enabled=true*) echo "Error: template: my-app/templates/worker.yaml:7:20: nil pointer" >&2; exit 1 ;;
+esac
+echo "kind: ConfigMap"`)
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		matrix      []any
+		wantSuccess bool
+	}{
+		{name: "defaults only", wantSuccess: true},
+		{name: "passing toggle", matrix: []any{map[string]any{"ingress.enabled": "true"}}, wantSuccess: true},
+		{name: "broken toggle", matrix: []any{map[string]any{"ingress.enabled": "true"}, map[string]any{"worker.enabled": "true"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &HelmPlugin{}
+			cfg := p.parseConfig(map[string]any{
+				"chart_path":    chartDir,
+				"lint":          false,
+				"render_matrix": tt.matrix,
+				"version":       map[string]any{"update_chart": false},
+				"dependencies":  map[string]any{"update": false, "build": false},
+			})
+
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got: %s", tt.wantSuccess, resp.Message)
+			}
+			if !tt.wantSuccess && !strings.Contains(resp.Message, "1 of 2 render(s) failed:\n  - worker.enabled=true") {
+				t.Errorf("expected the failing combination to be named, got: %s", resp.Message)
+			}
+		})
+	}
+}
+
+func TestVersionConfigChartVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  VersionConfig
+		version string
+		want    string
+		wantErr bool
+	}{
+		{name: "unchanged", version: "1.2.3-rc.1+build.7", want: "1.2.3-rc.1+build.7"},
+		{name: "strip build metadata", config: VersionConfig{StripBuildMetadata: true}, version: "1.2.3-rc.1+build.7", want: "1.2.3-rc.1"},
+		{name: "strip prerelease", config: VersionConfig{StripPrerelease: true}, version: "1.2.3-rc.1+build.7", want: "1.2.3+build.7"},
+		{name: "strip both", config: VersionConfig{StripPrerelease: true, StripBuildMetadata: true}, version: "1.2.3-rc.1+build.7", want: "1.2.3"},
+		{name: "keeps v prefix", config: VersionConfig{StripBuildMetadata: true}, version: "v1.2.3+build.7", want: "v1.2.3"},
+		{name: "invalid semver", version: "1.2", wantErr: true},
+		{name: "invalid when stripping", config: VersionConfig{StripBuildMetadata: true}, version: "1.2.3+", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.config.chartVersion(tt.version)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExecutePrePublishStripsChartVersion(t *testing.T) {
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\nappVersion: \"1.0.0\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_path":        chartDir,
+		"lint":              false,
+		"template_validate": false,
+		"version":           map[string]any{"strip_build_metadata": true},
+		"dependencies":      map[string]any{"update": false, "build": false},
+	})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "not-a-version"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success || !strings.Contains(resp.Message, "not a valid semantic version") {
+		t.Errorf("expected semver failure, got: %s", resp.Message)
+	}
+
+	resp, err = p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.2.3-rc.1+build.7"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+	chart, err := ParseChart(chartDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if chart.Version != "1.2.3-rc.1" {
+		t.Errorf("expected chart version 1.2.3-rc.1, got %s", chart.Version)
+	}
+	if chart.AppVersion != "1.2.3-rc.1+build.7" {
+		t.Errorf("expected appVersion to keep build metadata, got %s", chart.AppVersion)
+	}
+}
+
+func TestExecutePrePublishFailOnEmptyTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		render      string
+		wantSuccess bool
+	}{
+		{name: "renders nothing", render: `echo "---"; echo "# Source: my-app/templates/ingress.yaml"`, wantSuccess: false},
+		{name: "renders resources", render: `printf 'apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: my-app\n'`, wantSuccess: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFakeCommand(t, "helm", tt.render)
+			chartDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+				t.Fatalf("failed to write Chart.yaml: %v", err)
+			}
+
+			p := &HelmPlugin{}
+			cfg := p.parseConfig(map[string]any{
+				"chart_path":             chartDir,
+				"lint":                   false,
+				"fail_on_empty_template": true,
+				"version":                map[string]any{"update_chart": false},
+				"dependencies":           map[string]any{"update": false, "build": false},
+			})
+
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got: %s", tt.wantSuccess, resp.Message)
+			}
+			if !tt.wantSuccess && !strings.Contains(resp.Message, "no Kubernetes documents") {
+				t.Errorf("unexpected message: %s", resp.Message)
+			}
+		})
+	}
+}
+
+func TestExecutePrePublishUnitTest(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		wantSuccess bool
+		wantMessage string
+	}{
+		{
+			name:        "passing suites",
+			script:      `printf 'Test Suites: 3 passed, 3 total\n'`,
+			wantSuccess: true,
+			wantMessage: "(unittest: 3 suite(s) passed, 0 failed)",
+		},
+		{
+			name:        "failing suites",
+			script:      `printf 'Test Suites: 1 failed, 2 passed, 3 total\n'; exit 1`,
+			wantMessage: "Chart unit tests failed (2 suite(s) passed, 1 failed)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFakeCommand(t, "helm", tt.script)
+			chartDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+				t.Fatalf("failed to write Chart.yaml: %v", err)
+			}
+
+			p := &HelmPlugin{}
+			cfg := p.parseConfig(map[string]any{
+				"chart_path":        chartDir,
+				"lint":              false,
+				"unittest":          true,
+				"template_validate": false,
+				"version":           map[string]any{"update_chart": false},
+				"dependencies":      map[string]any{"update": false, "build": false},
+			})
+
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got: %s", tt.wantSuccess, resp.Message)
+			}
+			if !strings.Contains(resp.Message, tt.wantMessage) {
+				t.Errorf("expected message to contain %q, got: %s", tt.wantMessage, resp.Message)
+			}
+		})
+	}
+}
+
+func TestExecutePrePublishEmptyVersion(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "kind: ConfigMap"`)
+
+	tests := []struct {
+		name        string
+		version     string
+		raw         map[string]any
+		wantSuccess bool
+		wantVersion string
+	}{
+		{name: "empty version keeps the chart version", version: "", raw: map[string]any{}, wantSuccess: true, wantVersion: "1.0.0"},
+		{name: "blank version keeps the chart version", version: "  ", raw: map[string]any{"version": map[string]any{"update_chart": true, "update_app_version": false}}, wantSuccess: true, wantVersion: "1.0.0"},
+		{name: "empty version with require_version", version: "", raw: map[string]any{"require_version": true}},
+		{name: "empty version in mirror mode with require_version", version: "", raw: map[string]any{"require_version": true, "mirror": map[string]any{"enabled": true}}},
+		{name: "release version with require_version", version: "1.1.0", raw: map[string]any{"require_version": true}, wantSuccess: true, wantVersion: "1.1.0"},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chartDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\nappVersion: 0.9.0\n"), 0644); err != nil {
+				t.Fatalf("failed to write Chart.yaml: %v", err)
+			}
+			raw := map[string]any{
+				"chart_path":   chartDir,
+				"lint":         false,
+				"dependencies": map[string]any{"update": false, "build": false},
+			}
+			for k, v := range tt.raw {
+				raw[k] = v
+			}
+
+			p := &HelmPlugin{}
+			resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: tt.version}, p.parseConfig(raw), logger)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("expected success=%v, got: %s", tt.wantSuccess, resp.Message)
+			}
+			if !tt.wantSuccess {
+				if !strings.Contains(resp.Message, "No release version available") {
+					t.Errorf("expected a clear message, got: %s", resp.Message)
+				}
+				return
+			}
+
+			chart, err := ParseChart(chartDir)
+			if err != nil {
+				t.Fatalf("failed to parse chart: %v", err)
+			}
+			if chart.Version != tt.wantVersion {
+				t.Errorf("expected chart version %s, got %s", tt.wantVersion, chart.Version)
+			}
+			if tt.version == "" && chart.AppVersion != "0.9.0" {
+				t.Errorf("expected appVersion to be kept, got %s", chart.AppVersion)
+			}
+		})
+	}
+}
+
+func TestExecutePostPublishEmptyVersion(t *testing.T) {
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	p := &HelmPlugin{}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:   plugin.HookPostPublish,
+		DryRun: true,
+		Config: map[string]any{
+			"chart_path": chartDir,
+			"repository": map[string]any{"url": "oci://ghcr.io/myorg/charts"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+	if resp.Outputs["chart_version"] != "1.0.0" {
+		t.Errorf("expected the chart's own version, got %v", resp.Outputs["chart_version"])
+	}
+	want := "oci://ghcr.io/myorg/charts/my-app:1.0.0"
+	if targets, _ := resp.Outputs["push_targets"].([]string); len(targets) != 1 || targets[0] != want {
+		t.Errorf("expected push_targets [%s], got %v", want, resp.Outputs["push_targets"])
+	}
+}
+
+func TestExecutePostPublishSignatureVerificationFails(t *testing.T) {
+	// Fake helm whose signed package doesn't verify; pushes are recorded
+	dir := writeFakeCommand(t, "helm", `case "$1" in
+package)
+	touch "$4/my-app-1.0.0.tgz" "$4/my-app-1.0.0.tgz.prov"
+	echo "Successfully packaged chart and saved it to: $4/my-app-1.0.0.tgz"
+	;;
+verify) echo "Error: openpgp: invalid signature: hash tag doesn't match" >&2; exit 1 ;;
+push) touch "$(dirname "$0")/pushed" ;;
+esac
+`)
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_path": chartDir,
+		"output_dir": t.TempDir(),
+		"repository": map[string]any{"url": "oci://ghcr.io/myorg/charts"},
+		"sign":       true,
+		"sign_key":   "release@example.com",
+		"keyring":    "/keys/secring.gpg",
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePostPublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success || !strings.Contains(resp.Message, "signature verification of my-app-1.0.0.tgz failed: provenance file signature is invalid") {
+		t.Errorf("expected signature verification failure, got: %s", resp.Message)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pushed")); !os.IsNotExist(err) {
+		t.Error("expected the chart not to be pushed")
+	}
+}
+
+func TestCheckChartVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		chartVersion string
+		version      string
+		cfg          VersionConfig
+		wantErr      bool
+	}{
+		{name: "updated chart", chartVersion: "1.9.0", version: "2.0.0", cfg: VersionConfig{UpdateChart: true}},
+		{name: "matching version", chartVersion: "2.0.0", version: "v2.0.0"},
+		{name: "stripped prerelease", chartVersion: "2.0.0", version: "2.0.0-rc.1", cfg: VersionConfig{StripPrerelease: true}},
+		{name: "mismatch", chartVersion: "1.9.0", version: "2.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkChartVersion(&Chart{Version: tt.chartVersion}, tt.version, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error=%v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExecutePrePublishVersionMismatch(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "kind: ConfigMap"`)
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.9.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	for _, allow := range []bool{false, true} {
+		p := &HelmPlugin{}
+		cfg := p.parseConfig(map[string]any{
+			"chart_path":             chartDir,
+			"lint":                   false,
+			"allow_version_mismatch": allow,
+			"version":                map[string]any{"update_chart": false},
+			"dependencies":           map[string]any{"update": false, "build": false},
+		})
+
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "2.0.0"}, cfg, logger)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Success != allow {
+			t.Errorf("allow_version_mismatch=%v: expected success=%v, got: %s", allow, allow, resp.Message)
+		}
+		if !allow && !strings.Contains(resp.Message, "Chart.yaml version 1.9.0 does not match release version 2.0.0") {
+			t.Errorf("expected a version mismatch message, got: %s", resp.Message)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import "sync"
+
+// workerGroup runs functions on goroutines with at most limit of them running
+// at once, like errgroup.Group with SetLimit. With a limit of one, functions run
+// inline in the order they are added, so serial runs behave exactly as a plain
+// loop would.
+//
+// errgroup can't be used here: with SetLimit its Go blocks until a slot is
+// free, and the function only starts once it has one. A chart waiting for the
+// charts it depends on would then wait while holding a slot, and with every
+// slot taken by waiting charts the charts they wait for could never start.
+// workerGroup runs the wait before taking a slot instead.
+type workerGroup struct {
+	wg  sync.WaitGroup
+	sem chan struct{}
+}
+
+// newWorkerGroup creates a group running up to limit functions concurrently
+// (at least one).
+func newWorkerGroup(limit int) *workerGroup {
+	if limit < 1 {
+		limit = 1
+	}
+	return &workerGroup{sem: make(chan struct{}, limit)}
+}
+
+// Go runs fn once a slot is free. If wait is set it is called before taking a
+// slot, so functions waiting on others don't hold slots those others need.
+func (g *workerGroup) Go(wait, fn func()) {
+	if cap(g.sem) == 1 {
+		if wait != nil {
+			wait()
+		}
+		fn()
+		return
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if wait != nil {
+			wait()
+		}
+		g.sem <- struct{}{}
+		defer func() { <-g.sem }()
+		fn()
+	}()
+}
+
+// Wait blocks until every function added with Go has returned.
+func (g *workerGroup) Wait() {
+	g.wg.Wait()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerGroupRunsInlineWithLimitOne(t *testing.T) {
+	group := newWorkerGroup(1)
+	var order []int
+	for i := range 5 {
+		group.Go(func() { order = append(order, 100+i) }, func() { order = append(order, i) })
+		if len(order) != 2*(i+1) {
+			t.Fatalf("expected function %d to have run before Go returned, got %v", i, order)
+		}
+	}
+	group.Wait()
+
+	want := []int{100, 0, 101, 1, 102, 2, 103, 3, 104, 4}
+	if !slices.Equal(order, want) {
+		t.Errorf("expected %v, got %v", want, order)
+	}
+}
+
+func TestWorkerGroupRespectsLimit(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning, finished := 0, 0, 0
+	group := newWorkerGroup(3)
+	for range 10 {
+		group.Go(nil, func() {
+			mu.Lock()
+			running++
+			maxRunning = max(maxRunning, running)
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			finished++
+			mu.Unlock()
+		})
+	}
+	group.Wait()
+
+	if finished != 10 {
+		t.Errorf("expected Wait to return after all 10 functions, got %d", finished)
+	}
+	if maxRunning != 3 {
+		t.Errorf("expected 3 functions to run at once, got %d", maxRunning)
+	}
+}
+
+func TestWorkerGroupWaitDoesNotHoldSlot(t *testing.T) {
+	// first waits for second and third, which can only finish while running at
+	// the same time. If first's wait held one of the two slots they would
+	// never both start.
+	secondDone, thirdDone := make(chan struct{}), make(chan struct{})
+	var started sync.WaitGroup
+	started.Add(2)
+	both := func(done chan struct{}) func() {
+		return func() {
+			defer close(done)
+			started.Done()
+			started.Wait()
+		}
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		group := newWorkerGroup(2)
+		group.Go(func() { <-secondDone; <-thirdDone }, func() {})
+		group.Go(nil, both(secondDone))
+		group.Go(nil, both(thirdDone))
+		group.Wait()
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("functions waiting on others held slots those others needed")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ociManifestMediaType is the manifest media type helm pushes charts with.
+// Registries that predate OCI artifacts reject it.
+const ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+// CheckOCISupport probes an OCI registry for Helm chart support without pushing
+// anything: the registry must serve the distribution API at /v2/, and a HEAD
+// of the chart's manifest accepting only the OCI manifest type must not be
+// refused as unsupported. Unauthorized responses are inconclusive and pass.
+func (r *Repository) CheckOCISupport(ctx context.Context, chartName, version string) error {
+	base := r.baseURL()
+
+	status, header, err := r.preflightRequest(ctx, "GET", base+"/v2/")
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusUnauthorized {
+		return fmt.Errorf("registry does not serve the OCI distribution API at /v2/ (status %d)", status)
+	}
+	if v := header.Get("Docker-Distribution-Api-Version"); v != "" && !strings.HasPrefix(v, "registry/2.") {
+		return fmt.Errorf("registry advertises unsupported API version %q", v)
+	}
+
+	repository := strings.TrimPrefix(r.ociChart(chartName), "oci://")
+	repository = repository[strings.Index(repository, "/")+1:]
+	status, _, err = r.preflightRequest(ctx, "HEAD", fmt.Sprintf("%s/v2/%s/manifests/%s", base, repository, OCITag(version)))
+	if err != nil {
+		return err
+	}
+	switch status {
+	case http.StatusBadRequest, http.StatusNotAcceptable, http.StatusUnsupportedMediaType:
+		return fmt.Errorf("registry refused the OCI manifest media type %s (status %d)", ociManifestMediaType, status)
+	}
+	return nil
+}
+
+// preflightRequest sends a bodiless request accepting only OCI manifests and returns the
+// response status and headers.
+func (r *Repository) preflightRequest(ctx context.Context, method, endpoint string) (int, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", ociManifestMediaType)
+	if err := r.setAuth(ctx, req); err != nil {
+		return 0, nil, err
+	}
+
+	client, err := r.httpClient(10 * time.Second)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("request failed: %w", err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode, resp.Header, nil
+}
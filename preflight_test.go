@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRepositoryCheckOCISupport(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr string
+	}{
+		{
+			name: "supports OCI artifacts",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v2/" {
+					w.Header().Set("Docker-Distribution-Api-Version", "registry/2.0")
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+			},
+		},
+		{
+			name: "requires token auth",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="https://auth.example.com/token"`)
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+		},
+		{
+			name: "rejects OCI manifests",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v2/" {
+					w.Header().Set("Docker-Distribution-Api-Version", "registry/2.0")
+					return
+				}
+				if r.Header.Get("Accept") == ociManifestMediaType {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+				}
+			},
+			wantErr: "registry refused the OCI manifest media type",
+		},
+		{
+			name: "no distribution API",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			wantErr: "does not serve the OCI distribution API",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.Method+" "+r.URL.Path)
+				tt.handler(w, r)
+			}))
+			defer server.Close()
+
+			url := "oci://" + strings.TrimPrefix(server.URL, "https://") + "/myorg/charts"
+			repo := NewRepository(RepositoryConfig{Type: "oci", URL: url, Insecure: true})
+			err := repo.CheckOCISupport(context.Background(), "my-app", "1.0.0+build.7")
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != "does not serve the OCI distribution API" {
+				want := "HEAD /v2/myorg/charts/my-app/manifests/1.0.0_build.7"
+				if len(paths) != 2 || paths[1] != want {
+					t.Errorf("expected manifest probe %q, got %v", want, paths)
+				}
+			}
+		})
+	}
+}
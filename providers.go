@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cloudsmith API hosts: packages are uploaded as files first and then created
+// from the uploaded file. Variables so tests can point them at a local server.
+var (
+	cloudsmithUploadURL = "https://upload.cloudsmith.io"
+	cloudsmithAPIURL    = "https://api.cloudsmith.io"
+)
+
+// apiKeyHeaders are the headers repository types accept an API key in.
+var apiKeyHeaders = map[string]string{
+	"artifactory": "X-JFrog-Art-Api",
+	"cloudsmith":  "X-Api-Key",
+}
+
+// uploadEndpoint returns the URL the package at packagePath is uploaded to:
+// the package's path below the repository URL for Artifactory, or the
+// repository URL itself for generic http repositories.
+func (r *Repository) uploadEndpoint(packagePath string) string {
+	if r.config.Type == "artifactory" {
+		return strings.TrimSuffix(r.config.URL, "/") + "/" + url.PathEscape(filepath.Base(packagePath))
+	}
+	return r.config.URL
+}
+
+// cloudsmithRepository returns the owner and repository slugs of a Cloudsmith
+// repository URL such as https://helm.cloudsmith.io/myorg/charts.
+func cloudsmithRepository(repoURL string) (owner, repo string, err error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid Cloudsmith repository URL: %w", err)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("Cloudsmith repository URL %q must end in <owner>/<repository>", repoURL)
+	}
+	return parts[len(parts)-2], parts[len(parts)-1], nil
+}
+
+// pushCloudsmith uploads the package file to Cloudsmith and creates a Helm
+// package from it. With overwrite an existing version is republished; without
+// it an existing version fails with ErrVersionExists. Cloudsmith's Helm upload
+// takes only the package, so a provenance file is not uploaded.
+func (r *Repository) pushCloudsmith(ctx context.Context, packagePath string) error {
+	owner, repo, err := cloudsmithRepository(r.config.URL)
+	if err != nil {
+		return err
+	}
+	if err := checkChartArchive(packagePath); err != nil {
+		return err
+	}
+	client, err := r.uploadClient(120 * time.Second)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(packagePath)
+	if err != nil {
+		return fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat package: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/%s/%s", cloudsmithUploadURL, owner, repo, url.PathEscape(filepath.Base(packagePath)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, file)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = stat.Size()
+	var upload struct {
+		Identifier string `json:"identifier"`
+	}
+	if err := r.doCloudsmith(ctx, client, req, &upload); err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+	if upload.Identifier == "" {
+		return fmt.Errorf("upload failed: Cloudsmith returned no file identifier")
+	}
+
+	body, err := json.Marshal(map[string]any{"package_file": upload.Identifier, "republish": r.config.Overwrite})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	endpoint = fmt.Sprintf("%s/v1/packages/%s/%s/upload/helm/", cloudsmithAPIURL, owner, repo)
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := r.doCloudsmith(ctx, client, req, nil); errors.Is(err, ErrVersionExists) {
+		return fmt.Errorf("%s: %w (set overwrite to replace it)", filepath.Base(packagePath), ErrVersionExists)
+	} else if err != nil {
+		return fmt.Errorf("failed to create package: %w", err)
+	}
+	return nil
+}
+
+// doCloudsmith sends an authenticated Cloudsmith API request and decodes the
+// JSON response into out, if given. A reply that the package already exists
+// (409, or 422 saying so) is returned as ErrVersionExists.
+func (r *Repository) doCloudsmith(ctx context.Context, client *http.Client, req *http.Request, out any) error {
+	if err := r.setAuth(ctx, req); err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusConflict || (resp.StatusCode == http.StatusUnprocessableEntity && strings.Contains(string(body), "already exists")) {
+			return ErrVersionExists
+		}
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("invalid response: %w", err)
+		}
+	}
+	return nil
+}
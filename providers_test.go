@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+)
+
+func TestRepositoryPushArtifactory(t *testing.T) {
+	tests := []struct {
+		name       string
+		repo       RepositoryConfig
+		wantHeader string
+		wantValue  string
+	}{
+		{name: "api key", repo: RepositoryConfig{APIKey: "key123", Username: "ci", Password: "secret"}, wantHeader: "X-JFrog-Art-Api", wantValue: "key123"},
+		{name: "token", repo: RepositoryConfig{Token: "tok456"}, wantHeader: "Authorization", wantValue: "Bearer tok456"},
+		{name: "basic auth", repo: RepositoryConfig{Username: "ci", Password: "secret"}, wantHeader: "Authorization", wantValue: "Basic Y2k6c2VjcmV0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method, path, value, body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method, path, value = r.Method, r.URL.Path, r.Header.Get(tt.wantHeader)
+				data, _ := io.ReadAll(r.Body)
+				body = string(data)
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+			content := writeTestPackage(t, packagePath)
+
+			tt.repo.Type = "artifactory"
+			tt.repo.URL = server.URL + "/artifactory/helm-local/"
+			if _, err := NewRepository(tt.repo).Push(context.Background(), packagePath); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if method != http.MethodPut || path != "/artifactory/helm-local/my-app-1.0.0.tgz" {
+				t.Errorf("expected PUT /artifactory/helm-local/my-app-1.0.0.tgz, got %s %s", method, path)
+			}
+			if value != tt.wantValue {
+				t.Errorf("expected %s %q, got %q", tt.wantHeader, tt.wantValue, value)
+			}
+			if body != content {
+				t.Errorf("expected the package as the body, got %q", body)
+			}
+		})
+	}
+}
+
+func TestRepositoryPushCloudsmith(t *testing.T) {
+	var uploaded string
+	var created map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "key123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/upload/myorg/charts/my-app-1.0.0.tgz":
+			data, _ := io.ReadAll(r.Body)
+			uploaded = string(data)
+			_, _ = w.Write([]byte(`{"identifier": "file-abc"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/packages/myorg/charts/upload/helm/":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"slug": "my-app-100"}`))
+		default:
+			http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	uploadURL, apiURL := cloudsmithUploadURL, cloudsmithAPIURL
+	cloudsmithUploadURL, cloudsmithAPIURL = server.URL+"/upload", server.URL+"/api"
+	t.Cleanup(func() { cloudsmithUploadURL, cloudsmithAPIURL = uploadURL, apiURL })
+
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	content := writeTestPackage(t, packagePath)
+
+	t.Setenv("CLOUDSMITH_API_KEY", "key123")
+	repo := NewRepository(RepositoryConfig{
+		Type:      "cloudsmith",
+		URL:       "https://helm.cloudsmith.io/myorg/charts/",
+		APIKeyEnv: "CLOUDSMITH_API_KEY",
+		Overwrite: true,
+	})
+	if _, err := repo.Push(context.Background(), packagePath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if uploaded != content {
+		t.Errorf("expected the package to be uploaded, got %q", uploaded)
+	}
+	if created["package_file"] != "file-abc" || created["republish"] != true {
+		t.Errorf("expected the package created from the uploaded file, got %v", created)
+	}
+}
+
+func TestRepositoryPushCloudsmithExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			_, _ = w.Write([]byte(`{"identifier": "file-abc"}`))
+			return
+		}
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"detail": "A package with filename my-app-1.0.0.tgz already exists in this repository"}`))
+	}))
+	defer server.Close()
+
+	uploadURL, apiURL := cloudsmithUploadURL, cloudsmithAPIURL
+	cloudsmithUploadURL, cloudsmithAPIURL = server.URL+"/upload", server.URL+"/api"
+	t.Cleanup(func() { cloudsmithUploadURL, cloudsmithAPIURL = uploadURL, apiURL })
+
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	writeTestPackage(t, packagePath)
+	repo := NewRepository(RepositoryConfig{Type: "cloudsmith", URL: "https://helm.cloudsmith.io/myorg/charts", APIKey: "key123"})
+	_, err := repo.Push(context.Background(), packagePath)
+	if !errors.Is(err, ErrVersionExists) {
+		t.Fatalf("expected ErrVersionExists, got %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	results := pushToRepositories(context.Background(), []*Repository{repo}, packagePath, 1, false, false, logger)
+	if results[0].Err != nil || !results[0].Exists {
+		t.Errorf("expected the existing version to be skipped without fail_if_exists, got %+v", results[0])
+	}
+}
+
+func TestRepositoryCheckAuthCloudsmith(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/user/self/" {
+			http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("X-Api-Key") != "key123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"authenticated": true, "slug": "ci"}`))
+	}))
+	defer server.Close()
+
+	apiURL := cloudsmithAPIURL
+	cloudsmithAPIURL = server.URL + "/api"
+	t.Cleanup(func() { cloudsmithAPIURL = apiURL })
+
+	for apiKey, wantErr := range map[string]bool{"key123": false, "wrong": true} {
+		repo := NewRepository(RepositoryConfig{Type: "cloudsmith", URL: "https://helm.cloudsmith.io/myorg/charts", APIKey: apiKey})
+		if err := repo.CheckAuth(context.Background()); (err != nil) != wantErr {
+			t.Errorf("api key %s: expected error=%v, got %v", apiKey, wantErr, err)
+		}
+	}
+}
+
+func TestValidateProviderRepositories(t *testing.T) {
+	tests := []struct {
+		name      string
+		repo      RepositoryConfig
+		wantValid bool
+	}{
+		{name: "artifactory api key", repo: RepositoryConfig{Type: "artifactory", URL: "https://example.jfrog.io/artifactory/helm", APIKey: "key"}, wantValid: true},
+		{name: "cloudsmith token", repo: RepositoryConfig{Type: "cloudsmith", URL: "https://helm.cloudsmith.io/myorg/charts", TokenEnv: "CLOUDSMITH_TOKEN"}, wantValid: true},
+		{name: "cloudsmith overwrite", repo: RepositoryConfig{Type: "cloudsmith", URL: "https://helm.cloudsmith.io/myorg/charts", Overwrite: true}, wantValid: true},
+		{name: "api key and token", repo: RepositoryConfig{Type: "artifactory", URL: "https://example.jfrog.io/artifactory/helm", APIKey: "key", Token: "token"}},
+		{name: "api key on http", repo: RepositoryConfig{Type: "http", URL: "https://charts.example.com", APIKey: "key"}},
+		{name: "cloudsmith without repository", repo: RepositoryConfig{Type: "cloudsmith", URL: "https://helm.cloudsmith.io/myorg"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vb := helpers.NewValidationBuilder()
+			validateRepositoryConfig(vb, "repository", tt.repo, helmBinary{}, "")
+			if resp := vb.Build(); resp.Valid != tt.wantValid {
+				t.Errorf("expected valid=%v, got %+v", tt.wantValid, resp.Errors)
+			}
+		})
+	}
+}
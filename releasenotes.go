@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+	"gopkg.in/yaml.v3"
+)
+
+// Release notes placements for release_notes_mode.
+const (
+	releaseNotesReadmeAppend = "readme_append" // a section at the end of README.md
+	releaseNotesAnnotation   = "annotation"    // the artifacthub.io/changes annotation
+	releaseNotesFileMode     = "file"          // RELEASE_NOTES.md in the chart
+)
+
+// releaseNotesFile is the file the notes are written to in file mode.
+const releaseNotesFile = "RELEASE_NOTES.md"
+
+// Markers delimiting the README section the notes are written to, so a rerun
+// replaces the section instead of appending it again.
+const (
+	releaseNotesStart = "<!-- release-notes:start -->"
+	releaseNotesEnd   = "<!-- release-notes:end -->"
+)
+
+// loadReleaseNotes returns the notes in the release_notes file, or the
+// release's generated notes when no file is configured.
+func loadReleaseNotes(path string, releaseCtx *plugin.ReleaseContext) (string, error) {
+	if path == "" {
+		return strings.TrimSpace(releaseCtx.ReleaseNotes), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read release notes: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// writeReleaseNotes ships notes for version with the chart at chartPath, placed
+// according to mode.
+func writeReleaseNotes(chartPath, mode, version, notes string) error {
+	switch mode {
+	case releaseNotesReadmeAppend:
+		return appendReadmeReleaseNotes(chartPath, version, notes)
+	case releaseNotesAnnotation:
+		return UpdateChartAnnotations(chartPath, map[string]string{"artifacthub.io/changes": releaseNotesChanges(notes)})
+	case releaseNotesFileMode:
+		if err := os.WriteFile(filepath.Join(chartPath, releaseNotesFile), []byte(notes+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", releaseNotesFile, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported release_notes_mode %q", mode)
+	}
+}
+
+// appendReadmeReleaseNotes writes a "Release Notes" section for version to the
+// end of the chart's README.md, creating the file if needed. Existing content
+// is kept; only a section written by a previous run is replaced.
+func appendReadmeReleaseNotes(chartPath, version, notes string) error {
+	readmePath := filepath.Join(chartPath, "README.md")
+	data, err := os.ReadFile(readmePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read README.md: %w", err)
+	}
+
+	readme := string(data)
+	if start := strings.Index(readme, releaseNotesStart); start >= 0 {
+		if end := strings.Index(readme[start:], releaseNotesEnd); end >= 0 {
+			readme = readme[:start] + readme[start+end+len(releaseNotesEnd):]
+		}
+	}
+	readme = strings.TrimRight(readme, "\n")
+	if readme != "" {
+		readme += "\n\n"
+	}
+
+	section := fmt.Sprintf("%s\n## Release Notes\n\n### %s\n\n%s\n%s\n", releaseNotesStart, version, notes, releaseNotesEnd)
+	if err := os.WriteFile(readmePath, []byte(readme+section), 0644); err != nil {
+		return fmt.Errorf("failed to write README.md: %w", err)
+	}
+	return nil
+}
+
+// releaseNotesChanges turns markdown notes into an artifacthub.io/changes
+// list of strings: one entry per list item, or per line when the notes have
+// no list items. Headings and blank lines are dropped.
+func releaseNotesChanges(notes string) string {
+	var items, lines []string
+	for _, line := range strings.Split(notes, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if item, ok := strings.CutPrefix(line, "- "); ok {
+			items = append(items, strings.TrimSpace(item))
+		} else if item, ok := strings.CutPrefix(line, "* "); ok {
+			items = append(items, strings.TrimSpace(item))
+		}
+		lines = append(lines, line)
+	}
+	if len(items) == 0 {
+		items = lines
+	}
+
+	data, err := yaml.Marshal(items)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
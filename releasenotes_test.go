@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestAppendReadmeReleaseNotes(t *testing.T) {
+	chartDir := t.TempDir()
+	readmePath := filepath.Join(chartDir, "README.md")
+	if err := os.WriteFile(readmePath, []byte("# my-app\n\nInstall with helm.\n"), 0644); err != nil {
+		t.Fatalf("failed to write README.md: %v", err)
+	}
+
+	if err := appendReadmeReleaseNotes(chartDir, "1.0.0", "- first"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A rerun replaces the section written before instead of adding another
+	if err := appendReadmeReleaseNotes(chartDir, "1.1.0", "- second"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, _ := os.ReadFile(readmePath)
+	want := "# my-app\n\nInstall with helm.\n\n" + releaseNotesStart + "\n## Release Notes\n\n### 1.1.0\n\n- second\n" + releaseNotesEnd + "\n"
+	if string(got) != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestReleaseNotesChanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		notes string
+		want  string
+	}{
+		{name: "list items", notes: "## Features\n\n- add HPA\n* fix: probe port\n\nThanks to all contributors", want: "- add HPA\n- 'fix: probe port'\n"},
+		{name: "plain lines", notes: "# 1.2.0\nAdds an HPA.\n\nFixes the probe port.", want: "- Adds an HPA.\n- Fixes the probe port.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := releaseNotesChanges(tt.notes); got != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExecutePrePublishReleaseNotes(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "kind: ConfigMap"`)
+
+	tests := []struct {
+		name    string
+		mode    string
+		file    string // release_notes file content
+		context string // the release's generated notes
+		check   string
+		want    string
+	}{
+		{name: "file from release context", mode: "file", context: "- add HPA", check: releaseNotesFile, want: "- add HPA\n"},
+		{name: "annotation from notes file", mode: "annotation", file: "## 1.0.0\n- add HPA\n", check: "Chart.yaml", want: "apiVersion: v2\nname: my-app\nversion: 1.0.0\nannotations:\n  artifacthub.io/changes: |\n    - add HPA\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chartDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+				t.Fatalf("failed to write Chart.yaml: %v", err)
+			}
+			raw := map[string]any{
+				"chart_path":         chartDir,
+				"lint":               false,
+				"release_notes_mode": tt.mode,
+				"version":            map[string]any{"update_chart": false},
+				"dependencies":       map[string]any{"update": false, "build": false},
+			}
+			if tt.file != "" {
+				notesPath := filepath.Join(t.TempDir(), "CHANGES.md")
+				if err := os.WriteFile(notesPath, []byte(tt.file), 0644); err != nil {
+					t.Fatalf("failed to write notes: %v", err)
+				}
+				raw["release_notes"] = notesPath
+			}
+
+			p := &HelmPlugin{}
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0", ReleaseNotes: tt.context}, p.parseConfig(raw), logger)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !resp.Success {
+				t.Fatalf("expected success, got: %s", resp.Message)
+			}
+
+			got, _ := os.ReadFile(filepath.Join(chartDir, tt.check))
+			if string(got) != tt.want {
+				t.Errorf("expected %s:\n%s\ngot:\n%s", tt.check, tt.want, got)
+			}
+		})
+	}
+}
@@ -1,13 +1,25 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,12 +27,24 @@ import (
 type Repository struct {
 	config      RepositoryConfig
 	contextPath string
+	cosign      *CosignOptions
+	logins      loginLimiter
+	helm        helmBinary
+	timeout     time.Duration
+
+	credMu sync.Mutex
+	creds  *credentialSet
+
+	transportMu     sync.Mutex
+	apiTransport    *http.Transport
+	uploadTransport *http.Transport
 }
 
 // NewRepository creates a new repository handler.
 func NewRepository(config RepositoryConfig) *Repository {
 	return &Repository{
 		config: config,
+		logins: defaultLoginLimiter,
 	}
 }
 
@@ -29,111 +53,571 @@ func (r *Repository) SetContextPath(path string) {
 	r.contextPath = path
 }
 
+// SetCosign enables signing OCI pushes with cosign.
+func (r *Repository) SetCosign(opts *CosignOptions) {
+	r.cosign = opts
+}
+
+// SetHelmBinary sets the helm executable and environment used for each
+// helm invocation.
+func (r *Repository) SetHelmBinary(helm helmBinary) {
+	r.helm = helm
+}
+
+// SetTimeout sets the timeout applied to each helm invocation.
+func (r *Repository) SetTimeout(timeout time.Duration) {
+	r.timeout = timeout
+}
+
+// SetLoginLimiter sets the limiter bounding concurrent registry logins.
+func (r *Repository) SetLoginLimiter(l loginLimiter) {
+	r.logins = l
+}
+
+// transportTimeouts are the per-phase timeouts applied to chart uploads.
+type transportTimeouts struct {
+	Dial           time.Duration
+	TLSHandshake   time.Duration
+	ResponseHeader time.Duration
+}
+
+// transportTimeouts returns the configured upload transport timeouts and whether
+// any were set. Unset phases keep the net/http defaults.
+func (r *Repository) transportTimeouts() (transportTimeouts, bool) {
+	timeouts := transportTimeouts{Dial: 30 * time.Second, TLSHandshake: 10 * time.Second}
+	configured := false
+	for _, t := range []struct {
+		value string
+		dest  *time.Duration
+	}{
+		{r.config.DialTimeout, &timeouts.Dial},
+		{r.config.TLSHandshakeTimeout, &timeouts.TLSHandshake},
+		{r.config.ResponseHeaderTimeout, &timeouts.ResponseHeader},
+	} {
+		if d, err := time.ParseDuration(t.value); err == nil && d > 0 {
+			*t.dest = d
+			configured = true
+		}
+	}
+	return timeouts, configured
+}
+
+// uploadClient returns the HTTP client for chart uploads. Without transport
+// timeouts configured the whole request is bounded by upload_timeout, or by
+// timeout when that isn't set; otherwise the dial, TLS handshake and response
+// header phases are bounded individually so a slow upload of a large chart
+// isn't cut off, and only an explicit upload_timeout bounds the whole request.
+func (r *Repository) uploadClient(timeout time.Duration) (*http.Client, error) {
+	if _, configured := r.transportTimeouts(); configured {
+		timeout = 0
+	}
+	transport, err := r.transport(true)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: durationOr(r.config.UploadTimeout, timeout)}, nil
+}
+
+// ErrVersionExists is returned when the repository already holds the chart version
+// being pushed (ChartMuseum answers 409 Conflict unless it runs with overwrite).
+var ErrVersionExists = errors.New("chart version already exists in repository")
+
+// PushResult contains details reported by the repository after a push.
+type PushResult struct {
+	// Digest is the manifest digest reported by the registry (OCI only).
+	Digest string
+}
+
 // Push pushes a chart to the repository.
-func (r *Repository) Push(ctx context.Context, packagePath string) error {
+func (r *Repository) Push(ctx context.Context, packagePath string) (*PushResult, error) {
 	switch r.config.Type {
 	case "oci":
 		return r.pushOCI(ctx, packagePath)
 	case "chartmuseum":
-		return r.pushChartMuseum(ctx, packagePath)
-	case "http":
-		return r.pushHTTP(ctx, packagePath)
+		return &PushResult{}, r.pushChartMuseum(ctx, packagePath)
+	case "http", "artifactory":
+		return &PushResult{}, r.pushHTTP(ctx, packagePath)
+	case "cloudsmith":
+		return &PushResult{}, r.pushCloudsmith(ctx, packagePath)
+	case "s3", "gcs":
+		return &PushResult{}, r.pushBucket(ctx, packagePath)
 	default:
-		return fmt.Errorf("unsupported repository type: %s", r.config.Type)
+		return nil, fmt.Errorf("unsupported repository type: %s", r.config.Type)
 	}
 }
 
 // pushOCI pushes to an OCI registry.
-func (r *Repository) pushOCI(ctx context.Context, packagePath string) error {
-	// Login to registry if credentials provided
-	if r.config.Username != "" && r.config.Password != "" {
-		registry := strings.TrimPrefix(r.config.URL, "oci://")
-		// Extract just the host part
-		parts := strings.SplitN(registry, "/", 2)
-		registryHost := parts[0]
+func (r *Repository) pushOCI(ctx context.Context, packagePath string) (*PushResult, error) {
+	pushURL := r.config.URL
+	if r.config.StripChartName {
+		chart, err := readPackagedChart(packagePath)
+		if err != nil {
+			return nil, err
+		}
+		pushURL = r.ociPushURL(chart.Name)
+	}
+
+	// Push chart, keeping a copy of the output to read the digest from
+	var output syncBuffer
+	args := append([]string{"push", packagePath, pushURL}, r.ociTLSArgs(false)...)
+	err := r.withRegistrySession(ctx, func() error {
+		if err := runHelm(ctx, r.helm, r.timeout, args, runCapturingOutput(&output)); err != nil {
+			return helmFailure("push", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		if err := r.registryLogin(ctx, registryHost); err != nil {
-			return fmt.Errorf("registry login failed: %w", err)
+	result := &PushResult{Digest: extractPushDigest(output.String())}
+	if r.cosign != nil {
+		pushed := extractPushedReference(output.String())
+		if pushed == "" || result.Digest == "" {
+			return nil, fmt.Errorf("cannot sign chart: helm push did not report a reference and digest")
+		}
+		if err := cosignSign(ctx, r.cosign, digestReference(pushed, result.Digest), r.timeout); err != nil {
+			return nil, err
 		}
 	}
+	return result, nil
+}
 
-	// Push chart
-	cmd := exec.CommandContext(ctx, "helm", "push", packagePath, r.config.URL)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("helm push failed: %w", err)
+// loginOCI logs in to the OCI registry if credentials are provided.
+func (r *Repository) loginOCI(ctx context.Context) error {
+	return r.withRegistrySession(ctx, func() error { return nil })
+}
+
+// withRegistrySession logs in to the OCI registry if credentials are provided
+// and runs fn while holding the registry's session. Helm keeps one login per
+// host, so operations on the same host with different credentials take turns
+// rather than one replacing the other's login between its login and fn.
+func (r *Repository) withRegistrySession(ctx context.Context, fn func() error) error {
+	username, password, err := r.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	if username == "" || password == "" {
+		return fn()
 	}
 
+	registry := strings.TrimPrefix(r.config.URL, "oci://")
+	// Extract just the host part
+	parts := strings.SplitN(registry, "/", 2)
+	registryHost := parts[0]
+
+	fingerprint := credentialFingerprint(username, password)
+	if err := holdSession(ctx, registryHost, fingerprint); err != nil {
+		return err
+	}
+	defer releaseSession(registryHost)
+
+	if err := r.registryLogin(ctx, registryHost, username, password); err != nil {
+		return fmt.Errorf("registry login failed: %w", err)
+	}
+	return fn()
+}
+
+// Pull downloads a chart version from an OCI repository into destDir and
+// returns the path of the downloaded package.
+func (r *Repository) Pull(ctx context.Context, chartName, version, destDir string) (string, error) {
+	if r.config.Type != "oci" {
+		return "", fmt.Errorf("pulling charts is only supported for oci repositories, got %s", r.config.Type)
+	}
+	args := []string{"pull", r.ociChart(chartName), "--version", version, "--destination", destDir}
+	args = append(args, r.ociTLSArgs(false)...)
+	err := r.withRegistrySession(ctx, func() error {
+		if err := runHelm(ctx, r.helm, r.timeout, args, runWithOutput); err != nil {
+			return helmFailure("pull", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(destDir, fmt.Sprintf("%s-%s.tgz", chartName, version)), nil
+}
+
+// VerifyPushed pulls a chart back from the OCI repository and checks that the
+// digest of the downloaded package matches expectedDigest.
+func (r *Repository) VerifyPushed(ctx context.Context, chartName, version, expectedDigest string) error {
+	tmpDir, err := os.MkdirTemp("", "helm-verify-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	path, err := r.Pull(ctx, chartName, version, tmpDir)
+	if err != nil {
+		return err
+	}
+	digest, err := fileDigest(path)
+	if err != nil {
+		return err
+	}
+	if digest != expectedDigest {
+		return fmt.Errorf("digest mismatch for %s: pushed %s, pulled %s", r.OCIReference(chartName, version), expectedDigest, digest)
+	}
+	return nil
+}
+
+// extractPushDigest extracts the manifest digest from helm push output.
+// Output: "Pushed: ghcr.io/myorg/my-chart:1.0.0\nDigest: sha256:..."
+func extractPushDigest(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if digest, ok := strings.CutPrefix(line, "Digest:"); ok {
+			return strings.TrimSpace(digest)
+		}
+	}
+	return ""
+}
+
+// pushBucket pushes to an S3 or GCS bucket repository using the helm-s3 or
+// helm-gcs plugin, optionally regenerating the index afterwards.
+func (r *Repository) pushBucket(ctx context.Context, packagePath string) error {
+	target := r.bucketTarget()
+	if err := r.runHelmPlugin(ctx, "push", packagePath, target); err != nil {
+		return err
+	}
+	if r.config.Reindex {
+		if err := r.runHelmPlugin(ctx, "reindex", target); err != nil {
+			return err
+		}
+	}
+	if r.config.IndexLocation != "" {
+		if err := r.publishIndex(ctx); err != nil {
+			return fmt.Errorf("failed to publish index to %s: %w", r.config.IndexLocation, err)
+		}
+	}
+	return nil
+}
+
+// bucketScheme returns the URL scheme of buckets of the given repository type.
+func bucketScheme(repoType string) string {
+	if repoType == "gcs" {
+		return "gs://"
+	}
+	return "s3://"
+}
+
+// validIndexLocation reports whether location is an HTTP URL or a bucket URL
+// of the repository's provider.
+func validIndexLocation(repoType, location string) bool {
+	for _, prefix := range []string{"http://", "https://", bucketScheme(repoType)} {
+		if strings.HasPrefix(location, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// publishIndex copies the index.yaml regenerated in the bucket to the
+// repository's index location.
+func (r *Repository) publishIndex(ctx context.Context) error {
+	index, err := r.bucketCopy(ctx, nil, strings.TrimSuffix(r.config.URL, "/")+"/index.yaml", "-")
+	if err != nil {
+		return err
+	}
+	if index == nil {
+		return fmt.Errorf("index.yaml not found in %s", r.config.URL)
+	}
+
+	dst := r.config.IndexLocation + "/index.yaml"
+	if strings.HasPrefix(dst, "http://") || strings.HasPrefix(dst, "https://") {
+		return r.putObject(ctx, dst, string(index))
+	}
+	_, err = r.bucketCopy(ctx, bytes.NewReader(index), "-", dst)
+	return err
+}
+
+// bucketTarget returns the repository argument for helm s3/gcs commands: the
+// bucket URL. The repository name is only a label, so two repositories sharing
+// one never resolve to the same bucket.
+func (r *Repository) bucketTarget() string {
+	return strings.TrimSpace(r.config.URL)
+}
+
+// runHelmPlugin runs a command of the helm plugin named after the repository type.
+func (r *Repository) runHelmPlugin(ctx context.Context, args ...string) error {
+	if err := runHelm(ctx, r.helm, r.timeout, append([]string{r.config.Type}, args...), runWithOutput); err != nil {
+		return helmFailure(r.config.Type+" "+args[0], err)
+	}
+	return nil
+}
+
+// uploadMethods are the HTTP methods accepted for chart uploads.
+var uploadMethods = []string{http.MethodPut, http.MethodPost, http.MethodPatch}
+
+// uploadMethod returns the HTTP method chart uploads use: the configured method,
+// or POST for ChartMuseum and PUT for HTTP repositories.
+func (r *Repository) uploadMethod() string {
+	if r.config.Method != "" {
+		return strings.ToUpper(r.config.Method)
+	}
+	if r.config.Type == "chartmuseum" {
+		return http.MethodPost
+	}
+	return http.MethodPut
+}
+
+// newUploadRequest creates the request uploading the package in file to
+// endpoint: the package itself as the body, or with upload_format: multipart a
+// multipart/form-data form holding the package and its provenance file.
+func (r *Repository) newUploadRequest(ctx context.Context, endpoint string, file *os.File) (*http.Request, error) {
+	if r.config.UploadFormat != "multipart" {
+		// Get file info for Content-Length
+		stat, err := file.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat package: %w", err)
+		}
+		req, err := http.NewRequestWithContext(ctx, r.uploadMethod(), endpoint, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/gzip")
+		req.ContentLength = stat.Size()
+		return req, nil
+	}
+
+	field := r.config.UploadField
+	if field == "" {
+		field = "chart"
+	}
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := addFormFile(form, field, file.Name(), file); err != nil {
+		return nil, err
+	}
+	if prov, err := os.Open(file.Name() + provenanceSuffix); err == nil {
+		err = addFormFile(form, "prov", prov.Name(), prov)
+		_ = prov.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build upload form: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.uploadMethod(), endpoint, &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req, nil
+}
+
+// addFormFile adds the content of src to form as a file field.
+func addFormFile(form *multipart.Writer, field, path string, src io.Reader) error {
+	part, err := form.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to build upload form: %w", err)
+	}
+	if _, err := io.Copy(part, src); err != nil {
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
 	return nil
 }
 
 // pushChartMuseum pushes to ChartMuseum.
 func (r *Repository) pushChartMuseum(ctx context.Context, packagePath string) error {
+	if err := checkChartArchive(packagePath); err != nil {
+		return err
+	}
 	file, err := os.Open(packagePath)
 	if err != nil {
 		return fmt.Errorf("failed to open package: %w", err)
 	}
 	defer func() { _ = file.Close() }()
 
+	endpoint := r.chartMuseumAPI("")
+	if r.config.Overwrite {
+		endpoint += "?force=true"
+	}
+
+	req, err := r.newUploadRequest(ctx, endpoint, file)
+	if err != nil {
+		return err
+	}
+
+	if err := r.setAuth(ctx, req); err != nil {
+		return err
+	}
+
+	client, err := r.uploadClient(60 * time.Second)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push chart: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusConflict {
+		return fmt.Errorf("%s: %w (set overwrite to replace it)", filepath.Base(packagePath), ErrVersionExists)
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// chartMuseumAPI returns the ChartMuseum charts API endpoint, optionally
+// extended with a sub path such as "/my-chart/1.0.0".
+func (r *Repository) chartMuseumAPI(subPath string) string {
 	endpoint := r.config.URL + "/api/charts"
 	if r.contextPath != "" {
 		endpoint = r.config.URL + "/" + r.contextPath + "/api/charts"
 	}
+	return endpoint + subPath
+}
+
+// ChartVersion describes a chart version stored in a repository.
+type ChartVersion struct {
+	Version string    `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// ListVersions lists the stored versions of a chart in ChartMuseum.
+func (r *Repository) ListVersions(ctx context.Context, name string) ([]ChartVersion, error) {
+	if r.config.Type != "chartmuseum" {
+		return nil, fmt.Errorf("listing versions is not supported for repository type: %s", r.config.Type)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, file)
+	req, err := http.NewRequestWithContext(ctx, "GET", r.chartMuseumAPI("/"+url.PathEscape(name)), nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := r.setAuth(ctx, req); err != nil {
+		return nil, err
+	}
+
+	client, err := r.httpClient(60 * time.Second)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chart versions: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("listing versions failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
-	req.Header.Set("Content-Type", "application/gzip")
+	var versions []ChartVersion
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return nil, fmt.Errorf("failed to decode chart versions: %w", err)
+	}
+	return versions, nil
+}
+
+// Delete deletes a chart version from ChartMuseum.
+func (r *Repository) Delete(ctx context.Context, name, version string) error {
+	if r.config.Type != "chartmuseum" {
+		return fmt.Errorf("deleting charts is not supported for repository type: %s", r.config.Type)
+	}
+
+	endpoint := r.chartMuseumAPI("/" + url.PathEscape(name) + "/" + url.PathEscape(version))
+	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
 
-	if r.config.Username != "" && r.config.Password != "" {
-		req.SetBasicAuth(r.config.Username, r.config.Password)
+	if err := r.setAuth(ctx, req); err != nil {
+		return err
 	}
 
-	client := &http.Client{Timeout: 60 * time.Second}
+	client, err := r.httpClient(60 * time.Second)
+	if err != nil {
+		return err
+	}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to push chart: %w", err)
+		return fmt.Errorf("failed to delete chart: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("delete failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
 	return nil
 }
 
-// pushHTTP pushes to an HTTP repository (generic upload).
+// versionsToPrune returns the versions beyond the newest keep versions, ordered
+// newest first. Versions that aren't valid semver, were created after keepSince
+// (when set) or are listed in protect are never pruned.
+func versionsToPrune(versions []ChartVersion, keep int, keepSince time.Time, protect []string) []string {
+	type parsedVersion struct {
+		raw       string
+		sv        *SemVer
+		protected bool
+	}
+
+	parsed := make([]parsedVersion, 0, len(versions))
+	for _, v := range versions {
+		sv, err := ParseSemVer(v.Version)
+		if err != nil {
+			continue
+		}
+		protected := slices.Contains(protect, v.Version) || (!keepSince.IsZero() && v.Created.After(keepSince))
+		parsed = append(parsed, parsedVersion{raw: v.Version, sv: sv, protected: protected})
+	}
+
+	sort.SliceStable(parsed, func(i, j int) bool {
+		return parsed[i].sv.Compare(parsed[j].sv) > 0
+	})
+
+	if len(parsed) <= keep {
+		return nil
+	}
+
+	var prune []string
+	for _, p := range parsed[keep:] {
+		if !p.protected {
+			prune = append(prune, p.raw)
+		}
+	}
+	return prune
+}
+
+// pushHTTP pushes to an HTTP repository (generic upload) or Artifactory.
 func (r *Repository) pushHTTP(ctx context.Context, packagePath string) error {
+	if err := checkChartArchive(packagePath); err != nil {
+		return err
+	}
 	file, err := os.Open(packagePath)
 	if err != nil {
 		return fmt.Errorf("failed to open package: %w", err)
 	}
 	defer func() { _ = file.Close() }()
 
-	// Get file info for Content-Length
-	stat, err := file.Stat()
+	req, err := r.newUploadRequest(ctx, r.uploadEndpoint(packagePath), file)
 	if err != nil {
-		return fmt.Errorf("failed to stat package: %w", err)
+		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "PUT", r.config.URL, file)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if err := r.setAuth(ctx, req); err != nil {
+		return err
 	}
 
-	req.Header.Set("Content-Type", "application/gzip")
-	req.ContentLength = stat.Size()
-
-	if r.config.Username != "" && r.config.Password != "" {
-		req.SetBasicAuth(r.config.Username, r.config.Password)
+	client, err := r.uploadClient(120 * time.Second)
+	if err != nil {
+		return err
 	}
-
-	client := &http.Client{Timeout: 120 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to push chart: %w", err)
@@ -145,20 +629,223 @@ func (r *Repository) pushHTTP(ctx context.Context, packagePath string) error {
 		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
+	if r.config.Index {
+		if err := r.updateIndex(ctx, packagePath); err != nil {
+			return fmt.Errorf("failed to update %s: %w", r.indexURL(), err)
+		}
+	}
 	return nil
 }
 
-// registryLogin performs registry login for OCI.
-func (r *Repository) registryLogin(ctx context.Context, registry string) error {
-	cmd := exec.CommandContext(ctx, "helm", "registry", "login", registry,
-		"--username", r.config.Username,
-		"--password-stdin")
-	cmd.Stdin = strings.NewReader(r.config.Password)
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// ociTagPattern is the OCI distribution spec grammar for tags.
+var ociTagPattern = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`)
+
+// OCITag returns the tag helm push stores a chart version under. Like helm,
+// "+" (build metadata) is replaced with "_" since it isn't a valid OCI tag
+// character, e.g. 1.2.3+build.7 becomes 1.2.3_build.7.
+func OCITag(version string) string {
+	return strings.ReplaceAll(version, "+", "_")
+}
+
+// ociTag returns the OCI tag for version, failing if the repository rejects
+// build metadata or the result still isn't a valid tag.
+func (r *Repository) ociTag(version string) (string, error) {
+	if strings.Contains(version, "+") && r.config.OCITagBuildMetadata == "reject" {
+		return "", fmt.Errorf("version %s has build metadata, which OCI tags can't carry (set version.strip_build_metadata or oci_tag_build_metadata: underscore)", version)
+	}
+	tag := OCITag(version)
+	if !ociTagPattern.MatchString(tag) {
+		return "", fmt.Errorf("%q is not a valid OCI tag", tag)
+	}
+	return tag, nil
+}
+
+// OCIReference computes the full reference helm will push the chart to,
+// e.g. oci://ghcr.io/myorg/charts/my-chart:1.0.0. Like helm, the chart name is
+// appended to the URL pushed to, which with strip_chart_name is the parent of a
+// URL already ending in the chart name (see ociPushURL), and the version is
+// turned into a tag with OCITag.
+func (r *Repository) OCIReference(chartName, version string) string {
+	return fmt.Sprintf("%s:%s", r.ociChart(chartName), OCITag(version))
+}
+
+// ociChart returns the untagged OCI reference of a chart, e.g. "oci://ghcr.io/myorg/my-chart".
+func (r *Repository) ociChart(chartName string) string {
+	base := r.ociPushURL(chartName)
+	base = strings.TrimPrefix(base, "oci://")
+	base = strings.Trim(base, "/")
+	for strings.Contains(base, "//") {
+		base = strings.ReplaceAll(base, "//", "/")
+	}
+	return fmt.Sprintf("oci://%s/%s", base, chartName)
+}
+
+// ociPushURL returns the URL given to helm push, which appends the chart name.
+// With strip_chart_name a URL that already ends in the chart name, e.g.
+// oci://ghcr.io/myorg/charts/my-chart, is pushed from its parent so the chart
+// lands at that URL instead of under .../my-chart/my-chart.
+func (r *Repository) ociPushURL(chartName string) string {
+	base := strings.TrimSuffix(strings.TrimSpace(r.config.URL), "/")
+	if !r.config.StripChartName {
+		return base
+	}
+	// Only strip a path segment, never the registry host
+	path := strings.TrimPrefix(base, "oci://")
+	if i := strings.LastIndex(path, "/"); i > 0 && path[i+1:] == chartName {
+		return "oci://" + path[:i]
+	}
+	return base
+}
+
+// PushTarget describes where a chart will be pushed: the full OCI reference
+// for registries, or the repository URL otherwise.
+func (r *Repository) PushTarget(chartName, version string) string {
+	if r.config.Type == "oci" {
+		return r.OCIReference(chartName, version)
+	}
+	return r.config.URL
+}
+
+// loginLimiter bounds the number of concurrent helm registry login/logout calls,
+// which all write to the shared registry config file.
+type loginLimiter chan struct{}
+
+// defaultLoginLimiter serializes registry logins across repositories.
+var defaultLoginLimiter = newLoginLimiter(1)
+
+// newLoginLimiter creates a limiter allowing n concurrent logins (at least one).
+func newLoginLimiter(n int) loginLimiter {
+	if n < 1 {
+		n = 1
+	}
+	return make(loginLimiter, n)
+}
+
+// acquire blocks until a login slot is available or ctx is done.
+func (l loginLimiter) acquire(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a login slot.
+func (l loginLimiter) release() {
+	<-l
+}
+
+// registrySessions records the registries this process is logged in to, keyed
+// by host, with a fingerprint of the credentials used. Helm keeps one login per
+// host, so logging in again with the same credentials is skipped while
+// different credentials replace the session. held tracks the sessions
+// operations are currently relying on.
+var registrySessions = struct {
+	sync.Mutex
+	hosts map[string]string
+	held  map[string]*sessionHold
+}{hosts: make(map[string]string), held: make(map[string]*sessionHold)}
+
+// sessionHold is a registry session in use by holders operations, all with the
+// credentials identified by fingerprint. released is closed once the last
+// holder is done.
+type sessionHold struct {
+	fingerprint string
+	holders     int
+	released    chan struct{}
+}
+
+// holdSession waits until no operation holds registry's session with other
+// credentials, then holds it with fingerprint until releaseSession. Operations
+// with the same credentials share the session.
+func holdSession(ctx context.Context, registry, fingerprint string) error {
+	for {
+		registrySessions.Lock()
+		hold := registrySessions.held[registry]
+		if hold == nil {
+			registrySessions.held[registry] = &sessionHold{fingerprint: fingerprint, holders: 1, released: make(chan struct{})}
+			registrySessions.Unlock()
+			return nil
+		}
+		if hold.fingerprint == fingerprint {
+			hold.holders++
+			registrySessions.Unlock()
+			return nil
+		}
+		registrySessions.Unlock()
+
+		select {
+		case <-hold.released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// releaseSession releases a session held with holdSession.
+func releaseSession(registry string) {
+	registrySessions.Lock()
+	defer registrySessions.Unlock()
+	hold := registrySessions.held[registry]
+	if hold.holders--; hold.holders == 0 {
+		delete(registrySessions.held, registry)
+		close(hold.released)
+	}
+}
+
+// credentialFingerprint identifies a username/password pair without keeping the password.
+func credentialFingerprint(username, password string) string {
+	sum := sha256.Sum256([]byte(username + "\x00" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+// loggedIn reports whether registry is already logged in to with fingerprint.
+func loggedIn(registry, fingerprint string) bool {
+	registrySessions.Lock()
+	defer registrySessions.Unlock()
+	return registrySessions.hosts[registry] == fingerprint
+}
+
+// setSession records (or, with an empty fingerprint, forgets) the login to registry.
+func setSession(registry, fingerprint string) {
+	registrySessions.Lock()
+	defer registrySessions.Unlock()
+	if fingerprint == "" {
+		delete(registrySessions.hosts, registry)
+		return
+	}
+	registrySessions.hosts[registry] = fingerprint
+}
+
+// registryLogin performs registry login for OCI. It is a no-op if this process
+// already logged in to registry with the same credentials.
+func (r *Repository) registryLogin(ctx context.Context, registry, username, password string) error {
+	fingerprint := credentialFingerprint(username, password)
+	if loggedIn(registry, fingerprint) {
+		return nil
+	}
+
+	if err := r.logins.acquire(ctx); err != nil {
+		return err
+	}
+	defer r.logins.release()
+
+	args := []string{"registry", "login", registry, "--username", username, "--password-stdin"}
+	args = append(args, r.ociTLSArgs(true)...)
+	err := runHelm(ctx, r.helm, r.timeout, args, func(cmd *exec.Cmd) error {
+		cmd.Stdin = strings.NewReader(password)
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	})
+	if err != nil {
+		return err
+	}
+	setSession(registry, fingerprint)
+	return nil
 }
 
-// Logout performs registry logout for OCI.
+// Logout performs registry logout for OCI and forgets the cached login.
 func (r *Repository) Logout(ctx context.Context) error {
 	if r.config.Type != "oci" {
 		return nil
@@ -168,7 +855,73 @@ func (r *Repository) Logout(ctx context.Context) error {
 	parts := strings.SplitN(registry, "/", 2)
 	registryHost := parts[0]
 
-	cmd := exec.CommandContext(ctx, "helm", "registry", "logout", registryHost)
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	setSession(registryHost, "")
+
+	if err := r.logins.acquire(ctx); err != nil {
+		return err
+	}
+	defer r.logins.release()
+
+	return runHelm(ctx, r.helm, r.timeout, []string{"registry", "logout", registryHost}, func(cmd *exec.Cmd) error {
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	})
+}
+
+// CheckHealth verifies the repository responds successfully on its health endpoint.
+func (r *Repository) CheckHealth(ctx context.Context) error {
+	path := r.healthPath()
+	if path == "" {
+		return fmt.Errorf("no health path configured for repository type %s", r.config.Type)
+	}
+
+	endpoint := r.baseURL()
+	if r.contextPath != "" {
+		endpoint += "/" + strings.Trim(r.contextPath, "/")
+	}
+	endpoint += "/" + strings.TrimPrefix(path, "/")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client, err := r.httpClient(10 * time.Second)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("health check returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// healthPath returns the configured health path or the default for the repository type.
+func (r *Repository) healthPath() string {
+	if r.config.HealthPath != "" {
+		return r.config.HealthPath
+	}
+	if r.config.Type == "chartmuseum" {
+		return "/health"
+	}
+	return ""
+}
+
+// baseURL returns the repository URL suitable for HTTP requests.
+func (r *Repository) baseURL() string {
+	base := strings.TrimSuffix(r.config.URL, "/")
+	if strings.HasPrefix(base, "oci://") {
+		// Registries are reached over HTTPS at the host root
+		host := strings.SplitN(strings.TrimPrefix(base, "oci://"), "/", 2)[0]
+		return "https://" + host
+	}
+	return base
 }
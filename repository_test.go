@@ -2,12 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
 )
 
 func TestNewRepository(t *testing.T) {
@@ -62,10 +72,7 @@ func TestRepositoryPushChartMuseum(t *testing.T) {
 	// Create a test package file
 	tempDir := t.TempDir()
 	packagePath := filepath.Join(tempDir, "test-chart-1.0.0.tgz")
-	testContent := []byte("fake chart content")
-	if err := os.WriteFile(packagePath, testContent, 0644); err != nil {
-		t.Fatalf("failed to create test file: %v", err)
-	}
+	testContent := writeTestPackage(t, packagePath)
 
 	repo := NewRepository(RepositoryConfig{
 		Type:     "chartmuseum",
@@ -74,7 +81,7 @@ func TestRepositoryPushChartMuseum(t *testing.T) {
 		Password: "testpass",
 	})
 
-	err := repo.Push(context.Background(), packagePath)
+	_, err := repo.Push(context.Background(), packagePath)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -87,11 +94,84 @@ func TestRepositoryPushChartMuseum(t *testing.T) {
 		t.Error("expected Authorization header to be set")
 	}
 
-	if string(receivedBody) != string(testContent) {
+	if string(receivedBody) != testContent {
 		t.Error("body content mismatch")
 	}
 }
 
+func TestRepositoryPushChartMuseumVersionExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"error":"file already exists"}`))
+	}))
+	defer server.Close()
+
+	packagePath := filepath.Join(t.TempDir(), "test-chart-1.0.0.tgz")
+	writeTestPackage(t, packagePath)
+
+	repo := NewRepository(RepositoryConfig{Type: "chartmuseum", URL: server.URL})
+	_, err := repo.Push(context.Background(), packagePath)
+	if !errors.Is(err, ErrVersionExists) {
+		t.Fatalf("expected ErrVersionExists, got %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("fail if exists", func(t *testing.T) {
+		results := pushToRepositories(context.Background(), []*Repository{repo}, packagePath, 1, false, true, logger)
+		if err := joinPushErrors(results); !errors.Is(err, ErrVersionExists) {
+			t.Errorf("expected ErrVersionExists, got %v", err)
+		}
+	})
+
+	t.Run("skip if exists", func(t *testing.T) {
+		results := pushToRepositories(context.Background(), []*Repository{repo}, packagePath, 1, false, false, logger)
+		if err := joinPushErrors(results); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !results[0].Exists {
+			t.Errorf("expected push to be reported as existing, got %+v", results[0])
+		}
+		if !strings.Contains(formatPushResults(results), server.URL+": already exists") {
+			t.Errorf("expected existing status, got: %s", formatPushResults(results))
+		}
+	})
+}
+
+func TestRepositoryPushChartMuseumOverwrite(t *testing.T) {
+	packagePath := filepath.Join(t.TempDir(), "test-chart-1.0.0.tgz")
+	writeTestPackage(t, packagePath)
+
+	for _, overwrite := range []bool{false, true} {
+		var query string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			if r.URL.Query().Get("force") != "true" {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		repo := NewRepository(RepositoryConfig{Type: "chartmuseum", URL: server.URL, Overwrite: overwrite})
+		_, err := repo.Push(context.Background(), packagePath)
+		server.Close()
+
+		if overwrite {
+			if err != nil || query != "force=true" {
+				t.Errorf("expected forced upload to succeed, got query %q and error %v", query, err)
+			}
+			continue
+		}
+		if query != "" {
+			t.Errorf("expected no query without overwrite, got %q", query)
+		}
+		if err == nil || !strings.Contains(err.Error(), "test-chart-1.0.0.tgz: chart version already exists in repository") {
+			t.Errorf("expected version exists error, got %v", err)
+		}
+	}
+}
+
 func TestRepositoryPushChartMuseumWithContextPath(t *testing.T) {
 	var receivedPath string
 
@@ -103,9 +183,7 @@ func TestRepositoryPushChartMuseumWithContextPath(t *testing.T) {
 
 	tempDir := t.TempDir()
 	packagePath := filepath.Join(tempDir, "test-chart-1.0.0.tgz")
-	if err := os.WriteFile(packagePath, []byte("test"), 0644); err != nil {
-		t.Fatalf("failed to create test file: %v", err)
-	}
+	writeTestPackage(t, packagePath)
 
 	repo := NewRepository(RepositoryConfig{
 		Type: "chartmuseum",
@@ -113,7 +191,7 @@ func TestRepositoryPushChartMuseumWithContextPath(t *testing.T) {
 	})
 	repo.SetContextPath("v1")
 
-	err := repo.Push(context.Background(), packagePath)
+	_, err := repo.Push(context.Background(), packagePath)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -136,16 +214,14 @@ func TestRepositoryPushHTTP(t *testing.T) {
 
 	tempDir := t.TempDir()
 	packagePath := filepath.Join(tempDir, "test-chart-1.0.0.tgz")
-	if err := os.WriteFile(packagePath, []byte("test content"), 0644); err != nil {
-		t.Fatalf("failed to create test file: %v", err)
-	}
+	writeTestPackage(t, packagePath)
 
 	repo := NewRepository(RepositoryConfig{
 		Type: "http",
 		URL:  server.URL,
 	})
 
-	err := repo.Push(context.Background(), packagePath)
+	_, err := repo.Push(context.Background(), packagePath)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -159,13 +235,277 @@ func TestRepositoryPushHTTP(t *testing.T) {
 	}
 }
 
+func TestRepositoryPushMultipart(t *testing.T) {
+	tests := []struct {
+		name      string
+		repoType  string
+		field     string
+		signed    bool
+		wantField string
+	}{
+		{name: "http default field", repoType: "http", wantField: "chart"},
+		{name: "http custom field", repoType: "http", field: "file", wantField: "file"},
+		{name: "chartmuseum with provenance", repoType: "chartmuseum", signed: true, wantField: "chart"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+			content := writeTestPackage(t, packagePath)
+			if tt.signed {
+				if err := os.WriteFile(packagePath+".prov", []byte("provenance"), 0644); err != nil {
+					t.Fatalf("failed to write provenance: %v", err)
+				}
+			}
+
+			files := map[string]string{}
+			filenames := map[string]string{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reader, err := r.MultipartReader()
+				if err != nil {
+					t.Errorf("expected a multipart body, got Content-Type %q: %v", r.Header.Get("Content-Type"), err)
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				for {
+					part, err := reader.NextPart()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						t.Errorf("failed to read part: %v", err)
+						break
+					}
+					content, _ := io.ReadAll(part)
+					files[part.FormName()] = string(content)
+					filenames[part.FormName()] = part.FileName()
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			repo := NewRepository(RepositoryConfig{Type: tt.repoType, URL: server.URL, UploadFormat: "multipart", UploadField: tt.field})
+			if _, err := repo.Push(context.Background(), packagePath); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := map[string]string{tt.wantField: content}
+			if tt.signed {
+				want["prov"] = "provenance"
+			}
+			if fmt.Sprint(files) != fmt.Sprint(want) {
+				t.Errorf("expected form files %v, got %v", want, files)
+			}
+			if filenames[tt.wantField] != "my-app-1.0.0.tgz" {
+				t.Errorf("expected package filename my-app-1.0.0.tgz, got %q", filenames[tt.wantField])
+			}
+		})
+	}
+
+	// Formats other than raw and multipart are rejected
+	vb := helpers.NewValidationBuilder()
+	validateRepositoryConfig(vb, "repository", RepositoryConfig{Type: "http", URL: "https://charts.example.com", UploadFormat: "form"}, helmBinary{}, "")
+	if resp := vb.Build(); resp.Valid {
+		t.Error("expected upload_format form to be rejected")
+	}
+}
+
+func TestRepositoryUploadMethod(t *testing.T) {
+	tests := []struct {
+		name       string
+		repoType   string
+		method     string
+		wantMethod string
+	}{
+		{name: "http default", repoType: "http", wantMethod: http.MethodPut},
+		{name: "http post", repoType: "http", method: "post", wantMethod: http.MethodPost},
+		{name: "chartmuseum default", repoType: "chartmuseum", wantMethod: http.MethodPost},
+		{name: "chartmuseum put", repoType: "chartmuseum", method: "PUT", wantMethod: http.MethodPut},
+	}
+
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	writeTestPackage(t, packagePath)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotMethod = r.Method
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			repo := NewRepository(RepositoryConfig{Type: tt.repoType, URL: server.URL, Method: tt.method})
+			if _, err := repo.Push(context.Background(), packagePath); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotMethod != tt.wantMethod {
+				t.Errorf("expected %s, got %s", tt.wantMethod, gotMethod)
+			}
+		})
+	}
+
+	// Methods that can't upload a chart are rejected
+	vb := helpers.NewValidationBuilder()
+	validateRepositoryConfig(vb, "repository", RepositoryConfig{Type: "http", URL: "https://charts.example.com", Method: "GET"}, helmBinary{}, "")
+	if resp := vb.Build(); resp.Valid {
+		t.Error("expected GET to be rejected")
+	}
+}
+
+func TestRepositoryUploadClient(t *testing.T) {
+	repo := NewRepository(RepositoryConfig{Type: "http", URL: "https://charts.example.com"})
+	if client, _ := repo.uploadClient(2 * time.Minute); client.Timeout != 2*time.Minute || client.Transport != nil {
+		t.Errorf("expected blanket timeout without transport timeouts, got %+v", client)
+	}
+
+	repo = NewRepository(RepositoryConfig{
+		Type:                  "chartmuseum",
+		URL:                   "https://charts.example.com",
+		DialTimeout:           "3s",
+		TLSHandshakeTimeout:   "4s",
+		ResponseHeaderTimeout: "90s",
+	})
+	client, err := repo.uploadClient(time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.Timeout != 0 {
+		t.Errorf("expected no blanket timeout, got %s", client.Timeout)
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected *http.Transport, got %T", client.Transport)
+	}
+	if transport.TLSHandshakeTimeout != 4*time.Second {
+		t.Errorf("expected TLS handshake timeout 4s, got %s", transport.TLSHandshakeTimeout)
+	}
+	if transport.ResponseHeaderTimeout != 90*time.Second {
+		t.Errorf("expected response header timeout 90s, got %s", transport.ResponseHeaderTimeout)
+	}
+	if timeouts, _ := repo.transportTimeouts(); timeouts.Dial != 3*time.Second {
+		t.Errorf("expected dial timeout 3s, got %s", timeouts.Dial)
+	}
+
+	// Unset phases keep the net/http defaults
+	repo = NewRepository(RepositoryConfig{Type: "http", ResponseHeaderTimeout: "5s"})
+	timeouts, configured := repo.transportTimeouts()
+	if !configured || timeouts.Dial != 30*time.Second || timeouts.TLSHandshake != 10*time.Second || timeouts.ResponseHeader != 5*time.Second {
+		t.Errorf("unexpected timeouts: %+v (configured %v)", timeouts, configured)
+	}
+}
+
+func TestValidateTransportTimeoutsOrder(t *testing.T) {
+	repo := RepositoryConfig{
+		Type:                  "http",
+		URL:                   "https://charts.example.com",
+		DialTimeout:           "soon",
+		TLSHandshakeTimeout:   "-1s",
+		ResponseHeaderTimeout: "0",
+		UploadTimeout:         "later",
+	}
+	want := "repository.dial_timeout repository.response_header_timeout repository.tls_handshake_timeout repository.upload_timeout"
+	for range 5 {
+		vb := helpers.NewValidationBuilder()
+		validateRepositoryConfig(vb, "repository", repo, helmBinary{}, "")
+		var fields []string
+		for _, e := range vb.Build().Errors {
+			fields = append(fields, e.Field)
+		}
+		if strings.Join(fields, " ") != want {
+			t.Fatalf("expected errors for %s, got %v", want, fields)
+		}
+	}
+}
+
+func TestRepositoryPushHTTPResponseHeaderTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		time.Sleep(500 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	packagePath := filepath.Join(t.TempDir(), "test-chart-1.0.0.tgz")
+	writeTestPackage(t, packagePath)
+
+	repo := NewRepository(RepositoryConfig{Type: "http", URL: server.URL, ResponseHeaderTimeout: "50ms"})
+	_, err := repo.Push(context.Background(), packagePath)
+	if err == nil || !strings.Contains(err.Error(), "timeout awaiting response headers") {
+		t.Errorf("expected response header timeout, got %v", err)
+	}
+}
+
+func TestRepositoryHTTPTimeouts(t *testing.T) {
+	// Slow server answering after 300ms, unless the client gives up first
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-time.After(300 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`[{"name":"my-app","version":"1.0.0"}]`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	writeTestPackage(t, packagePath)
+
+	// The defaults leave plenty of time for a slow server
+	repo := NewRepository(RepositoryConfig{Type: "chartmuseum", URL: server.URL})
+	if _, err := repo.Push(context.Background(), packagePath); err != nil {
+		t.Errorf("expected push within the default timeout, got %v", err)
+	}
+	if versions, err := repo.ListVersions(context.Background(), "my-app"); err != nil || len(versions) != 1 {
+		t.Errorf("expected versions within the default timeout, got %v (err %v)", versions, err)
+	}
+
+	repo = NewRepository(RepositoryConfig{Type: "chartmuseum", URL: server.URL, UploadTimeout: "100ms", HTTPTimeout: "100ms"})
+	if _, err := repo.Push(context.Background(), packagePath); err == nil || !strings.Contains(err.Error(), "Client.Timeout exceeded") {
+		t.Errorf("expected upload_timeout to cut off the push, got %v", err)
+	}
+	if _, err := repo.ListVersions(context.Background(), "my-app"); err == nil || !strings.Contains(err.Error(), "Client.Timeout exceeded") {
+		t.Errorf("expected http_timeout to cut off the request, got %v", err)
+	}
+
+	// Each timeout only applies to its own kind of request
+	repo = NewRepository(RepositoryConfig{Type: "chartmuseum", URL: server.URL, HTTPTimeout: "100ms"})
+	if _, err := repo.Push(context.Background(), packagePath); err != nil {
+		t.Errorf("expected http_timeout not to apply to uploads, got %v", err)
+	}
+
+	// With transport timeouts, upload_timeout still bounds the whole upload
+	repo = NewRepository(RepositoryConfig{Type: "http", URL: server.URL, ResponseHeaderTimeout: "5s", UploadTimeout: "100ms", MaxIdleConns: 4})
+	client, err := repo.uploadClient(time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if transport, ok := client.Transport.(*http.Transport); !ok || transport.MaxIdleConnsPerHost != 4 || client.Timeout != 100*time.Millisecond {
+		t.Errorf("unexpected client %+v", client)
+	}
+	if _, err := repo.Push(context.Background(), packagePath); err == nil || !strings.Contains(err.Error(), "Client.Timeout exceeded") {
+		t.Errorf("expected upload_timeout to cut off the push, got %v", err)
+	}
+
+	vb := helpers.NewValidationBuilder()
+	validateRepositoryConfig(vb, "repository", RepositoryConfig{Type: "http", URL: server.URL, HTTPTimeout: "soon", MaxIdleConns: -1}, helmBinary{}, "")
+	if resp := vb.Build(); resp.Valid || len(resp.Errors) != 2 {
+		t.Errorf("expected invalid http_timeout and max_idle_conns, got %+v", resp.Errors)
+	}
+}
+
 func TestRepositoryPushUnsupportedType(t *testing.T) {
 	repo := NewRepository(RepositoryConfig{
 		Type: "unknown",
 		URL:  "http://example.com",
 	})
 
-	err := repo.Push(context.Background(), "/fake/path.tgz")
+	_, err := repo.Push(context.Background(), "/fake/path.tgz")
 	if err == nil {
 		t.Error("expected error for unsupported type")
 	}
@@ -185,16 +525,14 @@ func TestRepositoryPushChartMuseumError(t *testing.T) {
 
 	tempDir := t.TempDir()
 	packagePath := filepath.Join(tempDir, "test-chart-1.0.0.tgz")
-	if err := os.WriteFile(packagePath, []byte("test"), 0644); err != nil {
-		t.Fatalf("failed to create test file: %v", err)
-	}
+	writeTestPackage(t, packagePath)
 
 	repo := NewRepository(RepositoryConfig{
 		Type: "chartmuseum",
 		URL:  server.URL,
 	})
 
-	err := repo.Push(context.Background(), packagePath)
+	_, err := repo.Push(context.Background(), packagePath)
 	if err == nil {
 		t.Error("expected error for server error response")
 	}
@@ -206,8 +544,788 @@ func TestRepositoryPushFileNotFound(t *testing.T) {
 		URL:  "http://example.com",
 	})
 
-	err := repo.Push(context.Background(), "/nonexistent/path.tgz")
+	_, err := repo.Push(context.Background(), "/nonexistent/path.tgz")
 	if err == nil {
 		t.Error("expected error for nonexistent file")
 	}
 }
+
+func TestRepositoryPushRejectsNonChartFile(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	if err := os.WriteFile(packagePath, []byte("<html>Not Found</html>"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	for _, repoType := range []string{"chartmuseum", "http", "artifactory"} {
+		repo := NewRepository(RepositoryConfig{Type: repoType, URL: server.URL})
+		if _, err := repo.Push(context.Background(), packagePath); err == nil || !strings.Contains(err.Error(), "is not a chart package") {
+			t.Errorf("%s: expected the file to be rejected, got %v", repoType, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("expected nothing to be uploaded, got %d requests", requests)
+	}
+}
+
+func TestRepositoryCheckHealth(t *testing.T) {
+	tests := []struct {
+		name        string
+		repoType    string
+		healthPath  string
+		contextPath string
+		status      int
+		wantPath    string
+		wantErr     bool
+	}{
+		{
+			name:     "chartmuseum default path healthy",
+			repoType: "chartmuseum",
+			status:   http.StatusOK,
+			wantPath: "/health",
+		},
+		{
+			name:     "chartmuseum unhealthy",
+			repoType: "chartmuseum",
+			status:   http.StatusServiceUnavailable,
+			wantPath: "/health",
+			wantErr:  true,
+		},
+		{
+			name:       "custom health path",
+			repoType:   "http",
+			healthPath: "/status/ready",
+			status:     http.StatusNoContent,
+			wantPath:   "/status/ready",
+		},
+		{
+			name:        "chartmuseum with context path",
+			repoType:    "chartmuseum",
+			contextPath: "v1",
+			status:      http.StatusOK,
+			wantPath:    "/v1/health",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivedPath string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedPath = r.URL.Path
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			repo := NewRepository(RepositoryConfig{
+				Type:       tt.repoType,
+				URL:        server.URL,
+				HealthPath: tt.healthPath,
+			})
+			repo.SetContextPath(tt.contextPath)
+
+			err := repo.CheckHealth(context.Background())
+			if tt.wantErr && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if receivedPath != tt.wantPath {
+				t.Errorf("expected path '%s', got '%s'", tt.wantPath, receivedPath)
+			}
+		})
+	}
+}
+
+func TestRepositoryCheckHealthNoPath(t *testing.T) {
+	repo := NewRepository(RepositoryConfig{
+		Type: "http",
+		URL:  "http://example.com",
+	})
+
+	if err := repo.CheckHealth(context.Background()); err == nil {
+		t.Error("expected error when no health path is configured")
+	}
+}
+
+func TestRepositoryOCIReference(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		chart   string
+		version string
+		strip   bool
+		want    string
+	}{
+		{
+			name:    "standard url",
+			url:     "oci://ghcr.io/myorg/charts",
+			chart:   "my-app",
+			version: "1.0.0",
+			want:    "oci://ghcr.io/myorg/charts/my-app:1.0.0",
+		},
+		{
+			name:    "trailing slash",
+			url:     "oci://ghcr.io/myorg/",
+			chart:   "my-app",
+			version: "1.0.0",
+			want:    "oci://ghcr.io/myorg/my-app:1.0.0",
+		},
+		{
+			name:    "missing scheme and whitespace",
+			url:     "  registry.example.com//charts ",
+			chart:   "my-app",
+			version: "2.1.0",
+			want:    "oci://registry.example.com/charts/my-app:2.1.0",
+		},
+		{
+			name:    "build metadata",
+			url:     "oci://ghcr.io/myorg",
+			chart:   "my-app",
+			version: "1.0.0+build.7",
+			want:    "oci://ghcr.io/myorg/my-app:1.0.0_build.7",
+		},
+		{
+			name:    "url ending in the chart name",
+			url:     "oci://ghcr.io/myorg/charts/my-app",
+			chart:   "my-app",
+			version: "1.0.0",
+			want:    "oci://ghcr.io/myorg/charts/my-app/my-app:1.0.0",
+		},
+		{
+			name:    "url ending in the chart name with strip_chart_name",
+			url:     "oci://ghcr.io/myorg/charts/my-app/",
+			chart:   "my-app",
+			version: "1.0.0",
+			strip:   true,
+			want:    "oci://ghcr.io/myorg/charts/my-app:1.0.0",
+		},
+		{
+			name:    "strip_chart_name with another name",
+			url:     "oci://ghcr.io/myorg/charts",
+			chart:   "my-app",
+			version: "1.0.0",
+			strip:   true,
+			want:    "oci://ghcr.io/myorg/charts/my-app:1.0.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewRepository(RepositoryConfig{Type: "oci", URL: tt.url, StripChartName: tt.strip})
+			if got := repo.OCIReference(tt.chart, tt.version); got != tt.want {
+				t.Errorf("expected '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRepositoryOCITag(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		version string
+		want    string
+		wantErr string
+	}{
+		{name: "plain version", version: "1.2.3", want: "1.2.3"},
+		{name: "prerelease", version: "1.2.3-rc.1", want: "1.2.3-rc.1"},
+		{name: "build metadata", version: "1.2.3+build.7", want: "1.2.3_build.7"},
+		{name: "prerelease and build metadata", mode: "underscore", version: "1.2.3-rc.1+sha.abc123", want: "1.2.3-rc.1_sha.abc123"},
+		{name: "reject build metadata", mode: "reject", version: "1.2.3+build.7", wantErr: "has build metadata"},
+		{name: "reject allows plain version", mode: "reject", version: "1.2.3", want: "1.2.3"},
+		{name: "too long", version: "1.2.3-" + strings.Repeat("a", 130), wantErr: "is not a valid OCI tag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg", OCITagBuildMetadata: tt.mode})
+			got, err := repo.ociTag(tt.version)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected tag %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRepositoryPushTarget(t *testing.T) {
+	oci := NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg"})
+	if got := oci.PushTarget("app", "1.0.0"); got != "oci://ghcr.io/myorg/app:1.0.0" {
+		t.Errorf("unexpected OCI push target: %s", got)
+	}
+
+	museum := NewRepository(RepositoryConfig{Type: "chartmuseum", URL: "https://charts.example.com"})
+	if got := museum.PushTarget("app", "1.0.0"); got != "https://charts.example.com" {
+		t.Errorf("unexpected chartmuseum push target: %s", got)
+	}
+}
+
+func TestRepositoryListVersionsAndDelete(t *testing.T) {
+	var deleted []string
+	var deleteAuth string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/charts/my-app":
+			_, _ = w.Write([]byte(`[{"name":"my-app","version":"1.1.0"},{"name":"my-app","version":"1.0.0"}]`))
+		case r.Method == "DELETE":
+			deleteAuth = r.Header.Get("Authorization")
+			deleted = append(deleted, r.URL.Path)
+			_, _ = w.Write([]byte(`{"deleted":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	repo := NewRepository(RepositoryConfig{
+		Type:     "chartmuseum",
+		URL:      server.URL,
+		Username: "user",
+		Password: "pass",
+	})
+
+	versions, err := repo.ListVersions(context.Background(), "my-app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != "1.1.0" {
+		t.Errorf("unexpected versions: %+v", versions)
+	}
+
+	if err := repo.Delete(context.Background(), "my-app", "1.0.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "/api/charts/my-app/1.0.0" {
+		t.Errorf("unexpected delete requests: %v", deleted)
+	}
+	if deleteAuth == "" {
+		t.Error("expected Authorization header on delete")
+	}
+
+	missing, err := repo.ListVersions(context.Background(), "other")
+	if err != nil {
+		t.Fatalf("unexpected error for missing chart: %v", err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("expected no versions for missing chart, got %v", missing)
+	}
+}
+
+func TestRepositoryDeleteUnsupportedType(t *testing.T) {
+	repo := NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg"})
+	if err := repo.Delete(context.Background(), "my-app", "1.0.0"); err == nil {
+		t.Error("expected error for unsupported repository type")
+	}
+}
+
+func TestVersionsToPrune(t *testing.T) {
+	versions := []ChartVersion{
+		{Version: "1.2.0"},
+		{Version: "1.10.0"},
+		{Version: "1.9.0"},
+		{Version: "2.0.0-rc.1"},
+		{Version: "not-semver"},
+		{Version: "1.0.0"},
+	}
+
+	got := versionsToPrune(versions, 2, time.Time{}, nil)
+	want := []string{"1.9.0", "1.2.0", "1.0.0"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, got)
+			break
+		}
+	}
+
+	if prune := versionsToPrune(versions[:2], 5, time.Time{}, nil); prune != nil {
+		t.Errorf("expected nothing to prune, got %v", prune)
+	}
+}
+
+func TestExtractPushDigest(t *testing.T) {
+	output := "Pushed: ghcr.io/myorg/my-app:1.0.0\nDigest: sha256:abc123\n"
+	if got := extractPushDigest(output); got != "sha256:abc123" {
+		t.Errorf("expected 'sha256:abc123', got '%s'", got)
+	}
+
+	if got := extractPushDigest("Error: unauthorized\n"); got != "" {
+		t.Errorf("expected empty digest, got '%s'", got)
+	}
+}
+
+func TestRepositoryPushOCIReportsDigest(t *testing.T) {
+	dir := writeFakeCommand(t, "helm", `echo "$@" >> "$(dirname "$0")/calls"
+echo "Pushed: ghcr.io/myorg/my-app:1.0.0"
+echo "Digest: sha256:deadbeef"
+`)
+
+	repo := NewRepository(RepositoryConfig{
+		Type: "oci",
+		URL:  "oci://ghcr.io/myorg",
+	})
+
+	result, err := repo.Push(context.Background(), "/tmp/my-app-1.0.0.tgz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Digest != "sha256:deadbeef" {
+		t.Errorf("expected digest 'sha256:deadbeef', got '%s'", result.Digest)
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	if strings.TrimSpace(string(calls)) != "push /tmp/my-app-1.0.0.tgz oci://ghcr.io/myorg" {
+		t.Errorf("unexpected helm calls: %s", calls)
+	}
+}
+
+func TestRepositoryPushOCIStripChartName(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		strip         bool
+		wantPush      string
+		wantReference string
+	}{
+		{
+			name:          "helm appends the chart name",
+			url:           "oci://registry.example.com/org/charts/my-app",
+			wantPush:      "oci://registry.example.com/org/charts/my-app",
+			wantReference: "oci://registry.example.com/org/charts/my-app/my-app:1.0.0",
+		},
+		{
+			name:          "chart name stripped",
+			url:           "oci://registry.example.com/org/charts/my-app/",
+			strip:         true,
+			wantPush:      "oci://registry.example.com/org/charts",
+			wantReference: "oci://registry.example.com/org/charts/my-app:1.0.0",
+		},
+		{
+			name:          "url without the chart name",
+			url:           "oci://registry.example.com/org/charts",
+			strip:         true,
+			wantPush:      "oci://registry.example.com/org/charts",
+			wantReference: "oci://registry.example.com/org/charts/my-app:1.0.0",
+		},
+	}
+
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	writeChartArchive(t, packagePath, map[string]string{"my-app/Chart.yaml": "apiVersion: v2\nname: my-app\nversion: 1.0.0\n"})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetRegistrySessions(t)
+			dir := writeFakeCommand(t, "helm", `echo "$@" >> "$(dirname "$0")/calls"`)
+
+			repo := NewRepository(RepositoryConfig{Type: "oci", URL: tt.url, Username: "user", Password: "pass", StripChartName: tt.strip})
+			if _, err := repo.Push(context.Background(), packagePath); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+			lines := strings.Split(strings.TrimSpace(string(calls)), "\n")
+			if len(lines) != 2 || !strings.HasPrefix(lines[0], "registry login registry.example.com ") {
+				t.Fatalf("expected login to the registry host only, got calls:\n%s", calls)
+			}
+			if lines[1] != "push "+packagePath+" "+tt.wantPush {
+				t.Errorf("expected push to %s, got %q", tt.wantPush, lines[1])
+			}
+			if ref := repo.OCIReference("my-app", "1.0.0"); ref != tt.wantReference {
+				t.Errorf("expected reference %s, got %s", tt.wantReference, ref)
+			}
+		})
+	}
+}
+
+func TestRegistryLoginRespectsConcurrencyLimit(t *testing.T) {
+	// The fake helm takes a lock directory for the duration of the login and
+	// records an overlap if another login already holds it.
+	dir := writeFakeCommand(t, "helm", `lock="$(dirname "$0")/lock"
+if ! mkdir "$lock" 2>/dev/null; then
+	echo overlap >> "$(dirname "$0")/overlaps"
+	exit 0
+fi
+sleep 0.05
+rmdir "$lock"
+`)
+
+	resetRegistrySessions(t)
+	logins := newLoginLimiter(1)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repo := NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg"})
+			repo.SetLoginLimiter(logins)
+			if err := repo.registryLogin(context.Background(), fmt.Sprintf("registry-%d.example.com", i), "user", "pass"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if overlaps, err := os.ReadFile(filepath.Join(dir, "overlaps")); err == nil {
+		t.Errorf("logins ran concurrently beyond the limit:\n%s", overlaps)
+	}
+}
+
+// resetRegistrySessions clears the process-wide login cache before and after the test.
+func resetRegistrySessions(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		registrySessions.Lock()
+		defer registrySessions.Unlock()
+		clear(registrySessions.hosts)
+		clear(registrySessions.held)
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestRegistryLoginIsCachedPerHost(t *testing.T) {
+	resetRegistrySessions(t)
+	dir := writeFakeCommand(t, "helm", `echo "$@" >> "$(dirname "$0")/calls"`)
+	ctx := context.Background()
+	repo := NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg"})
+
+	for _, login := range []struct{ host, username, password string }{
+		{"ghcr.io", "user", "pass"},
+		{"ghcr.io", "user", "pass"},   // cached
+		{"quay.io", "user", "pass"},   // different host
+		{"ghcr.io", "other", "token"}, // different credentials
+	} {
+		if err := repo.registryLogin(ctx, login.host, login.username, login.password); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := repo.Logout(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.registryLogin(ctx, "ghcr.io", "other", "token"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	want := []string{
+		"registry login ghcr.io --username user --password-stdin",
+		"registry login quay.io --username user --password-stdin",
+		"registry login ghcr.io --username other --password-stdin",
+		"registry logout ghcr.io",
+		"registry login ghcr.io --username other --password-stdin",
+	}
+	if got := strings.TrimSpace(string(calls)); got != strings.Join(want, "\n") {
+		t.Errorf("unexpected helm calls:\n%s", got)
+	}
+}
+
+func TestRegistrySessionHeldAcrossLoginAndPush(t *testing.T) {
+	// The fake helm keeps the logged in user like helm's registry config does,
+	// and records the user each push started and finished with.
+	dir := writeFakeCommand(t, "helm", `dir="$(dirname "$0")"
+case "$1" in
+registry) echo "$5" > "$dir/session" ;;
+push)
+	user="$(cat "$dir/session")"
+	sleep 0.05
+	echo "$3 $user $(cat "$dir/session")" >> "$dir/pushes"
+	;;
+esac
+`)
+	resetRegistrySessions(t)
+
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	writeTestPackage(t, packagePath)
+	repos := []*Repository{
+		NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/org-a", Username: "user-a", Password: "pass-a"}),
+		NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/org-b", Username: "user-b", Password: "pass-b"}),
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, result := range pushToRepositories(context.Background(), repos, packagePath, 2, false, true, logger) {
+		if result.Err != nil {
+			t.Fatalf("unexpected error: %v", result.Err)
+		}
+	}
+
+	pushes, _ := os.ReadFile(filepath.Join(dir, "pushes"))
+	lines := strings.Split(strings.TrimSpace(string(pushes)), "\n")
+	slices.Sort(lines)
+	want := []string{"oci://ghcr.io/org-a user-a user-a", "oci://ghcr.io/org-b user-b user-b"}
+	if !slices.Equal(lines, want) {
+		t.Errorf("expected each push to use its own login, got %q", lines)
+	}
+}
+
+func TestLoginLimiterAllowsConfiguredConcurrency(t *testing.T) {
+	logins := newLoginLimiter(2)
+	ctx := context.Background()
+
+	if err := logins.acquire(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := logins.acquire(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := logins.acquire(ctx); err == nil {
+		t.Error("expected third acquire to block until the context expired")
+	}
+
+	logins.release()
+	if err := logins.acquire(context.Background()); err != nil {
+		t.Errorf("expected acquire to succeed after release, got %v", err)
+	}
+}
+
+func TestRepositoryVerifyPushed(t *testing.T) {
+	dir := t.TempDir()
+	packagePath := filepath.Join(dir, "my-app-1.0.0.tgz")
+	writeChartArchive(t, packagePath, map[string]string{
+		"my-app/Chart.yaml": "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+	})
+	corrupted := filepath.Join(dir, "corrupted.tgz")
+	if err := os.WriteFile(corrupted, []byte("corrupted"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	// The fake helm "pulls" whatever file $PULL_SOURCE points at.
+	writeFakeCommand(t, "helm", `echo "$@" >> "$(dirname "$0")/calls"
+if [ "$1" = "pull" ]; then
+	cp "$PULL_SOURCE" "$6/my-app-1.0.0.tgz"
+fi
+`)
+
+	digest, err := fileDigest(packagePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo := NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg"})
+
+	t.Setenv("PULL_SOURCE", packagePath)
+	if err := repo.VerifyPushed(context.Background(), "my-app", "1.0.0", digest); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	t.Setenv("PULL_SOURCE", corrupted)
+	err = repo.VerifyPushed(context.Background(), "my-app", "1.0.0", digest)
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Errorf("expected digest mismatch error, got %v", err)
+	}
+}
+
+func TestPushToRepositoriesVerifiesAfterPush(t *testing.T) {
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	writeChartArchive(t, packagePath, map[string]string{
+		"my-app/Chart.yaml": "apiVersion: v2\nname: my-app\nversion: 1.0.0\n",
+	})
+
+	writeFakeCommand(t, "helm", `if [ "$1" = "pull" ]; then
+	echo "corrupted" > "$6/my-app-1.0.0.tgz"
+fi
+`)
+
+	repo := NewRepository(RepositoryConfig{Type: "oci", URL: "oci://ghcr.io/myorg", VerifyAfterPush: true})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	results := pushToRepositories(context.Background(), []*Repository{repo}, packagePath, 1, false, true, logger)
+
+	err := joinPushErrors(results)
+	if err == nil || !strings.Contains(err.Error(), "push verification failed") {
+		t.Errorf("expected verification failure, got %v", err)
+	}
+}
+
+func TestRepositoryPushBucket(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    RepositoryConfig
+		wantCalls string
+	}{
+		{
+			name:      "s3 with reindex",
+			config:    RepositoryConfig{Type: "s3", URL: "s3://my-bucket/charts", Reindex: true},
+			wantCalls: "s3 push /tmp/my-app-1.0.0.tgz s3://my-bucket/charts\ns3 reindex s3://my-bucket/charts",
+		},
+		{
+			name:      "gcs with a repository name",
+			config:    RepositoryConfig{Type: "gcs", URL: "gs://my-bucket/charts", Name: "my-charts"},
+			wantCalls: "gcs push /tmp/my-app-1.0.0.tgz gs://my-bucket/charts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFakeCommand(t, "helm", `echo "$@" >> "$(dirname "$0")/calls"`)
+
+			if _, err := NewRepository(tt.config).Push(context.Background(), "/tmp/my-app-1.0.0.tgz"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+			if got := strings.TrimSpace(string(calls)); got != tt.wantCalls {
+				t.Errorf("expected calls:\n%s\ngot:\n%s", tt.wantCalls, got)
+			}
+		})
+	}
+}
+
+func TestRepositoryPushBucketIndexLocation(t *testing.T) {
+	var published string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/charts/index.yaml" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		published = string(body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		indexLocation string
+		wantAWSCalls  string
+	}{
+		{
+			name:          "bucket",
+			indexLocation: "s3://index-bucket/charts",
+			wantAWSCalls:  "s3 cp s3://my-bucket/charts/index.yaml -\ns3 cp - s3://index-bucket/charts/index.yaml",
+		},
+		{
+			name:          "http",
+			indexLocation: server.URL + "/charts",
+			wantAWSCalls:  "s3 cp s3://my-bucket/charts/index.yaml -",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helmDir := writeFakeCommand(t, "helm", `echo "$@" >> "$(dirname "$0")/calls"`)
+			awsDir := writeFakeCommand(t, "aws", `echo "$@" >> "$(dirname "$0")/calls"
+if [ "$4" = "-" ]; then
+	echo "apiVersion: v1"
+else
+	cat > "$(dirname "$0")/uploaded"
+fi`)
+
+			repo := NewRepository(RepositoryConfig{Type: "s3", URL: "s3://my-bucket/charts", IndexLocation: tt.indexLocation})
+			if _, err := repo.Push(context.Background(), "/tmp/my-app-1.0.0.tgz"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			helmCalls, _ := os.ReadFile(filepath.Join(helmDir, "calls"))
+			if got := strings.TrimSpace(string(helmCalls)); got != "s3 push /tmp/my-app-1.0.0.tgz s3://my-bucket/charts" {
+				t.Errorf("expected the package to go to the primary bucket, got:\n%s", got)
+			}
+			awsCalls, _ := os.ReadFile(filepath.Join(awsDir, "calls"))
+			if got := strings.TrimSpace(string(awsCalls)); got != tt.wantAWSCalls {
+				t.Errorf("expected calls:\n%s\ngot:\n%s", tt.wantAWSCalls, got)
+			}
+
+			uploaded, _ := os.ReadFile(filepath.Join(awsDir, "uploaded"))
+			index := string(uploaded)
+			if strings.HasPrefix(tt.indexLocation, "http") {
+				index = published
+			}
+			if index != "apiVersion: v1\n" {
+				t.Errorf("expected the regenerated index at the index location, got %q", index)
+			}
+		})
+	}
+}
+
+func TestAtomicMultiPushAbortsOnAuthFailure(t *testing.T) {
+	chartDir := filepath.Join(t.TempDir(), "my-app")
+	if err := os.MkdirAll(chartDir, 0755); err != nil {
+		t.Fatalf("failed to create chart: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	writeChartArchive(t, packagePath, map[string]string{"my-app/Chart.yaml": "apiVersion: v2\nname: my-app\nversion: 1.0.0\n"})
+	writeFakeCommand(t, "helm", `echo "Successfully packaged chart and saved it to: `+packagePath+`"`)
+
+	var mu sync.Mutex
+	uploads := 0
+	chartMuseum := func(password string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, pass, _ := r.BasicAuth(); pass != password {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.Method == http.MethodPost {
+				mu.Lock()
+				uploads++
+				mu.Unlock()
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			_, _ = w.Write([]byte("{}"))
+		}))
+	}
+	good := chartMuseum("secret")
+	defer good.Close()
+	bad := chartMuseum("rotated")
+	defer bad.Close()
+
+	tests := []struct {
+		name        string
+		atomic      bool
+		wantUploads int
+		wantMessage string
+	}{
+		{name: "atomic", atomic: true, wantUploads: 0, wantMessage: "1 of 2 repositories failed authentication"},
+		{name: "non-atomic", atomic: false, wantUploads: 1, wantMessage: "1 of 2 repositories failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploads = 0
+			p := &HelmPlugin{}
+			cfg := p.parseConfig(map[string]any{
+				"chart_path": chartDir,
+				"output_dir": t.TempDir(),
+				"repositories": []any{
+					map[string]any{"type": "chartmuseum", "url": good.URL, "username": "ci", "password": "secret"},
+					map[string]any{"type": "chartmuseum", "url": bad.URL, "username": "ci", "password": "secret"},
+				},
+				"atomic_multi_push": tt.atomic,
+			})
+
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			resp, err := p.executePostPublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success {
+				t.Fatal("expected publish to fail")
+			}
+			if !strings.Contains(resp.Message, tt.wantMessage) {
+				t.Errorf("expected message containing %q, got: %s", tt.wantMessage, resp.Message)
+			}
+			if uploads != tt.wantUploads {
+				t.Errorf("expected %d upload(s), got %d", tt.wantUploads, uploads)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoValuesSchema is returned by ValidateValuesSchema when the chart has no values.schema.json.
+var ErrNoValuesSchema = errors.New("values.schema.json not found")
+
+// ValidateValuesSchema checks the chart's default values.yaml against its
+// values.schema.json. Each violation is reported with the JSON path of the
+// offending value, e.g. "$.image.tag: got number, want string".
+func ValidateValuesSchema(chartPath string) error {
+	schemaFile := filepath.Join(chartPath, "values.schema.json")
+	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+		return ErrNoValuesSchema
+	}
+	return validateValuesFile(schemaFile, filepath.Join(chartPath, "values.yaml"))
+}
+
+// validateValuesFile checks a values file against a JSON schema file.
+func validateValuesFile(schemaFile, valuesFile string) error {
+	schemaName := filepath.Base(schemaFile)
+	schemaData, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", schemaName, err)
+	}
+
+	schemaDoc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaData))
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", schemaName, err)
+	}
+	schemaURL, err := filepath.Abs(schemaFile)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", schemaName, err)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(schemaURL, schemaDoc); err != nil {
+		return fmt.Errorf("failed to load %s: %w", schemaName, err)
+	}
+	schema, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", schemaName, err)
+	}
+
+	values, err := loadValuesJSON(valuesFile)
+	if err != nil {
+		return err
+	}
+
+	err = schema.Validate(values)
+	if err == nil {
+		return nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return fmt.Errorf("failed to validate %s: %w", filepath.Base(valuesFile), err)
+	}
+
+	violations := schemaViolations(validationErr, message.NewPrinter(language.English))
+	return fmt.Errorf("%s does not match %s:\n  - %s", filepath.Base(valuesFile), schemaName, strings.Join(violations, "\n  - "))
+}
+
+// loadValuesJSON reads a values file and converts it to the JSON data model the
+// schema validator expects. A missing or empty file validates as an empty object.
+func loadValuesJSON(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+
+	values := map[string]any{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+
+	converted, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s to JSON: %w", filepath.Base(path), err)
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(converted))
+}
+
+// schemaViolations flattens a validation error into one sorted message per
+// failing value, skipping the intermediate errors that only group causes.
+func schemaViolations(err *jsonschema.ValidationError, p *message.Printer) []string {
+	if len(err.Causes) == 0 {
+		return []string{fmt.Sprintf("%s: %s", jsonPath(err.InstanceLocation), err.ErrorKind.LocalizedString(p))}
+	}
+
+	var violations []string
+	for _, cause := range err.Causes {
+		violations = append(violations, schemaViolations(cause, p)...)
+	}
+	sort.Strings(violations)
+	return violations
+}
+
+// jsonPath formats an instance location as a JSONPath expression, e.g. $.ports[0].name.
+func jsonPath(location []string) string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, token := range location {
+		if _, err := strconv.Atoi(token); err == nil {
+			sb.WriteString("[" + token + "]")
+		} else {
+			sb.WriteString("." + token)
+		}
+	}
+	return sb.String()
+}
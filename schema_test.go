@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testValuesSchema = `{
+  "$schema": "https://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["image"],
+  "properties": {
+    "replicaCount": {"type": "integer", "minimum": 1},
+    "image": {
+      "type": "object",
+      "required": ["repository"],
+      "properties": {
+        "repository": {"type": "string"},
+        "tag": {"type": "string"}
+      }
+    },
+    "ports": {
+      "type": "array",
+      "items": {"type": "object", "properties": {"name": {"type": "string"}}}
+    }
+  }
+}`
+
+func TestValidateValuesSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		values   string
+		wantErrs []string
+	}{
+		{
+			name:   "valid values",
+			schema: testValuesSchema,
+			values: "replicaCount: 2\nimage:\n  repository: nginx\n  tag: \"1.25\"\n",
+		},
+		{
+			name:   "violations reported with paths",
+			schema: testValuesSchema,
+			values: "replicaCount: 0\nimage:\n  repository: nginx\n  tag: 1.25\nports:\n  - name: 80\n",
+			wantErrs: []string{
+				"$.image.tag: got number, want string",
+				"$.ports[0].name: got number, want string",
+				"$.replicaCount: minimum: got 0, want 1",
+			},
+		},
+		{
+			name:     "missing required value",
+			schema:   testValuesSchema,
+			values:   "replicaCount: 1\n",
+			wantErrs: []string{"$: missing property 'image'"},
+		},
+		{
+			name:     "invalid schema",
+			schema:   `{"type": 1}`,
+			values:   "",
+			wantErrs: []string{"invalid values.schema.json"},
+		},
+		{
+			name:     "unparseable schema",
+			schema:   `{`,
+			values:   "",
+			wantErrs: []string{"failed to parse values.schema.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chartDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(chartDir, "values.schema.json"), []byte(tt.schema), 0644); err != nil {
+				t.Fatalf("failed to write schema: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte(tt.values), 0644); err != nil {
+				t.Fatalf("failed to write values: %v", err)
+			}
+
+			err := ValidateValuesSchema(chartDir)
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error to contain %q, got:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateValuesSchemaMissingSchema(t *testing.T) {
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte("replicaCount: 1\n"), 0644); err != nil {
+		t.Fatalf("failed to write values: %v", err)
+	}
+
+	if err := ValidateValuesSchema(chartDir); !errors.Is(err, ErrNoValuesSchema) {
+		t.Errorf("expected ErrNoValuesSchema, got %v", err)
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	tests := []struct {
+		location []string
+		want     string
+	}{
+		{location: nil, want: "$"},
+		{location: []string{"image", "tag"}, want: "$.image.tag"},
+		{location: []string{"ports", "0", "name"}, want: "$.ports[0].name"},
+	}
+
+	for _, tt := range tests {
+		if got := jsonPath(tt.location); got != tt.want {
+			t.Errorf("jsonPath(%v) = %s, want %s", tt.location, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// stablePointerName is the object holding the newest stable chart version,
+// stored next to the chart packages.
+const stablePointerName = "stable.txt"
+
+// objectNotFound are substrings of aws/gcloud output reporting a missing object.
+var objectNotFound = []string{"404", "Not Found", "NoSuchKey", "No URLs matched"}
+
+// stablePointerURL returns the location of the stable pointer. HTTP repository
+// URLs name the uploaded package, so the pointer goes into the same directory.
+func (r *Repository) stablePointerURL() string {
+	base := strings.TrimSuffix(r.config.URL, "/")
+	if r.config.Type == "http" {
+		base = r.packageDirURL()
+	}
+	return base + "/" + stablePointerName
+}
+
+// packageDirURL returns the directory of an HTTP repository's upload URL, which
+// names the uploaded package.
+func (r *Repository) packageDirURL() string {
+	base := strings.TrimSuffix(r.config.URL, "/")
+	return base[:strings.LastIndex(base, "/")]
+}
+
+// UpdateStablePointer points the repository's stable pointer at version if it
+// is a stable (non-prerelease) release newer than the current one. It reports
+// whether the pointer was updated.
+func (r *Repository) UpdateStablePointer(ctx context.Context, version string) (bool, error) {
+	v, err := ParseSemVer(version)
+	if err != nil {
+		return false, err
+	}
+	if v.Prerelease != "" {
+		return false, nil
+	}
+
+	current, err := r.readStablePointer(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", stablePointerName, err)
+	}
+	if cur, err := ParseSemVer(current); err == nil && cur.Compare(v) >= 0 {
+		return false, nil
+	}
+
+	if err := r.writeStablePointer(ctx, v.String()+"\n"); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", stablePointerName, err)
+	}
+	return true, nil
+}
+
+// readStablePointer returns the version the stable pointer currently names, or
+// "" if there is no pointer yet.
+func (r *Repository) readStablePointer(ctx context.Context) (string, error) {
+	var data []byte
+	var err error
+	switch r.config.Type {
+	case "http":
+		data, err = r.getObject(ctx, r.stablePointerURL())
+	case "s3", "gcs":
+		data, err = r.bucketCopy(ctx, nil, r.stablePointerURL(), "-")
+	default:
+		return "", fmt.Errorf("stable pointers are not supported for repository type: %s", r.config.Type)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// writeStablePointer replaces the stable pointer's content.
+func (r *Repository) writeStablePointer(ctx context.Context, content string) error {
+	switch r.config.Type {
+	case "http":
+		return r.putObject(ctx, r.stablePointerURL(), content)
+	case "s3", "gcs":
+		_, err := r.bucketCopy(ctx, strings.NewReader(content), "-", r.stablePointerURL())
+		return err
+	default:
+		return fmt.Errorf("stable pointers are not supported for repository type: %s", r.config.Type)
+	}
+}
+
+// getObject downloads an object from an HTTP repository. A missing object
+// returns no data and no error.
+func (r *Repository) getObject(ctx context.Context, endpoint string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := r.setAuth(ctx, req); err != nil {
+		return nil, err
+	}
+
+	client, err := r.httpClient(30 * time.Second)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// putObject uploads content to an HTTP repository.
+func (r *Repository) putObject(ctx context.Context, endpoint, content string) error {
+	req, err := http.NewRequestWithContext(ctx, "PUT", endpoint, strings.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	if err := r.setAuth(ctx, req); err != nil {
+		return err
+	}
+
+	client, err := r.httpClient(30 * time.Second)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// bucketCopy copies src to dst with the cloud provider CLI ("aws s3 cp" or
+// "gcloud storage cp"), where "-" is stdin or stdout. Copying a missing object
+// returns no data and no error.
+func (r *Repository) bucketCopy(ctx context.Context, stdin io.Reader, src, dst string) ([]byte, error) {
+	name, args := "aws", []string{"s3", "cp", src, dst}
+	if r.config.Type == "gcs" {
+		name, args = "gcloud", []string{"storage", "cp", src, dst}
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = stdin
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		for _, s := range objectNotFound {
+			if stdin == nil && strings.Contains(stderr.String(), s) {
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("%s %s failed: %w: %s", name, strings.Join(args[:2], " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
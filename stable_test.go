@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepositoryUpdateStablePointerHTTP(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     string
+		version     string
+		wantUpdated bool
+		wantPointer string
+	}{
+		{name: "first stable release", version: "1.0.0", wantUpdated: true, wantPointer: "1.0.0\n"},
+		{name: "newer stable release", current: "1.0.0\n", version: "1.1.0", wantUpdated: true, wantPointer: "1.1.0\n"},
+		{name: "prerelease", current: "1.0.0\n", version: "1.1.0-rc.1", wantPointer: "1.0.0\n"},
+		{name: "older backport", current: "2.0.0\n", version: "1.9.1", wantPointer: "2.0.0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pointer := tt.current
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/helm/stable.txt" {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				switch r.Method {
+				case http.MethodGet:
+					if pointer == "" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					_, _ = w.Write([]byte(pointer))
+				case http.MethodPut:
+					body, _ := io.ReadAll(r.Body)
+					pointer = string(body)
+					w.WriteHeader(http.StatusCreated)
+				}
+			}))
+			defer server.Close()
+
+			repo := NewRepository(RepositoryConfig{Type: "http", URL: server.URL + "/helm/my-app-" + tt.version + ".tgz", UpdateStablePointer: true})
+			updated, err := repo.UpdateStablePointer(context.Background(), tt.version)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if updated != tt.wantUpdated {
+				t.Errorf("expected updated=%v, got %v", tt.wantUpdated, updated)
+			}
+			if pointer != tt.wantPointer {
+				t.Errorf("expected pointer %q, got %q", tt.wantPointer, pointer)
+			}
+		})
+	}
+}
+
+func TestRepositoryUpdateStablePointerBucket(t *testing.T) {
+	// Fake aws CLI backed by a local file standing in for the bucket object
+	dir := writeFakeCommand(t, "aws", `object="$(dirname "$0")/stable.txt"
+echo "$@" >> "$(dirname "$0")/calls"
+if [ "$4" = "-" ]; then
+	[ -f "$object" ] || { echo "fatal error: An error occurred (404) when calling the HeadObject operation: Not Found" >&2; exit 1; }
+	cat "$object"
+else
+	cat > "$object"
+fi
+`)
+
+	repo := NewRepository(RepositoryConfig{Type: "s3", URL: "s3://my-bucket/charts", UpdateStablePointer: true})
+	for _, version := range []string{"1.0.0", "1.1.0-beta.1"} {
+		if _, err := repo.UpdateStablePointer(context.Background(), version); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	pointer, _ := os.ReadFile(filepath.Join(dir, "stable.txt"))
+	if string(pointer) != "1.0.0\n" {
+		t.Errorf("expected pointer 1.0.0, got %q", pointer)
+	}
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	if !strings.Contains(string(calls), "s3 cp - s3://my-bucket/charts/stable.txt") {
+		t.Errorf("expected pointer upload, got calls:\n%s", calls)
+	}
+	if n := strings.Count(string(calls), "\n"); n != 2 {
+		t.Errorf("expected prerelease to skip the bucket entirely, got %d calls:\n%s", n, calls)
+	}
+}
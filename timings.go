@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+)
+
+// stepTimings logs how long each step of a hook took and records it in the
+// metrics. With debug_timings it also totals the durations per step for the
+// response outputs.
+type stepTimings struct {
+	logger  *slog.Logger
+	metrics *Metrics
+	totals  map[string]time.Duration
+}
+
+// newStepTimings returns a step recorder; totals are kept only when debug is set.
+func newStepTimings(logger *slog.Logger, metrics *Metrics, debug bool) *stepTimings {
+	s := &stepTimings{logger: logger, metrics: metrics}
+	if debug {
+		s.totals = make(map[string]time.Duration)
+	}
+	return s
+}
+
+// observe records that step took d.
+func (s *stepTimings) observe(step string, d time.Duration) {
+	s.logger.Info("Step completed", "step", step, "duration_ms", d.Milliseconds())
+	s.metrics.ObserveStep(step, d)
+	if s.totals != nil {
+		s.totals[step] += d
+	}
+}
+
+// addOutputs adds the per-step totals in milliseconds as the "timings" output,
+// including their sum as "total". It does nothing without debug_timings.
+func (s *stepTimings) addOutputs(outputs map[string]any) {
+	if s.totals == nil {
+		return
+	}
+	timings := make(map[string]int64, len(s.totals)+1)
+	var total time.Duration
+	for step, d := range s.totals {
+		timings[step] = d.Milliseconds()
+		total += d
+	}
+	timings["total"] = total.Milliseconds()
+	outputs["timings"] = timings
+}
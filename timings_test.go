@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestStepTimingsAddOutputs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	steps := newStepTimings(logger, nil, true)
+	steps.observe("lint", 200*time.Millisecond)
+	steps.observe("package", 1500*time.Millisecond)
+	steps.observe("lint", 100*time.Millisecond)
+
+	outputs := map[string]any{}
+	steps.addOutputs(outputs)
+	timings, ok := outputs["timings"].(map[string]int64)
+	if !ok {
+		t.Fatalf("expected timings output, got %v", outputs)
+	}
+	want := map[string]int64{"lint": 300, "package": 1500, "total": 1800}
+	for step, ms := range want {
+		if timings[step] != ms {
+			t.Errorf("expected %s=%dms, got %dms", step, ms, timings[step])
+		}
+	}
+
+	outputs = map[string]any{}
+	newStepTimings(logger, nil, false).addOutputs(outputs)
+	if _, ok := outputs["timings"]; ok {
+		t.Errorf("expected no timings without debug_timings, got %v", outputs["timings"])
+	}
+}
+
+func TestExecutePrePublishDebugTimings(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "kind: ConfigMap"`)
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_path":    chartDir,
+		"debug_timings": true,
+		"version":       map[string]any{"update_chart": false},
+		"dependencies":  map[string]any{"update": false, "build": false},
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.0.0"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	timings, ok := resp.Outputs["timings"].(map[string]int64)
+	if !ok {
+		t.Fatalf("expected timings output, got %v", resp.Outputs)
+	}
+	for _, step := range []string{"lint", "template", "total"} {
+		if _, ok := timings[step]; !ok {
+			t.Errorf("expected %s timing, got %v", step, timings)
+		}
+	}
+}
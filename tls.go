@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// tlsConfig returns the TLS settings for requests to the repository, or nil
+// when neither ca_file nor insecure is set. The CA bundle is trusted in
+// addition to the system roots.
+func (r *Repository) tlsConfig() (*tls.Config, error) {
+	if r.config.CAFile == "" && !r.config.Insecure {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{InsecureSkipVerify: r.config.Insecure}
+	if r.config.CAFile != "" {
+		pem, err := os.ReadFile(r.config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", r.config.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// httpClient returns a client for API requests to the repository, bounded by
+// http_timeout or, when that isn't set, by timeout.
+func (r *Repository) httpClient(timeout time.Duration) (*http.Client, error) {
+	transport, err := r.transport(false)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: durationOr(r.config.HTTPTimeout, timeout)}, nil
+}
+
+// transport returns the transport for requests to the repository, applying
+// its TLS and connection settings and, for uploads, its transport timeouts.
+// Transports are built once and kept on the repository, so connections and
+// the max_idle_conns pool are reused across requests. Without any settings it
+// returns nil, so requests share http.DefaultTransport.
+func (r *Repository) transport(upload bool) (http.RoundTripper, error) {
+	timeouts, phased := r.transportTimeouts()
+	phased = phased && upload
+	if r.config.CAFile == "" && !r.config.Insecure && r.config.MaxIdleConns == 0 && !phased {
+		return nil, nil
+	}
+
+	r.transportMu.Lock()
+	defer r.transportMu.Unlock()
+	cached := &r.apiTransport
+	if phased {
+		cached = &r.uploadTransport
+	}
+	if *cached != nil {
+		return *cached, nil
+	}
+
+	tlsConfig, err := r.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if r.config.MaxIdleConns > 0 {
+		transport.MaxIdleConns = r.config.MaxIdleConns
+		transport.MaxIdleConnsPerHost = r.config.MaxIdleConns
+	}
+	if phased {
+		transport.DialContext = (&net.Dialer{Timeout: timeouts.Dial, KeepAlive: 30 * time.Second}).DialContext
+		transport.TLSHandshakeTimeout = timeouts.TLSHandshake
+		transport.ResponseHeaderTimeout = timeouts.ResponseHeader
+	}
+	*cached = transport
+	return transport, nil
+}
+
+// durationOr parses value as a positive duration, returning fallback when it
+// is unset or invalid.
+func durationOr(value string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// ociTLSArgs returns the helm flags for the repository's TLS settings. helm
+// registry login names the skip-verify flag --insecure; push and pull use
+// --insecure-skip-tls-verify.
+func (r *Repository) ociTLSArgs(login bool) []string {
+	var args []string
+	if r.config.CAFile != "" {
+		args = append(args, "--ca-file", r.config.CAFile)
+	}
+	if r.config.Insecure {
+		if login {
+			args = append(args, "--insecure")
+		} else {
+			args = append(args, "--insecure-skip-tls-verify")
+		}
+	}
+	return args
+}
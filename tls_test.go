@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepositoryPushWithPrivateCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	packagePath := filepath.Join(t.TempDir(), "my-app-1.0.0.tgz")
+	writeTestPackage(t, packagePath)
+
+	tests := []struct {
+		name    string
+		config  RepositoryConfig
+		wantErr string
+	}{
+		{name: "untrusted", config: RepositoryConfig{}, wantErr: "certificate"},
+		{name: "ca file", config: RepositoryConfig{CAFile: caFile}},
+		{name: "insecure", config: RepositoryConfig{Insecure: true}},
+		{name: "invalid ca file", config: RepositoryConfig{CAFile: packagePath}, wantErr: "no certificates found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Type = "chartmuseum"
+			tt.config.URL = server.URL
+			_, err := NewRepository(tt.config).Push(context.Background(), packagePath)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRepositoryPushOCITLSFlags(t *testing.T) {
+	resetRegistrySessions(t)
+	dir := writeFakeCommand(t, "helm", `echo "$@" >> "$(dirname "$0")/calls"`)
+
+	repo := NewRepository(RepositoryConfig{
+		Type:     "oci",
+		URL:      "oci://harbor.internal/charts",
+		Username: "robot",
+		Password: "secret",
+		CAFile:   "/etc/ssl/harbor-ca.pem",
+		Insecure: true,
+	})
+	if _, err := repo.Push(context.Background(), "/tmp/my-app-1.0.0.tgz"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	want := "registry login harbor.internal --username robot --password-stdin --ca-file /etc/ssl/harbor-ca.pem --insecure\n" +
+		"push /tmp/my-app-1.0.0.tgz oci://harbor.internal/charts --ca-file /etc/ssl/harbor-ca.pem --insecure-skip-tls-verify"
+	if got := strings.TrimSpace(string(calls)); got != want {
+		t.Errorf("expected calls:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestRepositoryReusesTransport(t *testing.T) {
+	repo := NewRepository(RepositoryConfig{Type: "http", URL: "https://charts.example.com", MaxIdleConns: 8, ResponseHeaderTimeout: "5s"})
+
+	first, err := repo.httpClient(time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := repo.httpClient(time.Second)
+	if first.Transport != second.Transport {
+		t.Error("expected API clients to share one transport")
+	}
+	if transport := first.Transport.(*http.Transport); transport.MaxIdleConnsPerHost != 8 || transport.ResponseHeaderTimeout != 0 {
+		t.Errorf("unexpected API transport %+v", transport)
+	}
+
+	upload, _ := repo.uploadClient(time.Minute)
+	again, _ := repo.uploadClient(time.Minute)
+	if upload.Transport != again.Transport || upload.Transport == first.Transport {
+		t.Error("expected uploads to share their own transport")
+	}
+	if transport := upload.Transport.(*http.Transport); transport.MaxIdleConnsPerHost != 8 || transport.ResponseHeaderTimeout != 5*time.Second {
+		t.Errorf("unexpected upload transport %+v", transport)
+	}
+
+	// Without any settings, requests share http.DefaultTransport
+	client, _ := NewRepository(RepositoryConfig{Type: "http", URL: "https://charts.example.com"}).httpClient(time.Minute)
+	if client.Transport != nil {
+		t.Errorf("expected the default transport, got %+v", client.Transport)
+	}
+}
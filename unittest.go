@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// UnitTestResult summarizes a helm-unittest run.
+type UnitTestResult struct {
+	SuitesPassed int
+	SuitesFailed int
+	TestsPassed  int
+	TestsFailed  int
+}
+
+// Summary returns a short human-readable summary of the run.
+func (r *UnitTestResult) Summary() string {
+	return fmt.Sprintf("%d suite(s) passed, %d failed", r.SuitesPassed, r.SuitesFailed)
+}
+
+var (
+	ansiEscape   = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	passedCount  = regexp.MustCompile(`(\d+) passed`)
+	failedCount  = regexp.MustCompile(`(\d+) failed`)
+	summaryLabel = regexp.MustCompile(`^(Test Suites|Tests):\s+(.*)$`)
+)
+
+// parseUnitTestOutput parses the summary helm-unittest prints after a run:
+//
+//	Test Suites: 1 failed, 2 passed, 3 total
+//	Tests:       1 failed, 11 passed, 12 total
+func parseUnitTestOutput(output string) *UnitTestResult {
+	result := &UnitTestResult{}
+	for _, line := range strings.Split(ansiEscape.ReplaceAllString(output, ""), "\n") {
+		m := summaryLabel.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		passed := countMatch(passedCount, m[2])
+		failed := countMatch(failedCount, m[2])
+		if m[1] == "Test Suites" {
+			result.SuitesPassed, result.SuitesFailed = passed, failed
+		} else {
+			result.TestsPassed, result.TestsFailed = passed, failed
+		}
+	}
+	return result
+}
+
+// countMatch returns the number captured by re in s, or 0.
+func countMatch(re *regexp.Regexp, s string) int {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
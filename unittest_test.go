@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const unitTestFailureOutput = "\x1b[31mFAIL\x1b[0m  deployment	charts/my-app/tests/deployment_test.yaml\n" +
+	"	- should set replicas\n" +
+	"		- asserts[0] `equal` fail\n" +
+	"\n" +
+	"Charts:      1 failed, 0 passed, 1 total\n" +
+	"Test Suites: 1 failed, 2 passed, 3 total\n" +
+	"Tests:       1 failed, 11 passed, 12 total\n" +
+	"Snapshot:    0 passed, 0 total\n" +
+	"Time:        12.3ms\n"
+
+func TestParseUnitTestOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   UnitTestResult
+	}{
+		{
+			name:   "all passed",
+			output: "Charts:      1 passed, 1 total\nTest Suites: 3 passed, 3 total\nTests:       12 passed, 12 total\n",
+			want:   UnitTestResult{SuitesPassed: 3, TestsPassed: 12},
+		},
+		{
+			name:   "failures with color",
+			output: unitTestFailureOutput,
+			want:   UnitTestResult{SuitesPassed: 2, SuitesFailed: 1, TestsPassed: 11, TestsFailed: 1},
+		},
+		{
+			name:   "no summary",
+			output: "Error: plugin \"unittest\" exited with error\n",
+			want:   UnitTestResult{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseUnitTestOutput(tt.output); *got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, *got)
+			}
+		})
+	}
+}
+
+func TestHelmCLIUnitTest(t *testing.T) {
+	writeFakeCommand(t, "helm", `if [ "$1" != "unittest" ] || [ "$2" != "./chart" ]; then
+	echo "unexpected args: $@" >&2
+	exit 2
+fi
+printf 'Test Suites: 2 passed, 2 total\nTests:       5 passed, 5 total\n'
+`)
+
+	result, err := NewHelmCLI("./chart").UnitTest(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.SuitesPassed != 2 || result.SuitesFailed != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.Summary() != "2 suite(s) passed, 0 failed" {
+		t.Errorf("unexpected summary: %s", result.Summary())
+	}
+}
+
+func TestHelmCLIUnitTestFailure(t *testing.T) {
+	writeFakeCommand(t, "helm", `printf 'Test Suites: 1 failed, 2 passed, 3 total\nTests:       1 failed, 11 passed, 12 total\n'
+exit 1
+`)
+
+	result, err := NewHelmCLI("./chart").UnitTest(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "helm unittest failed") {
+		t.Fatalf("expected unittest failure, got %v", err)
+	}
+	if result == nil || result.SuitesFailed != 1 || result.SuitesPassed != 2 {
+		t.Errorf("expected failed suites to be reported, got %+v", result)
+	}
+}
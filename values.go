@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UpdateValuesFile sets dotted value paths in the chart's values.yaml, e.g.
+// "image.tag", to string values. The file is edited as a YAML node tree so
+// comments and formatting are preserved, and an updated value keeps its
+// quoting style. Paths that don't exist are an error unless create is set, in
+// which case missing keys and mappings are added.
+func UpdateValuesFile(chartPath string, updates map[string]string, create bool) error {
+	valuesFile := filepath.Join(chartPath, "values.yaml")
+	doc, err := readYAMLDocument(valuesFile)
+	if err != nil {
+		return err
+	}
+
+	paths := slices.Sorted(maps.Keys(updates))
+
+	var problems []string
+	for _, path := range paths {
+		if err := setValuePath(doc.Content[0], strings.Split(path, "."), updates[path], create); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d value path(s) could not be updated:\n  - %s", len(problems), strings.Join(problems, "\n  - "))
+	}
+
+	return writeYAMLDocument(valuesFile, doc)
+}
+
+// setValuePath walks keys down from mapping and sets the final key to value.
+func setValuePath(mapping *yaml.Node, keys []string, value string, create bool) error {
+	for i, key := range keys {
+		node := mappingValue(mapping, key)
+		if node == nil {
+			if !create {
+				return fmt.Errorf("%s not found", strings.Join(keys[:i+1], "."))
+			}
+			if i < len(keys)-1 {
+				node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, node)
+			}
+		}
+
+		if i == len(keys)-1 {
+			if node != nil && node.Kind != yaml.ScalarNode {
+				return fmt.Errorf("%s is not a scalar value", strings.Join(keys, "."))
+			}
+			var style yaml.Style
+			if node != nil {
+				style = node.Style
+			}
+			setMappingScalar(mapping, key, value, style, "")
+			return nil
+		}
+
+		if node.Kind != yaml.MappingNode {
+			if !create || node.Tag != "!!null" {
+				return fmt.Errorf("%s is not a mapping", strings.Join(keys[:i+1], "."))
+			}
+			// e.g. an empty "image:" key
+			*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		mapping = node
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestUpdateValuesFile(t *testing.T) {
+	const values = `# Default values for my-app.
+image:
+  repository: ghcr.io/myorg/my-app
+  # Overridden by the release
+  tag: "1.0.0"
+replicas: 1
+resources:
+`
+
+	tests := []struct {
+		name    string
+		updates map[string]string
+		create  bool
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "existing path keeps comments and quoting",
+			updates: map[string]string{"image.tag": "1.2.0"},
+			want: `# Default values for my-app.
+image:
+  repository: ghcr.io/myorg/my-app
+  # Overridden by the release
+  tag: "1.2.0"
+replicas: 1
+resources:
+`,
+		},
+		{
+			name:    "missing paths fail",
+			updates: map[string]string{"image.digest": "sha256:abc", "replicas.count": "2"},
+			wantErr: "2 value path(s) could not be updated:\n  - image.digest: image.digest not found\n  - replicas.count: replicas is not a mapping",
+		},
+		{
+			name:    "create adds missing keys",
+			updates: map[string]string{"image.digest": "sha256:abc", "resources.limits.cpu": "1.0", "sidecar.tag": "2.0.0"},
+			create:  true,
+			want: `# Default values for my-app.
+image:
+  repository: ghcr.io/myorg/my-app
+  # Overridden by the release
+  tag: "1.0.0"
+  digest: sha256:abc
+replicas: 1
+resources:
+  limits:
+    cpu: "1.0"
+sidecar:
+  tag: 2.0.0
+`,
+		},
+		{
+			name:    "mapping can't be replaced",
+			updates: map[string]string{"image": "my-app:1.2.0"},
+			wantErr: "image: image is not a scalar value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chartDir := t.TempDir()
+			valuesFile := filepath.Join(chartDir, "values.yaml")
+			if err := os.WriteFile(valuesFile, []byte(values), 0644); err != nil {
+				t.Fatalf("failed to write values.yaml: %v", err)
+			}
+
+			err := UpdateValuesFile(chartDir, tt.updates, tt.create)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, _ := os.ReadFile(valuesFile)
+			if string(got) != tt.want {
+				t.Errorf("unexpected values.yaml:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutePrePublishValuesUpdates(t *testing.T) {
+	writeFakeCommand(t, "helm", `echo "kind: ConfigMap"`)
+	chartDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: my-app\nversion: 1.2.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write Chart.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte("image:\n  tag: 1.0.0\n"), 0644); err != nil {
+		t.Fatalf("failed to write values.yaml: %v", err)
+	}
+
+	p := &HelmPlugin{}
+	cfg := p.parseConfig(map[string]any{
+		"chart_path":     chartDir,
+		"lint":           false,
+		"values_updates": map[string]any{"image.tag": "{{.Version}}-{{.ShortSHA}}"},
+		"version":        map[string]any{"update_chart": false},
+		"dependencies":   map[string]any{"update": false, "build": false},
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	resp, err := p.executePrePublish(context.Background(), &plugin.ReleaseContext{Version: "1.2.0", CommitSHA: "abcdef0123456"}, cfg, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(chartDir, "values.yaml"))
+	if string(got) != "image:\n  tag: 1.2.0-abcdef0\n" {
+		t.Errorf("expected image.tag to be updated, got:\n%s", got)
+	}
+}
+
+func TestValidateValuesUpdatesOrder(t *testing.T) {
+	p := &HelmPlugin{}
+	config := map[string]any{
+		"values_updates": map[string]any{
+			"image.tag":        "{{.Tag",
+			"app.version":      "{{.Missing}}",
+			"image.repository": "{{.Version}",
+		},
+	}
+	want := "values_updates.app.version values_updates.image.repository values_updates.image.tag"
+	for range 5 {
+		resp, err := p.Validate(context.Background(), config)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var fields []string
+		for _, e := range resp.Errors {
+			if strings.HasPrefix(e.Field, "values_updates.") {
+				fields = append(fields, e.Field)
+			}
+		}
+		if strings.Join(fields, " ") != want {
+			t.Fatalf("expected errors for %s, got %v", want, fields)
+		}
+	}
+}